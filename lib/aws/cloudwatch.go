@@ -0,0 +1,156 @@
+package aws
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const CLOUDWATCH_API_VERSION string = "2010-08-01"
+
+// A minimal CloudWatch client, only GetMetricData is supported
+type CloudWatch struct {
+	Region      string
+	Credentials Credentials
+
+	// Defaults to the regional monitoring endpoint
+	Endpoint string
+	Client   *http.Client
+}
+
+// A single metric to fetch with GetMetricData
+type MetricQuery struct {
+	Id         string
+	Namespace  string
+	MetricName string
+	Dimensions map[string]string
+	Period     int
+	Stat       string
+}
+
+// A single value for a metric
+type Datapoint struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+func NewCloudWatch(region string, creds Credentials) *CloudWatch {
+	return &CloudWatch{
+		Region:      region,
+		Credentials: creds,
+		Endpoint:    fmt.Sprintf("https://monitoring.%s.amazonaws.com/", region),
+		Client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Fetch all the given queries in a single call and return the most recent Datapoint for each query Id.  Queries without data in the time range are not in the result.
+func (cw *CloudWatch) GetMetricData(queries []MetricQuery, start, end time.Time) (map[string]Datapoint, error) {
+	form := url.Values{}
+	form.Set("Action", "GetMetricData")
+	form.Set("Version", CLOUDWATCH_API_VERSION)
+	form.Set("StartTime", start.UTC().Format(time.RFC3339))
+	form.Set("EndTime", end.UTC().Format(time.RFC3339))
+	form.Set("ScanBy", "TimestampDescending")
+
+	for i, q := range queries {
+		prefix := fmt.Sprintf("MetricDataQueries.member.%d.", i+1)
+		form.Set(prefix+"Id", q.Id)
+		form.Set(prefix+"MetricStat.Metric.Namespace", q.Namespace)
+		form.Set(prefix+"MetricStat.Metric.MetricName", q.MetricName)
+		form.Set(prefix+"MetricStat.Period", strconv.Itoa(q.Period))
+		form.Set(prefix+"MetricStat.Stat", q.Stat)
+
+		// Dimensions in a stable order
+		var names []string
+		for name := range q.Dimensions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for j, name := range names {
+			dprefix := fmt.Sprintf("%sMetricStat.Metric.Dimensions.member.%d.", prefix, j+1)
+			form.Set(dprefix+"Name", name)
+			form.Set(dprefix+"Value", q.Dimensions[name])
+		}
+	}
+
+	body := []byte(form.Encode())
+	req, err := http.NewRequest(http.MethodPost, cw.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	cw.Credentials.Sign(req, body, "monitoring", cw.Region, time.Now())
+
+	resp, err := cw.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cloudwatch request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("cloudwatch response read failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, parseErrorResponse(resp.StatusCode, respBody)
+	}
+
+	return parseMetricDataResponse(respBody)
+}
+
+type metricDataResponse struct {
+	Results []struct {
+		Id         string   `xml:"Id"`
+		Timestamps []string `xml:"Timestamps>member"`
+		Values     []string `xml:"Values>member"`
+	} `xml:"GetMetricDataResult>MetricDataResults>member"`
+}
+
+// Keep only the most recent Datapoint of each result
+func parseMetricDataResponse(body []byte) (map[string]Datapoint, error) {
+	var parsed metricDataResponse
+	if err := xml.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("cannot parse cloudwatch response: %v", err)
+	}
+
+	result := make(map[string]Datapoint)
+	for _, r := range parsed.Results {
+		if len(r.Timestamps) != len(r.Values) {
+			return nil, fmt.Errorf("cloudwatch result %s has mismatched timestamps and values", r.Id)
+		}
+		for i := range r.Timestamps {
+			ts, err := time.Parse(time.RFC3339, r.Timestamps[i])
+			if err != nil {
+				return nil, fmt.Errorf("cloudwatch result %s bad timestamp: %v", r.Id, err)
+			}
+			val, err := strconv.ParseFloat(r.Values[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("cloudwatch result %s bad value: %v", r.Id, err)
+			}
+
+			if dp, ok := result[r.Id]; !ok || ts.After(dp.Timestamp) {
+				result[r.Id] = Datapoint{Timestamp: ts, Value: val}
+			}
+		}
+	}
+	return result, nil
+}
+
+type errorResponse struct {
+	Code    string `xml:"Error>Code"`
+	Message string `xml:"Error>Message"`
+}
+
+func parseErrorResponse(status int, body []byte) error {
+	var parsed errorResponse
+	if err := xml.Unmarshal(body, &parsed); err != nil || parsed.Code == "" {
+		return fmt.Errorf("cloudwatch returned HTTP %d", status)
+	}
+	return fmt.Errorf("cloudwatch returned HTTP %d: %s: %s", status, parsed.Code, parsed.Message)
+}
@@ -0,0 +1,118 @@
+package aws
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const testMetricDataResponse = `<GetMetricDataResponse xmlns="http://monitoring.amazonaws.com/doc/2010-08-01/">
+  <GetMetricDataResult>
+    <MetricDataResults>
+      <member>
+        <Id>burstbalance</Id>
+        <Label>BurstBalance</Label>
+        <StatusCode>Complete</StatusCode>
+        <Timestamps>
+          <member>2023-01-01T00:02:00Z</member>
+          <member>2023-01-01T00:01:00Z</member>
+        </Timestamps>
+        <Values>
+          <member>98.5</member>
+          <member>99</member>
+        </Values>
+      </member>
+      <member>
+        <Id>readiops</Id>
+        <StatusCode>Complete</StatusCode>
+        <Timestamps/>
+        <Values/>
+      </member>
+    </MetricDataResults>
+  </GetMetricDataResult>
+</GetMetricDataResponse>`
+
+func TestParseMetricDataResponse(t *testing.T) {
+	result, err := parseMetricDataResponse([]byte(testMetricDataResponse))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dp, ok := result[`burstbalance`]
+	if !ok {
+		t.Fatal("burstbalance missing")
+	}
+	if dp.Value != 98.5 {
+		t.Errorf("unexpected value: %f", dp.Value)
+	}
+	if !dp.Timestamp.Equal(time.Date(2023, 1, 1, 0, 2, 0, 0, time.UTC)) {
+		t.Errorf("unexpected timestamp: %v", dp.Timestamp)
+	}
+
+	if _, ok := result[`readiops`]; ok {
+		t.Error("readiops should have no datapoint")
+	}
+}
+
+func TestGetMetricData(t *testing.T) {
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		form, _ = url.ParseQuery(string(body))
+		if r.Header.Get("Authorization") == "" {
+			t.Error("request not signed")
+		}
+		io.WriteString(w, testMetricDataResponse)
+	}))
+	defer server.Close()
+
+	cw := NewCloudWatch("us-east-1", Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	cw.Endpoint = server.URL
+
+	queries := []MetricQuery{{
+		Id:         "burstbalance",
+		Namespace:  "AWS/RDS",
+		MetricName: "BurstBalance",
+		Dimensions: map[string]string{"DBInstanceIdentifier": "mydb"},
+		Period:     60,
+		Stat:       "Average",
+	}}
+	result, err := cw.GetMetricData(queries, time.Now().Add(-5*time.Minute), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 1 {
+		t.Errorf("unexpected results: %v", result)
+	}
+
+	expected := map[string]string{
+		"Action": "GetMetricData",
+		"MetricDataQueries.member.1.MetricStat.Metric.MetricName":                "BurstBalance",
+		"MetricDataQueries.member.1.MetricStat.Metric.Dimensions.member.1.Name":  "DBInstanceIdentifier",
+		"MetricDataQueries.member.1.MetricStat.Metric.Dimensions.member.1.Value": "mydb",
+	}
+	for key, val := range expected {
+		if form.Get(key) != val {
+			t.Errorf("unexpected %s: `%s`", key, form.Get(key))
+		}
+	}
+}
+
+func TestGetMetricDataError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, `<ErrorResponse><Error><Type>Sender</Type><Code>AccessDenied</Code><Message>nope</Message></Error></ErrorResponse>`)
+	}))
+	defer server.Close()
+
+	cw := NewCloudWatch("us-east-1", Credentials{})
+	cw.Endpoint = server.URL
+
+	_, err := cw.GetMetricData(nil, time.Now(), time.Now())
+	if err == nil || err.Error() != "cloudwatch returned HTTP 403: AccessDenied: nope" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/ini.v1"
+)
+
+// Static AWS credentials used to sign requests
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// Load credentials from the standard AWS environment variables, or else the shared credentials file (~/.aws/credentials) using $AWS_PROFILE (or `default`)
+func LoadCredentials() (Credentials, error) {
+	creds := Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.AccessKeyID != "" && creds.SecretAccessKey != "" {
+		return creds, nil
+	}
+
+	file := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return creds, fmt.Errorf("no AWS credentials in environment: %v", err)
+		}
+		file = filepath.Join(home, ".aws", "credentials")
+	}
+
+	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+	return loadCredentialsFile(file, profile)
+}
+
+// Read the given profile from a shared credentials file
+func loadCredentialsFile(file, profile string) (Credentials, error) {
+	var creds Credentials
+
+	cfg, err := ini.Load(file)
+	if err != nil {
+		return creds, fmt.Errorf("cannot read AWS credentials: %v", err)
+	}
+	if !cfg.HasSection(profile) {
+		return creds, fmt.Errorf("AWS profile %s not found in %s", profile, file)
+	}
+
+	section := cfg.Section(profile)
+	creds.AccessKeyID = section.Key("aws_access_key_id").String()
+	creds.SecretAccessKey = section.Key("aws_secret_access_key").String()
+	creds.SessionToken = section.Key("aws_session_token").String()
+
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return creds, errors.New("AWS profile is missing aws_access_key_id or aws_secret_access_key")
+	}
+	return creds, nil
+}
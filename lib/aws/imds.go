@@ -0,0 +1,93 @@
+package aws
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// The EC2 instance metadata service, only reachable from EC2
+	IMDS_ENDPOINT string = "http://169.254.169.254"
+
+	// How long the IMDSv2 session token is valid
+	IMDS_TOKEN_TTL string = "300"
+)
+
+// The instance metadata paths we turn into tags
+var imdsTags = map[string]string{
+	"region":      "placement/region",
+	"az":          "placement/availability-zone",
+	"instance-id": "instance-id",
+}
+
+// A minimal IMDSv2 client
+type IMDS struct {
+	Endpoint string
+	Client   *http.Client
+}
+
+// Off EC2 the metadata service doesn't answer, so don't wait long for it
+func NewIMDS() *IMDS {
+	return &IMDS{
+		Endpoint: IMDS_ENDPOINT,
+		Client:   &http.Client{Timeout: time.Second},
+	}
+}
+
+// Get a session token for the metadata requests
+func (m *IMDS) getToken() (string, error) {
+	req, err := http.NewRequest(http.MethodPut, m.Endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", IMDS_TOKEN_TTL)
+	return m.do(req)
+}
+
+// Get a single meta-data path, e.g. `instance-id`
+func (m *IMDS) get(token, path string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, m.Endpoint+"/latest/meta-data/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	return m.do(req)
+}
+
+func (m *IMDS) do(req *http.Request) (string, error) {
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("instance metadata: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("instance metadata: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("instance metadata: %s %s", req.URL.Path, resp.Status)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// Tags describing the EC2 instance we are running on: region, az and instance-id
+func (m *IMDS) Tags() (map[string]string, error) {
+	token, err := m.getToken()
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string]string)
+	for tag, path := range imdsTags {
+		value, err := m.get(token, path)
+		if err != nil {
+			return nil, err
+		}
+		tags[tag] = value
+	}
+	return tags, nil
+}
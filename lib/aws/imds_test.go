@@ -0,0 +1,45 @@
+package aws
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIMDSTags(t *testing.T) {
+	metadata := map[string]string{
+		"/latest/meta-data/placement/region":            "us-east-1",
+		"/latest/meta-data/placement/availability-zone": "us-east-1b",
+		"/latest/meta-data/instance-id":                 "i-0123456789abcdef0",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut && r.URL.Path == "/latest/api/token" {
+			io.WriteString(w, "token\n")
+			return
+		}
+		value, ok := metadata[r.URL.Path]
+		if !ok || r.Header.Get("X-aws-ec2-metadata-token") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(w, value)
+	}))
+	defer server.Close()
+
+	imds := NewIMDS()
+	imds.Endpoint = server.URL
+	tags, err := imds.Tags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags["region"] != "us-east-1" || tags["az"] != "us-east-1b" || tags["instance-id"] != "i-0123456789abcdef0" {
+		t.Errorf("unexpected tags: %v", tags)
+	}
+
+	// Not on EC2
+	server.Close()
+	if _, err := imds.Tags(); err == nil {
+		t.Error("expected an error without a metadata service")
+	}
+}
@@ -0,0 +1,51 @@
+package aws
+
+import "strings"
+
+const RDS_ENDPOINT_SUFFIX string = ".rds.amazonaws.com"
+
+// An RDS instance or Aurora cluster as identified by its endpoint
+type RDSEndpoint struct {
+	Identifier string
+	Region     string
+	// Aurora cluster (and cluster reader) endpoints identify a cluster, not an instance
+	Cluster bool
+}
+
+// Parse an RDS hostname like `mydb.abc123xyz.us-east-1.rds.amazonaws.com` or `mycluster.cluster-abc123xyz.us-east-1.rds.amazonaws.com`
+func ParseRDSEndpoint(host string) (RDSEndpoint, bool) {
+	var ep RDSEndpoint
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if !strings.HasSuffix(host, RDS_ENDPOINT_SUFFIX) {
+		return ep, false
+	}
+
+	labels := strings.Split(strings.TrimSuffix(host, RDS_ENDPOINT_SUFFIX), ".")
+	if len(labels) != 3 {
+		return ep, false
+	}
+
+	ep.Identifier = labels[0]
+	ep.Region = labels[2]
+	ep.Cluster = strings.HasPrefix(labels[1], "cluster-")
+	return ep, true
+}
+
+// The CloudWatch dimension that identifies this endpoint
+func (ep RDSEndpoint) Dimensions() map[string]string {
+	if ep.Cluster {
+		return map[string]string{"DBClusterIdentifier": ep.Identifier}
+	}
+	return map[string]string{"DBInstanceIdentifier": ep.Identifier}
+}
+
+// Tags identifying this endpoint
+func (ep RDSEndpoint) Tags() map[string]string {
+	tags := map[string]string{"region": ep.Region}
+	if ep.Cluster {
+		tags["rds-cluster"] = ep.Identifier
+	} else {
+		tags["rds-instance"] = ep.Identifier
+	}
+	return tags
+}
@@ -0,0 +1,52 @@
+package aws
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IAM auth tokens are valid for connecting for this long
+const RDS_AUTH_TOKEN_EXPIRY time.Duration = 15 * time.Minute
+
+// An IAM database authentication token for the user at the RDS endpoint (host:port), used as the password with the cleartext plugin over TLS, see: https://docs.aws.amazon.com/AmazonRDS/latest/UserGuide/UsingWithRDS.IAMDBAuth.Connecting.html
+// The token is a presigned `connect` request without the scheme.
+func (c Credentials) RDSAuthToken(endpoint, region, user string, t time.Time) string {
+	amzDate := t.UTC().Format(SIGV4_DATEFORMAT)
+	scope := fmt.Sprintf("%s/%s/rds-db/aws4_request", amzDate[:8], region)
+
+	query := url.Values{}
+	query.Set("Action", "connect")
+	query.Set("DBUser", user)
+	query.Set("X-Amz-Algorithm", SIGV4_ALGORITHM)
+	query.Set("X-Amz-Credential", c.AccessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(RDS_AUTH_TOKEN_EXPIRY.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if c.SessionToken != "" {
+		query.Set("X-Amz-Security-Token", c.SessionToken)
+	}
+	canonical := strings.ReplaceAll(query.Encode(), "+", "%20")
+
+	canonicalRequest := strings.Join([]string{
+		"GET",
+		"/",
+		canonical,
+		"host:" + endpoint + "\n",
+		"host",
+		hashHex(nil),
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		SIGV4_ALGORITHM,
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signature := hex.EncodeToString(hmacSHA256(c.signingKey(amzDate[:8], region, "rds-db"), stringToSign))
+	return fmt.Sprintf("%s/?%s&X-Amz-Signature=%s", endpoint, canonical, signature)
+}
@@ -0,0 +1,87 @@
+package aws
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// The CAs that sign the certificates of RDS and Aurora instances in every commercial region
+const RDS_CA_BUNDLE_URL = "https://truststore.pki.rds.amazonaws.com/global/global-bundle.pem"
+
+// Where the RDS CA bundle comes from: a PEM file, else a cached copy of the one downloaded from URL
+type RDSCABundle struct {
+	// A PEM file to use instead of the download
+	File string
+
+	// Defaults to the global bundle, cached under the user's cache dir
+	URL       string
+	CachePath string
+	Client    *http.Client
+}
+
+func NewRDSCABundle(file string) *RDSCABundle {
+	bundle := &RDSCABundle{
+		File:   file,
+		URL:    RDS_CA_BUNDLE_URL,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		bundle.CachePath = filepath.Join(dir, "myq-tools", "rds-global-bundle.pem")
+	}
+	return bundle
+}
+
+// The system's CAs plus the RDS ones, downloading (over TLS verified by the system's CAs) and caching the bundle the first time
+func (b *RDSCABundle) Pool() (*x509.CertPool, error) {
+	pem, err := b.pem()
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificates in the RDS CA bundle")
+	}
+	return pool, nil
+}
+
+func (b *RDSCABundle) pem() ([]byte, error) {
+	if b.File != "" {
+		return os.ReadFile(b.File)
+	}
+	if b.CachePath != "" {
+		if pem, err := os.ReadFile(b.CachePath); err == nil {
+			return pem, nil
+		}
+	}
+
+	resp, err := b.Client.Get(b.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	pem, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", b.URL, resp.Status)
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%s: no certificates", b.URL)
+	}
+
+	// Not being able to cache it only means downloading it again next time
+	if b.CachePath != "" && os.MkdirAll(filepath.Dir(b.CachePath), 0o755) == nil {
+		os.WriteFile(b.CachePath, pem, 0o644)
+	}
+	return pem, nil
+}
@@ -0,0 +1,55 @@
+package aws
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRDSCABundle(t *testing.T) {
+	// Any certificate will do as the bundle
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsServer.Close()
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw})
+
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads += 1
+		w.Write(certPEM)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	bundle := NewRDSCABundle("")
+	bundle.URL = server.URL
+	bundle.CachePath = filepath.Join(dir, "cache", "bundle.pem")
+
+	for i := 0; i < 2; i++ {
+		if _, err := bundle.Pool(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if downloads != 1 {
+		t.Errorf("expected the bundle to be downloaded once and then cached, downloaded %d times", downloads)
+	}
+	if cached, err := os.ReadFile(bundle.CachePath); err != nil || string(cached) != string(certPEM) {
+		t.Errorf("expected the bundle to be cached: %v", err)
+	}
+
+	// A file is used as is
+	file := filepath.Join(dir, "file.pem")
+	os.WriteFile(file, certPEM, 0o644)
+	fromFile := NewRDSCABundle(file)
+	fromFile.URL = "http://127.0.0.1:0/"
+	if _, err := fromFile.Pool(); err != nil {
+		t.Error(err)
+	}
+
+	os.WriteFile(file, []byte("not a certificate"), 0o644)
+	if _, err := fromFile.Pool(); err == nil {
+		t.Error("expected an error for a file without certificates")
+	}
+}
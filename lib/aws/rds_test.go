@@ -0,0 +1,31 @@
+package aws
+
+import "testing"
+
+func TestParseRDSEndpoint(t *testing.T) {
+	ep, ok := ParseRDSEndpoint(`mydb.abc123xyz.us-east-1.rds.amazonaws.com`)
+	if !ok {
+		t.Fatal("instance endpoint not parsed")
+	}
+	if ep.Identifier != `mydb` || ep.Region != `us-east-1` || ep.Cluster {
+		t.Errorf("unexpected endpoint: %+v", ep)
+	}
+	if ep.Dimensions()[`DBInstanceIdentifier`] != `mydb` {
+		t.Errorf("unexpected dimensions: %v", ep.Dimensions())
+	}
+	if tags := ep.Tags(); tags[`rds-instance`] != `mydb` || tags[`region`] != `us-east-1` {
+		t.Errorf("unexpected tags: %v", tags)
+	}
+
+	ep, ok = ParseRDSEndpoint(`MyCluster.cluster-ro-abc123xyz.eu-west-1.rds.amazonaws.com`)
+	if !ok {
+		t.Fatal("cluster endpoint not parsed")
+	}
+	if ep.Identifier != `mycluster` || ep.Region != `eu-west-1` || !ep.Cluster {
+		t.Errorf("unexpected endpoint: %+v", ep)
+	}
+
+	if _, ok := ParseRDSEndpoint(`127.0.0.1`); ok {
+		t.Error("non-RDS host parsed")
+	}
+}
@@ -0,0 +1,105 @@
+package aws
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// A minimal Secrets Manager client, only GetSecretValue is supported
+type SecretsManager struct {
+	Region      string
+	Credentials Credentials
+
+	// Defaults to the regional secretsmanager endpoint
+	Endpoint string
+	Client   *http.Client
+}
+
+// The JSON secret RDS creates and rotates for a database user, other fields are ignored
+type RDSSecret struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+}
+
+func NewSecretsManager(region string, creds Credentials) *SecretsManager {
+	return &SecretsManager{
+		Region:      region,
+		Credentials: creds,
+		Endpoint:    fmt.Sprintf("https://secretsmanager.%s.amazonaws.com/", region),
+		Client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// The region of a secret ARN like `arn:aws:secretsmanager:us-east-1:123456789012:secret:mydb-AbCdEf`, empty for a secret name
+func SecretRegion(id string) string {
+	parts := strings.Split(id, ":")
+	if len(parts) < 7 || parts[0] != "arn" || parts[2] != "secretsmanager" {
+		return ""
+	}
+	return parts[3]
+}
+
+// Fetch the current string value of the secret, by name or ARN
+func (sm *SecretsManager) GetSecretValue(id string) (string, error) {
+	body, err := json.Marshal(map[string]string{"SecretId": id})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, sm.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	sm.Credentials.Sign(req, body, "secretsmanager", sm.Region, time.Now())
+
+	resp, err := sm.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("secretsmanager request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("secretsmanager response read failed: %v", err)
+	}
+
+	var parsed struct {
+		SecretString string `json:"SecretString"`
+		Type         string `json:"__type"`
+		Message      string `json:"message"`
+	}
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return "", fmt.Errorf("secretsmanager returned HTTP %d", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("secretsmanager returned HTTP %d: %s: %s", resp.StatusCode, parsed.Type, parsed.Message)
+	}
+	if parsed.SecretString == "" {
+		return "", fmt.Errorf("secret %s has no string value", id)
+	}
+	return parsed.SecretString, nil
+}
+
+// Fetch a secret holding database credentials
+func (sm *SecretsManager) GetRDSSecret(id string) (RDSSecret, error) {
+	var secret RDSSecret
+	value, err := sm.GetSecretValue(id)
+	if err != nil {
+		return secret, err
+	}
+	if err := json.Unmarshal([]byte(value), &secret); err != nil {
+		return secret, fmt.Errorf("secret %s is not JSON database credentials: %v", id, err)
+	}
+	if secret.Username == "" || secret.Password == "" {
+		return secret, fmt.Errorf("secret %s is missing the username or password", id)
+	}
+	return secret, nil
+}
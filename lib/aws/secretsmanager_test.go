@@ -0,0 +1,55 @@
+package aws
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSecretRegion(t *testing.T) {
+	if region := SecretRegion("arn:aws:secretsmanager:eu-west-1:123456789012:secret:mydb-AbCdEf"); region != "eu-west-1" {
+		t.Errorf("unexpected region: %s", region)
+	}
+	if region := SecretRegion("prod/mydb/monitor"); region != "" {
+		t.Errorf("a secret name has no region: %s", region)
+	}
+}
+
+func TestGetRDSSecret(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" || !strings.Contains(r.Header.Get("Authorization"), "/secretsmanager/aws4_request") {
+			t.Errorf("unexpected request headers: %v", r.Header)
+		}
+		switch string(body) {
+		case `{"SecretId":"mydb"}`:
+			w.Write([]byte(`{"Name":"mydb","SecretString":"{\"username\":\"monitor\",\"password\":\"s3cret\",\"port\":3306}"}`))
+		case `{"SecretId":"plain"}`:
+			w.Write([]byte(`{"Name":"plain","SecretString":"s3cret"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+		}
+	}))
+	defer server.Close()
+
+	sm := NewSecretsManager("us-east-1", Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	sm.Endpoint = server.URL
+
+	secret, err := sm.GetRDSSecret("mydb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Username != "monitor" || secret.Password != "s3cret" || secret.Port != 3306 {
+		t.Errorf("unexpected secret: %+v", secret)
+	}
+
+	if _, err := sm.GetRDSSecret("plain"); err == nil {
+		t.Error("expected an error for a secret that isn't credentials")
+	}
+	if _, err := sm.GetRDSSecret("missing"); err == nil || !strings.Contains(err.Error(), "ResourceNotFoundException") {
+		t.Errorf("expected the API error: %v", err)
+	}
+}
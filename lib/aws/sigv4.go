@@ -0,0 +1,99 @@
+package aws
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	SIGV4_ALGORITHM  string = "AWS4-HMAC-SHA256"
+	SIGV4_DATEFORMAT string = "20060102T150405Z"
+)
+
+// Sign the given request with AWS Signature Version 4, see: https://docs.aws.amazon.com/IAM/latest/UserGuide/create-signed-request.html
+// - body must be the exact payload sent with the request (can be nil)
+func (c Credentials) Sign(req *http.Request, body []byte, service, region string, t time.Time) {
+	amzDate := t.UTC().Format(SIGV4_DATEFORMAT)
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", amzDate[:8], region, service)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if c.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.SessionToken)
+	}
+
+	// Headers we sign, host is never in req.Header
+	headers := map[string]string{"host": req.URL.Host}
+	for key, vals := range req.Header {
+		lkey := strings.ToLower(key)
+		if lkey == "content-type" || strings.HasPrefix(lkey, "x-amz-") {
+			headers[lkey] = strings.TrimSpace(strings.Join(vals, ","))
+		}
+	}
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hashHex(body),
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		SIGV4_ALGORITHM,
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signature := hex.EncodeToString(hmacSHA256(c.signingKey(amzDate[:8], region, service), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		SIGV4_ALGORITHM, c.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// Derive the signing key from the date, region, and service
+func (c Credentials) signingKey(date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+c.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+// AWS wants the query string sorted and spaces encoded as %20
+func canonicalQuery(req *http.Request) string {
+	return strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20")
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,127 @@
+package clientconf
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Find a local MySQL server when no host or socket was given
+
+// Where common MySQL packages put their socket
+var defaultSockets = []string{
+	`/var/run/mysqld/mysqld.sock`,
+	`/run/mysqld/mysqld.sock`,
+	`/var/lib/mysql/mysql.sock`,
+	`/tmp/mysql.sock`,
+	`/usr/local/var/mysql/mysql.sock`,
+	`/opt/homebrew/var/mysql/mysql.sock`,
+}
+
+// Users tried (after the configured one) when detecting
+var autoUsers = []string{`root`, `blip`}
+
+// Listening unix sockets, per the kernel
+const PROC_NET_UNIX string = `/proc/net/unix`
+
+// How long to wait on each detection attempt
+const AUTO_TIMEOUT time.Duration = time.Second
+
+// Try every candidate socket with every candidate user and return a config for the first one that works
+func autoDetect(config *mysql.Config) (*mysql.Config, error) {
+	users := []string{config.User}
+	for _, user := range autoUsers {
+		if !slices.Contains(users, user) {
+			users = append(users, user)
+		}
+	}
+
+	sockets := candidateSockets()
+	for _, socket := range sockets {
+		for _, user := range users {
+			candidate := config.Clone()
+			candidate.Net = `unix`
+			candidate.Addr = socket
+			candidate.User = user
+			if user != config.User {
+				// Only the configured user gets the configured password
+				candidate.Passwd = ``
+			}
+
+			if connect(candidate) == nil {
+				fmt.Fprintf(os.Stderr, "Auto-detected MySQL on socket %s as user %s\n", socket, user)
+				return candidate, nil
+			}
+		}
+	}
+	return config, fmt.Errorf("auto-detect: no working local MySQL found (tried sockets: %s)", strings.Join(sockets, ", "))
+}
+
+// How detection finds sockets and tries them, replaced in tests
+var (
+	candidateSockets = getCandidateSockets
+	connect          = tryConnect
+)
+
+// Listening mysql sockets, followed by the default socket paths that exist
+func getCandidateSockets() (sockets []string) {
+	if f, err := os.Open(PROC_NET_UNIX); err == nil {
+		sockets = parseUnixSockets(f)
+		f.Close()
+	}
+
+	for _, socket := range defaultSockets {
+		if slices.Contains(sockets, socket) {
+			continue
+		}
+		if fi, err := os.Stat(socket); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			sockets = append(sockets, socket)
+		}
+	}
+	return
+}
+
+// Get the listening mysql (but not mysqlx) socket paths in /proc/net/unix output
+func parseUnixSockets(r io.Reader) (sockets []string) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// Num RefCount Protocol Flags Type St Inode Path
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			continue
+		}
+
+		// __SO_ACCEPTCON: a listening socket
+		if fields[3] != `00010000` {
+			continue
+		}
+
+		path := fields[7]
+		if strings.Contains(path, `mysql`) && !strings.Contains(path, `mysqlx`) && !slices.Contains(sockets, path) {
+			sockets = append(sockets, path)
+		}
+	}
+	return
+}
+
+// Open and ping a connection with the given config
+func tryConnect(config *mysql.Config) error {
+	config.Timeout = AUTO_TIMEOUT
+	db, err := sql.Open("mysql", config.FormatDSN())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), AUTO_TIMEOUT)
+	defer cancel()
+	return db.PingContext(ctx)
+}
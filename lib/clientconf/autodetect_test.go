@@ -0,0 +1,94 @@
+package clientconf
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestParseUnixSockets(t *testing.T) {
+	procNetUnix := `Num       RefCount Protocol Flags    Type St Inode Path
+0000000000000000: 00000002 00000000 00010000 0001 01 23456 /var/run/mysqld/mysqld.sock
+0000000000000000: 00000002 00000000 00010000 0001 01 23457 /var/run/mysqld/mysqlx.sock
+0000000000000000: 00000003 00000000 00000000 0001 03 23458 /var/run/mysqld/mysqld.sock
+0000000000000000: 00000002 00000000 00010000 0001 01 23459 /run/systemd/notify
+0000000000000000: 00000002 00000000 00010000 0001 01 23460 /data/mysql2/mysql.sock
+0000000000000000: 00000002 00000000 00010000 0001 01 23461
+`
+	sockets := parseUnixSockets(strings.NewReader(procNetUnix))
+
+	expected := []string{`/var/run/mysqld/mysqld.sock`, `/data/mysql2/mysql.sock`}
+	if !reflect.DeepEqual(sockets, expected) {
+		t.Errorf("unexpected sockets: %v", sockets)
+	}
+}
+
+func TestHasHostOrSocket(t *testing.T) {
+	cnf := initCnf()
+	if hasHostOrSocket(cnf) {
+		t.Error(`default cnf has a host or socket`)
+	}
+
+	cnf.Section(`client`).NewKey(`socket`, `/tmp/mysql.sock`)
+	if !hasHostOrSocket(cnf) {
+		t.Error(`socket not found`)
+	}
+}
+
+func TestIsUnconfigured(t *testing.T) {
+	cnf := initCnf()
+	if !isUnconfigured(cnf) {
+		t.Error(`default cnf is configured`)
+	}
+
+	cnf.Section(`client`).NewKey(`user`, `monitor`)
+	if isUnconfigured(cnf) {
+		t.Error(`user not found`)
+	}
+
+	cnf = initCnf()
+	cnf.Section(`client`).NewKey(`host`, `db1`)
+	if isUnconfigured(cnf) {
+		t.Error(`host not found`)
+	}
+}
+
+func TestGenerateConfigDetects(t *testing.T) {
+	// Nothing configured by flag, and a local server only root can connect to
+	userFlag, passwordFlag, hostFlag, portFlag, socketFlag, autoFlag = ``, ``, ``, ``, ``, false
+	origSockets, origConnect := candidateSockets, connect
+	t.Cleanup(func() { candidateSockets, connect = origSockets, origConnect })
+	candidateSockets = func() []string { return []string{`/fake/mysql.sock`} }
+	connect = func(config *mysql.Config) error {
+		if config.Net == `unix` && config.User == `root` {
+			return nil
+		}
+		return errors.New(`access denied`)
+	}
+
+	cnf := initCnf()
+	appendFiles(cnf, getCnfFiles())
+	if !isUnconfigured(cnf) {
+		t.Skip(`a cnf file configures the connection`)
+	}
+
+	config, err := GenerateConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Net != `unix` || config.Addr != `/fake/mysql.sock` || config.User != `root` {
+		t.Errorf(`expected the local socket to be detected: %s`, config.FormatDSN())
+	}
+
+	// Configs for given hosts keep the configured user
+	config, err = GenerateHostsConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Net != `tcp` || config.User != defaultUsername() {
+		t.Errorf(`expected no detection: %s`, config.FormatDSN())
+	}
+}
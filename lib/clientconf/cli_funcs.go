@@ -4,6 +4,8 @@ package clientconf
 
 import (
 	"flag"
+	"net"
+	"strings"
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/hashicorp/go-multierror"
@@ -12,6 +14,7 @@ import (
 // Set the standard MySQL flags we expect
 func SetMySQLFlags() {
 	flag.StringVar(&defaultsFile, "defaults-file", "", "mysql defaults file")
+	flag.StringVar(&loginPathFlag, "login-path", "", "read the settings of this login path in ~/.mylogin.cnf (written by mysql_config_editor) over its [client] ones")
 
 	flag.StringVar(&userFlag, "user", "", "mysql user, defaults to your username")
 	flag.StringVar(&userFlag, "u", "", "short for -user")
@@ -33,6 +36,8 @@ func SetMySQLFlags() {
 	flag.StringVar(&sslCaFlag, "ssl-ca", "", "mysql ssl CA")
 
 	flag.BoolVar(&enableCleartextPlugin, "enable-cleartext-plugin", false, "mysql enable cleartext plugin")
+
+	flag.BoolVar(&autoFlag, "auto", false, "when no host or socket is configured, look for a local mysql socket and connect to the first that works (without any host, socket or user configured, this is done anyway when the defaults don't connect)")
 }
 
 // Creates a [https://pkg.go.dev/github.com/go-sql-driver/mysql#Config]('Config') option from the go-sql-driver/mysql from three sources:
@@ -41,6 +46,15 @@ func SetMySQLFlags() {
 // 3. Command line arguments for necessary config flags
 // Later settings override earlier.  I.e., command line arguments override .my.cnf file settings.
 func GenerateConfig() (*mysql.Config, error) {
+	return generateConfig(true)
+}
+
+// Like GenerateConfig, for callers that connect to the hosts they are given with ConfigForHost: no local server is auto-detected, as its socket and user don't apply to those hosts
+func GenerateHostsConfig() (*mysql.Config, error) {
+	return generateConfig(false)
+}
+
+func generateConfig(detect bool) (*mysql.Config, error) {
 	var errs *multierror.Error
 
 	// construct a cnf that merges our three sources
@@ -57,5 +71,40 @@ func GenerateConfig() (*mysql.Config, error) {
 		errs = multierror.Append(errs, err)
 	}
 
+	// Look for a local server if we don't know where to connect, or when nothing was configured and the defaults don't connect
+	if !detect {
+		return config, errs.ErrorOrNil()
+	}
+	if autoFlag && !hasHostOrSocket(cnf) {
+		config, err = autoDetect(config)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	} else if isUnconfigured(cnf) && connect(config.Clone()) != nil {
+		// Failing to find one leaves the defaults to fail as they would have
+		if detected, err := autoDetect(config); err == nil {
+			config = detected
+		}
+	}
+
 	return config, errs.ErrorOrNil()
 }
+
+// Copy the given config to connect to another host over tcp.  The host can include a port, otherwise the config's port (or 3306) is used.
+func ConfigForHost(config *mysql.Config, host string) *mysql.Config {
+	hostConfig := config.Clone()
+
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		port := `3306`
+		if config.Net == `tcp` {
+			if _, p, err := net.SplitHostPort(config.Addr); err == nil {
+				port = p
+			}
+		}
+		host = net.JoinHostPort(strings.Trim(host, `[]`), port)
+	}
+
+	hostConfig.Net = `tcp`
+	hostConfig.Addr = host
+	return hostConfig
+}
@@ -1,6 +1,10 @@
 package clientconf
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
 
 func TestSetMySQLFlags(t *testing.T) {
 	SetMySQLFlags()
@@ -32,3 +36,26 @@ func TestSocketConfig(t *testing.T) {
 		t.Errorf(`Unexpected dsn: %s`, config.FormatDSN())
 	}
 }
+
+func TestConfigForHost(t *testing.T) {
+	config := mysql.NewConfig()
+	config.User = "testuser"
+	config.Net = "tcp"
+	config.Addr = "127.0.0.1:3307"
+
+	tests := map[string]string{
+		"replica1":       `testuser@tcp(replica1:3307)/`,
+		"replica1:3308":  `testuser@tcp(replica1:3308)/`,
+		"[2001:db8::1]":  `testuser@tcp([2001:db8::1]:3307)/`,
+		"10.0.0.2:33060": `testuser@tcp(10.0.0.2:33060)/`,
+	}
+	for host, expected := range tests {
+		if dsn := ConfigForHost(config, host).FormatDSN(); dsn != expected {
+			t.Errorf(`%s: unexpected dsn: %s`, host, dsn)
+		}
+	}
+
+	if config.Addr != "127.0.0.1:3307" {
+		t.Errorf(`original config modified: %s`, config.Addr)
+	}
+}
@@ -29,11 +29,12 @@ func getCnfFiles() []string {
 
 	home, err := os.UserHomeDir()
 	if err == nil {
-		homedirFiles := []string{
-			fmt.Sprintf(`%s/.my.cnf`, home),
-			fmt.Sprintf(`%s/.mylogin.cnf`, home),
-		}
-		files = append(files, homedirFiles...)
+		files = append(files, fmt.Sprintf(`%s/.my.cnf`, home))
+	}
+
+	// Last, like the mysql client
+	if path := loginFilePath(); path != "" {
+		files = append(files, path)
 	}
 
 	return files
@@ -48,22 +49,31 @@ func initCnf() *ini.File {
 	cnf := ini.Empty(opts)
 
 	// Set some basic defaults
-	username := `root`
-	if user, err := user.Current(); err == nil {
-		username = user.Username
-	}
 	cnf.NewSection(`client`)
-	cnf.Section(`client`).NewKey(`user`, username)
+	cnf.Section(`client`).NewKey(`user`, defaultUsername())
 
 	return cnf
 }
 
+// The user we connect as unless one is configured: our own
+func defaultUsername() string {
+	if user, err := user.Current(); err == nil {
+		return user.Username
+	}
+	return `root`
+}
+
 // Append each of the given files to the cnf
 func appendFiles(cnf *ini.File, files []string) error {
 	var errs *multierror.Error
 
 	for _, file := range files {
-		err := cnf.Append(file)
+		var err error
+		if file == loginFilePath() {
+			err = appendLoginFile(cnf, file)
+		} else {
+			err = cnf.Append(file)
+		}
 		if err != nil {
 			errs = multierror.Append(errs, err)
 		}
@@ -83,6 +93,7 @@ var sslKeyFlag string
 var sslCaFlag string
 var sslMode string
 var enableCleartextPlugin bool
+var autoFlag bool
 
 // ssl cipher support TODO.  MySQL cipher names don't match go's crypto/tls
 // package of course:
@@ -125,6 +136,17 @@ func applyFlags(cnf *ini.File) {
 
 }
 
+// Was a host or socket set in any cnf file or flag?
+func hasHostOrSocket(cnf *ini.File) bool {
+	client := cnf.Section(`client`)
+	return client.HasKey(`host`) || client.HasKey(`socket`)
+}
+
+// Was nothing about where and as whom to connect set in any cnf file or flag?
+func isUnconfigured(cnf *ini.File) bool {
+	return !hasHostOrSocket(cnf) && cnf.Section(`client`).Key(`user`).String() == defaultUsername()
+}
+
 // Translate cnf to mysql.Config
 func cnfToConfig(cnf *ini.File) (*mysql.Config, error) {
 	config := mysql.NewConfig()
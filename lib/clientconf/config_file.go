@@ -0,0 +1,167 @@
+package clientconf
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The config file read from the home directory, unless -config is given
+const CONFIG_FILE = `.myq-tools.yaml`
+
+// The setting of the config file for the default view, it isn't a flag
+const VIEW_SETTING = `view`
+
+var configFile string
+var profileName string
+
+// Set the flags choosing the config file and its profile
+func SetConfigFileFlags() {
+	flag.StringVar(&configFile, "config", "", "read default flags, profiles and custom views from this YAML file (default ~/"+CONFIG_FILE+" if it exists)")
+	flag.StringVar(&profileName, "profile-name", "", "use the flags of this profile of the config file (example: prod-primary)")
+}
+
+// Flag values by flag name, without the dash.  Flags that can be repeated (like -file) take a list.
+type FlagSettings map[string]settingValues
+
+type settingValues []string
+
+// A single value or a list of them
+func (sv *settingValues) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.SequenceNode {
+		var values []string
+		if err := value.Decode(&values); err != nil {
+			return err
+		}
+		*sv = values
+		return nil
+	}
+	var single string
+	if err := value.Decode(&single); err != nil {
+		return err
+	}
+	*sv = settingValues{single}
+	return nil
+}
+
+// A YAML config file, e.g.:
+//
+//	defaults:
+//	  interval: 5s
+//	  color: true
+//	  view: innodb
+//	profiles:
+//	  prod-primary:
+//	    host: db1.prod
+//	    user: monitor
+//	views:
+//	  - my-views.yaml
+type ConfigFile struct {
+	Path string `yaml:"-"`
+
+	// Flags of every run, and of the runs with -profile-name
+	Defaults FlagSettings            `yaml:"defaults"`
+	Profiles map[string]FlagSettings `yaml:"profiles"`
+
+	// Files of custom views, relative to the config file
+	Views []string `yaml:"views"`
+}
+
+// Read the config file, nil if there is none
+func ReadConfigFile() (*ConfigFile, error) {
+	path := configFile
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil
+		}
+		path = filepath.Join(home, CONFIG_FILE)
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			if profileName != "" {
+				return nil, fmt.Errorf("no config file %s for -profile-name", path)
+			}
+			return nil, nil
+		}
+	}
+
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cf := &ConfigFile{Path: path}
+	if err := yaml.Unmarshal(bytes, cf); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cf, nil
+}
+
+// The settings of the run: the defaults, overridden by those of the -profile-name profile
+func (cf *ConfigFile) settings() (FlagSettings, error) {
+	settings := make(FlagSettings)
+	for name, values := range cf.Defaults {
+		settings[name] = values
+	}
+	if profileName != "" {
+		profile, ok := cf.Profiles[profileName]
+		if !ok {
+			return nil, fmt.Errorf("%s: no profile %s", cf.Path, profileName)
+		}
+		for name, values := range profile {
+			settings[name] = values
+		}
+	}
+	return settings, nil
+}
+
+// Set the flags that weren't given on the command line from the settings, after they are parsed.  The command line overrides the profile, which overrides the defaults.
+func (cf *ConfigFile) ApplyFlags(fs *flag.FlagSet) error {
+	settings, err := cf.settings()
+	if err != nil {
+		return err
+	}
+
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	for _, name := range slices.Sorted(maps.Keys(settings)) {
+		if name == VIEW_SETTING || given[name] {
+			continue
+		}
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("%s: unknown flag %s", cf.Path, name)
+		}
+		for _, value := range settings[name] {
+			if err := fs.Set(name, value); err != nil {
+				return fmt.Errorf("%s: %s: %w", cf.Path, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// The view to show when none is given on the command line, if any
+func (cf *ConfigFile) View() string {
+	settings, err := cf.settings()
+	if err != nil || len(settings[VIEW_SETTING]) == 0 {
+		return ""
+	}
+	return settings[VIEW_SETTING][0]
+}
+
+// The paths of the custom view files
+func (cf *ConfigFile) ViewFiles() (paths []string) {
+	for _, path := range cf.Views {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(cf.Path), path)
+		}
+		paths = append(paths, path)
+	}
+	return
+}
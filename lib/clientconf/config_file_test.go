@@ -0,0 +1,98 @@
+package clientconf
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testConfigFile = `
+defaults:
+  interval: 5s
+  color: true
+  view: innodb
+  file: [a.txt, b.txt]
+profiles:
+  prod-primary:
+    interval: 10s
+    view: repl
+views:
+  - views.yaml
+  - /etc/myq/views.yaml
+`
+
+type testFiles []string
+
+func (tf *testFiles) String() string { return "" }
+
+func (tf *testFiles) Set(value string) error {
+	*tf = append(*tf, value)
+	return nil
+}
+
+// The flags the test config file sets
+func newTestFlagSet() (*flag.FlagSet, *time.Duration, *bool, *testFiles) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	interval := fs.Duration("interval", time.Second, "")
+	color := fs.Bool("color", false, "")
+	files := &testFiles{}
+	fs.Var(files, "file", "")
+	return fs, interval, color, files
+}
+
+func TestConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), CONFIG_FILE)
+	if err := os.WriteFile(path, []byte(testConfigFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	configFile, profileName = path, ""
+	defer func() { configFile, profileName = "", "" }()
+
+	cf, err := ReadConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if view := cf.View(); view != `innodb` {
+		t.Errorf("unexpected view: %s", view)
+	}
+	expected := []string{filepath.Join(filepath.Dir(path), `views.yaml`), `/etc/myq/views.yaml`}
+	if files := cf.ViewFiles(); !reflect.DeepEqual(files, expected) {
+		t.Errorf("unexpected view files: %q", files)
+	}
+
+	// The command line overrides the config file
+	fs, interval, color, files := newTestFlagSet()
+	if err := fs.Parse([]string{"-color=false"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cf.ApplyFlags(fs); err != nil {
+		t.Fatal(err)
+	}
+	if *interval != 5*time.Second || *color || !reflect.DeepEqual([]string(*files), []string{`a.txt`, `b.txt`}) {
+		t.Errorf("unexpected flags: %s %v %q", *interval, *color, files)
+	}
+
+	// The profile overrides the defaults
+	profileName = `prod-primary`
+	fs, interval, _, _ = newTestFlagSet()
+	if err := cf.ApplyFlags(fs); err != nil {
+		t.Fatal(err)
+	}
+	if *interval != 10*time.Second || cf.View() != `repl` {
+		t.Errorf("unexpected profile settings: %s %s", *interval, cf.View())
+	}
+
+	profileName = `nope`
+	if err := cf.ApplyFlags(flag.NewFlagSet("test", flag.ContinueOnError)); err == nil {
+		t.Error("no error for an unknown profile")
+	}
+
+	// Only flags that exist
+	profileName = ``
+	if err := cf.ApplyFlags(flag.NewFlagSet("empty", flag.ContinueOnError)); err == nil {
+		t.Error("no error for unknown flags")
+	}
+}
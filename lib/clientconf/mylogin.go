@@ -0,0 +1,113 @@
+package clientconf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/ini.v1"
+)
+
+// The obfuscated cnf file written by mysql_config_editor: https://dev.mysql.com/doc/refman/8.0/en/mysql-config-editor.html
+
+// Where mysql_config_editor writes the login paths, overridden by this environment variable like for the mysql client
+const LOGIN_FILE_ENV = `MYSQL_TEST_LOGIN_FILE`
+
+// Bytes of the file: 4 unused, then the key
+const (
+	loginFileUnused = 4
+	loginKeyLength  = 20
+)
+
+var loginPathFlag string
+
+// The path of the login file, empty if we can't tell
+func loginFilePath() string {
+	if path := os.Getenv(LOGIN_FILE_ENV); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, `.mylogin.cnf`)
+}
+
+// Decrypt the login file into cnf text.  The key is folded into an AES-128 key, each line is a little endian length followed by that many bytes encrypted with AES ECB.
+func decryptLoginFile(data []byte) ([]byte, error) {
+	if len(data) < loginFileUnused+loginKeyLength {
+		return nil, errors.New("too short for a login file")
+	}
+	key := make([]byte, aes.BlockSize)
+	for i, b := range data[loginFileUnused : loginFileUnused+loginKeyLength] {
+		key[i%aes.BlockSize] ^= b
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var plain bytes.Buffer
+	rest := data[loginFileUnused+loginKeyLength:]
+	for len(rest) > 0 {
+		if len(rest) < 4 {
+			return nil, errors.New("truncated login file")
+		}
+		length := int(binary.LittleEndian.Uint32(rest))
+		rest = rest[4:]
+		if length == 0 || length%aes.BlockSize != 0 || length > len(rest) {
+			return nil, fmt.Errorf("invalid line length %d in login file", length)
+		}
+
+		line := make([]byte, length)
+		for i := 0; i < length; i += aes.BlockSize {
+			block.Decrypt(line[i:i+aes.BlockSize], rest[i:i+aes.BlockSize])
+		}
+		rest = rest[length:]
+
+		// PKCS#7 padding
+		padding := int(line[length-1])
+		if padding == 0 || padding > aes.BlockSize {
+			return nil, errors.New("invalid padding in login file")
+		}
+		plain.Write(line[:length-padding])
+	}
+	return plain.Bytes(), nil
+}
+
+// Append the [client] group of the login file to the cnf, then the -login-path group over it
+func appendLoginFile(cnf *ini.File, path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && loginPathFlag == "" {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	plain, err := decryptLoginFile(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	login, err := ini.LoadSources(ini.LoadOptions{AllowBooleanKeys: true}, plain)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	groups := []string{`client`}
+	if loginPathFlag != "" && loginPathFlag != `client` {
+		if !login.HasSection(loginPathFlag) {
+			return fmt.Errorf("%s: no login path %s", path, loginPathFlag)
+		}
+		groups = append(groups, loginPathFlag)
+	}
+	for _, group := range groups {
+		for _, key := range login.Section(group).Keys() {
+			cnf.Section(`client`).NewKey(key.Name(), key.Value())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package clientconf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Write a login file like mysql_config_editor does
+func encryptLoginFile(t *testing.T, lines []string) []byte {
+	key := []byte(`0123456789abcdefghij`)
+	aesKey := make([]byte, aes.BlockSize)
+	for i, b := range key {
+		aesKey[i%aes.BlockSize] ^= b
+	}
+	block, err := aes.NewCipher(aesKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	out.Write(make([]byte, loginFileUnused))
+	out.Write(key)
+	for _, line := range lines {
+		padding := aes.BlockSize - len(line)%aes.BlockSize
+		plain := append([]byte(line), bytes.Repeat([]byte{byte(padding)}, padding)...)
+		encrypted := make([]byte, len(plain))
+		for i := 0; i < len(plain); i += aes.BlockSize {
+			block.Encrypt(encrypted[i:i+aes.BlockSize], plain[i:i+aes.BlockSize])
+		}
+		binary.Write(&out, binary.LittleEndian, uint32(len(encrypted)))
+		out.Write(encrypted)
+	}
+	return out.Bytes()
+}
+
+func TestDecryptLoginFile(t *testing.T) {
+	lines := []string{"[client]\n", "user = \"jayj\"\n", "password = \"a long enough password\"\n"}
+	plain, err := decryptLoginFile(encryptLoginFile(t, lines))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(plain) != lines[0]+lines[1]+lines[2] {
+		t.Errorf("unexpected plain text: %q", plain)
+	}
+
+	if _, err := decryptLoginFile([]byte(`[client]`)); err == nil {
+		t.Error("no error for a plain text file")
+	}
+}
+
+func TestAppendLoginFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), `.mylogin.cnf`)
+	lines := []string{"[client]\n", "user = \"jayj\"\n", "password = \"secret\"\n", "[prod]\n", "user = \"monitor\"\n", "host = \"db1.prod\"\n"}
+	if err := os.WriteFile(path, encryptLoginFile(t, lines), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(LOGIN_FILE_ENV, path)
+	defer func() { loginPathFlag = "" }()
+
+	tests := map[string]map[string]string{
+		``:     {`user`: `jayj`, `password`: `secret`},
+		`prod`: {`user`: `monitor`, `password`: `secret`, `host`: `db1.prod`},
+	}
+	for loginPath, expected := range tests {
+		loginPathFlag = loginPath
+		cnf := initCnf()
+		if err := appendFiles(cnf, []string{`./testcnf/my.cnf`, path}); err != nil {
+			t.Fatal(err)
+		}
+		clientMap := cnf.Section(`client`).KeysHash()
+		for k, v := range expected {
+			if clientMap[k] != v {
+				t.Errorf("%s: unexpected value for key %s: %s", loginPath, k, clientMap[k])
+			}
+		}
+	}
+
+	loginPathFlag = `nope`
+	if err := appendFiles(initCnf(), []string{path}); err == nil {
+		t.Error("no error for a missing login path")
+	}
+}
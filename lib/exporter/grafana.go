@@ -0,0 +1,163 @@
+package exporter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jayjanssen/myq-tools/lib/viewer"
+)
+
+// Panels per row of the snapshot dashboard, Grafana's grid is 24 wide
+const (
+	GRAFANA_PANEL_WIDTH  = 12
+	GRAFANA_PANEL_HEIGHT = 8
+)
+
+// The most Records a session keeps for its snapshot, the latest ones, so a long session doesn't grow without bound
+const GRAFANA_SNAPSHOT_RECORDS = 10000
+
+// A Grafana dashboard snapshot of a session's Records, as POSTed to /api/snapshots.  The data is in the snapshot, so it can be shared without a TSDB.
+type GrafanaSnapshot struct {
+	Dashboard grafanaDashboard `json:"dashboard"`
+	Name      string           `json:"name"`
+	Expires   int              `json:"expires"` // seconds, 0 is never
+}
+
+type grafanaDashboard struct {
+	Title         string         `json:"title"`
+	Tags          []string       `json:"tags"`
+	Time          grafanaTime    `json:"time"`
+	Panels        []grafanaPanel `json:"panels"`
+	SchemaVersion int            `json:"schemaVersion"`
+}
+
+type grafanaTime struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// A timeseries panel with its data frame
+type grafanaPanel struct {
+	ID           int            `json:"id"`
+	Type         string         `json:"type"`
+	Title        string         `json:"title"`
+	GridPos      grafanaGridPos `json:"gridPos"`
+	SnapshotData []grafanaFrame `json:"snapshotData"`
+}
+
+type grafanaGridPos struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type grafanaFrame struct {
+	Fields []grafanaField `json:"fields"`
+}
+
+type grafanaField struct {
+	Name   string         `json:"name"`
+	Type   string         `json:"type"` // time or number
+	Values []any          `json:"values"`
+	Config map[string]any `json:"config"`
+}
+
+// Build a snapshot with a panel per Group of the view (and one for its cols outside of Groups), in the order of the given col names.  Only numeric values are plotted, Records from several hosts get a series per host.
+func NewGrafanaSnapshot(records []viewer.Record, cols []string) GrafanaSnapshot {
+	var snapshot GrafanaSnapshot
+	snapshot.Dashboard.SchemaVersion = 39
+	snapshot.Dashboard.Tags = []string{`myq-tools`}
+	if len(records) == 0 {
+		return snapshot
+	}
+
+	first, last := records[0], records[len(records)-1]
+	snapshot.Name = fmt.Sprintf("myq_status %s %s", first.View, first.Timestamp.Format(time.RFC3339))
+	snapshot.Dashboard.Title = snapshot.Name
+	snapshot.Dashboard.Time = grafanaTime{
+		From: first.Timestamp.Format(time.RFC3339),
+		To:   last.Timestamp.Format(time.RFC3339),
+	}
+
+	// The series of each panel, as record value names with a host prefix if any
+	type series struct{ host, col string }
+	panels := make(map[string][]series)
+	var panelOrder []string
+	for _, record := range records {
+		for _, col := range sortedKeys(record.Values) {
+			if _, ok := record.Values[col].(float64); !ok {
+				continue
+			}
+			panel := panelName(record.View, col)
+			s := series{record.Tags[`host`], col}
+			if !slices.Contains(panels[panel], s) {
+				if panels[panel] == nil {
+					panelOrder = append(panelOrder, panel)
+				}
+				panels[panel] = append(panels[panel], s)
+			}
+		}
+	}
+
+	// Panels follow the view's col order, then any others
+	position := func(panel string) int {
+		for i, col := range cols {
+			if panelName(first.View, col) == panel {
+				return i
+			}
+		}
+		return len(cols)
+	}
+	slices.SortStableFunc(panelOrder, func(a, b string) int {
+		return position(a) - position(b)
+	})
+
+	times := make([]any, len(records))
+	for i, record := range records {
+		times[i] = record.Timestamp.UnixMilli()
+	}
+
+	for i, panel := range panelOrder {
+		frame := grafanaFrame{Fields: []grafanaField{
+			{Name: `Time`, Type: `time`, Values: times, Config: map[string]any{}},
+		}}
+		for _, s := range panels[panel] {
+			name := strings.TrimPrefix(s.col, panel+`/`)
+			if s.host != `` {
+				name = s.host + ` ` + name
+			}
+			values := make([]any, len(records))
+			for j, record := range records {
+				if value, ok := record.Values[s.col].(float64); ok && record.Tags[`host`] == s.host {
+					values[j] = value
+				}
+			}
+			frame.Fields = append(frame.Fields, grafanaField{Name: name, Type: `number`, Values: values, Config: map[string]any{}})
+		}
+
+		snapshot.Dashboard.Panels = append(snapshot.Dashboard.Panels, grafanaPanel{
+			ID:    i + 1,
+			Type:  `timeseries`,
+			Title: panel,
+			GridPos: grafanaGridPos{
+				X: (i % 2) * GRAFANA_PANEL_WIDTH,
+				Y: (i / 2) * GRAFANA_PANEL_HEIGHT,
+				W: GRAFANA_PANEL_WIDTH,
+				H: GRAFANA_PANEL_HEIGHT,
+			},
+			SnapshotData: []grafanaFrame{frame},
+		})
+	}
+	return snapshot
+}
+
+// The panel of a col: its Group, or the view for cols outside of Groups
+func panelName(view, col string) string {
+	if group, _, found := strings.Cut(col, `/`); found {
+		return group
+	}
+	return view
+}
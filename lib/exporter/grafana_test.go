@@ -0,0 +1,66 @@
+package exporter
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jayjanssen/myq-tools/lib/viewer"
+)
+
+func TestGrafanaSnapshot(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	var records []viewer.Record
+	for i, host := range []string{`db1`, `db2`, `db1`} {
+		record := getTestRecord()
+		record.Timestamp = start.Add(time.Duration(i) * time.Second)
+		record.Tags = map[string]string{`host`: host}
+		record.Values[`load`] = float64(i)
+		records = append(records, record)
+	}
+
+	snapshot := NewGrafanaSnapshot(records, []string{`load`, `Threads/conn`, `Connects/cons`})
+	if snapshot.Dashboard.Time.From != `2024-01-02T03:04:05Z` || snapshot.Dashboard.Time.To != `2024-01-02T03:04:07Z` {
+		t.Errorf("unexpected time range: %+v", snapshot.Dashboard.Time)
+	}
+
+	// Groups with numbers in col order, the view's own cols first
+	var titles []string
+	for _, panel := range snapshot.Dashboard.Panels {
+		titles = append(titles, panel.Title)
+	}
+	if len(titles) != 2 || titles[0] != `cttf` || titles[1] != `Connects` {
+		t.Fatalf("unexpected panels: %v", titles)
+	}
+	if pos := snapshot.Dashboard.Panels[1].GridPos; pos.X != GRAFANA_PANEL_WIDTH || pos.Y != 0 {
+		t.Errorf("unexpected second panel position: %+v", pos)
+	}
+
+	// A series per host, with nulls where the other host's Records are
+	fields := snapshot.Dashboard.Panels[1].SnapshotData[0].Fields
+	if len(fields) != 3 || fields[0].Name != `Time` || fields[1].Name != `db1 cons` || fields[2].Name != `db2 cons` {
+		t.Fatalf("unexpected fields: %+v", fields)
+	}
+	if fields[0].Values[1] != start.Add(time.Second).UnixMilli() {
+		t.Errorf("unexpected time: %v", fields[0].Values[1])
+	}
+	if fields[1].Values[0] != 420.0 || fields[1].Values[1] != nil {
+		t.Errorf("unexpected db1 values: %v", fields[1].Values)
+	}
+
+	// Nulls are kept in the JSON
+	data, err := json.Marshal(fields[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"name":"db2 cons","type":"number","values":[null,420,null],"config":{}}` {
+		t.Errorf("unexpected json: %s", data)
+	}
+}
+
+func TestGrafanaSnapshotEmpty(t *testing.T) {
+	snapshot := NewGrafanaSnapshot(nil, nil)
+	if len(snapshot.Dashboard.Panels) != 0 {
+		t.Errorf("unexpected panels: %+v", snapshot.Dashboard.Panels)
+	}
+}
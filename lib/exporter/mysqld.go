@@ -0,0 +1,154 @@
+package exporter
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// Metric names and labels compatible with prometheus/mysqld_exporter, so dashboards built for it work against our endpoint
+const COMPAT_MYSQLD = `mysqld`
+
+// Status variables mysqld_exporter turns into a labeled metric
+var mysqldStatusRE = regexp.MustCompile(`^(com|handler|connection_errors|innodb_buffer_pool_pages|innodb_rows|performance_schema)_(.*)$`)
+
+// Parse a value the way mysqld_exporter does: booleans and states are 1 or 0, timestamps are unix time
+func parseMysqldValue(str string) (float64, bool) {
+	switch strings.ToLower(str) {
+	case `yes`, `on`, `primary`:
+		return 1, true
+	case `no`, `off`, `disabled`, `connecting`, `non-primary`, `disconnected`:
+		return 0, true
+	}
+	for _, layout := range []string{`Jan 02 15:04:05 2006 MST`, `2006-01-02 15:04:05`} {
+		if ts, err := time.Parse(layout, str); err == nil {
+			return float64(ts.Unix()), true
+		}
+	}
+	value, err := strconv.ParseFloat(str, 64)
+	return value, err == nil
+}
+
+// The keys of a Sample, sorted
+func sortedSampleKeys(sample loader.SampleReader) []string {
+	keys := sample.GetKeys()
+	slices.Sort(keys)
+	return keys
+}
+
+// Write the status, variables and replica Sources like mysqld_exporter's default collectors
+func writeMysqldMetrics(mw *metricWriter, samples map[loader.SourceName]loader.SampleReader) {
+	mw.write(`mysql_up`, `Whether the MySQL server is up.`, `gauge`, nil, 1)
+
+	if sample, ok := samples[`status`]; ok {
+		writeMysqldStatus(mw, sample)
+	}
+	if sample, ok := samples[`variables`]; ok {
+		writeMysqldVariables(mw, sample)
+	}
+	if sample, ok := samples[`replica`]; ok && sample.Length() > 0 {
+		writeMysqldReplica(mw, sample)
+	}
+}
+
+// SHOW GLOBAL STATUS as mysql_global_status_*
+func writeMysqldStatus(mw *metricWriter, sample loader.SampleReader) {
+	const prefix = `mysql_global_status_`
+	for _, key := range sortedSampleKeys(sample) {
+		str, _ := sample.GetString(key)
+		value, ok := parseMysqldValue(str)
+		if !ok {
+			continue
+		}
+		name := sanitizeName(key)
+
+		match := mysqldStatusRE.FindStringSubmatch(name)
+		if match == nil {
+			mw.write(prefix+name, `Generic metric from SHOW GLOBAL STATUS.`, `untyped`, nil, value)
+			continue
+		}
+		switch match[1] {
+		case `com`:
+			mw.write(prefix+`commands_total`, `Total number of executed MySQL commands.`, `counter`,
+				map[string]string{`command`: match[2]}, value)
+		case `handler`:
+			mw.write(prefix+`handlers_total`, `Total number of executed MySQL handlers.`, `counter`,
+				map[string]string{`handler`: match[2]}, value)
+		case `connection_errors`:
+			mw.write(prefix+`connection_errors_total`, `Total number of MySQL connection errors.`, `counter`,
+				map[string]string{`error`: match[2]}, value)
+		case `innodb_buffer_pool_pages`:
+			switch match[2] {
+			case `data`, `free`, `misc`, `old`:
+				mw.write(prefix+`buffer_pool_pages`, `Innodb buffer pool pages by state.`, `gauge`,
+					map[string]string{`state`: match[2]}, value)
+			case `dirty`:
+				mw.write(prefix+`buffer_pool_dirty_pages`, `Innodb buffer pool dirty pages.`, `gauge`, nil, value)
+			case `total`:
+			default:
+				mw.write(prefix+`buffer_pool_page_changes_total`, `Innodb buffer pool page state changes.`, `counter`,
+					map[string]string{`operation`: match[2]}, value)
+			}
+		case `innodb_rows`:
+			mw.write(prefix+`innodb_row_ops_total`, `Total number of MySQL InnoDB row operations.`, `counter`,
+				map[string]string{`operation`: match[2]}, value)
+		case `performance_schema`:
+			mw.write(prefix+`performance_schema_lost_total`, `Total number of MySQL instrumentations that could not be loaded or created due to memory constraints.`, `counter`,
+				map[string]string{`instrumentation`: match[2]}, value)
+		}
+	}
+}
+
+// SHOW GLOBAL VARIABLES as mysql_global_variables_*, and mysql_version_info
+func writeMysqldVariables(mw *metricWriter, sample loader.SampleReader) {
+	for _, key := range sortedSampleKeys(sample) {
+		str, _ := sample.GetString(key)
+		if value, ok := parseMysqldValue(str); ok {
+			mw.write(`mysql_global_variables_`+sanitizeName(key), `Generic gauge metric from SHOW GLOBAL VARIABLES.`, `gauge`, nil, value)
+		}
+	}
+
+	labels := make(map[string]string)
+	for _, key := range []string{`innodb_version`, `version`, `version_comment`} {
+		labels[key], _ = sample.GetString(key)
+	}
+	mw.write(`mysql_version_info`, `MySQL version and distribution.`, `gauge`, labels, 1)
+}
+
+// SHOW REPLICA STATUS as mysql_slave_status_*, under the pre-8.0.22 column names too
+func writeMysqldReplica(mw *metricWriter, sample loader.SampleReader) {
+	get := func(keys ...string) string {
+		for _, key := range keys {
+			if value, err := sample.GetString(key); err == nil {
+				return value
+			}
+		}
+		return ``
+	}
+	labels := map[string]string{
+		`master_host`:     get(`source_host`, `master_host`),
+		`master_uuid`:     get(`source_uuid`, `master_uuid`),
+		`channel_name`:    get(`channel_name`),
+		`connection_name`: get(`connection_name`),
+	}
+
+	for _, key := range sortedSampleKeys(sample) {
+		str, _ := sample.GetString(key)
+		value, ok := parseMysqldValue(str)
+		if !ok {
+			continue
+		}
+		names := []string{key}
+		if old, ok := loader.OldKeyName(`replica`, key); ok {
+			names = append(names, old)
+		}
+		for _, name := range names {
+			mw.write(fmt.Sprintf("mysql_slave_status_%s", sanitizeName(name)), `Generic metric from SHOW SLAVE STATUS.`, `untyped`, labels, value)
+		}
+	}
+}
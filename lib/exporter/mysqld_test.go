@@ -0,0 +1,91 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+func TestParseMysqldValue(t *testing.T) {
+	tests := map[string]float64{
+		`ON`:                  1,
+		`Connecting`:          0,
+		`Primary`:             1,
+		`12.5`:                12.5,
+		`2024-01-02 03:04:05`: 1704164645,
+	}
+	for str, expected := range tests {
+		if value, ok := parseMysqldValue(str); !ok || value != expected {
+			t.Errorf("%s: unexpected %f, %v", str, value, ok)
+		}
+	}
+	if _, ok := parseMysqldValue(`ROW`); ok {
+		t.Error("expected ROW to be unparseable")
+	}
+}
+
+func TestExporterMysqldCompat(t *testing.T) {
+	e := NewExporter()
+	if err := e.SetCompat(`node`); err == nil {
+		t.Error("expected an error for unknown compat")
+	}
+	if err := e.SetCompat(COMPAT_MYSQLD); err != nil {
+		t.Fatal(err)
+	}
+	if len(e.CompatSources()) != 2 {
+		t.Errorf("unexpected compat sources: %v", e.CompatSources())
+	}
+
+	state := loader.NewState()
+	status := loader.NewSample()
+	for key, value := range map[string]string{
+		`com_select`:                       `10`,
+		`com_insert`:                       `5`,
+		`innodb_buffer_pool_pages_data`:    `100`,
+		`innodb_buffer_pool_pages_dirty`:   `3`,
+		`innodb_buffer_pool_pages_free`:    `50`,
+		`innodb_buffer_pool_pages_total`:   `153`,
+		`innodb_buffer_pool_pages_flushed`: `7`,
+		`threads_connected`:                `4`,
+	} {
+		status.Data[key] = value
+	}
+	variables := loader.NewSample()
+	variables.Data[`max_connections`] = `151`
+	variables.Data[`version`] = `8.0.36`
+	variables.Data[`binlog_format`] = `ROW`
+	replica := loader.NewSample()
+	replica.Data[`seconds_behind_source`] = `2`
+	replica.Data[`source_host`] = `db1`
+	replica.Data[`replica_io_running`] = `Yes`
+	state.GetCurrentWriter().SetSample(`status`, status)
+	state.GetCurrentWriter().SetSample(`variables`, variables)
+	state.GetCurrentWriter().SetSample(`replica`, replica)
+
+	e.Update(getTestRecord(), state)
+	metrics := string(e.metrics)
+
+	for _, expected := range []string{
+		"mysql_up 1\n",
+		"# TYPE mysql_global_status_commands_total counter\nmysql_global_status_commands_total{command=\"insert\"} 5\nmysql_global_status_commands_total{command=\"select\"} 10\n",
+		"# TYPE mysql_global_status_buffer_pool_pages gauge\nmysql_global_status_buffer_pool_pages{state=\"data\"} 100\nmysql_global_status_buffer_pool_pages{state=\"free\"} 50\n",
+		"mysql_global_status_buffer_pool_dirty_pages 3\n",
+		"mysql_global_status_buffer_pool_page_changes_total{operation=\"flushed\"} 7\n",
+		"mysql_global_status_threads_connected 4\n",
+		"mysql_global_variables_max_connections 151\n",
+		"mysql_version_info{innodb_version=\"\",version=\"8.0.36\",version_comment=\"\"} 1\n",
+		"mysql_slave_status_seconds_behind_master{channel_name=\"\",connection_name=\"\",master_host=\"db1\",master_uuid=\"\"} 2\n",
+		"mysql_slave_status_slave_io_running{channel_name=\"\",connection_name=\"\",master_host=\"db1\",master_uuid=\"\"} 1\n",
+		"myq_cttf_connects_cons 420\n",
+	} {
+		if !strings.Contains(metrics, expected) {
+			t.Errorf("missing %q in:\n%s", expected, metrics)
+		}
+	}
+	for _, unexpected := range []string{`pages_total`, `binlog_format`} {
+		if strings.Contains(metrics, unexpected) {
+			t.Errorf("unexpected %s in:\n%s", unexpected, metrics)
+		}
+	}
+}
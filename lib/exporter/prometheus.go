@@ -0,0 +1,239 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+	"github.com/jayjanssen/myq-tools/lib/viewer"
+)
+
+// Prefix of every metric we export
+const METRIC_PREFIX = `myq`
+
+// Serves the latest values rendered by myq_status as a Prometheus /metrics endpoint
+type Exporter struct {
+	// Also export every numeric value collected, not just the view's
+	sources bool
+
+	// Constant labels added to every metric, e.g. from -aws-tags
+	labels map[string]string
+
+	// Also export the Sources under another exporter's names, e.g. COMPAT_MYSQLD
+	compat string
+
+	mutex   sync.Mutex
+	metrics []byte
+
+	server *http.Server
+}
+
+func NewExporter() *Exporter {
+	return &Exporter{labels: make(map[string]string)}
+}
+
+// Export every numeric value collected from every Source as well as the view's
+func (e *Exporter) SetSources(sources bool) {
+	e.sources = sources
+}
+
+// Also export the Sources another exporter collects under its names, so its dashboards work.  Only COMPAT_MYSQLD is supported.
+func (e *Exporter) SetCompat(compat string) error {
+	if compat != COMPAT_MYSQLD {
+		return fmt.Errorf("unknown exporter compatibility `%s`, only %s is supported", compat, COMPAT_MYSQLD)
+	}
+	e.compat = compat
+	return nil
+}
+
+// The Sources needed for the compatibility mode, if any
+func (e *Exporter) CompatSources() []loader.SourceName {
+	if e.compat == COMPAT_MYSQLD {
+		return []loader.SourceName{`status`, `variables`}
+	}
+	return nil
+}
+
+// Add constant labels to every metric
+func (e *Exporter) SetLabels(labels map[string]string) {
+	for label, value := range labels {
+		e.labels[sanitizeName(label)] = value
+	}
+}
+
+// Replace the exported metrics with the given Record and the State it came from
+func (e *Exporter) Update(record viewer.Record, state loader.StateReader) {
+	mw := newMetricWriter(e.labels)
+
+	mw.write(METRIC_PREFIX+`_seq`, `Interval number of the latest sample`, `counter`, nil, float64(record.Seq))
+	mw.write(METRIC_PREFIX+`_last_sample_timestamp_seconds`, `When the latest sample was collected`, `gauge`, nil,
+		float64(state.GetCurrent().GetTimeGenerated().UnixMilli())/1000)
+
+	// The view's cols, strings and values that could not be computed are left out
+	for _, col := range sortedKeys(record.Values) {
+		if value, ok := record.Values[col].(float64); ok {
+			name := fmt.Sprintf("%s_%s_%s", METRIC_PREFIX, sanitizeName(record.View), sanitizeName(col))
+			mw.write(name, fmt.Sprintf("%s %s", record.View, col), `gauge`, nil, value)
+		}
+	}
+
+	samples := state.(*loader.State).Current.Samples
+	if e.compat == COMPAT_MYSQLD {
+		writeMysqldMetrics(mw, samples)
+	}
+
+	if e.sources {
+		for _, source := range sortedKeys(samples) {
+			sample := samples[source]
+			keys := sample.GetKeys()
+			slices.Sort(keys)
+			for _, key := range keys {
+				str, _ := sample.GetString(key)
+				if value, err := strconv.ParseFloat(str, 64); err == nil {
+					name := fmt.Sprintf("%s_%s_%s", METRIC_PREFIX, sanitizeName(string(source)), sanitizeName(key))
+					mw.write(name, fmt.Sprintf("%s %s", source, key), `untyped`, nil, value)
+				}
+			}
+		}
+	}
+
+	metrics := mw.bytes()
+	e.mutex.Lock()
+	e.metrics = metrics
+	e.mutex.Unlock()
+}
+
+// A metric name with its HELP, TYPE and series
+type metricFamily struct {
+	name, help, metricType string
+	series                 []string
+}
+
+// Builds metrics in the text exposition format, the series of each family are grouped together under its HELP and TYPE
+type metricWriter struct {
+	// Labels on every series
+	labels map[string]string
+
+	families []*metricFamily
+	byName   map[string]*metricFamily
+
+	// Series written so far, duplicates are invalid
+	seen map[string]bool
+}
+
+func newMetricWriter(labels map[string]string) *metricWriter {
+	return &metricWriter{
+		labels: labels,
+		byName: make(map[string]*metricFamily),
+		seen:   make(map[string]bool),
+	}
+}
+
+// Add a series with the given labels (as well as the writer's), duplicates are left out
+func (mw *metricWriter) write(name, help, metricType string, labels map[string]string, value float64) {
+	all := make(map[string]string)
+	for label, value := range mw.labels {
+		all[label] = value
+	}
+	for label, value := range labels {
+		all[label] = value
+	}
+	series := name + formatLabels(all)
+	if mw.seen[series] {
+		return
+	}
+	mw.seen[series] = true
+
+	family, ok := mw.byName[name]
+	if !ok {
+		family = &metricFamily{name: name, help: help, metricType: metricType}
+		mw.byName[name] = family
+		mw.families = append(mw.families, family)
+	}
+	family.series = append(family.series, fmt.Sprintf("%s %s", series, strconv.FormatFloat(value, 'g', -1, 64)))
+}
+
+// The families in the order they were first written
+func (mw *metricWriter) bytes() []byte {
+	var buf bytes.Buffer
+	for _, family := range mw.families {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s %s\n", family.name, family.help, family.name, family.metricType)
+		for _, series := range family.series {
+			fmt.Fprintln(&buf, series)
+		}
+	}
+	return buf.Bytes()
+}
+
+// Serve the latest metrics, nothing until the first Update
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mutex.Lock()
+	metrics := e.metrics
+	e.mutex.Unlock()
+
+	w.Header().Set(`Content-Type`, `text/plain; version=0.0.4; charset=utf-8`)
+	w.Write(metrics)
+}
+
+// Serve /metrics on the given address in the background
+func (e *Exporter) Listen(address string) (net.Addr, error) {
+	listener, err := net.Listen(`tcp`, address)
+	if err != nil {
+		return nil, fmt.Errorf("cannot listen on %s: %v", address, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(`/metrics`, e)
+	e.server = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go e.server.Serve(listener)
+	return listener.Addr(), nil
+}
+
+// Stop serving
+func (e *Exporter) Close() error {
+	if e.server == nil {
+		return nil
+	}
+	return e.server.Close()
+}
+
+// Labels in exposition format, e.g. `{region="us-east-1"}`, or an empty string
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ``
+	}
+	var pairs []string
+	for _, label := range sortedKeys(labels) {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", label, strconv.Quote(labels[label])))
+	}
+	return `{` + strings.Join(pairs, `,`) + `}`
+}
+
+// Lower case the name and replace anything Prometheus doesn't allow in a name with _
+func sanitizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		if r >= 'A' && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return '_'
+	}, name)
+}
+
+// The keys of a map, sorted
+func sortedKeys[K ~string, V any](m map[K]V) (keys []K) {
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return
+}
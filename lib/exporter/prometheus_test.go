@@ -0,0 +1,97 @@
+package exporter
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+	"github.com/jayjanssen/myq-tools/lib/viewer"
+)
+
+func getTestState() loader.StateReader {
+	state := loader.NewState()
+	sample := loader.NewSample()
+	sample.Data[`queries`] = `1000`
+	sample.Data[`version_comment`] = `MySQL Community Server`
+	state.GetCurrentWriter().SetSample(`status`, sample)
+	return state
+}
+
+func getTestRecord() viewer.Record {
+	return viewer.Record{
+		View: `cttf`,
+		Seq:  3,
+		Values: map[string]any{
+			`Connects/cons`: 420.0,
+			`Threads/conn`:  nil,
+			`state`:         `ON`,
+		},
+	}
+}
+
+func TestExporterUpdate(t *testing.T) {
+	e := NewExporter()
+	e.SetLabels(map[string]string{`instance-id`: `i-123`})
+	e.Update(getTestRecord(), getTestState())
+	metrics := string(e.metrics)
+
+	for _, expected := range []string{
+		"# TYPE myq_cttf_connects_cons gauge\nmyq_cttf_connects_cons{instance_id=\"i-123\"} 420\n",
+		"myq_seq{instance_id=\"i-123\"} 3\n",
+	} {
+		if !strings.Contains(metrics, expected) {
+			t.Errorf("missing %q in:\n%s", expected, metrics)
+		}
+	}
+	for _, unexpected := range []string{`threads_conn`, `_state`, `myq_status_queries`} {
+		if strings.Contains(metrics, unexpected) {
+			t.Errorf("unexpected %s in:\n%s", unexpected, metrics)
+		}
+	}
+
+	// All the numeric values collected
+	e.SetSources(true)
+	e.Update(getTestRecord(), getTestState())
+	metrics = string(e.metrics)
+	if !strings.Contains(metrics, "myq_status_queries{instance_id=\"i-123\"} 1000\n") {
+		t.Errorf("missing myq_status_queries in:\n%s", metrics)
+	}
+	if strings.Contains(metrics, `version_comment`) {
+		t.Errorf("unexpected string value in:\n%s", metrics)
+	}
+}
+
+func TestExporterListen(t *testing.T) {
+	e := NewExporter()
+	addr, err := e.Listen(`127.0.0.1:0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+	e.Update(getTestRecord(), getTestState())
+
+	resp, err := http.Get("http://" + addr.String() + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), `myq_cttf_connects_cons 420`) {
+		t.Errorf("unexpected response %d:\n%s", resp.StatusCode, body)
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	tests := map[string]string{
+		`Connects/cons`: `connects_cons`,
+		`>10s`:          `_10s`,
+		`aws.rds`:       `aws_rds`,
+	}
+	for name, expected := range tests {
+		if sanitizeName(name) != expected {
+			t.Errorf("%s: unexpected %s", name, sanitizeName(name))
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jayjanssen/myq-tools/lib/viewer"
+)
+
+// Sink protocols: plain statsd, or DogStatsD (Datadog's agent) which also takes tags
+const (
+	SINK_STATSD    = `statsd`
+	SINK_DOGSTATSD = `dogstatsd`
+)
+
+// Datagrams are kept under a typical MTU so they aren't fragmented, see: https://github.com/statsd/statsd/blob/master/docs/metric_types.md#multi-metric-packets
+const STATSD_MAX_PACKET = 1432
+
+// Ships the view's values of every Record to a statsd server as gauges, so a view doubles as a derived metrics pipeline
+type StatsD struct {
+	protocol string
+	conn     net.Conn
+}
+
+// Connect to a sink like `statsd://host:8125` or `dogstatsd://host:8125` (UDP)
+func NewStatsD(sink string) (*StatsD, error) {
+	protocol, address, found := strings.Cut(sink, `://`)
+	if !found || (protocol != SINK_STATSD && protocol != SINK_DOGSTATSD) {
+		return nil, fmt.Errorf("unknown sink `%s`, use %s://host:port or %s://host:port", sink, SINK_STATSD, SINK_DOGSTATSD)
+	}
+	conn, err := net.Dial(`udp`, address)
+	if err != nil {
+		return nil, err
+	}
+	return &StatsD{protocol: protocol, conn: conn}, nil
+}
+
+// The metric lines of the Record, strings and values that could not be computed are left out.  Names are like `myq.innodb.row_ops_read`, tags are only sent with DogStatsD.  Plain statsd has the host of a -hosts Record in the name instead, like `myq.db1.innodb.row_ops_read`, so the hosts don't overwrite each other's gauges.
+func (s *StatsD) lines(record viewer.Record) []string {
+	var suffix string
+	prefix := METRIC_PREFIX
+	if s.protocol == SINK_DOGSTATSD && len(record.Tags) > 0 {
+		var tags []string
+		for _, tag := range sortedKeys(record.Tags) {
+			tags = append(tags, sanitizeName(tag)+`:`+sanitizeTagValue(record.Tags[tag]))
+		}
+		suffix = `|#` + strings.Join(tags, `,`)
+	} else if host := record.Tags[`host`]; host != `` {
+		prefix += `.` + sanitizeName(host)
+	}
+
+	var lines []string
+	for _, col := range sortedKeys(record.Values) {
+		if value, ok := record.Values[col].(float64); ok {
+			lines = append(lines, fmt.Sprintf("%s.%s.%s:%s|g%s", prefix, sanitizeName(record.View), sanitizeName(col),
+				strconv.FormatFloat(value, 'f', -1, 64), suffix))
+		}
+	}
+	return lines
+}
+
+// Send the Record's values in as few datagrams as fit.  Repeated values aren't sent again, statsd gauges keep their last value.
+func (s *StatsD) Write(record viewer.Record) error {
+	if record.Repeated {
+		return nil
+	}
+	var packet bytes.Buffer
+	flush := func() error {
+		if packet.Len() == 0 {
+			return nil
+		}
+		_, err := s.conn.Write(packet.Bytes())
+		packet.Reset()
+		return err
+	}
+	for _, line := range s.lines(record) {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > STATSD_MAX_PACKET {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	return flush()
+}
+
+// A tag value without the characters that separate tags and fields in a DogStatsD line
+func sanitizeTagValue(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ',' || r == '|' || r == '#' || unicode.IsSpace(r) {
+			return '_'
+		}
+		return r
+	}, value)
+}
+
+func (s *StatsD) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,136 @@
+package exporter
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A statsd server on a local UDP port
+func getTestStatsDServer(t *testing.T) net.PacketConn {
+	server, err := net.ListenPacket(`udp`, `127.0.0.1:0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+	return server
+}
+
+func readPacket(t *testing.T, server net.PacketConn) string {
+	buf := make([]byte, STATSD_MAX_PACKET)
+	server.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := server.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf[:n])
+}
+
+func TestNewStatsDUnknownSink(t *testing.T) {
+	for _, sink := range []string{`127.0.0.1:8125`, `kafka://127.0.0.1:9092`} {
+		if _, err := NewStatsD(sink); err == nil {
+			t.Errorf("%s: expected an error", sink)
+		}
+	}
+}
+
+func TestStatsDWrite(t *testing.T) {
+	server := getTestStatsDServer(t)
+	s, err := NewStatsD(`statsd://` + server.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// Plain statsd has no tags
+	record := getTestRecord()
+	record.Tags = map[string]string{`region`: `us-east-1`}
+	if err := s.Write(record); err != nil {
+		t.Fatal(err)
+	}
+	if packet := readPacket(t, server); packet != `myq.cttf.connects_cons:420|g` {
+		t.Errorf("unexpected packet: %q", packet)
+	}
+
+	// Repeated values aren't sent again
+	record.Repeated = true
+	if err := s.Write(record); err != nil {
+		t.Fatal(err)
+	}
+	record.Repeated = false
+	record.Values[`Connects/cons`] = 12.5
+	if err := s.Write(record); err != nil {
+		t.Fatal(err)
+	}
+	if packet := readPacket(t, server); packet != `myq.cttf.connects_cons:12.5|g` {
+		t.Errorf("unexpected packet: %q", packet)
+	}
+}
+
+func TestDogStatsDTags(t *testing.T) {
+	server := getTestStatsDServer(t)
+	s, err := NewStatsD(`dogstatsd://` + server.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	record := getTestRecord()
+	record.Tags = map[string]string{`host`: `db1:3306`, `instance-id`: `i-123`, `env`: `a,b|c #d`}
+	if err := s.Write(record); err != nil {
+		t.Fatal(err)
+	}
+	if packet := readPacket(t, server); packet != `myq.cttf.connects_cons:420|g|#env:a_b_c__d,host:db1:3306,instance_id:i-123` {
+		t.Errorf("unexpected packet: %q", packet)
+	}
+}
+
+// Without tags, each host's gauges are named apart
+func TestStatsDHostNames(t *testing.T) {
+	server := getTestStatsDServer(t)
+	s, err := NewStatsD(`statsd://` + server.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	record := getTestRecord()
+	record.Tags = map[string]string{`host`: `db1.example.com:3306`}
+	if err := s.Write(record); err != nil {
+		t.Fatal(err)
+	}
+	if packet := readPacket(t, server); packet != `myq.db1_example_com_3306.cttf.connects_cons:420|g` {
+		t.Errorf("unexpected packet: %q", packet)
+	}
+}
+
+func TestStatsDPackets(t *testing.T) {
+	server := getTestStatsDServer(t)
+	s, err := NewStatsD(`statsd://` + server.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	record := getTestRecord()
+	for i := range 200 {
+		record.Values[fmt.Sprintf("col%03d", i)] = float64(i)
+	}
+	if err := s.Write(record); err != nil {
+		t.Fatal(err)
+	}
+
+	var lines int
+	for lines < 201 {
+		packet := readPacket(t, server)
+		if len(packet) > STATSD_MAX_PACKET {
+			t.Errorf("packet too big: %d", len(packet))
+		}
+		lines += len(strings.Split(packet, "\n"))
+	}
+	if lines != 201 {
+		t.Errorf("unexpected lines: %d", lines)
+	}
+}
@@ -0,0 +1,60 @@
+package loader
+
+// A key renamed in a newer server version.  Views use the new name, and Samples from older servers get it too.
+type keyAlias struct {
+	source   SourceName
+	old, new string
+}
+
+// Keys renamed in MySQL 8.0.22+ (replica/source terminology), 8.4 and Galera 4
+var keyAliases = []keyAlias{
+	// SHOW SLAVE STATUS columns
+	{`replica`, `slave_io_running`, `replica_io_running`},
+	{`replica`, `slave_sql_running`, `replica_sql_running`},
+	{`replica`, `seconds_behind_master`, `seconds_behind_source`},
+	{`replica`, `master_host`, `source_host`},
+	{`replica`, `master_port`, `source_port`},
+	{`replica`, `master_log_file`, `source_log_file`},
+	{`replica`, `read_master_log_pos`, `read_source_log_pos`},
+	{`replica`, `relay_master_log_file`, `relay_source_log_file`},
+	{`replica`, `exec_master_log_pos`, `exec_source_log_pos`},
+	{`replica`, `master_uuid`, `source_uuid`},
+
+	{`status`, `slave_open_temp_tables`, `replica_open_temp_tables`},
+	{`status`, `com_show_slave_status`, `com_show_replica_status`},
+	{`status`, `com_show_slave_hosts`, `com_show_replicas`},
+	{`status`, `rpl_semi_sync_master_status`, `rpl_semi_sync_source_status`},
+	{`status`, `rpl_semi_sync_master_clients`, `rpl_semi_sync_source_clients`},
+	{`status`, `rpl_semi_sync_master_yes_tx`, `rpl_semi_sync_source_yes_tx`},
+	{`status`, `rpl_semi_sync_master_no_tx`, `rpl_semi_sync_source_no_tx`},
+	{`status`, `rpl_semi_sync_slave_status`, `rpl_semi_sync_replica_status`},
+
+	{`variables`, `slave_parallel_workers`, `replica_parallel_workers`},
+	{`variables`, `slave_parallel_type`, `replica_parallel_type`},
+	{`variables`, `log_slave_updates`, `log_replica_updates`},
+	{`variables`, `wsrep_slave_threads`, `wsrep_applier_threads`},
+}
+
+// Copy old keys to their new names, unless the server already has the new one
+func (s *Sample) applyAliases(source SourceName) {
+	for _, alias := range keyAliases {
+		if alias.source != source {
+			continue
+		}
+		if value, ok := s.Data[alias.old]; ok {
+			if _, ok := s.Data[alias.new]; !ok {
+				s.Data[alias.new] = value
+			}
+		}
+	}
+}
+
+// The name the given key had before it was renamed, if it was
+func OldKeyName(source SourceName, key string) (string, bool) {
+	for _, alias := range keyAliases {
+		if alias.source == source && alias.new == key {
+			return alias.old, true
+		}
+	}
+	return ``, false
+}
@@ -0,0 +1,49 @@
+package loader
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// How long to wait before retrying a failed collection, growing with each consecutive failure
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+}
+
+// Defaults suitable for a server on the local network
+func DefaultBackoff() Backoff {
+	return Backoff{
+		Initial:    time.Second,
+		Max:        30 * time.Second,
+		Multiplier: 2,
+	}
+}
+
+// Check the settings make sense
+func (b Backoff) Validate() error {
+	if b.Initial <= 0 {
+		return fmt.Errorf("backoff initial delay must be > 0 (%s)", b.Initial)
+	}
+	if b.Max < b.Initial {
+		return fmt.Errorf("backoff max delay (%s) must be >= initial delay (%s)", b.Max, b.Initial)
+	}
+	if b.Multiplier < 1 {
+		return fmt.Errorf("backoff multiplier must be >= 1 (%g)", b.Multiplier)
+	}
+	return nil
+}
+
+// The delay after the given number of consecutive failures (starting at 1)
+func (b Backoff) Delay(failures int) time.Duration {
+	if failures < 1 {
+		return 0
+	}
+	delay := float64(b.Initial) * math.Pow(b.Multiplier, float64(failures-1))
+	if delay > float64(b.Max) {
+		return b.Max
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,41 @@
+package loader
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: 10 * time.Second, Multiplier: 3}
+
+	expected := map[int]time.Duration{
+		0: 0,
+		1: time.Second,
+		2: 3 * time.Second,
+		3: 9 * time.Second,
+		4: 10 * time.Second,
+		9: 10 * time.Second,
+	}
+	for failures, delay := range expected {
+		if d := b.Delay(failures); d != delay {
+			t.Errorf("unexpected delay after %d failures: %s", failures, d)
+		}
+	}
+}
+
+func TestBackoffValidate(t *testing.T) {
+	if err := DefaultBackoff().Validate(); err != nil {
+		t.Error(err)
+	}
+
+	bad := []Backoff{
+		{Initial: 0, Max: time.Second, Multiplier: 2},
+		{Initial: time.Minute, Max: time.Second, Multiplier: 2},
+		{Initial: time.Second, Max: time.Minute, Multiplier: 0.5},
+	}
+	for _, b := range bad {
+		if b.Validate() == nil {
+			t.Errorf("expected an error for %+v", b)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package loader
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The most load a LiveLoader may put on the server each interval, zero is no limit.  Time is measured by the client, so it includes the network and is an upper bound on the server's time.
+type Budget struct {
+	Queries int
+	Time    time.Duration
+}
+
+// Parse a budget like `queries=5,time=50ms`, either limit can be left out
+func ParseBudget(str string) (Budget, error) {
+	var b Budget
+	for _, pair := range strings.Split(str, `,`) {
+		name, value, found := strings.Cut(pair, `=`)
+		var err error
+		switch {
+		case !found:
+			err = fmt.Errorf("expected name=value")
+		case name == `queries`:
+			b.Queries, err = strconv.Atoi(value)
+		case name == `time`:
+			b.Time, err = time.ParseDuration(value)
+		default:
+			err = fmt.Errorf("unknown limit %s, use queries or time", name)
+		}
+		if err != nil {
+			return b, fmt.Errorf("invalid budget `%s`: %v", pair, err)
+		}
+	}
+	if b.Queries < 0 || b.Time < 0 {
+		return b, fmt.Errorf("budget limits must be >= 0: `%s`", str)
+	}
+	return b, nil
+}
+
+func (b Budget) String() string {
+	var limits []string
+	if b.Queries > 0 {
+		limits = append(limits, fmt.Sprintf("queries=%d", b.Queries))
+	}
+	if b.Time > 0 {
+		limits = append(limits, fmt.Sprintf("time=%s", b.Time))
+	}
+	return strings.Join(limits, `,`)
+}
+
+// Tracks what each Source costs to collect, to choose which fit in the Budget each interval
+type budgetPlanner struct {
+	budget Budget
+
+	// The time the last collection of each Source took, and the interval it was collected in
+	costs     map[SourceName]time.Duration
+	collected map[SourceName]uint64
+}
+
+func newBudgetPlanner(b Budget) *budgetPlanner {
+	return &budgetPlanner{
+		budget:    b,
+		costs:     make(map[SourceName]time.Duration),
+		collected: make(map[SourceName]uint64),
+	}
+}
+
+// Split the Sources into those to collect in the given interval and those to skip.  Status (or else the first Source) is always collected, the others are collected least recently collected first while they fit.  The time a Source takes is spread over the intervals since it was last collected, so a Source heavier than the whole Budget is still collected every few intervals and the Budget holds on average.  A Source that has never been collected is assumed to be free, so everything is collected once.
+func (bp *budgetPlanner) plan(sources []SourceName, seq uint64) (collect, skip []SourceName) {
+	if len(sources) == 0 {
+		return
+	}
+	first := 0
+	if i := slices.Index(sources, `status`); i >= 0 {
+		first = i
+	}
+	collect = []SourceName{sources[first]}
+	spent := bp.costs[sources[first]]
+
+	rest := slices.Delete(slices.Clone(sources), first, first+1)
+	slices.SortStableFunc(rest, func(a, b SourceName) int {
+		return cmp.Compare(bp.collected[a], bp.collected[b])
+	})
+	for _, source := range rest {
+		cost := bp.costs[source] / time.Duration(max(seq-bp.collected[source], 1))
+		if bp.budget.Queries > 0 && len(collect)+1 > bp.budget.Queries ||
+			bp.budget.Time > 0 && spent+cost > bp.budget.Time {
+			skip = append(skip, source)
+			continue
+		}
+		collect = append(collect, source)
+		spent += cost
+	}
+
+	for _, source := range collect {
+		bp.collected[source] = seq
+	}
+	return
+}
+
+// Record what collecting a Source took
+func (bp *budgetPlanner) spent(source SourceName, d time.Duration) {
+	bp.costs[source] = d
+}
+
+// Source names as a comma separated list
+func joinSources(sources []SourceName) string {
+	var names []string
+	for _, source := range sources {
+		names = append(names, string(source))
+	}
+	return strings.Join(names, `, `)
+}
@@ -0,0 +1,65 @@
+package loader
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseBudget(t *testing.T) {
+	tests := map[string]Budget{
+		`queries=5,time=50ms`: {Queries: 5, Time: 50 * time.Millisecond},
+		`queries=3`:           {Queries: 3},
+		`time=1s`:             {Time: time.Second},
+	}
+	for str, expected := range tests {
+		b, err := ParseBudget(str)
+		if err != nil || b != expected {
+			t.Errorf("%s: unexpected %+v %v", str, b, err)
+		}
+		if b.String() != str {
+			t.Errorf("%s: unexpected String() %s", str, b)
+		}
+	}
+
+	for _, bad := range []string{``, `queries`, `queries=x`, `time=5`, `cpu=1`, `queries=-1`} {
+		if _, err := ParseBudget(bad); err == nil {
+			t.Errorf("expected error for `%s`", bad)
+		}
+	}
+}
+
+func TestBudgetPlanner(t *testing.T) {
+	sources := []SourceName{`variables`, `status`, `innodb_trx`, `replica`}
+	bp := newBudgetPlanner(Budget{Queries: 2})
+
+	// Status always comes first, the rest take turns
+	collect, skip := bp.plan(sources, 1)
+	if !reflect.DeepEqual(collect, []SourceName{`status`, `variables`}) || len(skip) != 2 {
+		t.Errorf("unexpected plan 1: %v %v", collect, skip)
+	}
+	collect, _ = bp.plan(sources, 2)
+	if !reflect.DeepEqual(collect, []SourceName{`status`, `innodb_trx`}) {
+		t.Errorf("unexpected plan 2: %v", collect)
+	}
+	collect, _ = bp.plan(sources, 3)
+	if !reflect.DeepEqual(collect, []SourceName{`status`, `replica`}) {
+		t.Errorf("unexpected plan 3: %v", collect)
+	}
+
+	// A heavy Source is skipped until its time spread over the intervals since it was collected fits
+	bp = newBudgetPlanner(Budget{Time: 50 * time.Millisecond})
+	bp.spent(`status`, 10*time.Millisecond)
+	bp.spent(`innodb_trx`, 100*time.Millisecond)
+	bp.spent(`variables`, 10*time.Millisecond)
+	for seq := uint64(1); seq <= 2; seq++ {
+		collect, skip = bp.plan(sources, seq)
+		if !reflect.DeepEqual(skip, []SourceName{`innodb_trx`}) {
+			t.Errorf("unexpected time plan %d: %v %v", seq, collect, skip)
+		}
+	}
+	if collect, skip = bp.plan(sources, 3); !slices.Contains(collect, `innodb_trx`) {
+		t.Errorf("expected innodb_trx in the third interval: %v %v", collect, skip)
+	}
+}
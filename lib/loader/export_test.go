@@ -0,0 +1,30 @@
+package loader
+
+// For the loader_test tests of what the default views query, the viewer package imports the loader so they can't be in it
+
+// A server with a given version and performance_schema setting, recording the probes
+type staticProber struct {
+	version string
+	pfs     bool
+	probes  []string
+}
+
+func (p *staticProber) probeVersion() string {
+	p.probes = append(p.probes, VERSION_QUERY)
+	return p.version
+}
+
+func (p *staticProber) probePerformanceSchema() bool {
+	p.probes = append(p.probes, PFS_ENABLED_QUERY)
+	return p.pfs
+}
+
+// The queries the LiveLoader runs for the given Sources on a server with the given version (e.g. `8.4.0`) and performance_schema setting: the probes when it connects, then the collections every interval.  Sources it doesn't know are left out, and nothing is queried for Sources that aren't given.
+func LiveQueries(sources []SourceName, pfs bool, version string) (probes, collect []string, err error) {
+	prober := &staticProber{version: version, pfs: pfs}
+	names, queries, err := routeLiveSources(knownLiveSources(sources), prober)
+	for _, name := range names {
+		collect = append(collect, queries[name].query)
+	}
+	return prober.probes, collect, err
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -17,12 +18,27 @@ const (
 	F_END_STRING string         = "MYQTOOLSEND"
 	BATCH        showoutputtype = iota
 	TABULAR
+	// pt-stalk's captures: each record starts with a `TS <epoch> <date>` line, followed by batch or tabular output
+	TIMESTAMPED
 )
 
 type FileParser struct {
 	scanner    *bufio.Scanner
 	outputtype showoutputtype
 	fileName   string
+
+	// Read from here instead of opening fileName, e.g. a network stream
+	reader io.Reader
+
+	// Error on malformed samples instead of skipping them
+	strict bool
+
+	// Number of malformed samples skipped so far
+	skipped int
+
+	// Number of records read so far, and if the last one was cut off by the end of the file
+	records   int
+	truncated bool
 }
 
 func NewFileParser(fileName string) *FileParser {
@@ -30,11 +46,20 @@ func NewFileParser(fileName string) *FileParser {
 	return &f
 }
 
+// Parse a stream instead of a file, the name is used in errors
+func newStreamParser(name string, r io.Reader) *FileParser {
+	return &FileParser{fileName: name, reader: r}
+}
+
 func (f *FileParser) Initialize(interval time.Duration) error {
 	// Open the given file
-	r, err := os.OpenFile(f.fileName, os.O_RDONLY, 0)
-	if err != nil {
-		return err
+	r := f.reader
+	if r == nil {
+		file, err := os.OpenFile(f.fileName, os.O_RDONLY, 0)
+		if err != nil {
+			return err
+		}
+		r = file
 	}
 
 	// Check the interval
@@ -79,12 +104,18 @@ func (f *FileParser) Initialize(interval time.Duration) error {
 		if !typechecked {
 			if bytes.HasPrefix(data, []byte(`+`)) || bytes.HasPrefix(data, []byte(`|`)) {
 				f.outputtype, recordmatch = TABULAR, []byte(`| Variable_name`)
+			} else if bytes.HasPrefix(data, []byte(`TS `)) {
+				f.outputtype, recordmatch = TIMESTAMPED, []byte(`TS `)
 			}
 			typechecked = true
 		}
 
 		// Find a new record
-		if end := bytes.Index(data, recordmatch); end >= 0 {
+		end := bytes.Index(data, recordmatch)
+		if f.outputtype == TIMESTAMPED {
+			end = indexLineStart(data, recordmatch)
+		}
+		if end >= 0 {
 			nl := bytes.IndexByte(data[end:], '\n') // Find the subsequent newline
 
 			// if our record match is at position 0, we skip this line and start from the next
@@ -97,11 +128,15 @@ func (f *FileParser) Initialize(interval time.Duration) error {
 				return end + nl + 1, nil, nil
 			}
 			// fmt.Println( "Found record: ", string(data[0:end]))
+			f.records++
 			return end + nl + 1, data[0:end], nil
 		}
 
 		// if we're at EOF and have data, return it, otherwise let it fall through
 		if atEOF && len(data) > 0 {
+			// A BATCH record is only complete with its end string, unless the file has just one record (e.g., variables)
+			f.truncated = f.outputtype == BATCH && f.records > 0 && len(bytes.TrimSpace(data)) > 0
+			f.records++
 			return len(data), data, nil
 		}
 
@@ -112,6 +147,27 @@ func (f *FileParser) Initialize(interval time.Duration) error {
 	return nil
 }
 
+// The position of the first line starting with prefix, or -1
+func indexLineStart(data, prefix []byte) int {
+	if bytes.HasPrefix(data, prefix) {
+		return 0
+	}
+	if i := bytes.Index(data, append([]byte{'\n'}, prefix...)); i >= 0 {
+		return i + 1
+	}
+	return -1
+}
+
+// Error on malformed samples instead of skipping them
+func (f *FileParser) SetStrict(strict bool) {
+	f.strict = strict
+}
+
+// The number of malformed samples skipped so far
+func (f *FileParser) Skipped() int {
+	return f.skipped
+}
+
 // Scan for the next record set in the file and return it
 // If the return is (nil, nil), it indicates end of file
 func (f *FileParser) GetNextSample() *Sample {
@@ -125,6 +181,7 @@ func (f *FileParser) GetNextSample() *Sample {
 
 	buffer := bytes.NewBuffer(f.scanner.Bytes())
 	var divideridx int
+	var malformed error
 
 	sample := NewSample()
 	chunkScanner := bufio.NewScanner(buffer)
@@ -133,7 +190,16 @@ func (f *FileParser) GetNextSample() *Sample {
 		line := chunkScanner.Bytes()
 		var key, value []byte
 
-		switch f.outputtype {
+		outputtype := f.outputtype
+		if outputtype == TIMESTAMPED {
+			// pt-stalk runs mysql with or without -t
+			outputtype = BATCH
+			if bytes.HasPrefix(line, []byte(`|`)) || bytes.HasPrefix(line, []byte(`+`)) {
+				outputtype = TABULAR
+			}
+		}
+
+		switch outputtype {
 		case TABULAR:
 			// Line here looks like this: (value can contain spaces)
 			// | varname   | value    |
@@ -144,8 +210,10 @@ func (f *FileParser) GetNextSample() *Sample {
 			// Get the position of the divider if we don't have it already
 			if divideridx == 0 {
 				divideridx = bytes.Index(line, []byte(` | `))
-			} else if len(line) < divideridx {
+			}
+			if len(line) < divideridx || !bytes.HasSuffix(bytes.TrimSpace(line), []byte(`|`)) {
 				// line truncated, probably EOF
+				malformed = fmt.Errorf("truncated line: %q", line)
 				continue
 			}
 
@@ -155,8 +223,11 @@ func (f *FileParser) GetNextSample() *Sample {
 		case BATCH:
 			// Batch is much easier, just split on the tab
 			raw := bytes.Split(line, []byte("\t"))
-			// If we don't get 2 fields, skip it.
+			// If we don't get 2 fields, skip it.  A single field is a key with an empty value, more than 2 is garbage.
 			if len(raw) != 2 {
+				if len(raw) > 2 {
+					malformed = fmt.Errorf("unparseable line: %q", line)
+				}
 				continue
 			}
 			key, value = raw[0], raw[1]
@@ -165,6 +236,19 @@ func (f *FileParser) GetNextSample() *Sample {
 		sample.Data[strings.ToLower(string(key))] = string(value)
 	}
 
+	if malformed == nil && f.truncated {
+		malformed = fmt.Errorf("incomplete sample at end of file")
+	}
+
+	// Skip or fail on malformed samples that otherwise have data
+	if malformed != nil && len(sample.Data) > 0 {
+		if f.strict {
+			return NewSampleErr(fmt.Errorf("%s: malformed sample %d: %v", f.fileName, f.records, malformed))
+		}
+		f.skipped++
+		return f.GetNextSample()
+	}
+
 	if len(sample.Data) > 0 {
 		return sample
 	} else {
@@ -1,6 +1,7 @@
 package loader
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -257,6 +258,68 @@ func TestTokuSample(t *testing.T) {
 	checkFileParserExpected(t, fp, 2)
 }
 
+// Test pt-stalk captures
+
+// Confirm parsing yields good data, with the header and TS lines skipped
+func TestPtStalkSample(t *testing.T) {
+	fp := newGoodFileParser(t, "./testdata/pt-stalk.status")
+
+	data := fp.GetNextSample()
+	if data.Error() != nil {
+		t.Error(data.Error())
+	}
+	checkStatusParseTypes(t, data)
+	if data.Data[`uptime`] != `5556` {
+		t.Errorf("unexpected uptime: %q", data.Data[`uptime`])
+	}
+	for key := range data.Data {
+		if strings.HasPrefix(key, `ts `) {
+			t.Errorf("unexpected key: %q", key)
+		}
+	}
+}
+
+// Check the batch records
+func TestPtStalkSamples(t *testing.T) {
+	fp := newGoodFileParser(t, "./testdata/pt-stalk.status")
+	checkFileParserExpected(t, fp, 3)
+}
+
+// Check the tabular records
+func TestPtStalkTabularSamples(t *testing.T) {
+	fp := newGoodFileParser(t, "./testdata/pt-stalk.tab")
+	checkFileParserExpected(t, fp, 2)
+}
+
+// Truncated files
+
+// The incomplete final sample is skipped and counted
+func TestTruncatedSamples(t *testing.T) {
+	for _, fileName := range []string{"./testdata/mysql.truncated", "./testdata/mysqladmin.err"} {
+		fp := newGoodFileParser(t, fileName)
+		checkFileParserExpected(t, fp, 1)
+		if fp.Skipped() != 1 {
+			t.Errorf("%s: unexpected skipped samples: %d", fileName, fp.Skipped())
+		}
+	}
+}
+
+// Strict parsers return an error for the incomplete final sample
+func TestTruncatedSamplesStrict(t *testing.T) {
+	fp := newGoodFileParser(t, "./testdata/mysql.truncated")
+	fp.SetStrict(true)
+
+	if sd := fp.GetNextSample(); sd == nil || sd.Error() != nil {
+		t.Fatalf("expected a good first sample: %v", sd)
+	}
+	if sd := fp.GetNextSample(); sd == nil || sd.Error() == nil {
+		t.Fatalf("expected an error for the truncated sample: %v", sd)
+	}
+	if fp.Skipped() != 0 {
+		t.Errorf("unexpected skipped samples: %d", fp.Skipped())
+	}
+}
+
 // Benchmarking
 
 // Benchmark a given fileName
@@ -0,0 +1,112 @@
+package loader
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A range of transaction numbers, inclusive
+type gtidInterval struct {
+	start, end uint64
+}
+
+// A GTID set like `3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5:7, ...`, the intervals of each source (uuid, and tag on 8.3+) are sorted and merged
+type GtidSet map[string][]gtidInterval
+
+// Parse a GTID set as found in gtid_executed or SHOW REPLICA STATUS, which may be split over lines
+func ParseGtidSet(str string) (GtidSet, error) {
+	set := make(GtidSet)
+	for _, member := range strings.Split(str, `,`) {
+		member = strings.TrimSpace(member)
+		if member == `` {
+			continue
+		}
+
+		parts := strings.Split(member, `:`)
+		source := strings.ToLower(parts[0])
+		if source == `` || len(parts) < 2 {
+			return nil, fmt.Errorf("invalid gtid set member: `%s`", member)
+		}
+
+		for _, part := range parts[1:] {
+			startStr, endStr, isRange := strings.Cut(part, `-`)
+			start, err := strconv.ParseUint(startStr, 10, 64)
+			if err != nil {
+				// Not a number, this is a tag and the intervals that follow belong to it
+				source = strings.ToLower(parts[0]) + `:` + strings.ToLower(part)
+				continue
+			}
+			end := start
+			if isRange {
+				if end, err = strconv.ParseUint(endStr, 10, 64); err != nil || end < start {
+					return nil, fmt.Errorf("invalid gtid interval `%s` in `%s`", part, member)
+				}
+			}
+			set[source] = append(set[source], gtidInterval{start, end})
+		}
+	}
+
+	for source := range set {
+		set[source] = mergeGtidIntervals(set[source])
+	}
+	return set, nil
+}
+
+// Sort and merge overlapping or adjacent intervals
+func mergeGtidIntervals(intervals []gtidInterval) (merged []gtidInterval) {
+	slices.SortFunc(intervals, func(a, b gtidInterval) int {
+		return cmp.Compare(a.start, b.start)
+	})
+	for _, i := range intervals {
+		if last := len(merged) - 1; last >= 0 && i.start <= merged[last].end+1 {
+			merged[last].end = max(merged[last].end, i.end)
+			continue
+		}
+		merged = append(merged, i)
+	}
+	return
+}
+
+// The number of transactions in the set
+func (gs GtidSet) Count() (count uint64) {
+	for _, intervals := range gs {
+		for _, i := range intervals {
+			count += i.end - i.start + 1
+		}
+	}
+	return
+}
+
+// The transactions in this set that are not in the other
+func (gs GtidSet) Subtract(other GtidSet) GtidSet {
+	result := make(GtidSet)
+	for source, intervals := range gs {
+		for _, i := range intervals {
+			remaining := []gtidInterval{i}
+			for _, o := range other[source] {
+				var next []gtidInterval
+				for _, r := range remaining {
+					if o.end < r.start || o.start > r.end {
+						next = append(next, r)
+						continue
+					}
+					if o.start > r.start {
+						next = append(next, gtidInterval{r.start, o.start - 1})
+					}
+					if o.end < r.end {
+						next = append(next, gtidInterval{o.end + 1, r.end})
+					}
+				}
+				remaining = next
+			}
+			result[source] = append(result[source], remaining...)
+		}
+		if len(result[source]) == 0 {
+			delete(result, source)
+		}
+	}
+	return result
+}
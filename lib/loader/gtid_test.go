@@ -0,0 +1,55 @@
+package loader
+
+import (
+	"testing"
+)
+
+func TestParseGtidSet(t *testing.T) {
+	set, err := ParseGtidSet("3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5:7:6,\n4c2a6f2e-71ca-11e1-9e33-c80aa9429562:10-20")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set) != 2 || set.Count() != 18 {
+		t.Errorf("unexpected set: %v (%d)", set, set.Count())
+	}
+	// 1-5, 6 and 7 are merged
+	if intervals := set[`3e11fa47-71ca-11e1-9e33-c80aa9429562`]; len(intervals) != 1 || intervals[0] != (gtidInterval{1, 7}) {
+		t.Errorf("unexpected intervals: %v", intervals)
+	}
+
+	// Tagged GTIDs (8.3+) are their own source
+	set, err = ParseGtidSet("3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5:batch:1-3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set[`3e11fa47-71ca-11e1-9e33-c80aa9429562:batch`]) != 1 || set.Count() != 8 {
+		t.Errorf("unexpected tagged set: %v", set)
+	}
+
+	if set, err := ParseGtidSet(``); err != nil || set.Count() != 0 {
+		t.Errorf("unexpected empty set: %v, %v", set, err)
+	}
+
+	for _, bad := range []string{`uuid`, `uuid:5-1`, `uuid:1-x`} {
+		if _, err := ParseGtidSet(bad); err == nil {
+			t.Errorf("expected error for %s", bad)
+		}
+	}
+}
+
+func TestGtidSetSubtract(t *testing.T) {
+	retrieved, _ := ParseGtidSet(`a:1-100,b:1-10`)
+	executed, _ := ParseGtidSet(`a:1-40:50-90,b:1-10,c:1-5`)
+
+	backlog := retrieved.Subtract(executed)
+	if backlog.Count() != 19 {
+		t.Errorf("unexpected backlog: %v (%d)", backlog, backlog.Count())
+	}
+	if _, ok := backlog[`b`]; ok {
+		t.Errorf("b should be fully executed: %v", backlog)
+	}
+
+	if executed.Subtract(executed).Count() != 0 {
+		t.Error("a set minus itself should be empty")
+	}
+}
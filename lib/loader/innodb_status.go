@@ -0,0 +1,127 @@
+package loader
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The lines of SHOW ENGINE INNODB STATUS with metrics, each submatch is the key at its position.  A line can have several (the OS wait array info is one line before 5.7).
+var innodbStatusLines = []struct {
+	re   *regexp.Regexp
+	keys []string
+}{
+	{regexp.MustCompile(`^OS WAIT ARRAY INFO: reservation count (\d+)`), []string{`os_wait_reservations`}},
+	{regexp.MustCompile(`^OS WAIT ARRAY INFO:.*signal count (\d+)`), []string{`os_wait_signals`}},
+	{regexp.MustCompile(`^RW-shared spins (\d+), rounds (\d+), OS waits (\d+)`), []string{`rw_shared_spins`, `rw_shared_rounds`, `rw_shared_os_waits`}},
+	{regexp.MustCompile(`^RW-excl spins (\d+), rounds (\d+), OS waits (\d+)`), []string{`rw_excl_spins`, `rw_excl_rounds`, `rw_excl_os_waits`}},
+	{regexp.MustCompile(`^RW-sx spins (\d+), rounds (\d+), OS waits (\d+)`), []string{`rw_sx_spins`, `rw_sx_rounds`, `rw_sx_os_waits`}},
+	{regexp.MustCompile(`^History list length (\d+)`), []string{`history_list_length`}},
+	{regexp.MustCompile(`^Pending flushes \(fsync\) log: (\d+); buffer pool: (\d+)`), []string{`pending_fsync_log`, `pending_fsync_buffer_pool`}},
+	{regexp.MustCompile(`^Log sequence number\s+(\d+)`), []string{`log_sequence_number`}},
+	{regexp.MustCompile(`^Log flushed up to\s+(\d+)`), []string{`log_flushed_up_to`}},
+	{regexp.MustCompile(`^Pages flushed up to\s+(\d+)`), []string{`pages_flushed_up_to`}},
+	{regexp.MustCompile(`^Last checkpoint at\s+(\d+)`), []string{`last_checkpoint_at`}},
+	{regexp.MustCompile(`^(\d+) pending log flushes, (\d+) pending chkp writes`), []string{`pending_log_flushes`, `pending_chkp_writes`}},
+	{regexp.MustCompile(`^(\d+) log i/o's done`), []string{`log_ios_done`}},
+	{regexp.MustCompile(`^(\d+) queries inside InnoDB, (\d+) queries in queue`), []string{`queries_inside`, `queries_in_queue`}},
+	{regexp.MustCompile(`^(\d+) read views open inside InnoDB`), []string{`read_views`}},
+}
+
+var (
+	// A thread waiting on a semaphore, for how long
+	innodbSemaphoreWaitRE = regexp.MustCompile(`^--Thread \d+ has waited at .* for ([\d.]+) seconds the semaphore`)
+
+	// The aio requests pending, as a total (5.6) or one per I/O thread (5.7+)
+	innodbPendingAioRE = regexp.MustCompile(`^Pending normal aio reads:(.*), aio writes:(.*)`)
+
+	// When the output was generated, or the latest deadlock happened, in server time
+	innodbTimestampRE = regexp.MustCompile(`^(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d|\d{6} {1,2}\d\d?:\d\d:\d\d)`)
+)
+
+// Parse the output of SHOW ENGINE INNODB STATUS into metrics, many of them not in the status counters (e.g. the semaphore waits).  The latest deadlock is given as its age when the output was generated, in seconds.
+func ParseInnodbStatus(text string) map[string]string {
+	data := make(map[string]string)
+
+	var section, previous string
+	var generated, deadlock time.Time
+	var semaphoreWaits int
+	var longestWait float64
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		// Sections are a title between lines of dashes
+		if strings.HasPrefix(line, `----`) && previous != `` && !strings.HasPrefix(previous, `----`) {
+			section = previous
+		}
+		previous = line
+
+		if match := innodbTimestampRE.FindStringSubmatch(line); match != nil {
+			switch {
+			case strings.Contains(line, `INNODB MONITOR OUTPUT`):
+				generated = parseInnodbTimestamp(match[1])
+			case section == `LATEST DETECTED DEADLOCK` && deadlock.IsZero():
+				deadlock = parseInnodbTimestamp(match[1])
+			}
+			continue
+		}
+
+		if match := innodbSemaphoreWaitRE.FindStringSubmatch(line); match != nil {
+			semaphoreWaits++
+			if seconds, err := strconv.ParseFloat(match[1], 64); err == nil {
+				longestWait = max(longestWait, seconds)
+			}
+			continue
+		}
+		if match := innodbPendingAioRE.FindStringSubmatch(line); match != nil {
+			data[`pending_aio_reads`] = strconv.FormatInt(pendingAio(match[1]), 10)
+			data[`pending_aio_writes`] = strconv.FormatInt(pendingAio(match[2]), 10)
+			continue
+		}
+		for _, isl := range innodbStatusLines {
+			if match := isl.re.FindStringSubmatch(line); match != nil {
+				for i, key := range isl.keys {
+					data[key] = match[i+1]
+				}
+			}
+		}
+	}
+
+	// Only the waiting threads are listed, none in the SEMAPHORES section is no waits
+	if _, ok := data[`os_wait_reservations`]; ok {
+		data[`semaphore_waits`] = strconv.Itoa(semaphoreWaits)
+		data[`semaphore_wait_longest`] = strconv.FormatFloat(longestWait, 'f', -1, 64)
+	}
+	if !generated.IsZero() && !deadlock.IsZero() {
+		data[`last_deadlock_age`] = strconv.FormatInt(int64(generated.Sub(deadlock).Seconds()), 10)
+	}
+	return data
+}
+
+// A timestamp like `2024-03-01 14:05:00`, or `240301 14:05:00` before 5.7
+func parseInnodbTimestamp(str string) time.Time {
+	for _, layout := range []string{`2006-01-02 15:04:05`, `060102 15:04:05`, `060102  15:04:05`, `060102 5:04:05`, `060102  5:04:05`} {
+		if t, err := time.Parse(layout, str); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// The aio requests pending in `0 [0, 0]` (the total first) or `[0, 0]` (the sum)
+func pendingAio(str string) (pending int64) {
+	total, perThread, found := strings.Cut(str, `[`)
+	if n, err := strconv.ParseInt(strings.TrimSpace(total), 10, 64); err == nil {
+		return n
+	}
+	if !found {
+		return 0
+	}
+	perThread, _, _ = strings.Cut(perThread, `]`)
+	for _, field := range strings.Split(perThread, `,`) {
+		n, _ := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+		pending += n
+	}
+	return pending
+}
@@ -0,0 +1,85 @@
+package loader
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseInnodbStatus(t *testing.T) {
+	text, err := os.ReadFile("./testdata/innodb_status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := ParseInnodbStatus(string(text))
+
+	expected := map[string]string{
+		`os_wait_reservations`:      `98765`,
+		`os_wait_signals`:           `87654`,
+		`rw_shared_os_waits`:        `1234`,
+		`rw_excl_os_waits`:          `567`,
+		`rw_sx_os_waits`:            `89`,
+		`semaphore_waits`:           `2`,
+		`semaphore_wait_longest`:    `12`,
+		`last_deadlock_age`:         `177`,
+		`history_list_length`:       `4321`,
+		`pending_aio_reads`:         `3`,
+		`pending_aio_writes`:        `3`,
+		`pending_fsync_log`:         `1`,
+		`pending_fsync_buffer_pool`: `4`,
+		`log_sequence_number`:       `123456789`,
+		`log_flushed_up_to`:         `123456000`,
+		`pages_flushed_up_to`:       `123000000`,
+		`last_checkpoint_at`:        `122000000`,
+		`pending_log_flushes`:       `2`,
+		`pending_chkp_writes`:       `1`,
+		`log_ios_done`:              `5678`,
+		`queries_inside`:            `3`,
+		`queries_in_queue`:          `5`,
+		`read_views`:                `2`,
+	}
+	for key, value := range expected {
+		if data[key] != value {
+			t.Errorf("%s: expected %q, got %q", key, value, data[key])
+		}
+	}
+}
+
+// Before 5.7 the timestamps are shorter and the pending aio starts with the total
+func TestParseInnodbStatusOld(t *testing.T) {
+	data := ParseInnodbStatus(`=====================================
+240301 14:05:00 INNODB MONITOR OUTPUT
+=====================================
+----------
+SEMAPHORES
+----------
+OS WAIT ARRAY INFO: reservation count 10, signal count 9
+------------------------
+LATEST DETECTED DEADLOCK
+------------------------
+240301  9:05:00
+*** (1) TRANSACTION:
+--------
+FILE I/O
+--------
+Pending normal aio reads: 5 [1, 4] , aio writes: 2 [2, 0] ,
+`)
+	expected := map[string]string{
+		`os_wait_reservations`: `10`,
+		`os_wait_signals`:      `9`,
+		`semaphore_waits`:      `0`,
+		`last_deadlock_age`:    `18000`,
+		`pending_aio_reads`:    `5`,
+		`pending_aio_writes`:   `2`,
+	}
+	for key, value := range expected {
+		if data[key] != value {
+			t.Errorf("%s: expected %q, got %q", key, value, data[key])
+		}
+	}
+}
+
+func TestParseInnodbStatusEmpty(t *testing.T) {
+	if data := ParseInnodbStatus(``); len(data) != 0 {
+		t.Errorf("unexpected data: %v", data)
+	}
+}
@@ -12,14 +12,54 @@ type Loader interface {
 	GetStateChannel() <-chan StateReader
 }
 
+// A Loader whose interval can change after Initialize
+type IntervalSetter interface {
+	SetInterval(time.Duration)
+}
+
+// A Loader that knows which Sources it can collect before Initialize, e.g. to reject a misspelled one
+type SourceLister interface {
+	CanCollect(SourceName) bool
+}
+
+// Collects a Source on its own (usually slower) cadence, independent of the Loader interval
+type Poller interface {
+	// Start polling in the background
+	Start()
+
+	// The most recently polled Sample, or nil if there isn't one yet
+	GetLatest() SampleReader
+}
+
 // Functions to read a State
 type StateReader interface {
 	// Seconds between Cur and Prev samples for the given SourceName
 	SecondsDiff() float64
 
+	// Seconds between the Cur and Prev Samples of the given Source, more than SecondsDiff if it wasn't collected every interval
+	SourceSecondsDiff(SourceName) float64
+
+	// Interval number of this State since collection started, starting at 1
+	GetSeq() uint64
+
+	// Intervals missed between the Previous and Current SampleSets, values computed from both span them
+	GetMissed() uint64
+
+	// Did the server restart since the last State?
+	HasRestarted() bool
+
+	// Are the values repeated from an earlier State because this interval's collection failed?
+	IsRepeated() bool
+
 	// Get what to print in the timestamp col
 	GetTimeString() string
 
+	// Was the State collected from a server as it happened (rather than from a file)?
+	IsLive() bool
+
+	// Get out-of-band messages to print before this State
+	GetAnnotations() []string
+
 	// Get the Current and Previous Samplesets, could be nil!
 	GetCurrent() SampleSetReader
 	GetPrevious() SampleSetReader
@@ -38,10 +78,19 @@ type SampleSetReader interface {
 	// Collect errors from all the Samples
 	GetErrors() error
 
+	// Did every Sample fail to collect?
+	CollectionFailed() bool
+
+	// Was the Sample of the given Source collected without an error?
+	SourceCollected(SourceName) bool
+
 	// Get Time data for the Set
 	GetTimeGenerated() time.Time
 	GetUptime() int64
 
+	// Get the time the Sample for the given Source was generated, which may be older than the Set
+	GetSourceTime(SourceName) (time.Time, error)
+
 	// Fetch the given SourceKey and parse it into the given type
 	GetString(SourceKey) (string, error)
 	GetInt(SourceKey) (int64, error)
@@ -0,0 +1,40 @@
+package loader
+
+// Merges the States of two Loaders, usually captures of a server before and after a change, aligned by their uptime since their first State.  Like a MultiLoader with two roles, but the first role's States are each merged with the second's last State at or before the same uptime, rather than whichever arrived last, so captures replay in step.
+type CompareLoader struct {
+	MultiLoader
+}
+
+func NewCompareLoader() *CompareLoader {
+	return &CompareLoader{MultiLoader: *NewMultiLoader()}
+}
+
+// Merge the States of the first two roles
+func (l *CompareLoader) GetStateChannel() <-chan StateReader {
+	ch := make(chan StateReader)
+
+	go func() {
+		first, second := l.roles[0], l.roles[1]
+		others := l.loaders[second].GetStateChannel()
+		next, more := <-others
+
+		var prev_ssp *SampleSet
+		latest := make(map[string]StateReader)
+		merged := make(map[string]StateReader)
+		for state := range l.loaders[first].GetStateChannel() {
+			latest[first] = state
+			for more && next.GetCurrent().GetUptime() <= state.GetCurrent().GetUptime() {
+				latest[second] = next
+				next, more = <-others
+			}
+
+			newState := l.mergeStates(latest, merged)
+			newState.SetPrevious(prev_ssp)
+			ch <- newState
+			prev_ssp = newState.Current
+		}
+		close(ch)
+	}()
+
+	return ch
+}
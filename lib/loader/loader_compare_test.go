@@ -0,0 +1,54 @@
+package loader
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestCompareLoaderStates(t *testing.T) {
+	cl := NewCompareLoader()
+	cl.AddLoader(`before`, NewGoodFileLoader(t, "./testdata/mysqladmin.lots", "", "1s"))
+	cl.AddLoader(`after`, NewGoodFileLoader(t, "./testdata/mysqladmin.byfives", "", "5s"))
+
+	count, updated := 0, 0
+	questions := SourceKey{`status`, `questions`}
+	for state := range cl.GetStateChannel() {
+		count++
+		uptime := state.GetCurrent().GetUptime()
+		if !state.GetCurrent().HasSource(`before.status`) {
+			t.Fatal("missing before.status")
+		}
+
+		// The capture every 5s is in step with the one every second, until it ends at 95s
+		after, err := state.GetCurrent().GetString(SourceKey{`after.status`, `uptime`})
+		if err != nil {
+			t.Fatalf("missing after.status at uptime %d", uptime)
+		}
+		expected := 20064 + 5*(min(uptime, 95)/5)
+		if after != strconv.FormatInt(expected, 10) {
+			t.Errorf("uptime %d: unexpected after uptime %s, expected %d", uptime, after, expected)
+		}
+
+		// The capture every 5s has its own Previous, its rates are over its own 5s and not 0 in between
+		if !RoleUpdated(state, `after`) {
+			continue
+		}
+		updated++
+		role := RoleState(state, `after`)
+		if role.GetPrevious() == nil {
+			continue
+		}
+		if role.SecondsDiff() != 5 {
+			t.Errorf("uptime %d: unexpected after SecondsDiff %f", uptime, role.SecondsDiff())
+		}
+		if diff := role.GetCurrent().GetF(questions) - role.GetPrevious().GetF(questions); diff <= 0 {
+			t.Errorf("uptime %d: unexpected after questions diff %f", uptime, diff)
+		}
+	}
+	if count != 220 {
+		t.Errorf("unexpected state count: %d", count)
+	}
+	if updated != 20 {
+		t.Errorf("unexpected after state count: %d", updated)
+	}
+}
@@ -3,23 +3,40 @@ package loader
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 )
 
-// Load mysql status output from a mysqladmin output file
+// Load mysql status output from one or more mysqladmin output files, read one after the other
 type FileLoader struct {
-	statusFile      *FileParser
+	statusFiles     []*FileParser
 	variablesFile   *FileParser
 	variablesSample *Sample
 
-	// The first uptime reported in the status file
+	// The first uptime reported in the status files
 	firstUptime int64
+
+	// Added to the uptime of every sample after the server restarted
+	uptimeOffset int64
+
+	interval time.Duration
+
+	// Replay the States this many times faster than they were captured, 0 sends them as fast as they are read
+	speed float64
+
+	// Skip the States of this much of the beginning of the capture
+	seek time.Duration
+
+	// Set if a strict status file had a malformed sample
+	err error
 }
 
-func NewFileLoader(statusFile, varFile string) *FileLoader {
+func NewFileLoader(statusFiles []string, varFile string) *FileLoader {
 	l := &FileLoader{}
 
-	l.statusFile = NewFileParser(statusFile)
+	for _, statusFile := range statusFiles {
+		l.statusFiles = append(l.statusFiles, NewFileParser(statusFile))
+	}
 	if varFile != "" {
 		l.variablesFile = NewFileParser(varFile)
 	}
@@ -27,16 +44,84 @@ func NewFileLoader(statusFile, varFile string) *FileLoader {
 	return l
 }
 
+// Error on malformed samples in the status files instead of skipping them
+func (l *FileLoader) SetStrict(strict bool) {
+	for _, statusFile := range l.statusFiles {
+		statusFile.SetStrict(strict)
+	}
+}
+
+// Send the States speed times faster than they were captured rather than as fast as they are read, e.g. 1 to replay in real time
+func (l *FileLoader) SetSpeed(speed float64) {
+	l.speed = speed
+}
+
+// Start at this far into the capture, by the uptime of its samples.  The States before are read but not sent, the first one sent has a previous.
+func (l *FileLoader) SetSeek(seek time.Duration) {
+	l.seek = seek
+}
+
+// Parse a replay speed like `10x` or `0.5`
+func ParseReplaySpeed(str string) (float64, error) {
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(str, `x`), 64)
+	if err != nil || speed <= 0 {
+		return 0, fmt.Errorf("invalid speed %s, expected a positive number like 10x", str)
+	}
+	return speed, nil
+}
+
+// Parse an offset into a capture like `00:45:00`, `45:00` or `45m`
+func ParseReplayOffset(str string) (time.Duration, error) {
+	if !strings.Contains(str, `:`) {
+		offset, err := time.ParseDuration(str)
+		if err != nil || offset < 0 {
+			return 0, fmt.Errorf("invalid offset %s, expected hh:mm:ss or a duration like 45m", str)
+		}
+		return offset, nil
+	}
+
+	parts := strings.Split(str, `:`)
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid offset %s, expected hh:mm:ss", str)
+	}
+	var offset time.Duration
+	for _, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid offset %s, expected hh:mm:ss", str)
+		}
+		offset = offset*60 + time.Duration(n)*time.Second
+	}
+	return offset, nil
+}
+
+// The number of malformed samples skipped in all status files
+func (l *FileLoader) Skipped() (skipped int) {
+	for _, statusFile := range l.statusFiles {
+		skipped += statusFile.Skipped()
+	}
+	return
+}
+
+// The error that stopped the State channel early, if any
+func (l *FileLoader) Err() error {
+	return l.err
+}
+
 func (l *FileLoader) Initialize(interval time.Duration, sources []SourceName) error {
-	// Initialize the status file loader, this has to work
-	err := l.statusFile.Initialize(interval)
-	if err != nil {
-		return fmt.Errorf("error inititalizing status file loader: %v", err)
+	l.interval = interval
+
+	// Initialize the status file loaders, these have to work
+	for _, statusFile := range l.statusFiles {
+		err := statusFile.Initialize(interval)
+		if err != nil {
+			return fmt.Errorf("error inititalizing status file loader: %v", err)
+		}
 	}
 
 	if l.variablesFile != nil {
 		// Now initialize the variables file loader if it is set
-		err = l.variablesFile.Initialize(interval)
+		err := l.variablesFile.Initialize(interval)
 
 		if err != nil {
 			return fmt.Errorf("error inititalizing error file loader: %v", err)
@@ -47,33 +132,56 @@ func (l *FileLoader) Initialize(interval time.Duration, sources []SourceName) er
 		if l.variablesSample != nil && l.variablesSample.Error() != nil {
 			return fmt.Errorf("error parsing variables: %v", l.variablesSample.Error())
 		}
+		if l.variablesSample != nil {
+			l.variablesSample.applyAliases(`variables`)
+		}
 
 	}
 
 	return nil
 }
 
-// Create and feed a channel of MyqSamples based on the given status and var file.
+// Create and feed a channel of MyqSamples based on the given status files and var file.
 func (l *FileLoader) GetStateChannel() <-chan StateReader {
 	ch := make(chan StateReader)
 
-	sfl := l.statusFile
-
 	// Goroutine to get status data and feed it to ch
 	go func() {
 		var prev_ssp *SampleSet
+		var lastUptime, minGap int64
+		var seq uint64
+		fileIdx := 0
+
+		// When the replay started, and the uptime it started from
+		var replayStart time.Time
+		var replayUptime int64
 		for {
 			// Get the next data from the Status file
-			sd := sfl.GetNextSample()
+			sd := l.statusFiles[fileIdx].GetNextSample()
 
-			// Nil status data == EOF
+			// Nil status data == EOF, move on to the next file if there is one
 			if sd == nil {
+				fileIdx++
+				if fileIdx < len(l.statusFiles) {
+					continue
+				}
+				close(ch)
+				break
+			}
+
+			// Only strict parsers return errors, stop here
+			if sd.Error() != nil {
+				l.err = sd.Error()
 				close(ch)
 				break
 			}
 
+			sd.applyAliases(`status`)
+
 			// Construct the new State
+			seq++
 			state := NewState()
+			state.Seq = seq
 			state.GetCurrentWriter().SetSample(`status`, sd)
 			if l.variablesSample != nil {
 				// Resuse variapes sample (assume it hasn't changed)
@@ -88,10 +196,45 @@ func (l *FileLoader) GetStateChannel() <-chan StateReader {
 				// Set the first up time if we don't have it
 				if l.firstUptime == 0 {
 					l.firstUptime = currUptime
+				} else if currUptime < lastUptime {
+					// The server restarted between (or during) files, rates across the restart would be garbage.  Continue our uptime from the last sample.
+					state.AddAnnotation(fmt.Sprintf("server restarted (%s)", l.statusFiles[fileIdx].fileName))
+					state.SetPrevious(nil)
+					state.Restarted = true
+					l.uptimeOffset += lastUptime - l.firstUptime + int64(l.interval.Seconds())
+					l.firstUptime = currUptime
+				} else if gap := currUptime - lastUptime; prev_ssp != nil && gap > 0 {
+					// The capture may have been taken less often than our interval, compare gaps with the smallest one seen
+					if minGap == 0 || gap < minGap {
+						minGap = gap
+					}
+					step := max(minGap, int64(l.interval.Seconds()))
+
+					// The capture is missing samples (a sample a little late is just jitter)
+					if gap >= 2*step {
+						state.Missed = uint64(gap/step - 1)
+					}
 				}
+				lastUptime = currUptime
 
 				// Set the uptime if we have it
-				state.GetCurrentWriter().SetUptime(currUptime - l.firstUptime)
+				uptime := currUptime - l.firstUptime + l.uptimeOffset
+				state.GetCurrentWriter().SetUptime(uptime)
+
+				// Fast-forward to the seek, the States skipped still count as the previous of the next
+				if time.Duration(uptime)*time.Second < l.seek {
+					prev_ssp = state.Current
+					continue
+				}
+
+				// Wait until this State is due, as far after the first one sent as it was captured (divided by the speed)
+				if l.speed > 0 {
+					if replayStart.IsZero() {
+						replayStart, replayUptime = time.Now(), uptime
+					}
+					elapsed := time.Duration(float64(uptime-replayUptime) * float64(time.Second) / l.speed)
+					time.Sleep(time.Until(replayStart.Add(elapsed)))
+				}
 			}
 
 			ch <- state
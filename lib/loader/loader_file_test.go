@@ -9,14 +9,14 @@ var sources_file_test = []SourceName{`status`, `variables`}
 
 func NewTestFileLoader(statusFile, varFile string) (*FileLoader, error) {
 	i, _ := time.ParseDuration("1s")
-	fl := NewFileLoader(statusFile, varFile)
+	fl := NewFileLoader([]string{statusFile}, varFile)
 	err := fl.Initialize(i, sources_file_test)
 	return fl, err
 }
 
 func NewGoodFileLoader(t testing.TB, statusFile, varFile, intervalStr string) *FileLoader {
 	i, _ := time.ParseDuration(intervalStr)
-	fl := NewFileLoader(statusFile, varFile)
+	fl := NewFileLoader([]string{statusFile}, varFile)
 
 	err := fl.Initialize(i, sources_file_test)
 
@@ -143,3 +143,157 @@ func TestFileLoader5sInterval(t *testing.T) {
 		t.Error("Sample missing")
 	}
 }
+
+// Multiple files are read in order, a restart between them is annotated
+func TestFileLoaderMultipleFiles(t *testing.T) {
+	i, _ := time.ParseDuration("1s")
+	l := NewFileLoader([]string{"./testdata/mysql.two", "./testdata/mysql.single"}, "")
+	if err := l.Initialize(i, sources_file_test); err != nil {
+		t.Fatal(err)
+	}
+
+	var states []StateReader
+	for s := range l.GetStateChannel() {
+		states = append(states, s)
+	}
+	if len(states) != 3 {
+		t.Fatalf("unexpected number of states: %d", len(states))
+	}
+
+	for i, state := range states {
+		if state.GetSeq() != uint64(i+1) {
+			t.Errorf("unexpected seq of state %d: %d", i, state.GetSeq())
+		}
+	}
+
+	// mysql.two has uptimes 5893 and 5894
+	if states[1].SecondsDiff() != 1 || len(states[1].GetAnnotations()) != 0 {
+		t.Errorf("unexpected second state: %f %v", states[1].SecondsDiff(), states[1].GetAnnotations())
+	}
+
+	// mysql.single has uptime 5556, so the server restarted
+	restart := states[2]
+	if annotations := restart.GetAnnotations(); len(annotations) != 1 || annotations[0] != "server restarted (./testdata/mysql.single)" {
+		t.Errorf("unexpected annotations: %v", annotations)
+	}
+	if restart.GetPrevious() != nil {
+		t.Error("rates should not be calculated across a restart")
+	}
+	if restart.GetTimeString() != `2s` {
+		t.Errorf("unexpected time after restart: %s", restart.GetTimeString())
+	}
+}
+
+// Files without a restart between them are treated as one continuous capture
+func TestFileLoaderContinuousFiles(t *testing.T) {
+	i, _ := time.ParseDuration("1s")
+	l := NewFileLoader([]string{"./testdata/mysql.single", "./testdata/mysql.two"}, "")
+	if err := l.Initialize(i, sources_file_test); err != nil {
+		t.Fatal(err)
+	}
+
+	var states []StateReader
+	for s := range l.GetStateChannel() {
+		states = append(states, s)
+	}
+	if len(states) != 3 {
+		t.Fatalf("unexpected number of states: %d", len(states))
+	}
+
+	// 5556 -> 5893
+	if states[1].SecondsDiff() != 337 || len(states[1].GetAnnotations()) != 0 {
+		t.Errorf("unexpected second state: %f %v", states[1].SecondsDiff(), states[1].GetAnnotations())
+	}
+}
+
+// A strict loader stops at the truncated sample and reports why
+func TestFileLoaderStrict(t *testing.T) {
+	l := NewGoodFileLoader(t, "./testdata/mysql.truncated", "", "1s")
+	l.SetStrict(true)
+
+	count := 0
+	for range l.GetStateChannel() {
+		count++
+	}
+	if count != 1 {
+		t.Errorf("unexpected state count: %d", count)
+	}
+	if l.Err() == nil {
+		t.Error("expected an error")
+	}
+	if l.Skipped() != 0 {
+		t.Errorf("unexpected skipped samples: %d", l.Skipped())
+	}
+}
+
+func TestParseReplaySpeed(t *testing.T) {
+	for str, expected := range map[string]float64{`10x`: 10, `10`: 10, `0.5x`: 0.5, `1x`: 1} {
+		if speed, err := ParseReplaySpeed(str); err != nil || speed != expected {
+			t.Errorf("%s: unexpected speed %f: %v", str, speed, err)
+		}
+	}
+	for _, str := range []string{``, `x`, `0x`, `-2x`, `fast`} {
+		if _, err := ParseReplaySpeed(str); err == nil {
+			t.Errorf("%s: expected an error", str)
+		}
+	}
+}
+
+func TestParseReplayOffset(t *testing.T) {
+	for str, expected := range map[string]time.Duration{
+		`00:45:00`: 45 * time.Minute,
+		`1:02:03`:  time.Hour + 2*time.Minute + 3*time.Second,
+		`45:00`:    45 * time.Minute,
+		`0:10`:     10 * time.Second,
+		`45m`:      45 * time.Minute,
+		`90s`:      90 * time.Second,
+	} {
+		if offset, err := ParseReplayOffset(str); err != nil || offset != expected {
+			t.Errorf("%s: unexpected offset %s: %v", str, offset, err)
+		}
+	}
+	for _, str := range []string{``, `1:2:3:4`, `a:00`, `-5m`, `:`} {
+		if _, err := ParseReplayOffset(str); err == nil {
+			t.Errorf("%s: expected an error", str)
+		}
+	}
+}
+
+// Seeking skips the States before the offset but the first one sent still has a previous
+func TestFileLoaderSeek(t *testing.T) {
+	l := NewGoodFileLoader(t, "./testdata/mysqladmin.lots", "", "1s")
+	l.SetSeek(200 * time.Second)
+
+	var states []StateReader
+	for s := range l.GetStateChannel() {
+		states = append(states, s)
+	}
+	if len(states) == 0 || len(states) >= 440 {
+		t.Fatalf("unexpected number of states: %d", len(states))
+	}
+	if states[0].GetCurrent().GetUptime() < 200 {
+		t.Errorf("unexpected first uptime: %d", states[0].GetCurrent().GetUptime())
+	}
+	if states[0].GetPrevious() == nil {
+		t.Error("expected the first state to have a previous")
+	}
+}
+
+// At a speed the States are sent as far apart as they were captured, divided by the speed
+func TestFileLoaderSpeed(t *testing.T) {
+	l := NewGoodFileLoader(t, "./testdata/mysqladmin.lots", "", "1s")
+	l.SetSpeed(50)
+
+	start := time.Now()
+	ch := l.GetStateChannel()
+	first := <-ch
+	var last StateReader
+	for i := 0; i < 5; i++ {
+		last = <-ch
+	}
+	elapsed := time.Since(start)
+	expected := time.Duration(last.GetCurrent().GetUptime()-first.GetCurrent().GetUptime()) * time.Second / 50
+	if expected == 0 || elapsed < expected {
+		t.Errorf("replayed %s of capture in %s", expected*50, elapsed)
+	}
+}
@@ -0,0 +1,228 @@
+package loader
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The largest UDP datagram we accept, a SHOW GLOBAL STATUS sample is usually well under this
+const MAX_DATAGRAM_SIZE = 65535
+
+// Split an address like `host:port` (TCP) or `udp://host:port` into its network and address
+func ParseNetworkAddress(str string) (network, address string) {
+	if network, address, found := strings.Cut(str, `://`); found {
+		return network, address
+	}
+	return `tcp`, str
+}
+
+// Write a Sample in mysqladmin batch format, terminated by F_END_STRING like our capture files
+func WriteSample(w io.Writer, s SampleReader) error {
+	var buf bytes.Buffer
+	keys := s.GetKeys()
+	slices.Sort(keys)
+	for _, key := range keys {
+		value, _ := s.GetString(key)
+		fmt.Fprintf(&buf, "%s\t%s\n", key, value)
+	}
+	fmt.Fprintf(&buf, "%s\n", F_END_STRING)
+
+	// A single write, so a UDP sample is a single datagram
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// A status Sample received from an agent, or the error that stopped reading its stream
+type pushedSample struct {
+	sample *Sample
+	from   string
+	err    error
+}
+
+// Receives status Samples pushed by a remote agent (e.g. myq_status -push, or mysqladmin ext output piped into nc) over TCP or UDP, where each datagram must hold a whole Sample.  Samples are expected from one agent at a time: a new TCP connection replaces the previous one, and UDP Samples from several senders would be mixed.
+type ListenLoader struct {
+	network, address string
+	interval         time.Duration
+
+	listener   net.Listener
+	packetConn net.PacketConn
+
+	samples chan pushedSample
+}
+
+func NewListenLoader(network, address string) *ListenLoader {
+	return &ListenLoader{network: network, address: address}
+}
+
+// Start listening, only the status Source can be pushed
+func (l *ListenLoader) Initialize(interval time.Duration, sources []SourceName) error {
+	l.interval = interval
+	l.samples = make(chan pushedSample)
+
+	var err error
+	switch l.network {
+	case `tcp`, `tcp4`, `tcp6`:
+		l.listener, err = net.Listen(l.network, l.address)
+	case `udp`, `udp4`, `udp6`:
+		l.packetConn, err = net.ListenPacket(l.network, l.address)
+	default:
+		return fmt.Errorf("cannot listen on %s, use tcp or udp", l.network)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot listen on %s://%s: %v", l.network, l.address, err)
+	}
+	return nil
+}
+
+// The address we are listening on
+func (l *ListenLoader) Addr() net.Addr {
+	if l.listener != nil {
+		return l.listener.Addr()
+	}
+	return l.packetConn.LocalAddr()
+}
+
+// Stop listening, the State channel is closed
+func (l *ListenLoader) Close() error {
+	if l.listener != nil {
+		return l.listener.Close()
+	}
+	return l.packetConn.Close()
+}
+
+// Accept TCP connections, each is a stream of Samples.  A new connection replaces the current one.
+func (l *ListenLoader) acceptStreams() {
+	var mutex sync.Mutex
+	var current net.Conn
+	var streams sync.WaitGroup
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			break
+		}
+
+		mutex.Lock()
+		if current != nil {
+			current.Close()
+		}
+		current = conn
+		mutex.Unlock()
+
+		streams.Add(1)
+		go func() {
+			defer streams.Done()
+			l.readStream(conn.RemoteAddr().String(), conn)
+			conn.Close()
+		}()
+	}
+
+	mutex.Lock()
+	if current != nil {
+		current.Close()
+	}
+	mutex.Unlock()
+	streams.Wait()
+	close(l.samples)
+}
+
+// Receive UDP datagrams, each is a single Sample
+func (l *ListenLoader) receiveDatagrams() {
+	buf := make([]byte, MAX_DATAGRAM_SIZE)
+	for {
+		n, from, err := l.packetConn.ReadFrom(buf)
+		if err != nil {
+			break
+		}
+		l.readStream(from.String(), bytes.NewReader(slices.Clone(buf[:n])))
+	}
+	close(l.samples)
+}
+
+// Send every Sample in the stream, the parser skips malformed Samples.  A read error (e.g., a line too long to parse) ends the stream, and is sent for the next State to say so unless the stream was replaced.
+func (l *ListenLoader) readStream(from string, r io.Reader) {
+	parser := newStreamParser(from, r)
+	if err := parser.Initialize(l.interval); err != nil {
+		return
+	}
+	for sample := parser.GetNextSample(); sample != nil; sample = parser.GetNextSample() {
+		if err := sample.Error(); err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				l.samples <- pushedSample{from: from, err: err}
+			}
+			return
+		}
+		l.samples <- pushedSample{sample: sample, from: from}
+	}
+}
+
+// A State for every Sample received, timed by when it arrived
+func (l *ListenLoader) GetStateChannel() <-chan StateReader {
+	ch := make(chan StateReader)
+
+	if l.listener != nil {
+		go l.acceptStreams()
+	} else {
+		go l.receiveDatagrams()
+	}
+
+	go func() {
+		var prev_ssp *SampleSet
+		var lastFrom string
+		var lastUptime int64
+		var seq uint64
+		var stopped []string
+		for pushed := range l.samples {
+			if pushed.err != nil {
+				stopped = append(stopped, fmt.Sprintf("stopped reading from %s: %v", pushed.from, pushed.err))
+				continue
+			}
+			sd := pushed.sample
+			sd.applyAliases(`status`)
+
+			seq++
+			state := NewState()
+			state.Live = true
+			state.Seq = seq
+			state.GetCurrentWriter().SetSample(`status`, sd)
+			state.SetPrevious(prev_ssp)
+			for _, annotation := range stopped {
+				state.AddAnnotation(annotation)
+			}
+			stopped = nil
+
+			if pushed.from != lastFrom {
+				state.AddAnnotation(fmt.Sprintf("receiving from %s", pushed.from))
+				lastFrom = pushed.from
+			}
+
+			uptime, _ := strconv.ParseInt(sd.Data[`uptime`], 10, 64)
+			if prev_ssp != nil && uptime < lastUptime {
+				// Rates across the restart would be garbage
+				state.AddAnnotation(fmt.Sprintf("server restarted (%s)", pushed.from))
+				state.SetPrevious(nil)
+				state.Restarted = true
+			} else if prev_ssp != nil {
+				// Samples the agent didn't send, or that were lost on the way
+				gap := state.Current.Timestamp.Sub(prev_ssp.Timestamp)
+				if gap >= 2*l.interval {
+					state.Missed = uint64(gap/l.interval - 1)
+				}
+			}
+			lastUptime = uptime
+
+			ch <- state
+			prev_ssp = state.Current
+		}
+		close(ch)
+	}()
+
+	return ch
+}
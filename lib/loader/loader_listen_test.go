@@ -0,0 +1,149 @@
+package loader
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListenLoaderImplementsLoader(t *testing.T) {
+	var _ Loader = NewListenLoader(`tcp`, `127.0.0.1:0`)
+}
+
+func TestParseNetworkAddress(t *testing.T) {
+	tests := map[string][2]string{
+		`localhost:7777`:      {`tcp`, `localhost:7777`},
+		`udp://:7777`:         {`udp`, `:7777`},
+		`tcp6://[::1]:7777`:   {`tcp6`, `[::1]:7777`},
+		`udp4://0.0.0.0:7777`: {`udp4`, `0.0.0.0:7777`},
+	}
+	for str, expected := range tests {
+		network, address := ParseNetworkAddress(str)
+		if network != expected[0] || address != expected[1] {
+			t.Errorf("%s: unexpected %s %s", str, network, address)
+		}
+	}
+}
+
+// A status Sample like an agent would push
+func getTestPushSample(uptime, queries string) *Sample {
+	sample := NewSample()
+	sample.Data[`uptime`] = uptime
+	sample.Data[`queries`] = queries
+	return sample
+}
+
+// Push the Samples to a new ListenLoader and return the States it produced
+func pushTestSamples(t *testing.T, network string, samples ...*Sample) (states []StateReader) {
+	l := NewListenLoader(network, `127.0.0.1:0`)
+	if err := l.Initialize(time.Second, []SourceName{`status`}); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	ch := l.GetStateChannel()
+
+	conn, err := net.Dial(network, l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	for _, sample := range samples {
+		if err := WriteSample(conn, sample); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case state := <-ch:
+			states = append(states, state)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a State")
+		}
+	}
+	return
+}
+
+func TestListenLoaderTCP(t *testing.T) {
+	states := pushTestSamples(t, `tcp`,
+		getTestPushSample(`100`, `1000`),
+		getTestPushSample(`101`, `1050`),
+		getTestPushSample(`5`, `10`),
+	)
+
+	queries := SourceKey{SourceName: `status`, Key: `queries`}
+	if states[1].GetCurrent().GetF(queries) != 1050 || states[1].GetPrevious().GetF(queries) != 1000 {
+		t.Errorf("unexpected second State: %+v", states[1])
+	}
+	if len(states[0].GetAnnotations()) != 1 || len(states[1].GetAnnotations()) != 0 {
+		t.Errorf("unexpected annotations: %v, %v", states[0].GetAnnotations(), states[1].GetAnnotations())
+	}
+	if !states[2].HasRestarted() || states[2].GetPrevious() != nil {
+		t.Errorf("expected a restart: %+v", states[2])
+	}
+	if states[2].GetSeq() != 3 {
+		t.Errorf("unexpected seq: %d", states[2].GetSeq())
+	}
+}
+
+func TestListenLoaderUDP(t *testing.T) {
+	states := pushTestSamples(t, `udp`,
+		getTestPushSample(`100`, `1000`),
+		getTestPushSample(`101`, `1050`),
+	)
+
+	queries := SourceKey{SourceName: `status`, Key: `queries`}
+	if states[1].GetCurrent().GetF(queries) != 1050 || states[1].GetPrevious() == nil {
+		t.Errorf("unexpected second State: %+v", states[1])
+	}
+}
+
+// Malformed Samples are skipped without dropping the connection, a stream that can't be read any more is annotated
+func TestListenLoaderMalformed(t *testing.T) {
+	l := NewListenLoader(`tcp`, `127.0.0.1:0`)
+	if err := l.Initialize(time.Second, []SourceName{`status`}); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	ch := l.GetStateChannel()
+	nextState := func() StateReader {
+		select {
+		case state := <-ch:
+			return state
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a State")
+		}
+		return nil
+	}
+
+	conn, err := net.Dial(`tcp`, l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	WriteSample(conn, getTestPushSample(`100`, `1000`))
+	fmt.Fprintf(conn, "uptime\t101\nqueries\t1\t2\n%s\n", F_END_STRING)
+	WriteSample(conn, getTestPushSample(`102`, `1100`))
+
+	queries := SourceKey{SourceName: `status`, Key: `queries`}
+	if first, second := nextState(), nextState(); first.GetCurrent().GetF(queries) != 1000 || second.GetCurrent().GetF(queries) != 1100 {
+		t.Errorf("unexpected States around a malformed Sample: %+v, %+v", first, second)
+	}
+
+	// A line longer than the parser can hold, the stream is closed once it gave up
+	conn.Write(bytes.Repeat([]byte(`x`), bufio.MaxScanTokenSize*17))
+	conn.Read(make([]byte, 1))
+
+	conn, err = net.Dial(`tcp`, l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	WriteSample(conn, getTestPushSample(`103`, `1200`))
+	state := nextState()
+	if annotations := state.GetAnnotations(); len(annotations) != 2 || !strings.HasPrefix(annotations[0], `stopped reading from`) {
+		t.Errorf("unexpected annotations: %q", annotations)
+	}
+}
@@ -1,25 +1,386 @@
 package loader
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-sql-driver/mysql"
+	"github.com/hashicorp/go-multierror"
 )
 
 const (
 	// The commands we send to the mysql cli
 	STATUS_QUERY    string = "SELECT VARIABLE_NAME, VARIABLE_VALUE FROM performance_schema.global_status"
 	VARIABLES_QUERY string = "SELECT VARIABLE_NAME, VARIABLE_VALUE FROM performance_schema.global_variables"
+	REPLICA_QUERY   string = "SHOW REPLICA STATUS"
+
+	// Before MySQL 8.0.22
+	SLAVE_QUERY string = "SHOW SLAVE STATUS"
+
+	// Fallbacks when performance_schema is disabled
+	SHOW_STATUS_QUERY    string = "SHOW GLOBAL STATUS"
+	SHOW_VARIABLES_QUERY string = "SHOW GLOBAL VARIABLES"
+
+	// Disabled counters are left out, their COUNT would be a misleading 0
+	INNODB_METRICS_QUERY string = "SELECT NAME, COUNT FROM information_schema.innodb_metrics WHERE STATUS = 'enabled'"
+
+	// A single row summarizing open InnoDB transactions: how many in each state, how old the oldest is (seconds), how many are older than 1s, 10s and 60s, and the rows they hold
+	INNODB_TRX_QUERY string = `SELECT COUNT(*) AS active,
+		COALESCE(SUM(trx_state = 'RUNNING'), 0) AS running,
+		COALESCE(SUM(trx_state = 'LOCK WAIT'), 0) AS lock_wait,
+		COALESCE(SUM(trx_state = 'ROLLING BACK'), 0) AS rolling_back,
+		COALESCE(SUM(trx_state = 'COMMITTING'), 0) AS committing,
+		COALESCE(MAX(TIMESTAMPDIFF(SECOND, trx_started, NOW())), 0) AS oldest,
+		COALESCE(SUM(trx_started <= NOW() - INTERVAL 1 SECOND), 0) AS over_1s,
+		COALESCE(SUM(trx_started <= NOW() - INTERVAL 10 SECOND), 0) AS over_10s,
+		COALESCE(SUM(trx_started <= NOW() - INTERVAL 60 SECOND), 0) AS over_60s,
+		COALESCE(SUM(trx_rows_locked), 0) AS rows_locked,
+		COALESCE(SUM(trx_rows_modified), 0) AS rows_modified
+		FROM information_schema.innodb_trx`
+
+	// Metadata lock waits as name/value rows: pending locks, waiting threads, blocked objects, the oldest wait (seconds), and the mdl wait instrument's count and time (ns)
+	METADATA_LOCKS_QUERY string = `SELECT 'pending', COUNT(*) FROM performance_schema.metadata_locks WHERE LOCK_STATUS = 'PENDING'
+		UNION ALL SELECT 'waiting_threads', COUNT(DISTINCT OWNER_THREAD_ID) FROM performance_schema.metadata_locks WHERE LOCK_STATUS = 'PENDING'
+		UNION ALL SELECT 'blocked_objects', COUNT(DISTINCT OBJECT_TYPE, OBJECT_SCHEMA, OBJECT_NAME) FROM performance_schema.metadata_locks WHERE LOCK_STATUS = 'PENDING'
+		UNION ALL SELECT 'oldest_wait', COALESCE(MAX(t.PROCESSLIST_TIME), 0) FROM performance_schema.metadata_locks ml
+			JOIN performance_schema.threads t ON t.THREAD_ID = ml.OWNER_THREAD_ID WHERE ml.LOCK_STATUS = 'PENDING'
+		UNION ALL SELECT 'waits', COUNT_STAR FROM performance_schema.events_waits_summary_global_by_event_name WHERE EVENT_NAME = 'wait/lock/metadata/sql/mdl'
+		UNION ALL SELECT 'wait_time', SUM_TIMER_WAIT DIV 1000 FROM performance_schema.events_waits_summary_global_by_event_name WHERE EVENT_NAME = 'wait/lock/metadata/sql/mdl'`
+
+	// Who waits on whom for metadata locks, like sys.schema_table_lock_waits
+	METADATA_LOCK_WAITS_QUERY string = `SELECT wt.PROCESSLIST_TIME, w.OBJECT_TYPE, w.OBJECT_SCHEMA, w.OBJECT_NAME, wt.PROCESSLIST_ID, w.LOCK_TYPE, bt.PROCESSLIST_ID, b.LOCK_TYPE
+		FROM performance_schema.metadata_locks w
+		JOIN performance_schema.threads wt ON wt.THREAD_ID = w.OWNER_THREAD_ID
+		JOIN performance_schema.metadata_locks b ON b.OBJECT_TYPE = w.OBJECT_TYPE AND b.OBJECT_SCHEMA <=> w.OBJECT_SCHEMA
+			AND b.OBJECT_NAME <=> w.OBJECT_NAME AND b.LOCK_STATUS = 'GRANTED' AND b.OWNER_THREAD_ID != w.OWNER_THREAD_ID
+		JOIN performance_schema.threads bt ON bt.THREAD_ID = b.OWNER_THREAD_ID
+		WHERE w.LOCK_STATUS = 'PENDING' AND wt.PROCESSLIST_TIME >= ?
+		ORDER BY wt.PROCESSLIST_TIME DESC LIMIT 10`
+
+	// A single row summarizing InnoDB row lock waits: how many, how many transactions are waiting and blocking, and the longest wait so far (seconds)
+	INNODB_LOCK_WAITS_QUERY string = `SELECT COUNT(*) AS waits,
+		COUNT(DISTINCT w.REQUESTING_ENGINE_TRANSACTION_ID) AS waiting,
+		COUNT(DISTINCT w.BLOCKING_ENGINE_TRANSACTION_ID) AS blocking,
+		COALESCE(MAX(TIMESTAMPDIFF(SECOND, t.trx_wait_started, NOW())), 0) AS longest_wait
+		FROM performance_schema.data_lock_waits w
+		JOIN information_schema.innodb_trx t ON t.trx_id = w.REQUESTING_ENGINE_TRANSACTION_ID`
+
+	// Before MySQL 8.0.1, lock waits were in information_schema
+	INNODB_LOCK_WAITS_57_QUERY string = `SELECT COUNT(*) AS waits,
+		COUNT(DISTINCT w.requesting_trx_id) AS waiting,
+		COUNT(DISTINCT w.blocking_trx_id) AS blocking,
+		COALESCE(MAX(TIMESTAMPDIFF(SECOND, t.trx_wait_started, NOW())), 0) AS longest_wait
+		FROM information_schema.innodb_lock_waits w
+		JOIN information_schema.innodb_trx t ON t.trx_id = w.requesting_trx_id`
+
+	// A single row summarizing the client threads (not our own): how many are in each command, how many queries are in each common state, how many are blocked on a lock, and the age of the longest running query (seconds).  The threads come from PROCESSLIST_THREADS or PROCESSLIST_SCHEMA below.
+	PROCESSLIST_SUMMARY string = `SELECT COUNT(*) AS threads,
+		COALESCE(SUM(command = 'Sleep'), 0) AS sleep,
+		COALESCE(SUM(command IN ('Query', 'Execute')), 0) AS query,
+		COALESCE(SUM(command IN ('Connect', 'Init DB', 'Change user')), 0) AS connect,
+		COALESCE(SUM(command LIKE 'Binlog Dump%'), 0) AS binlog_dump,
+		COALESCE(SUM(command = 'Killed'), 0) AS killed,
+		COALESCE(SUM(state IN ('executing', 'Sending data', 'Sending to client')), 0) AS sending,
+		COALESCE(SUM(state IN ('Creating sort index', 'Sorting result')), 0) AS sorting,
+		COALESCE(SUM(state IN ('Creating tmp table', 'Copying to tmp table', 'converting HEAP to ondisk')), 0) AS tmp_table,
+		COALESCE(SUM(state IN ('Opening tables', 'closing tables')), 0) AS opening,
+		COALESCE(SUM(state LIKE 'Waiting for%lock%' OR state = 'Locked'), 0) AS blocked,
+		COALESCE(SUM(state = 'Waiting for table metadata lock'), 0) AS mdl_wait,
+		COALESCE(MAX(IF(command IN ('Query', 'Execute'), time, NULL)), 0) AS longest,
+		COALESCE(SUM(command IN ('Query', 'Execute') AND time >= 1), 0) AS over_1s,
+		COALESCE(SUM(command IN ('Query', 'Execute') AND time >= 10), 0) AS over_10s
+		FROM `
+
+	// performance_schema.threads doesn't take the mutex SHOW PROCESSLIST does, and needs no PROCESS privilege to see every thread
+	PROCESSLIST_THREADS string = `(SELECT PROCESSLIST_COMMAND AS command, PROCESSLIST_STATE AS state, PROCESSLIST_TIME AS time
+		FROM performance_schema.threads WHERE TYPE = 'FOREGROUND' AND PROCESSLIST_ID != CONNECTION_ID()) AS p`
+
+	// Fallback when performance_schema is disabled
+	PROCESSLIST_SCHEMA string = `(SELECT COMMAND AS command, STATE AS state, TIME AS time
+		FROM information_schema.processlist WHERE ID != CONNECTION_ID()) AS p`
+
+	// The client threads (not our own) of each user@host: how many, how many are running a query or sleeping, and the longest idle (seconds).  The threads come from CLIENTS_THREADS or CLIENTS_SCHEMA below.
+	CLIENTS_SUMMARY string = `SELECT CONCAT(IFNULL(user, ''), '@', IFNULL(SUBSTRING_INDEX(host, ':', 1), '')) AS name,
+		COUNT(*) AS connections,
+		SUM(command IN ('Query', 'Execute')) AS running,
+		SUM(command = 'Sleep') AS sleeping,
+		MAX(IF(command = 'Sleep', time, 0)) AS max_idle
+		FROM `
+	CLIENTS_THREADS string = `(SELECT PROCESSLIST_USER AS user, PROCESSLIST_HOST AS host, PROCESSLIST_COMMAND AS command, PROCESSLIST_TIME AS time
+		FROM performance_schema.threads WHERE TYPE = 'FOREGROUND' AND PROCESSLIST_ID != CONNECTION_ID()) AS p GROUP BY name`
+	CLIENTS_SCHEMA string = `(SELECT USER AS user, HOST AS host, COMMAND AS command, TIME AS time
+		FROM information_schema.processlist WHERE ID != CONNECTION_ID()) AS p GROUP BY name`
+
+	// Per-user and per-table activity counters of Percona Server and MariaDB, when userstat is ON
+	USER_STATISTICS_QUERY  string = "SELECT USER AS name, s.* FROM information_schema.USER_STATISTICS s"
+	TABLE_STATISTICS_QUERY string = "SELECT CONCAT(TABLE_SCHEMA, '.', TABLE_NAME) AS name, ROWS_READ, ROWS_CHANGED, ROWS_CHANGED_X_INDEXES FROM information_schema.TABLE_STATISTICS"
+
+	// The statements run by digest, the latency in nanoseconds
+	STATEMENT_DIGESTS_QUERY string = "SELECT CONCAT(LEFT(DIGEST, 8), ' ', IFNULL(SCHEMA_NAME, '-'), ' ', LEFT(DIGEST_TEXT, 80)) AS name, COUNT_STAR, ROUND(SUM_TIMER_WAIT / 1000) AS sum_latency, SUM_ROWS_EXAMINED, SUM_ROWS_SENT FROM performance_schema.events_statements_summary_by_digest WHERE DIGEST IS NOT NULL"
+
+	// File IO by kind of file, the latency in nanoseconds.  By event name rather than instance: the counters of an instance go when its file is closed (e.g. temp files), so sums over instances go backwards.
+	FILE_IO_QUERY string = `SELECT CASE
+		WHEN EVENT_NAME = 'wait/io/file/innodb/innodb_data_file' THEN 'data'
+		WHEN EVENT_NAME = 'wait/io/file/innodb/innodb_log_file' THEN 'redo'
+		WHEN EVENT_NAME IN ('wait/io/file/sql/binlog', 'wait/io/file/sql/binlog_index', 'wait/io/file/sql/relaylog', 'wait/io/file/sql/relaylog_index') THEN 'binlog'
+		WHEN EVENT_NAME IN ('wait/io/file/innodb/innodb_temp_file', 'wait/io/file/sql/io_cache') THEN 'temp'
+		ELSE 'other' END AS name,
+		SUM(SUM_NUMBER_OF_BYTES_READ) AS read_bytes, SUM(SUM_NUMBER_OF_BYTES_WRITE) AS write_bytes, ROUND(SUM(SUM_TIMER_WAIT) / 1000) AS latency
+		FROM performance_schema.file_summary_by_event_name GROUP BY name`
+
+	// Each replication applier worker by channel and worker id, all of them listed: the sequence number of the last transaction it applied, the retries of that one and of the one it is applying, and how long since the one it is applying was committed on the original source (µs, 0 when idle)
+	APPLIER_WORKERS_QUERY string = `SELECT CONCAT(IF(CHANNEL_NAME = '', '', CONCAT(CHANNEL_NAME, ' ')), 'worker ', LPAD(WORKER_ID, 3, ' ')) AS name,
+		1 AS listed,
+		IFNULL(NULLIF(SUBSTRING_INDEX(LAST_APPLIED_TRANSACTION, ':', -1), ''), 0) AS seqno,
+		LAST_APPLIED_TRANSACTION_RETRIES_COUNT AS last_retries,
+		APPLYING_TRANSACTION_RETRIES_COUNT AS retries,
+		IF(APPLYING_TRANSACTION != '', TIMESTAMPDIFF(MICROSECOND, APPLYING_TRANSACTION_ORIGINAL_COMMIT_TIMESTAMP, NOW(6)), 0) AS lag
+		FROM performance_schema.replication_applier_status_by_worker`
+
+	// Memory allocated by the server as name/value rows, in total and for the InnoDB buffer pool, like sys.memory_global_total
+	MEMORY_QUERY string = `SELECT 'total', SUM(CURRENT_NUMBER_OF_BYTES_USED) FROM performance_schema.memory_summary_global_by_event_name
+		UNION ALL SELECT 'innodb_buffer_pool', COALESCE(SUM(CURRENT_NUMBER_OF_BYTES_USED), 0) FROM performance_schema.memory_summary_global_by_event_name WHERE EVENT_NAME = 'memory/innodb/buf_buf_pool'`
+
+	// Memory allocated by each memory instrument (without the memory/ prefix), those with any
+	MEMORY_EVENTS_QUERY string = `SELECT SUBSTRING(EVENT_NAME, 8) AS name, CURRENT_NUMBER_OF_BYTES_USED AS current_bytes, HIGH_NUMBER_OF_BYTES_USED AS high_bytes, CURRENT_COUNT_USED AS current_count
+		FROM performance_schema.memory_summary_global_by_event_name WHERE CURRENT_NUMBER_OF_BYTES_USED > 0`
+
+	// The InnoDB monitor output, parsed by ParseInnodbStatus
+	INNODB_STATUS_QUERY string = "SHOW ENGINE INNODB STATUS"
+
+	// What we ask when we connect to choose how to collect the Sources, only when a Source depends on it
+	VERSION_QUERY     string = "SELECT VERSION()"
+	PFS_ENABLED_QUERY string = "SELECT @@performance_schema"
+
+	// Default limit on how long each collection query can run
+	DEFAULT_QUERY_TIMEOUT time.Duration = 5 * time.Second
 )
 
+// How to collect a Source from a live server
+type liveSource struct {
+	// Returns VARIABLE_NAME, VARIABLE_VALUE rows
+	query string
+
+	// The privilege the query needs, for reporting
+	grant string
+
+	// The query instead returns a single row whose column names are the keys
+	columns bool
+
+	// The query instead returns a row per object named by its `name` column, the keys are `<name>.<column>`
+	keyed bool
+
+	// The query instead returns a single row whose `status` column is text, parsed into the keys
+	parse func(string) map[string]string
+
+	// The query reads performance_schema, use the fallback (if any) when it is disabled
+	pfs      bool
+	fallback *liveSource
+
+	// The query needs at least this MySQL version, use the legacy source (if any) on older ones and on MariaDB, whose versions don't compare
+	since  ServerVersion
+	legacy *liveSource
+}
+
+// The Sources the LiveLoader can collect
+var liveSources = map[SourceName]liveSource{
+	`status`: {
+		query:    STATUS_QUERY,
+		grant:    `SELECT ON performance_schema.global_status`,
+		pfs:      true,
+		fallback: &liveSource{query: SHOW_STATUS_QUERY},
+	},
+	`variables`: {
+		query:    VARIABLES_QUERY,
+		grant:    `SELECT ON performance_schema.global_variables`,
+		pfs:      true,
+		fallback: &liveSource{query: SHOW_VARIABLES_QUERY},
+	},
+	`replica`: {
+		query:   REPLICA_QUERY,
+		grant:   `REPLICATION CLIENT ON *.*`,
+		columns: true,
+		since:   ServerVersion{8, 0, 22},
+		legacy:  &liveSource{query: SLAVE_QUERY, grant: `REPLICATION CLIENT ON *.*`, columns: true},
+	},
+	`innodb_metrics`: {
+		query: INNODB_METRICS_QUERY,
+		grant: `PROCESS ON *.*`,
+	},
+	`innodb_trx`: {
+		query:   INNODB_TRX_QUERY,
+		grant:   `PROCESS ON *.*`,
+		columns: true,
+	},
+	`innodb_lock_waits`: {
+		query:   INNODB_LOCK_WAITS_QUERY,
+		grant:   `SELECT ON performance_schema.data_lock_waits and PROCESS ON *.*`,
+		columns: true,
+		pfs:     true,
+		since:   ServerVersion{8, 0, 1},
+		legacy:  &liveSource{query: INNODB_LOCK_WAITS_57_QUERY, grant: `PROCESS ON *.*`, columns: true},
+	},
+	`processlist`: {
+		query:    PROCESSLIST_SUMMARY + PROCESSLIST_THREADS,
+		grant:    `SELECT ON performance_schema.threads`,
+		columns:  true,
+		pfs:      true,
+		fallback: &liveSource{query: PROCESSLIST_SUMMARY + PROCESSLIST_SCHEMA, grant: `PROCESS ON *.*`, columns: true},
+	},
+	`clients`: {
+		query:    CLIENTS_SUMMARY + CLIENTS_THREADS,
+		grant:    `SELECT ON performance_schema.threads`,
+		keyed:    true,
+		pfs:      true,
+		fallback: &liveSource{query: CLIENTS_SUMMARY + CLIENTS_SCHEMA, grant: `PROCESS ON *.*`, keyed: true},
+	},
+	`metadata_locks`: {
+		query: METADATA_LOCKS_QUERY,
+		grant: `SELECT ON performance_schema.*`,
+		pfs:   true,
+	},
+	`statement_digests`: {
+		query: STATEMENT_DIGESTS_QUERY,
+		grant: `SELECT ON performance_schema.*`,
+		keyed: true,
+		pfs:   true,
+	},
+	`file_io`: {
+		query: FILE_IO_QUERY,
+		grant: `SELECT ON performance_schema.*`,
+		keyed: true,
+		pfs:   true,
+	},
+	`applier_workers`: {
+		query: APPLIER_WORKERS_QUERY,
+		grant: `SELECT ON performance_schema.*`,
+		keyed: true,
+		pfs:   true,
+		since: ServerVersion{8, 0, 2},
+	},
+	`memory`: {
+		query: MEMORY_QUERY,
+		grant: `SELECT ON performance_schema.*`,
+		pfs:   true,
+	},
+	`memory_events`: {
+		query: MEMORY_EVENTS_QUERY,
+		grant: `SELECT ON performance_schema.*`,
+		keyed: true,
+		pfs:   true,
+	},
+	`innodb_status`: {
+		query: INNODB_STATUS_QUERY,
+		grant: `PROCESS ON *.*`,
+		parse: ParseInnodbStatus,
+	},
+	`user_statistics`: {
+		query: USER_STATISTICS_QUERY,
+		grant: `PROCESS ON *.*`,
+		keyed: true,
+	},
+	`table_statistics`: {
+		query: TABLE_STATISTICS_QUERY,
+		grant: `PROCESS ON *.*`,
+		keyed: true,
+	},
+}
+
+// Does the given Source need performance_schema enabled?  Unknown Sources don't.
+func SourceRequiresPFS(name SourceName) bool {
+	source, ok := liveSources[name]
+	return ok && source.pfs && source.fallback == nil
+}
+
+// A Source that cannot be collected because performance_schema is disabled
+type PerformanceSchemaError struct {
+	Source SourceName
+}
+
+func (e *PerformanceSchemaError) Error() string {
+	return fmt.Sprintf("source %s requires performance_schema, which is disabled", e.Source)
+}
+
+// A Source whose query needs a newer MySQL than the server is
+type ServerVersionError struct {
+	Source SourceName
+	Since  ServerVersion
+}
+
+func (e *ServerVersionError) Error() string {
+	return fmt.Sprintf("source %s needs MySQL %s+", e.Source, e.Since)
+}
+
+// MySQL error numbers that indicate a missing privilege
+var privilegeErrors = []uint16{
+	1044, // ER_DBACCESS_DENIED_ERROR
+	1142, // ER_TABLEACCESS_DENIED_ERROR
+	1143, // ER_COLUMNACCESS_DENIED_ERROR
+	1227, // ER_SPECIFIC_ACCESS_DENIED_ERROR
+}
+
+// A Source that cannot be collected because the user lacks a privilege
+type PrivilegeError struct {
+	Source SourceName
+	Grant  string
+	Err    error
+}
+
+func (e *PrivilegeError) Error() string {
+	return fmt.Sprintf("source %s needs GRANT %s: %v", e.Source, e.Grant, e.Err)
+}
+
+func (e *PrivilegeError) Unwrap() error {
+	return e.Err
+}
+
 // SHOW output via mysqladmin on a live server
 type LiveLoader struct {
 	interval time.Duration
 	config   *mysql.Config
 	db       *sql.DB
+
+	// The interval can change while collecting, see SetInterval
+	intervalMutex   sync.Mutex
+	intervalChanged chan struct{}
+
+	// The liveSources we collect, and how (depends on performance_schema)
+	sources []SourceName
+	queries map[SourceName]liveSource
+
+	// Sources collected outside of the mysql connection
+	pollers map[SourceName]Poller
+
+	// Retry policy after failed collections and the limit for each query
+	backoff      Backoff
+	queryTimeout time.Duration
+
+	// Limits for the queries of some Sources instead of the queryTimeout
+	sourceTimeouts map[SourceName]time.Duration
+
+	// Collect up to this many Sources at once, over as many connections
+	parallel int
+
+	// innodb_metrics counters to enable when we connect
+	innodbMonitors []string
+
+	// The server's version, zero if unknown
+	version ServerVersion
+
+	// Annotate States with the metadata lock waits at least this old, 0 is never
+	lockWaitDetail time.Duration
+
+	// Chooses the Sources to collect each interval to stay within the Budget, nil is no Budget
+	budget *budgetPlanner
+
+	// Collect on the wall clock multiples of the interval
+	align bool
 }
 
 // Create a new SqlLoader
@@ -29,16 +390,135 @@ func NewLiveLoader(config *mysql.Config) *LiveLoader {
 	ll := &LiveLoader{}
 	ll.config = config
 	ll.config.Timeout, _ = time.ParseDuration(`5s`)
+	ll.pollers = make(map[SourceName]Poller)
+	ll.backoff = DefaultBackoff()
+	ll.queryTimeout = DEFAULT_QUERY_TIMEOUT
+	ll.sourceTimeouts = make(map[SourceName]time.Duration)
+	ll.parallel = 1
+	ll.intervalChanged = make(chan struct{}, 1)
 	return ll
 }
 
-// Connect to the DB and report any errors
+// Set how long to wait before collecting again after failures
+func (l *LiveLoader) SetBackoff(b Backoff) {
+	l.backoff = b
+}
+
+// Collect at a new interval from now on, e.g. to look closer at a problem without restarting
+func (l *LiveLoader) SetInterval(interval time.Duration) {
+	l.intervalMutex.Lock()
+	l.interval = interval
+	l.intervalMutex.Unlock()
+
+	select {
+	case l.intervalChanged <- struct{}{}:
+	default:
+	}
+}
+
+func (l *LiveLoader) getInterval() time.Duration {
+	l.intervalMutex.Lock()
+	defer l.intervalMutex.Unlock()
+	return l.interval
+}
+
+// Set the timeout for each collection query, 0 is no timeout
+func (l *LiveLoader) SetQueryTimeout(d time.Duration) {
+	l.queryTimeout = d
+}
+
+// Limit the queries of the Source to d instead of the query timeout, e.g. a slow one that shouldn't hold up the interval
+func (l *LiveLoader) SetSourceTimeout(name SourceName, d time.Duration) {
+	l.sourceTimeouts[name] = d
+}
+
+// Parse a Source's timeout like `innodb_trx=2s`, for SetSourceTimeout
+func ParseSourceTimeout(str string) (SourceName, time.Duration, error) {
+	name, duration, found := strings.Cut(str, `=`)
+	if !found {
+		return ``, 0, fmt.Errorf("expected <source>=<duration>: %s", str)
+	}
+	if _, ok := liveSources[SourceName(name)]; !ok {
+		return ``, 0, fmt.Errorf("unknown source %s", name)
+	}
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return ``, 0, err
+	} else if d < 0 {
+		return ``, 0, fmt.Errorf("negative timeout: %s", str)
+	}
+	return SourceName(name), d, nil
+}
+
+// Collect up to n Sources at once over as many connections, so a slow Source doesn't delay the others.  1 (the default) collects them one after the other over a single connection.
+func (l *LiveLoader) SetParallel(n int) {
+	l.parallel = max(n, 1)
+}
+
+// Enable the given innodb_metrics counters with SET GLOBAL innodb_monitor_enable when we connect.  This changes the server's settings!
+func (l *LiveLoader) SetInnodbMonitors(counters []string) {
+	l.innodbMonitors = counters
+}
+
+// Annotate each State with who is waiting on whom when a metadata lock wait is at least this old, 0 to disable
+func (l *LiveLoader) SetLockWaitDetail(d time.Duration) {
+	l.lockWaitDetail = d
+}
+
+// Limit the load on the server each interval.  Sources other than status that don't fit are collected less often, keeping their last Sample in between.
+func (l *LiveLoader) SetBudget(b Budget) {
+	if b == (Budget{}) {
+		l.budget = nil
+		return
+	}
+	l.budget = newBudgetPlanner(b)
+}
+
+// Collect on the wall clock multiples of the interval (e.g., every minute at :00) instead of from when we start, the first collection waits for the first one
+func (l *LiveLoader) SetAlign(align bool) {
+	l.align = align
+}
+
+// Close the connection to the server, if we opened one
+func (l *LiveLoader) Close() error {
+	if l.db == nil {
+		return nil
+	}
+	return l.db.Close()
+}
+
+// Add a Poller whose latest Sample is included in every State as the given Source
+func (l *LiveLoader) AddPoller(name SourceName, p Poller) {
+	l.pollers[name] = p
+}
+
+// Can the server or a Poller give the Source?
+func (l *LiveLoader) CanCollect(name SourceName) bool {
+	_, ok := liveSources[name]
+	return ok || l.pollers[name] != nil
+}
+
+// Connect to the DB and report any errors.  We don't connect at all when all of the Sources come from Pollers, and fail on Sources neither the server nor a Poller has (e.g., role Sources without a MultiLoader).
 func (l *LiveLoader) Initialize(interval time.Duration, sources []SourceName) error {
 	l.interval = interval
 
-	// Open the db connection and confirm it works
-	dsn := l.config.FormatDSN()
-	db, err := sql.Open("mysql", dsn)
+	// Only collect the requested Sources we know how to query
+	names := knownLiveSources(sources)
+	if len(names) == 0 && len(l.innodbMonitors) == 0 {
+		var unknown []SourceName
+		for _, name := range sources {
+			if _, ok := l.pollers[name]; !ok {
+				unknown = append(unknown, name)
+			}
+		}
+		if len(unknown) > 0 {
+			return fmt.Errorf("cannot collect %s from a server (role sources like primary.status need -pair or -hosts)", joinSources(unknown))
+		}
+		return nil
+	}
+
+	// Open the db connection and confirm it works, a connector keeps any BeforeConnect of the config (e.g., fresh IAM auth tokens)
+	connector, err := mysql.NewConnector(l.config)
 
 	l.config.Passwd = "******"
 	cleanDsn := l.config.FormatDSN()
@@ -46,59 +526,372 @@ func (l *LiveLoader) Initialize(interval time.Duration, sources []SourceName) er
 	if err != nil {
 		return fmt.Errorf("%s\n%s", cleanDsn, err)
 	}
-	db.SetMaxOpenConns(1)
+	db := sql.OpenDB(connector)
+	db.SetMaxOpenConns(l.parallel)
 
-	err = db.Ping()
+	ctx, cancel := l.queryContext()
+	defer cancel()
+	err = db.PingContext(ctx)
 	if err != nil {
 		return fmt.Errorf("%s\n%s", cleanDsn, err)
 	}
 
 	l.db = db
 
+	if err := l.enableInnodbMonitors(); err != nil {
+		return err
+	}
+
+	l.sources, l.queries, err = routeLiveSources(names, l)
+	if err != nil {
+		return err
+	}
+	return l.preflight()
+}
+
+// The server's version string, empty if we can't tell
+func (l *LiveLoader) probeVersion() string {
+	ctx, cancel := l.queryContext()
+	defer cancel()
+
+	var str string
+	if err := l.db.QueryRowContext(ctx, VERSION_QUERY).Scan(&str); err != nil {
+		return ``
+	}
+	l.version, _ = ParseServerVersion(str)
+	return str
+}
+
+// Is performance_schema enabled?  If we can't tell, assume it is and let preflight complain.
+func (l *LiveLoader) probePerformanceSchema() bool {
+	ctx, cancel := l.queryContext()
+	defer cancel()
+
+	var enabled bool
+	if err := l.db.QueryRowContext(ctx, PFS_ENABLED_QUERY).Scan(&enabled); err != nil {
+		return true
+	}
+	return enabled
+}
+
+// The Sources the LiveLoader knows how to query, others come from Pollers or not at all
+func knownLiveSources(sources []SourceName) (known []SourceName) {
+	for _, name := range sources {
+		if _, ok := liveSources[name]; ok {
+			known = append(known, name)
+		}
+	}
+	return
+}
+
+// Do any of the Sources depend on the server version or on performance_schema being enabled?
+func liveProbes(sources []SourceName) (version, pfs bool) {
+	for _, name := range sources {
+		source := liveSources[name]
+		version = version || !source.since.IsZero()
+		pfs = pfs || source.pfs || source.legacy != nil && source.legacy.pfs
+	}
+	return
+}
+
+// Probes a server for what the choice of queries for the Sources depends on
+type liveProber interface {
+	// The server's version string, e.g. `8.4.0` or `10.11.6-MariaDB`, empty if unknown
+	probeVersion() string
+
+	// Is performance_schema enabled?
+	probePerformanceSchema() bool
+}
+
+// How to collect each of the known Sources, in order, probing the server only for what they depend on
+func routeLiveSources(names []SourceName, prober liveProber) ([]SourceName, map[SourceName]liveSource, error) {
+	needVersion, needPFS := liveProbes(names)
+	var version ServerVersion
+	flavor := MYSQL_FLAVOR
+	if needVersion {
+		str := prober.probeVersion()
+		version, _ = ParseServerVersion(str)
+		flavor = ParseServerFlavor(str)
+	}
+	pfs := true
+	if needPFS {
+		pfs = prober.probePerformanceSchema()
+	}
+
+	var routed []SourceName
+	queries := make(map[SourceName]liveSource)
+	var errs *multierror.Error
+	for _, name := range names {
+		source, err := resolveLiveSource(name, pfs, version, flavor)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		routed = append(routed, name)
+		queries[name] = source
+	}
+	return routed, queries, errs.ErrorOrNil()
+}
+
+// How to collect the named Source, given whether performance_schema is enabled and the server version (if known) and flavor
+func resolveLiveSource(name SourceName, pfs bool, version ServerVersion, flavor ServerFlavor) (liveSource, error) {
+	source := liveSources[name]
+	if !source.since.IsZero() && (flavor == MARIADB_FLAVOR || !version.IsZero() && !version.AtLeast(source.since)) {
+		if source.legacy == nil {
+			return source, &ServerVersionError{Source: name, Since: source.since}
+		}
+		source = *source.legacy
+	}
+	if !source.pfs || pfs {
+		return source, nil
+	}
+	if source.fallback == nil {
+		return source, &PerformanceSchemaError{Source: name}
+	}
+	return *source.fallback, nil
+}
+
+// Does the server not answer a ping?  Its queries can all fail for other reasons, e.g. timing out on a busy server.
+func (l *LiveLoader) connectionLost() bool {
+	ctx, cancel := l.queryContext()
+	defer cancel()
+	return l.db.PingContext(ctx) != nil
+}
+
+// Turn on the requested innodb_metrics counters, they stay on after we exit
+func (l *LiveLoader) enableInnodbMonitors() error {
+	for _, counter := range l.innodbMonitors {
+		ctx, cancel := l.queryContext()
+		_, err := l.db.ExecContext(ctx, `SET GLOBAL innodb_monitor_enable = ?`, counter)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("cannot enable innodb_metrics counter %s: %v", counter, err)
+		}
+	}
 	return nil
 }
 
+// Check every Source query can run before we start, so we don't fail mid-session
+func (l *LiveLoader) preflight() error {
+	var errs *multierror.Error
+	for _, name := range l.sources {
+		source := l.queries[name]
+
+		// EXPLAIN checks privileges without executing the query, SHOW commands are cheap enough to just run
+		query := `EXPLAIN ` + source.query
+		if strings.HasPrefix(source.query, `SHOW`) {
+			query = source.query
+		}
+		rows, err := l.db.Query(query)
+		if err != nil {
+			errs = multierror.Append(errs, newPreflightError(name, source, err))
+			continue
+		}
+		rows.Close()
+	}
+	return errs.ErrorOrNil()
+}
+
+// Wrap a failed preflight of the given Source into a PrivilegeError, if that's what it is
+func newPreflightError(name SourceName, source liveSource, err error) error {
+	var merr *mysql.MySQLError
+	if errors.As(err, &merr) && slices.Contains(privilegeErrors, merr.Number) {
+		return &PrivilegeError{Source: name, Grant: source.grant, Err: err}
+	}
+	return fmt.Errorf("source %s preflight failed: %v", name, err)
+}
+
 // Returns a channel where new MyqSamples are collected and sent every l.interval from the l.db connection.
 func (l *LiveLoader) GetStateChannel() <-chan StateReader {
 	ch := make(chan StateReader)
 
-	// Closure to build the next state and send to down the channel
+	// Closure to build the next state and send to down the channel, returns false if any query failed
 	var prev_ssp *SampleSet
-	generateState := func() {
+	var seq, prevSeq uint64
+	var throttling bool
+	var lostAt time.Time
+	lastSamples := make(map[SourceName]SampleReader)
+	generateState := func() bool {
 		state := NewState()
 		state.Live = true
+		state.Seq = seq
+		if prev_ssp != nil {
+			state.Missed = seq - prevSeq - 1
+		}
 
-		status := l.getSample(STATUS_QUERY)
-		variables := l.getSample(VARIABLES_QUERY)
+		collect, skip := l.sources, []SourceName(nil)
+		if l.budget != nil {
+			collect, skip = l.budget.plan(l.sources, seq)
+		}
 
-		state.GetCurrentWriter().SetSample(`status`, status)
-		state.GetCurrentWriter().SetSample(`variables`, variables)
+		ok := true
+		collected := 0
+		samples, took := l.collectSources(collect)
+		for i, source := range collect {
+			sample := samples[i]
+			if l.budget != nil {
+				l.budget.spent(source, took[i])
+			}
+			if sample.Error() != nil {
+				ok = false
+			} else {
+				collected++
+			}
+			sample.applyAliases(source)
+			state.GetCurrentWriter().SetSample(source, sample)
+		}
+
+		// Skipped Sources are left out, their cols show - until they are collected again
+		if len(skip) > 0 && !throttling {
+			state.AddAnnotation(fmt.Sprintf("budget %s: collecting %s less often", l.budget.budget, joinSources(skip)))
+		} else if len(skip) == 0 && throttling {
+			state.AddAnnotation(fmt.Sprintf("budget %s: collecting every source again", l.budget.budget))
+		}
+		throttling = len(skip) > 0
+
+		if l.lockWaitDetail > 0 && state.GetCurrent().GetF(oldestLockWaitKey) >= l.lockWaitDetail.Seconds() {
+			for _, wait := range l.getLockWaits() {
+				state.AddAnnotation(wait)
+			}
+		}
+
+		// Every query failed, and the connection is lost (or the server is down) if it doesn't answer a ping either.  The pool reconnects on the next query.
+		failed := len(collect) > 0 && collected == 0
+		lost := failed && l.connectionLost()
+		if lost && lostAt.IsZero() {
+			lostAt = time.Now()
+			state.AddAnnotation("connection lost, retrying")
+		} else if !failed && !lostAt.IsZero() {
+			state.AddAnnotation(fmt.Sprintf("connection restored after %s", time.Since(lostAt).Round(time.Second)))
+			lostAt = time.Time{}
 
-		state.SetPrevious(prev_ssp)
+			// The server may have restarted, which turns the counters off
+			if err := l.enableInnodbMonitors(); err != nil {
+				state.AddAnnotation(err.Error())
+			}
+		}
+
+		for name, poller := range l.pollers {
+			if sample := poller.GetLatest(); sample != nil {
+				state.GetCurrentWriter().SetSample(name, sample)
+			}
+		}
+
+		// Rates of a Source collected again after a skip are from its last Sample, over the time since
+		if state.setPreviousOrRestart(prev_ssp.withMissing(lastSamples)) {
+			state.AddAnnotation("server restarted")
+		}
+		for i, source := range collect {
+			if samples[i].Error() == nil {
+				lastSamples[source] = samples[i]
+			}
+		}
 
 		ch <- state
-		prev_ssp = state.Current
+
+		// Values are computed from the last State that was collected, across the intervals lost in between
+		if !failed {
+			prev_ssp = state.Current
+			prevSeq = seq
+		}
+		return ok
+	}
+
+	// Skip ticks while backing off after consecutive failures
+	var failures int
+	var retryAt time.Time
+	collect := func() {
+		// Every tick counts, even if we skip it
+		seq++
+		if time.Now().Before(retryAt) {
+			return
+		}
+		if generateState() {
+			failures = 0
+		} else {
+			failures++
+			retryAt = time.Now().Add(l.backoff.Delay(failures))
+		}
+	}
+
+	for _, poller := range l.pollers {
+		poller.Start()
 	}
 
 	// Start a ticker in a goroutine to collect samples every l.interval
-	ticker := time.NewTicker(l.interval)
+	ticks, stop := newTicks(l.getInterval(), l.align)
 	go func() {
-		// Generate the first state right away
-		generateState()
+		// Generate the first state right away, unless it waits for an aligned tick
+		if !l.align {
+			collect()
+		}
 
-		// Send another State every tick
-		for range ticker.C {
-			generateState()
+		// Send another State every tick, the next one is a new interval away when it changes
+		for {
+			select {
+			case <-ticks:
+				collect()
+			case <-l.intervalChanged:
+				stop()
+				ticks, stop = newTicks(l.getInterval(), l.align)
+			}
 		}
 	}()
 	return ch
 }
 
+// Collect a Sample of each Source, up to parallel at once, and how long each took
+func (l *LiveLoader) collectSources(sources []SourceName) ([]*Sample, []time.Duration) {
+	samples := make([]*Sample, len(sources))
+	took := make([]time.Duration, len(sources))
+	slots := make(chan struct{}, l.parallel)
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			start := time.Now()
+			samples[i] = l.collectSource(l.queries[source], l.sourceTimeout(source))
+			took[i] = time.Since(start)
+		}()
+	}
+	wg.Wait()
+	return samples, took
+}
+
+// The limit for the queries of the Source
+func (l *LiveLoader) sourceTimeout(name SourceName) time.Duration {
+	if d, ok := l.sourceTimeouts[name]; ok {
+		return d
+	}
+	return l.queryTimeout
+}
+
+// Create a Sample for the given liveSource, its query limited to timeout
+func (l *LiveLoader) collectSource(source liveSource, timeout time.Duration) *Sample {
+	switch {
+	case source.parse != nil:
+		return l.getParsedSample(source.query, source.parse, timeout)
+	case source.columns:
+		return l.getColumnsSample(source.query, timeout)
+	case source.keyed:
+		return l.getKeyedSample(source.query, timeout)
+	}
+	return l.getSample(source.query, timeout)
+}
+
 // Create a Sample given a query
-func (l *LiveLoader) getSample(query string) *Sample {
+func (l *LiveLoader) getSample(query string, timeout time.Duration) *Sample {
 	sample := NewSample()
 
-	rows, err := l.db.Query(query)
+	ctx, cancel := timeoutContext(timeout)
+	defer cancel()
+
+	rows, err := l.db.QueryContext(ctx, query)
 	if err != nil {
 		sample.err = fmt.Errorf("cannot run query (%s): %s", query, err)
 		return sample
@@ -116,3 +909,147 @@ func (l *LiveLoader) getSample(query string) *Sample {
 	}
 	return sample
 }
+
+// Create a Sample from the first row of a query, keyed by column name.  No rows is an empty Sample.
+func (l *LiveLoader) getColumnsSample(query string, timeout time.Duration) *Sample {
+	sample := NewSample()
+
+	rows, err := l.getColumnRows(query, timeout)
+	if err != nil {
+		sample.err = err
+		return sample
+	}
+	if len(rows) > 0 {
+		sample.Data = rows[0]
+	}
+	return sample
+}
+
+// Create a Sample by parsing the `status` column of the first row of a query
+func (l *LiveLoader) getParsedSample(query string, parse func(string) map[string]string, timeout time.Duration) *Sample {
+	sample := l.getColumnsSample(query, timeout)
+	if sample.err == nil {
+		sample.Data = parse(sample.Data[`status`])
+	}
+	return sample
+}
+
+// Create a Sample from a row per object, keyed by `<name>.<column>`
+func (l *LiveLoader) getKeyedSample(query string, timeout time.Duration) *Sample {
+	sample := NewSample()
+
+	rows, err := l.getColumnRows(query, timeout)
+	if err != nil {
+		sample.err = err
+		return sample
+	}
+	for _, row := range rows {
+		name := row[`name`]
+		for column, value := range row {
+			if column != `name` {
+				sample.Data[name+`.`+column] = value
+			}
+		}
+	}
+	return sample
+}
+
+// The rows of a query, each keyed by lower case column name
+func (l *LiveLoader) getColumnRows(query string, timeout time.Duration) (rows []map[string]string, err error) {
+	ctx, cancel := timeoutContext(timeout)
+	defer cancel()
+
+	results, err := l.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("cannot run query (%s): %s", query, err)
+	}
+	defer results.Close()
+
+	columns, err := results.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing query results (%s): %s", query, err)
+	}
+
+	values := make([]sql.NullString, len(columns))
+	dests := make([]any, len(columns))
+	for i := range values {
+		dests[i] = &values[i]
+	}
+	for results.Next() {
+		if err := results.Scan(dests...); err != nil {
+			return nil, fmt.Errorf("Error parsing query results (%s): %s", query, err)
+		}
+		row := make(map[string]string)
+		for i, column := range columns {
+			// NULLs, like Seconds_Behind_Source when replication is stopped, are left out
+			if values[i].Valid {
+				row[strings.ToLower(column)] = values[i].String
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, results.Err()
+}
+
+// The age of the oldest metadata lock wait in seconds
+var oldestLockWaitKey = SourceKey{SourceName: `metadata_locks`, Key: `oldest_wait`}
+
+// Describe the metadata lock waits at least lockWaitDetail old, the oldest first
+func (l *LiveLoader) getLockWaits() (waits []string) {
+	ctx, cancel := l.queryContext()
+	defer cancel()
+
+	rows, err := l.db.QueryContext(ctx, METADATA_LOCK_WAITS_QUERY, int64(l.lockWaitDetail.Seconds()))
+	if err != nil {
+		return []string{fmt.Sprintf("cannot collect metadata lock waits: %v", err)}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var lw lockWait
+		if err := rows.Scan(&lw.seconds, &lw.objectType, &lw.schema, &lw.name, &lw.waiter, &lw.wants, &lw.blocker, &lw.holds); err != nil {
+			return append(waits, fmt.Sprintf("cannot parse metadata lock waits: %v", err))
+		}
+		waits = append(waits, lw.String())
+	}
+	return
+}
+
+// A thread waiting for a metadata lock, and a thread holding a conflicting one
+type lockWait struct {
+	seconds                  int64
+	objectType, schema, name sql.NullString
+	waiter, blocker          sql.NullString // processlist ids, NULL for background threads
+	wants, holds             string
+}
+
+func (lw lockWait) String() string {
+	object := lw.objectType.String
+	if lw.name.Valid {
+		object += " " + lw.name.String
+		if lw.schema.Valid {
+			object = fmt.Sprintf("%s %s.%s", lw.objectType.String, lw.schema.String, lw.name.String)
+		}
+	}
+	thread := func(id sql.NullString) string {
+		if !id.Valid {
+			return "background thread"
+		}
+		return "conn " + id.String
+	}
+	return fmt.Sprintf("mdl wait %ds on %s: %s wants %s, %s holds %s",
+		lw.seconds, object, thread(lw.waiter), lw.wants, thread(lw.blocker), lw.holds)
+}
+
+// A context for a single query limited by the queryTimeout
+func (l *LiveLoader) queryContext() (context.Context, context.CancelFunc) {
+	return timeoutContext(l.queryTimeout)
+}
+
+// A context limited to the timeout, none if it is 0
+func timeoutContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
@@ -1,6 +1,12 @@
 package loader
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"strings"
 	"testing"
 	"time"
 
@@ -110,7 +116,300 @@ func Benchmark(b *testing.B) {
 	l := NewGoodLiveLoader(b)
 
 	for i := 0; i < b.N; i++ {
-		l.getSample(STATUS_QUERY)
-		l.getSample(VARIABLES_QUERY)
+		l.getSample(STATUS_QUERY, l.queryTimeout)
+		l.getSample(VARIABLES_QUERY, l.queryTimeout)
+	}
+}
+
+// Privilege failures in the preflight are reported with the needed GRANT
+func TestNewPreflightError(t *testing.T) {
+	source := liveSources[`status`]
+
+	denied := &mysql.MySQLError{Number: 1142, Message: "SELECT command denied to user"}
+	err := newPreflightError(`status`, source, denied)
+
+	var perr *PrivilegeError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected a PrivilegeError: %v", err)
+	}
+	if perr.Source != `status` || perr.Grant != source.grant {
+		t.Errorf("unexpected PrivilegeError: %+v", perr)
+	}
+	if !errors.Is(err, denied) {
+		t.Error("PrivilegeError does not wrap the mysql error")
+	}
+
+	// Other errors are not privilege problems
+	err = newPreflightError(`status`, source, &mysql.MySQLError{Number: 1146, Message: "Table doesn't exist"})
+	if errors.As(err, &perr) {
+		t.Errorf("unexpected PrivilegeError: %v", err)
+	}
+}
+
+// Without performance_schema, Sources fall back to SHOW commands or fail clearly
+func TestResolveLiveSource(t *testing.T) {
+	source, err := resolveLiveSource(`status`, true, ServerVersion{}, MYSQL_FLAVOR)
+	if err != nil || source.query != STATUS_QUERY {
+		t.Errorf("unexpected status source with pfs: %+v, %v", source, err)
+	}
+
+	source, err = resolveLiveSource(`status`, false, ServerVersion{}, MYSQL_FLAVOR)
+	if err != nil || source.query != SHOW_STATUS_QUERY {
+		t.Errorf("unexpected status source without pfs: %+v, %v", source, err)
+	}
+
+	source, err = resolveLiveSource(`replica`, false, ServerVersion{8, 4, 0}, MYSQL_FLAVOR)
+	if err != nil || source.query != REPLICA_QUERY {
+		t.Errorf("unexpected replica source without pfs: %+v, %v", source, err)
+	}
+
+	// Older servers only have SHOW SLAVE STATUS
+	source, err = resolveLiveSource(`replica`, true, ServerVersion{5, 7, 44}, MYSQL_FLAVOR)
+	if err != nil || source.query != SLAVE_QUERY {
+		t.Errorf("unexpected replica source on 5.7: %+v, %v", source, err)
+	}
+
+	// InnoDB lock waits are in information_schema before 8.0 and need performance_schema after
+	source, err = resolveLiveSource(`innodb_lock_waits`, false, ServerVersion{5, 7, 44}, MYSQL_FLAVOR)
+	if err != nil || source.query != INNODB_LOCK_WAITS_57_QUERY {
+		t.Errorf("unexpected innodb_lock_waits source on 5.7: %+v, %v", source, err)
+	}
+	// MariaDB 10.x still has them in information_schema, its versions don't compare to MySQL's
+	source, err = resolveLiveSource(`innodb_lock_waits`, true, ServerVersion{10, 11, 6}, MARIADB_FLAVOR)
+	if err != nil || source.query != INNODB_LOCK_WAITS_57_QUERY {
+		t.Errorf("unexpected innodb_lock_waits source on MariaDB: %+v, %v", source, err)
+	}
+	var lwerr *PerformanceSchemaError
+	if _, err = resolveLiveSource(`innodb_lock_waits`, false, ServerVersion{8, 0, 36}, MYSQL_FLAVOR); !errors.As(err, &lwerr) {
+		t.Errorf("expected a PerformanceSchemaError for innodb_lock_waits: %v", err)
+	}
+
+	// Sources without a legacy query fail clearly on older servers and MariaDB
+	var verr *ServerVersionError
+	if _, err = resolveLiveSource(`applier_workers`, true, ServerVersion{5, 7, 44}, MYSQL_FLAVOR); !errors.As(err, &verr) || err.Error() != "source applier_workers needs MySQL 8.0.2+" {
+		t.Errorf("expected a ServerVersionError for applier_workers on 5.7: %v", err)
+	}
+	if _, err = resolveLiveSource(`applier_workers`, true, ServerVersion{10, 11, 6}, MARIADB_FLAVOR); !errors.As(err, &verr) {
+		t.Errorf("expected a ServerVersionError for applier_workers on MariaDB: %v", err)
+	}
+	if _, err = resolveLiveSource(`applier_workers`, true, ServerVersion{}, MYSQL_FLAVOR); err != nil {
+		t.Errorf("an unknown version should try the query: %v", err)
+	}
+
+	// The processlist summary reads information_schema instead
+	source, err = resolveLiveSource(`processlist`, false, ServerVersion{}, MYSQL_FLAVOR)
+	if err != nil || !source.columns || !strings.HasSuffix(source.query, PROCESSLIST_SCHEMA) {
+		t.Errorf("unexpected processlist source without pfs: %+v, %v", source, err)
+	}
+
+	liveSources[`pfs_only`] = liveSource{query: `SELECT 1, 1 FROM performance_schema.accounts`, pfs: true}
+	defer delete(liveSources, `pfs_only`)
+
+	var perr *PerformanceSchemaError
+	if _, err = resolveLiveSource(`pfs_only`, false, ServerVersion{}, MYSQL_FLAVOR); !errors.As(err, &perr) || perr.Source != `pfs_only` {
+		t.Errorf("expected a PerformanceSchemaError: %v", err)
+	}
+	if !SourceRequiresPFS(`pfs_only`) || SourceRequiresPFS(`status`) {
+		t.Error("unexpected SourceRequiresPFS")
+	}
+}
+
+// Sources only Pollers have don't need the server, Sources nothing has are an error rather than a session of blank rows
+func TestInitializeWithoutServerSources(t *testing.T) {
+	config := mysql.NewConfig()
+	config.Net = "tcp"
+	config.Addr = "127.0.0.1:1"
+
+	l := NewLiveLoader(config)
+	l.AddPoller(`os`, NewOSPoller())
+	if err := l.Initialize(time.Second, []SourceName{`os`}); err != nil || l.db != nil {
+		t.Errorf("unexpected connection for a Poller: %v", err)
+	}
+
+	err := NewLiveLoader(config).Initialize(time.Second, []SourceName{`primary.status`, `replica.replica`})
+	if err == nil || !strings.Contains(err.Error(), `primary.status, replica.replica`) {
+		t.Errorf("expected an error naming the sources: %v", err)
+	}
+}
+
+func TestLockWaitString(t *testing.T) {
+	valid := func(s string) sql.NullString {
+		return sql.NullString{String: s, Valid: true}
+	}
+
+	lw := lockWait{
+		seconds:    12,
+		objectType: valid(`TABLE`),
+		schema:     valid(`shop`),
+		name:       valid(`orders`),
+		waiter:     valid(`123`),
+		wants:      `EXCLUSIVE`,
+		blocker:    valid(`45`),
+		holds:      `SHARED_READ`,
+	}
+	expected := `mdl wait 12s on TABLE shop.orders: conn 123 wants EXCLUSIVE, conn 45 holds SHARED_READ`
+	if lw.String() != expected {
+		t.Errorf("unexpected lock wait: %s", lw)
+	}
+
+	// Global locks have no schema or name, background threads no processlist id
+	lw = lockWait{seconds: 3, objectType: valid(`GLOBAL`), waiter: valid(`7`), wants: `INTENTION_EXCLUSIVE`, holds: `SHARED`}
+	expected = `mdl wait 3s on GLOBAL: conn 7 wants INTENTION_EXCLUSIVE, background thread holds SHARED`
+	if lw.String() != expected {
+		t.Errorf("unexpected lock wait: %s", lw)
+	}
+}
+
+// - a lost connection is annotated once, and failed States are no baseline for later ones
+func TestLiveLoaderConnectionLost(t *testing.T) {
+	config := mysql.NewConfig()
+	config.Net = "tcp"
+	config.Addr = "127.0.0.1:1"
+	l := NewLiveLoader(config)
+	l.SetBackoff(Backoff{Initial: time.Millisecond, Max: time.Millisecond, Multiplier: 1})
+
+	// Opening doesn't connect yet
+	db, err := sql.Open("mysql", config.FormatDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	l.db, l.interval = db, 10*time.Millisecond
+	l.sources = []SourceName{`status`}
+	l.queries = map[SourceName]liveSource{`status`: liveSources[`status`]}
+
+	ch := l.GetStateChannel()
+	first, second := <-ch, <-ch
+	if annotations := first.GetAnnotations(); len(annotations) != 1 || annotations[0] != `connection lost, retrying` {
+		t.Errorf("unexpected annotations of the first failed state: %q", annotations)
+	}
+	if annotations := second.GetAnnotations(); len(annotations) != 0 {
+		t.Errorf("unexpected annotations of the second failed state: %q", annotations)
+	}
+	if second.GetPrevious() != nil {
+		t.Errorf("a failed state is the previous of the next")
+	}
+}
+
+// A server that answers pings but fails every query, e.g. timing out while busy
+type failingQueriesConn struct{}
+
+func (failingQueriesConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("timed out") }
+func (failingQueriesConn) Close() error                        { return nil }
+func (failingQueriesConn) Begin() (driver.Tx, error)           { return nil, errors.New("timed out") }
+func (failingQueriesConn) Ping(context.Context) error          { return nil }
+
+type failingQueriesConnector struct{}
+
+func (failingQueriesConnector) Connect(context.Context) (driver.Conn, error) {
+	return failingQueriesConn{}, nil
+}
+func (failingQueriesConnector) Driver() driver.Driver { return nil }
+
+// - failed queries on a connection that still answers are not a lost connection
+func TestLiveLoaderQueriesFailed(t *testing.T) {
+	l := NewLiveLoader(mysql.NewConfig())
+	l.SetBackoff(Backoff{Initial: time.Millisecond, Max: time.Millisecond, Multiplier: 1})
+	db := sql.OpenDB(failingQueriesConnector{})
+	defer db.Close()
+	l.db, l.interval = db, 10*time.Millisecond
+	l.sources = []SourceName{`status`}
+	l.queries = map[SourceName]liveSource{`status`: liveSources[`status`]}
+
+	state := <-l.GetStateChannel()
+	if !state.GetCurrent().CollectionFailed() || len(state.GetAnnotations()) != 0 {
+		t.Errorf("unexpected failed State: %+v", state)
+	}
+}
+
+// - a new interval applies from the next tick
+func TestLiveLoaderSetInterval(t *testing.T) {
+	config := mysql.NewConfig()
+	config.Net = "tcp"
+	config.Addr = "127.0.0.1:1"
+	l := NewLiveLoader(config)
+	l.SetBackoff(Backoff{Initial: time.Millisecond, Max: time.Millisecond, Multiplier: 1})
+
+	db, err := sql.Open("mysql", config.FormatDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	l.db, l.interval = db, time.Hour
+	l.sources = []SourceName{`status`}
+	l.queries = map[SourceName]liveSource{`status`: liveSources[`status`]}
+
+	ch := l.GetStateChannel()
+	<-ch
+	l.SetInterval(10 * time.Millisecond)
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Error("no state at the new interval")
+	}
+	if l.getInterval() != 10*time.Millisecond {
+		t.Errorf("unexpected interval: %v", l.getInterval())
+	}
+}
+
+func TestParseSourceTimeout(t *testing.T) {
+	source, timeout, err := ParseSourceTimeout(`innodb_trx=2s`)
+	if err != nil || source != `innodb_trx` || timeout != 2*time.Second {
+		t.Errorf("unexpected source timeout: %s %v %v", source, timeout, err)
+	}
+	for _, str := range []string{`innodb_trx`, `nosuch=2s`, `innodb_trx=2`, `innodb_trx=-1s`} {
+		if _, _, err := ParseSourceTimeout(str); err == nil {
+			t.Errorf("expected an error for %s", str)
+		}
+	}
+}
+
+// - Sources are collected at once, each within its own timeout, from a server that never answers
+func TestLiveLoaderCollectSourcesParallel(t *testing.T) {
+	listener, err := net.Listen(`tcp`, `127.0.0.1:0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	config := mysql.NewConfig()
+	config.Net = "tcp"
+	config.Addr = listener.Addr().String()
+	l := NewLiveLoader(config)
+	l.SetQueryTimeout(time.Hour)
+	l.SetSourceTimeout(`status`, 100*time.Millisecond)
+	l.SetSourceTimeout(`variables`, 100*time.Millisecond)
+	l.SetParallel(2)
+
+	db, err := sql.Open("mysql", config.FormatDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(l.parallel)
+	l.db = db
+	l.queries = map[SourceName]liveSource{`status`: liveSources[`status`], `variables`: liveSources[`variables`]}
+
+	start := time.Now()
+	samples, took := l.collectSources([]SourceName{`status`, `variables`})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("collecting took %v", elapsed)
+	}
+	for i, query := range []string{STATUS_QUERY, VARIABLES_QUERY} {
+		if err := samples[i].Error(); err == nil || !strings.Contains(err.Error(), query) {
+			t.Errorf("unexpected error of sample %d: %v", i, err)
+		}
+		if took[i] < 100*time.Millisecond {
+			t.Errorf("sample %d took %v", i, took[i])
+		}
 	}
 }
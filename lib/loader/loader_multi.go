@@ -0,0 +1,211 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// Collects from several Loaders (usually one per host) at once.  Each Loader has a role, and its Sources are renamed with the role as a prefix, e.g., `primary.status`.
+type MultiLoader struct {
+	roles   []string
+	loaders map[string]Loader
+}
+
+func NewMultiLoader() *MultiLoader {
+	return &MultiLoader{loaders: make(map[string]Loader)}
+}
+
+// Add a Loader for the given role.  The first role added drives the interval of the States.
+func (l *MultiLoader) AddLoader(role string, load Loader) {
+	l.roles = append(l.roles, role)
+	l.loaders[role] = load
+}
+
+// The Source name of a role's Source
+func roleSource(role string, source SourceName) SourceName {
+	return SourceName(role + "." + string(source))
+}
+
+// Initialize every Loader with the Sources requested for its role
+func (l *MultiLoader) Initialize(interval time.Duration, sources []SourceName) error {
+	var errs *multierror.Error
+	for _, role := range l.roles {
+		var roleSources []SourceName
+		for _, source := range sources {
+			if name, found := strings.CutPrefix(string(source), role+"."); found {
+				roleSources = append(roleSources, SourceName(name))
+			}
+		}
+
+		err := l.loaders[role].Initialize(interval, roleSources)
+		if err != nil {
+			errs = multierror.Append(errs, roleError(role, err))
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// Can the role's Loader collect the role prefixed Source?  Loaders that can't tell are assumed to.
+func (l *MultiLoader) CanCollect(name SourceName) bool {
+	for _, role := range l.roles {
+		if source, found := strings.CutPrefix(string(name), role+"."); found {
+			lister, ok := l.loaders[role].(SourceLister)
+			return !ok || lister.CanCollect(SourceName(source))
+		}
+	}
+	return false
+}
+
+// Change the interval of every Loader that can
+func (l *MultiLoader) SetInterval(interval time.Duration) {
+	for _, role := range l.roles {
+		if setter, ok := l.loaders[role].(IntervalSetter); ok {
+			setter.SetInterval(interval)
+		}
+	}
+}
+
+// Tag the error(s) of a role's Loader with the role
+func roleError(role string, err error) error {
+	errs := []error{err}
+	if merr, ok := err.(*multierror.Error); ok {
+		errs = merr.Errors
+	}
+
+	var result *multierror.Error
+	for _, err := range errs {
+		var perr *PrivilegeError
+		if errors.As(err, &perr) {
+			perr.Source = roleSource(role, perr.Source)
+			result = multierror.Append(result, perr)
+		} else {
+			result = multierror.Append(result, fmt.Errorf("%s: %w", role, err))
+		}
+	}
+	return result
+}
+
+// Merge the States of all the Loaders into one.  A new State is sent whenever the first role has one, with the latest State seen from every other role.
+func (l *MultiLoader) GetStateChannel() <-chan StateReader {
+	ch := make(chan StateReader)
+
+	var mutex sync.Mutex
+	latest := make(map[string]StateReader)
+
+	// Keep the latest State of every other role
+	for _, role := range l.roles[1:] {
+		go func(role string, states <-chan StateReader) {
+			for state := range states {
+				mutex.Lock()
+				latest[role] = state
+				mutex.Unlock()
+			}
+		}(role, l.loaders[role].GetStateChannel())
+	}
+
+	go func() {
+		var prev_ssp *SampleSet
+		merged := make(map[string]StateReader) // the last State merged for each role
+		for state := range l.loaders[l.roles[0]].GetStateChannel() {
+			mutex.Lock()
+			latest[l.roles[0]] = state
+			newState := l.mergeStates(latest, merged)
+			mutex.Unlock()
+
+			// Rates across a role's restart would be garbage
+			if !newState.Restarted {
+				newState.SetPrevious(prev_ssp)
+			}
+			ch <- newState
+			prev_ssp = newState.Current
+		}
+		close(ch)
+	}()
+
+	return ch
+}
+
+// Merge the latest State of every role into a new State, which takes its sequence and uptime from the first role's.  A restart or missed intervals of any role's new State carry over, and each role's own State is kept for RoleState.
+func (l *MultiLoader) mergeStates(latest, merged map[string]StateReader) *State {
+	state := latest[l.roles[0]]
+	newState := NewState()
+	newState.Live = state.(*State).Live
+	newState.Seq = state.GetSeq()
+	newState.GetCurrentWriter().SetUptime(state.GetCurrent().GetUptime())
+	newState.roles = make(map[string]mergedRole)
+
+	for _, role := range l.roles {
+		if roleState, ok := latest[role]; ok {
+			// Only annotate with a role's State the first time we merge it
+			isNew := merged[role] != roleState
+			mergeRoleState(newState, role, roleState, isNew)
+			if isNew {
+				newState.Missed = max(newState.Missed, roleState.GetMissed())
+				newState.Restarted = newState.Restarted || roleState.HasRestarted()
+			}
+			newState.roles[role] = mergedRole{roleState, isNew}
+			merged[role] = roleState
+		}
+	}
+	return newState
+}
+
+// Copy the Samples and annotations of a role's State into the merged State
+func mergeRoleState(merged *State, role string, state StateReader, annotate bool) {
+	for name, sample := range state.(*State).Current.Samples {
+		merged.GetCurrentWriter().SetSample(roleSource(role, name), sample)
+	}
+	if !annotate {
+		return
+	}
+	for _, annotation := range state.GetAnnotations() {
+		merged.AddAnnotation(fmt.Sprintf("%s: %s", role, annotation))
+	}
+}
+
+// The given Sources of a role, as named in the merged States
+func RoleSources(role string, sources []SourceName) (named []SourceName) {
+	for _, source := range sources {
+		named = append(named, roleSource(role, source))
+	}
+	return
+}
+
+// The role of a State from RoleState, empty for any other State
+func StateRole(sr StateReader) string {
+	if state, ok := sr.(*State); ok {
+		return state.role
+	}
+	return ``
+}
+
+// Did the role have a new State since the last merge?  The first role always does.
+func RoleUpdated(sr StateReader, role string) bool {
+	return sr.(*State).roles[role].updated
+}
+
+// The State of one role in a merged State: the role's own State, with its own Previous, timing and restarts, and the Sources named as in its Loader.  Annotations stay with the merged State.  A role without a State yet has no Samples.
+func RoleState(sr StateReader, role string) StateReader {
+	merged := sr.(*State)
+	mr, ok := merged.roles[role]
+	if !ok {
+		state := NewState()
+		state.Live = merged.Live
+		state.Seq = merged.Seq
+		state.Current.Timestamp = merged.Current.Timestamp
+		state.role = role
+		return state
+	}
+	state := *mr.state.(*State)
+	state.Annotations = nil
+	state.role = role
+	if merged.Repeated {
+		state.Repeated = true
+	}
+	return &state
+}
@@ -0,0 +1,165 @@
+package loader
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// A Loader that records the Sources it was initialized with
+type recordingLoader struct {
+	sources []SourceName
+	err     error
+}
+
+func (l *recordingLoader) Initialize(interval time.Duration, sources []SourceName) error {
+	l.sources = sources
+	return l.err
+}
+
+func (l *recordingLoader) GetStateChannel() <-chan StateReader {
+	ch := make(chan StateReader)
+	close(ch)
+	return ch
+}
+
+func TestMultiLoaderInitialize(t *testing.T) {
+	primary := &recordingLoader{}
+	replica := &recordingLoader{err: &PrivilegeError{Source: `replica`, Grant: `REPLICATION CLIENT ON *.*`, Err: errors.New("denied")}}
+
+	ml := NewMultiLoader()
+	ml.AddLoader(`primary`, primary)
+	ml.AddLoader(`replica`, replica)
+
+	err := ml.Initialize(time.Second, []SourceName{`primary.status`, `replica.status`, `replica.replica`, `aws.rds`})
+	if !reflect.DeepEqual(primary.sources, []SourceName{`status`}) {
+		t.Errorf("unexpected primary sources: %v", primary.sources)
+	}
+	if !reflect.DeepEqual(replica.sources, []SourceName{`status`, `replica`}) {
+		t.Errorf("unexpected replica sources: %v", replica.sources)
+	}
+
+	var perr *PrivilegeError
+	if !errors.As(err, &perr) || perr.Source != `replica.replica` {
+		t.Errorf("expected a PrivilegeError for replica.replica: %v", err)
+	}
+}
+
+func TestMultiLoaderStates(t *testing.T) {
+	ml := NewMultiLoader()
+	ml.AddLoader(`primary`, NewGoodFileLoader(t, "./testdata/mysql.two", "", "1s"))
+	ml.AddLoader(`replica`, &recordingLoader{})
+
+	count := 0
+	for state := range ml.GetStateChannel() {
+		count++
+		if !state.GetCurrent().HasSource(`primary.status`) {
+			t.Error("missing primary.status")
+		}
+		if state.GetCurrent().HasSource(`status`) {
+			t.Error("unprefixed status source")
+		}
+		if count == 2 && state.SecondsDiff() != 1 {
+			t.Errorf("unexpected SecondsDiff: %f", state.SecondsDiff())
+		}
+	}
+	if count != 2 {
+		t.Errorf("unexpected state count: %d", count)
+	}
+}
+
+func TestRoleState(t *testing.T) {
+	if sources := RoleSources(`db1`, []SourceName{`status`, `variables`}); !reflect.DeepEqual(sources, []SourceName{`db1.status`, `db1.variables`}) {
+		t.Errorf("unexpected sources: %v", sources)
+	}
+
+	roleState := func(seq uint64, queries string, prev *State) *State {
+		state := NewState()
+		state.Live = true
+		state.Seq = seq
+		sample := NewSample()
+		sample.Data[`queries`] = queries
+		state.GetCurrentWriter().SetSample(`status`, sample)
+		if prev != nil {
+			state.SetPrevious(prev.Current)
+		}
+		return state
+	}
+
+	ml := NewMultiLoader()
+	ml.AddLoader(`db1`, &recordingLoader{})
+	ml.AddLoader(`db2`, &recordingLoader{})
+	merged := make(map[string]StateReader)
+
+	db1 := roleState(1, `10`, nil)
+	db2 := roleState(1, `100`, nil)
+	ml.mergeStates(map[string]StateReader{`db1`: db1}, merged)
+	db1 = roleState(2, `20`, db1)
+	db2.AddAnnotation(`server restarted`)
+	db2.Restarted = true
+	state := ml.mergeStates(map[string]StateReader{`db1`: db1, `db2`: db2}, merged)
+	if !state.HasRestarted() || len(state.GetAnnotations()) != 1 {
+		t.Errorf("expected db2's restart in the merged State: %+v", state)
+	}
+
+	// Each role keeps its own State and Previous
+	one := RoleState(state, `db1`)
+	if one.GetSeq() != 2 || len(one.GetAnnotations()) != 0 || one.HasRestarted() || StateRole(one) != `db1` || StateRole(state) != `` {
+		t.Errorf("unexpected State: %+v", one)
+	}
+	if one.GetCurrent().GetF(SourceKey{SourceName: `status`, Key: `queries`}) != 20 || one.GetPrevious().GetF(SourceKey{SourceName: `status`, Key: `queries`}) != 10 {
+		t.Errorf("unexpected db1 status: %+v %+v", one.GetCurrent(), one.GetPrevious())
+	}
+	if two := RoleState(state, `db2`); !two.HasRestarted() || two.GetCurrent().HasSource(`db1.status`) {
+		t.Errorf("unexpected db2 State: %+v", two)
+	}
+
+	// A role without a new State is its last one, not the same Sample as Current and Previous
+	db1 = roleState(3, `30`, db1)
+	state = ml.mergeStates(map[string]StateReader{`db1`: db1, `db2`: db2}, merged)
+	if state.HasRestarted() {
+		t.Error("db2's restart was already merged")
+	}
+	if two := RoleState(state, `db2`); two.GetPrevious() != nil || two.GetCurrent().GetF(SourceKey{SourceName: `status`, Key: `queries`}) != 100 {
+		t.Errorf("unexpected db2 State: %+v", two)
+	}
+
+	// Nothing from a role yet
+	if three := RoleState(state, `db3`); three.GetCurrent().HasSource(`status`) || three.GetSeq() != 3 {
+		t.Errorf("unexpected db3 State: %+v", three)
+	}
+}
+
+func TestMultiLoaderCanCollect(t *testing.T) {
+	ml := NewMultiLoader()
+	ml.AddLoader(`primary`, NewLiveLoader(mysql.NewConfig()))
+	ml.AddLoader(`replica`, &recordingLoader{})
+
+	var _ SourceLister = ml
+	for source, expected := range map[SourceName]bool{
+		`primary.status`:   true,
+		`primary.stauts`:   false,
+		`replica.anything`: true, // its Loader can't tell
+		`status`:           false,
+	} {
+		if ml.CanCollect(source) != expected {
+			t.Errorf("%s: expected %v", source, expected)
+		}
+	}
+}
+
+func TestMultiLoaderSetInterval(t *testing.T) {
+	live := NewLiveLoader(mysql.NewConfig())
+	ml := NewMultiLoader()
+	ml.AddLoader(`primary`, live)
+	ml.AddLoader(`replica`, &recordingLoader{})
+
+	var _ IntervalSetter = ml
+	ml.SetInterval(5 * time.Second)
+	if live.getInterval() != 5*time.Second {
+		t.Errorf("unexpected interval: %v", live.getInterval())
+	}
+}
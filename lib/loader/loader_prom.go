@@ -0,0 +1,329 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Prometheus refuses a range query of more than 11000 points per series, ask for fewer at a time
+const PROM_STEPS_PER_QUERY = 1000
+
+// The timeout of each request to the Prometheus server
+const PROM_REQUEST_TIMEOUT = 60 * time.Second
+
+// The Sources mysqld_exporter's default collectors export, by metric name prefix
+var promSourcePrefixes = []struct {
+	source SourceName
+	prefix string
+}{
+	{`status`, `mysql_global_status_`},
+	{`variables`, `mysql_global_variables_`},
+	{`replica`, `mysql_slave_status_`},
+}
+
+// Status variables mysqld_exporter turns into a labeled metric: the status variable is the prefix followed by the label's value
+var promLabeledStatus = map[string]struct{ label, prefix string }{
+	`commands_total`:                 {`command`, `com_`},
+	`handlers_total`:                 {`handler`, `handler_`},
+	`connection_errors_total`:        {`error`, `connection_errors_`},
+	`buffer_pool_pages`:              {`state`, `innodb_buffer_pool_pages_`},
+	`buffer_pool_page_changes_total`: {`operation`, `innodb_buffer_pool_pages_`},
+	`innodb_row_ops_total`:           {`operation`, `innodb_rows_`},
+	`performance_schema_lost_total`:  {`instrumentation`, `performance_schema_`},
+}
+
+// The Source and key of the status variable, variable or replica status column mysqld_exporter exported as this series, or false if it isn't one
+func PromMetricKey(labels map[string]string) (SourceName, string, bool) {
+	for _, sp := range promSourcePrefixes {
+		name, found := strings.CutPrefix(labels[`__name__`], sp.prefix)
+		if !found || name == `` {
+			continue
+		}
+		if sp.source != `status` {
+			return sp.source, name, true
+		}
+
+		if name == `buffer_pool_dirty_pages` {
+			return sp.source, `innodb_buffer_pool_pages_dirty`, true
+		}
+		if labeled, ok := promLabeledStatus[name]; ok {
+			value, ok := labels[labeled.label]
+			return sp.source, labeled.prefix + strings.ToLower(value), ok && value != ``
+		}
+		return sp.source, name, true
+	}
+	return ``, ``, false
+}
+
+// Parse a time like `2024-03-01T14:00:00Z`, `2024-03-01 14:00[:05]` (local time) or a duration ago like `2h`
+func ParsePromTime(str string, now time.Time) (time.Time, error) {
+	if ago, err := time.ParseDuration(strings.TrimPrefix(str, `-`)); err == nil {
+		return now.Add(-ago), nil
+	}
+	if t, err := time.Parse(time.RFC3339, str); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{`2006-01-02 15:04:05`, `2006-01-02 15:04`, `2006-01-02`} {
+		if t, err := time.ParseInLocation(layout, str, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %s, expected RFC3339, 2006-01-02 15:04:05 or a duration ago like 2h", str)
+}
+
+// The API's response to a query
+type promResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		Result []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][2]any          `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// Replays the metrics mysqld_exporter scraped into Prometheus between two times, mapped back to the status, variables and replica Sources.  Reads from the Prometheus HTTP API (query_range), a State for every interval with data.
+type PromLoader struct {
+	url      string
+	instance string
+	from, to time.Time
+
+	interval time.Duration
+	sources  []SourceName
+	client   *http.Client
+
+	// Set if a query failed while replaying
+	err error
+}
+
+func NewPromLoader(promURL, instance string, from, to time.Time) *PromLoader {
+	return &PromLoader{
+		url:      strings.TrimSuffix(promURL, `/`),
+		instance: instance,
+		from:     from,
+		to:       to,
+		client:   &http.Client{Timeout: PROM_REQUEST_TIMEOUT},
+	}
+}
+
+// Check the range, and that the Prometheus server has the instance in it.  Without an instance, there must be only one.
+func (l *PromLoader) Initialize(interval time.Duration, sources []SourceName) error {
+	if interval.Seconds() < 1 {
+		return fmt.Errorf("interval cannot be less than 1s (%s)", interval.String())
+	}
+	if !l.from.Before(l.to) {
+		return fmt.Errorf("-from (%s) must be before -to (%s)", l.from.Format(time.RFC3339), l.to.Format(time.RFC3339))
+	}
+	l.interval = interval
+	l.sources = sources
+
+	// The instances that had an uptime in the range
+	query := fmt.Sprintf(`count by (instance) (count_over_time(mysql_global_status_uptime%s[%ds]))`,
+		l.instanceMatcher(), int64(math.Ceil(l.to.Sub(l.from).Seconds())))
+	resp, err := l.query(`query`, url.Values{`query`: {query}, `time`: {promTimestamp(l.to)}})
+	if err != nil {
+		return err
+	}
+	var instances []string
+	for _, result := range resp.Data.Result {
+		instances = append(instances, result.Metric[`instance`])
+	}
+	slices.Sort(instances)
+
+	switch {
+	case len(instances) == 0 && l.instance != ``:
+		return fmt.Errorf("%s has no mysqld_exporter metrics of instance %s between %s and %s", l.url, l.instance, l.from.Format(time.RFC3339), l.to.Format(time.RFC3339))
+	case len(instances) == 0:
+		return fmt.Errorf("%s has no mysqld_exporter metrics between %s and %s", l.url, l.from.Format(time.RFC3339), l.to.Format(time.RFC3339))
+	case len(instances) > 1:
+		return fmt.Errorf("%s has mysqld_exporter metrics of several instances, choose one with -prom-instance: %s", l.url, strings.Join(instances, `, `))
+	}
+	l.instance = instances[0]
+	return nil
+}
+
+// The instance replayed
+func (l *PromLoader) Instance() string {
+	return l.instance
+}
+
+// The error that stopped the replay, if any
+func (l *PromLoader) Err() error {
+	return l.err
+}
+
+// The label matcher of the instance, if there is one
+func (l *PromLoader) instanceMatcher() string {
+	if l.instance == `` {
+		return ``
+	}
+	return fmt.Sprintf(`{instance=%s}`, strconv.Quote(l.instance))
+}
+
+// A Prometheus timestamp in seconds
+func promTimestamp(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixMilli())/1000, 'f', -1, 64)
+}
+
+// Call one of the query endpoints of the API
+func (l *PromLoader) query(endpoint string, params url.Values) (*promResponse, error) {
+	resp, err := l.client.PostForm(l.url+`/api/v1/`+endpoint, params)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var pr promResponse
+	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
+		return nil, fmt.Errorf("%s: unexpected response (%s): %v", l.url, resp.Status, err)
+	}
+	if pr.Status != `success` {
+		return nil, fmt.Errorf("%s: %s", l.url, pr.Error)
+	}
+	return &pr, nil
+}
+
+// The Samples of every step in the range with data, by time
+func (l *PromLoader) queryRange(start, end time.Time) (map[time.Time]map[SourceName]*Sample, error) {
+	var names []string
+	for _, sp := range promSourcePrefixes {
+		// Status has the uptime, we need it even if the view doesn't
+		if sp.source == `status` || slices.Contains(l.sources, sp.source) {
+			names = append(names, sp.prefix+`.+`)
+		}
+	}
+	matchers := fmt.Sprintf(`__name__=~"%s"`, strings.Join(names, `|`))
+	if l.instance != `` {
+		matchers += fmt.Sprintf(`,instance=%s`, strconv.Quote(l.instance))
+	}
+
+	resp, err := l.query(`query_range`, url.Values{
+		`query`: {`{` + matchers + `}`},
+		`start`: {promTimestamp(start)},
+		`end`:   {promTimestamp(end)},
+		`step`:  {strconv.FormatFloat(l.interval.Seconds(), 'f', -1, 64)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	steps := make(map[time.Time]map[SourceName]*Sample)
+	for _, result := range resp.Data.Result {
+		source, key, ok := PromMetricKey(result.Metric)
+		if !ok {
+			continue
+		}
+		for _, value := range result.Values {
+			seconds, ok := value[0].(float64)
+			str, _ := value[1].(string)
+			if !ok || str == `NaN` {
+				continue
+			}
+			ts := time.UnixMilli(int64(math.Round(seconds * 1000)))
+			if steps[ts] == nil {
+				steps[ts] = make(map[SourceName]*Sample)
+			}
+			sample := steps[ts][source]
+			if sample == nil {
+				sample = NewSample()
+				sample.Timestamp = ts
+				steps[ts][source] = sample
+			}
+			sample.Data[key] = str
+		}
+	}
+	return steps, nil
+}
+
+// mysqld_exporter doesn't export the buffer pool size in pages, add them up
+func addPromPagesTotal(sample *Sample) {
+	if _, ok := sample.Data[`innodb_buffer_pool_pages_total`]; ok {
+		return
+	}
+	var total int64
+	for _, state := range []string{`data`, `free`, `misc`} {
+		pages, err := strconv.ParseFloat(sample.Data[`innodb_buffer_pool_pages_`+state], 64)
+		if err != nil {
+			return
+		}
+		total += int64(pages)
+	}
+	sample.Data[`innodb_buffer_pool_pages_total`] = strconv.FormatInt(total, 10)
+}
+
+// A State for every step with status, timed like a live collection by the time of the step
+func (l *PromLoader) GetStateChannel() <-chan StateReader {
+	ch := make(chan StateReader)
+
+	go func() {
+		defer close(ch)
+
+		var prev_ssp *SampleSet
+		var lastUptime int64
+		var seq uint64
+		chunk := time.Duration(PROM_STEPS_PER_QUERY-1) * l.interval
+		for start := l.from; !start.After(l.to); start = start.Add(chunk + l.interval) {
+			end := start.Add(chunk)
+			if end.After(l.to) {
+				end = l.to
+			}
+			steps, err := l.queryRange(start, end)
+			if err != nil {
+				l.err = err
+				return
+			}
+
+			var times []time.Time
+			for ts := range steps {
+				times = append(times, ts)
+			}
+			slices.SortFunc(times, func(a, b time.Time) int { return a.Compare(b) })
+
+			for _, ts := range times {
+				status, ok := steps[ts][`status`]
+				if !ok {
+					continue
+				}
+				addPromPagesTotal(status)
+
+				seq++
+				state := NewState()
+				state.Live = true
+				state.Seq = seq
+				state.Current.Timestamp = ts
+				for source, sample := range steps[ts] {
+					sample.applyAliases(source)
+					state.GetCurrentWriter().SetSample(source, sample)
+				}
+				state.SetPrevious(prev_ssp)
+
+				uptime, _ := strconv.ParseInt(status.Data[`uptime`], 10, 64)
+				if prev_ssp != nil && uptime < lastUptime {
+					// Rates across the restart would be garbage
+					state.AddAnnotation(fmt.Sprintf("server restarted (%s)", l.instance))
+					state.SetPrevious(nil)
+					state.Restarted = true
+				} else if prev_ssp != nil {
+					// Steps Prometheus has no scrape for
+					if gap := ts.Sub(prev_ssp.Timestamp); gap >= 2*l.interval {
+						state.Missed = uint64(gap/l.interval - 1)
+					}
+				}
+				lastUptime = uptime
+
+				ch <- state
+				prev_ssp = state.Current
+			}
+		}
+	}()
+
+	return ch
+}
@@ -0,0 +1,152 @@
+package loader
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPromMetricKey(t *testing.T) {
+	tests := []struct {
+		labels map[string]string
+		source SourceName
+		key    string
+		ok     bool
+	}{
+		{map[string]string{`__name__`: `mysql_global_status_uptime`}, `status`, `uptime`, true},
+		{map[string]string{`__name__`: `mysql_global_status_commands_total`, `command`: `select`}, `status`, `com_select`, true},
+		{map[string]string{`__name__`: `mysql_global_status_handlers_total`, `handler`: `read_rnd_next`}, `status`, `handler_read_rnd_next`, true},
+		{map[string]string{`__name__`: `mysql_global_status_buffer_pool_pages`, `state`: `free`}, `status`, `innodb_buffer_pool_pages_free`, true},
+		{map[string]string{`__name__`: `mysql_global_status_buffer_pool_dirty_pages`}, `status`, `innodb_buffer_pool_pages_dirty`, true},
+		{map[string]string{`__name__`: `mysql_global_status_buffer_pool_page_changes_total`, `operation`: `flushed`}, `status`, `innodb_buffer_pool_pages_flushed`, true},
+		{map[string]string{`__name__`: `mysql_global_status_innodb_row_ops_total`, `operation`: `inserted`}, `status`, `innodb_rows_inserted`, true},
+		{map[string]string{`__name__`: `mysql_global_variables_max_connections`}, `variables`, `max_connections`, true},
+		{map[string]string{`__name__`: `mysql_slave_status_seconds_behind_master`}, `replica`, `seconds_behind_master`, true},
+		{map[string]string{`__name__`: `mysql_global_status_commands_total`}, `status`, `com_`, false},
+		{map[string]string{`__name__`: `mysql_up`}, ``, ``, false},
+	}
+	for _, test := range tests {
+		source, key, ok := PromMetricKey(test.labels)
+		if source != test.source || key != test.key || ok != test.ok {
+			t.Errorf("%v: unexpected %s/%s %v", test.labels, source, key, ok)
+		}
+	}
+}
+
+func TestParsePromTime(t *testing.T) {
+	now := time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		`2h`:                   now.Add(-2 * time.Hour),
+		`-30m`:                 now.Add(-30 * time.Minute),
+		`2024-03-01T14:00:00Z`: time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC),
+		`2024-03-01 14:00:05`:  time.Date(2024, 3, 1, 14, 0, 5, 0, time.Local),
+		`2024-03-01 14:00`:     time.Date(2024, 3, 1, 14, 0, 0, 0, time.Local),
+	}
+	for str, expected := range tests {
+		if parsed, err := ParsePromTime(str, now); err != nil || !parsed.Equal(expected) {
+			t.Errorf("%s: unexpected %s: %v", str, parsed, err)
+		}
+	}
+	if _, err := ParsePromTime(`yesterday`, now); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+// A Prometheus server with the metrics of one instance at 100, 101 and 103 (the scrape at 102 is missing)
+func newTestPromServer(t *testing.T, instances ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case `/api/v1/query`:
+			var results []string
+			for _, instance := range instances {
+				results = append(results, fmt.Sprintf(`{"metric":{"instance":%q},"value":[110,"4"]}`, instance))
+			}
+			fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[%s]}}`, strings.Join(results, `,`))
+		case `/api/v1/query_range`:
+			if !strings.Contains(r.FormValue(`query`), `instance="db1:9104"`) || r.FormValue(`step`) != `1` {
+				t.Errorf("unexpected query: %s step %s", r.FormValue(`query`), r.FormValue(`step`))
+			}
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"matrix","result":[
+				{"metric":{"__name__":"mysql_global_status_uptime","instance":"db1:9104"},"values":[[100,"5000"],[101,"5001"],[103,"5003"]]},
+				{"metric":{"__name__":"mysql_global_status_commands_total","command":"select","instance":"db1:9104"},"values":[[100,"10"],[101,"25"],[103,"55"]]},
+				{"metric":{"__name__":"mysql_global_status_buffer_pool_pages","state":"data","instance":"db1:9104"},"values":[[100,"80"],[101,"80"],[103,"90"]]},
+				{"metric":{"__name__":"mysql_global_status_buffer_pool_pages","state":"free","instance":"db1:9104"},"values":[[100,"20"],[101,"20"],[103,"10"]]},
+				{"metric":{"__name__":"mysql_global_status_buffer_pool_pages","state":"misc","instance":"db1:9104"},"values":[[100,"0"],[101,"0"],[103,"0"]]},
+				{"metric":{"__name__":"mysql_global_variables_max_connections","instance":"db1:9104"},"values":[[100,"151"],[101,"151"],[103,"151"]]},
+				{"metric":{"__name__":"mysql_slave_status_seconds_behind_master","instance":"db1:9104"},"values":[[100,"0"],[101,"3"],[103,"NaN"]]}
+			]}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestPromLoader(t *testing.T) {
+	server := newTestPromServer(t, `db1:9104`)
+	defer server.Close()
+
+	l := NewPromLoader(server.URL+`/`, ``, time.Unix(100, 0), time.Unix(103, 0))
+	if err := l.Initialize(time.Second, []SourceName{`status`, `variables`, `replica`}); err != nil {
+		t.Fatal(err)
+	}
+	if l.Instance() != `db1:9104` {
+		t.Errorf("unexpected instance: %s", l.Instance())
+	}
+
+	var states []StateReader
+	for state := range l.GetStateChannel() {
+		states = append(states, state)
+	}
+	if l.Err() != nil {
+		t.Fatal(l.Err())
+	}
+	if len(states) != 3 {
+		t.Fatalf("unexpected number of states: %d", len(states))
+	}
+
+	second := states[1]
+	if !second.IsLive() || second.SecondsDiff() != 1 || !second.GetCurrent().GetTimeGenerated().Equal(time.Unix(101, 0)) {
+		t.Errorf("unexpected timing: %v %f %s", second.IsLive(), second.SecondsDiff(), second.GetCurrent().GetTimeGenerated())
+	}
+	if sel, _ := second.GetCurrent().GetInt(SourceKey{`status`, `com_select`}); sel != 25 {
+		t.Errorf("unexpected com_select: %d", sel)
+	}
+	if total, _ := second.GetCurrent().GetInt(SourceKey{`status`, `innodb_buffer_pool_pages_total`}); total != 100 {
+		t.Errorf("unexpected pages total: %d", total)
+	}
+	if conns, _ := second.GetCurrent().GetInt(SourceKey{`variables`, `max_connections`}); conns != 151 {
+		t.Errorf("unexpected max_connections: %d", conns)
+	}
+	if lag, _ := second.GetCurrent().GetInt(SourceKey{`replica`, `seconds_behind_source`}); lag != 3 {
+		t.Errorf("unexpected seconds_behind_source: %d", lag)
+	}
+
+	if states[2].GetMissed() != 1 || states[2].SecondsDiff() != 2 {
+		t.Errorf("unexpected gap: %d %f", states[2].GetMissed(), states[2].SecondsDiff())
+	}
+}
+
+func TestPromLoaderInstances(t *testing.T) {
+	server := newTestPromServer(t, `db1:9104`, `db2:9104`)
+	defer server.Close()
+
+	l := NewPromLoader(server.URL, ``, time.Unix(100, 0), time.Unix(103, 0))
+	if err := l.Initialize(time.Second, []SourceName{`status`}); err == nil || !strings.Contains(err.Error(), `db1:9104, db2:9104`) {
+		t.Errorf("expected an error listing the instances: %v", err)
+	}
+
+	empty := newTestPromServer(t)
+	defer empty.Close()
+	l = NewPromLoader(empty.URL, `db3:9104`, time.Unix(100, 0), time.Unix(103, 0))
+	if err := l.Initialize(time.Second, []SourceName{`status`}); err == nil || !strings.Contains(err.Error(), `db3:9104`) {
+		t.Errorf("expected an error naming the instance: %v", err)
+	}
+
+	l = NewPromLoader(empty.URL, ``, time.Unix(103, 0), time.Unix(100, 0))
+	if err := l.Initialize(time.Second, []SourceName{`status`}); err == nil {
+		t.Error("expected an error for the range")
+	}
+}
@@ -0,0 +1,145 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+// The Sources an SSHLoader can collect, in the order the remote client outputs them
+var sshQueries = []struct {
+	source SourceName
+	query  string
+}{
+	{`status`, `SHOW GLOBAL STATUS`},
+	{`variables`, `SHOW GLOBAL VARIABLES`},
+}
+
+// Collects status and variables by running the mysql client on a remote host over ssh every interval, for when the MySQL port is firewalled but ssh is allowed.  The remote client connects with its own configuration (e.g., ~/.my.cnf on that host), and ssh must not prompt for a password.
+type SSHLoader struct {
+	host     string
+	interval time.Duration
+	sources  []SourceName
+
+	// The ssh client to run, and the mysql client on the remote host
+	sshCommand   string
+	mysqlCommand string
+
+	// Collect on the wall clock multiples of the interval
+	align bool
+}
+
+func NewSSHLoader(host string) *SSHLoader {
+	return &SSHLoader{host: host, sshCommand: `ssh`, mysqlCommand: `mysql`}
+}
+
+// Collect on the wall clock multiples of the interval (e.g., every minute at :00), the first collection waits for the first one
+func (l *SSHLoader) SetAlign(align bool) {
+	l.align = align
+}
+
+// Keep the Sources we can collect, others will be missing from the States
+func (l *SSHLoader) Initialize(interval time.Duration, sources []SourceName) error {
+	l.interval = interval
+	for _, sq := range sshQueries {
+		if slices.Contains(sources, sq.source) {
+			l.sources = append(l.sources, sq.source)
+		}
+	}
+	if len(l.sources) == 0 {
+		return fmt.Errorf("ssh %s: none of the sources can be collected over ssh", l.host)
+	}
+
+	// Fail early if we can't collect at all
+	for _, sample := range l.collect() {
+		if sample.Error() != nil {
+			return sample.Error()
+		}
+	}
+	return nil
+}
+
+// The command the remote host runs, each query's results end with F_END_STRING like the samples in our capture files
+func (l *SSHLoader) remoteCommand() string {
+	var queries []string
+	for _, sq := range sshQueries {
+		if slices.Contains(l.sources, sq.source) {
+			queries = append(queries, sq.query, fmt.Sprintf(`SELECT '%s'`, F_END_STRING))
+		}
+	}
+	return fmt.Sprintf(`%s -BNe "%s"`, l.mysqlCommand, strings.Join(queries, `; `))
+}
+
+// Run the remote client once and parse a Sample for each Source
+func (l *SSHLoader) collect() map[SourceName]*Sample {
+	samples := make(map[SourceName]*Sample)
+	failed := func(err error) map[SourceName]*Sample {
+		for _, source := range l.sources {
+			samples[source] = NewSampleErr(err)
+		}
+		return samples
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), l.interval)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, l.sshCommand, `-o`, `BatchMode=yes`, l.host, l.remoteCommand())
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return failed(fmt.Errorf("ssh %s: %v: %s", l.host, err, strings.TrimSpace(stderr.String())))
+	}
+
+	// No interval skipping within a single run
+	parser := newStreamParser(`ssh `+l.host, bytes.NewReader(output))
+	if err := parser.Initialize(time.Second); err != nil {
+		return failed(err)
+	}
+	for _, source := range l.sources {
+		sample := parser.GetNextSample()
+		if sample == nil {
+			sample = NewSampleErr(fmt.Errorf("ssh %s: no %s in the output", l.host, source))
+		}
+		samples[source] = sample
+	}
+	return samples
+}
+
+// Collect every interval, a collection still running when the next is due delays it
+func (l *SSHLoader) GetStateChannel() <-chan StateReader {
+	ch := make(chan StateReader)
+
+	go func() {
+		var prev_ssp *SampleSet
+		var seq uint64
+		ticks, stop := newTicks(l.interval, l.align)
+		defer stop()
+		if l.align {
+			<-ticks
+		}
+		for {
+			seq++
+			state := NewState()
+			state.Live = true
+			state.Seq = seq
+			for source, sample := range l.collect() {
+				sample.applyAliases(source)
+				state.GetCurrentWriter().SetSample(source, sample)
+			}
+			if state.setPreviousOrRestart(prev_ssp) {
+				state.AddAnnotation(fmt.Sprintf("server restarted (%s)", l.host))
+			}
+
+			ch <- state
+			prev_ssp = state.Current
+			<-ticks
+		}
+	}()
+
+	return ch
+}
@@ -0,0 +1,85 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// An SSHLoader whose ssh is a script that prints the given output
+func getTestSSHLoader(t *testing.T, script string) *SSHLoader {
+	path := filepath.Join(t.TempDir(), `ssh`)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	l := NewSSHLoader(`db1`)
+	l.sshCommand = path
+	return l
+}
+
+func TestSSHLoaderImplementsLoader(t *testing.T) {
+	var _ Loader = NewSSHLoader(`db1`)
+}
+
+func TestSSHLoaderRemoteCommand(t *testing.T) {
+	l := NewSSHLoader(`db1`)
+	l.sources = []SourceName{`status`, `variables`}
+	expected := `mysql -BNe "SHOW GLOBAL STATUS; SELECT 'MYQTOOLSEND'; SHOW GLOBAL VARIABLES; SELECT 'MYQTOOLSEND'"`
+	if cmd := l.remoteCommand(); cmd != expected {
+		t.Errorf("unexpected command: %s", cmd)
+	}
+}
+
+func TestSSHLoaderStates(t *testing.T) {
+	l := getTestSSHLoader(t, `printf 'Uptime\t100\nQueries\t1000\nMYQTOOLSEND\nmax_connections\t151\nMYQTOOLSEND\n'`)
+	if err := l.Initialize(time.Second, []SourceName{`status`, `variables`, `replica`}); err != nil {
+		t.Fatal(err)
+	}
+
+	state := <-l.GetStateChannel()
+	if state.GetCurrent().GetF(SourceKey{SourceName: `status`, Key: `queries`}) != 1000 {
+		t.Errorf("unexpected status: %+v", state.GetCurrent())
+	}
+	if state.GetCurrent().GetF(SourceKey{SourceName: `variables`, Key: `max_connections`}) != 151 {
+		t.Errorf("unexpected variables: %+v", state.GetCurrent())
+	}
+	if state.GetCurrent().HasSource(`replica`) {
+		t.Error("replica can't be collected over ssh")
+	}
+}
+
+func TestSSHLoaderFailure(t *testing.T) {
+	l := getTestSSHLoader(t, `echo "Permission denied (publickey)." >&2; exit 255`)
+	err := l.Initialize(time.Second, []SourceName{`status`})
+	if err == nil || !strings.Contains(err.Error(), `Permission denied`) {
+		t.Errorf("expected the ssh error: %v", err)
+	}
+
+	if err := NewSSHLoader(`db1`).Initialize(time.Second, []SourceName{`replica`}); err == nil {
+		t.Error("expected an error without sources to collect")
+	}
+}
+
+// The uptime of each collection is lower than the last, as if the server kept restarting
+func TestSSHLoaderRestart(t *testing.T) {
+	calls := filepath.Join(t.TempDir(), `calls`)
+	l := getTestSSHLoader(t, `n=$(($(cat `+calls+` 2>/dev/null || echo 0) + 1)); echo $n > `+calls+`
+printf "Uptime\t$((1000 - n))\nMYQTOOLSEND\n"`)
+	if err := l.Initialize(time.Second, []SourceName{`status`}); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := l.GetStateChannel()
+	if first := <-ch; first.HasRestarted() {
+		t.Error("the first state has nothing to compare to")
+	}
+	second := <-ch
+	if !second.HasRestarted() || second.GetPrevious() != nil {
+		t.Error("expected a restart without a previous")
+	}
+	if annotations := second.GetAnnotations(); len(annotations) != 1 || annotations[0] != "server restarted (db1)" {
+		t.Errorf("unexpected annotations: %q", annotations)
+	}
+}
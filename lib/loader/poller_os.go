@@ -0,0 +1,307 @@
+package loader
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Where cgroups and procfs are mounted
+	CGROUP_ROOT string = `/sys/fs/cgroup`
+	PROC_ROOT   string = `/proc`
+
+	// Clock ticks per second in /proc/stat, this is USER_HZ on every Linux we run on
+	PROC_STAT_HZ float64 = 100
+
+	// cgroup v1 has no limit as a huge number (a page-aligned int64 max)
+	CGROUP_V1_UNLIMITED float64 = 1 << 62
+)
+
+// Reads the OS metrics of the container (cgroup v2 or v1) we run in, or of the host from /proc when we aren't in one.  The limits are what the container may use: the cgroup CPU quota and memory limit, or the host's CPUs and memory.  Keys in the Sample:
+//   - cgroup: v2, v1 or none
+//   - cpu_usage_usec, cpu_capacity_usec: counters since the first Sample of CPU used and CPU the limit allowed (limit * wall time), their rates make the use of the limit
+//   - cpu_limit: CPUs
+//   - cpu_periods, cpu_throttled_periods: counters of CFS periods and those throttled by the quota (cgroups only)
+//   - memory_usage_bytes, memory_limit_bytes
+//   - io_read_bytes, io_write_bytes, io_reads, io_writes: counters (cgroups only)
+type OSPoller struct {
+	cgroupRoot, procRoot string
+
+	// The CPU counters count from the first Sample
+	started    bool
+	start      time.Time
+	startUsage float64
+}
+
+func NewOSPoller() *OSPoller {
+	return &OSPoller{cgroupRoot: CGROUP_ROOT, procRoot: PROC_ROOT}
+}
+
+// Nothing runs in the background, the files are read for every State
+func (p *OSPoller) Start() {}
+
+func (p *OSPoller) GetLatest() SampleReader {
+	return p.getSample(time.Now())
+}
+
+func (p *OSPoller) getSample(now time.Time) *Sample {
+	sample := NewSample()
+	sample.Timestamp = now
+
+	var err error
+	switch {
+	case p.exists(`memory.current`):
+		// Our own cgroup v2 (not the host's root cgroup, which has no memory.current) is mounted in a container
+		sample.Data[`cgroup`] = `v2`
+		err = p.readCgroupV2(sample.Data)
+	case p.exists(`memory`, `memory.usage_in_bytes`):
+		sample.Data[`cgroup`] = `v1`
+		err = p.readCgroupV1(sample.Data)
+	default:
+		sample.Data[`cgroup`] = `none`
+		err = p.readProc(sample.Data)
+	}
+	if err != nil {
+		return NewSampleErr(err)
+	}
+
+	// The limits default to the host's
+	if _, ok := sample.Data[`cpu_limit`]; !ok {
+		sample.Data[`cpu_limit`] = strconv.Itoa(runtime.NumCPU())
+	}
+	if _, ok := sample.Data[`memory_limit_bytes`]; !ok {
+		if total, err := p.memTotal(); err == nil {
+			sample.Data[`memory_limit_bytes`] = formatFloat(total)
+		}
+	}
+
+	usage, err := strconv.ParseFloat(sample.Data[`cpu_usage_usec`], 64)
+	if err != nil {
+		return NewSampleErr(errors.New("cannot read the CPU usage"))
+	}
+	if !p.started {
+		p.started, p.start, p.startUsage = true, now, usage
+	}
+	sample.Data[`cpu_usage_usec`] = formatFloat(usage - p.startUsage)
+	if limit, err := strconv.ParseFloat(sample.Data[`cpu_limit`], 64); err == nil {
+		sample.Data[`cpu_capacity_usec`] = formatFloat(limit * float64(now.Sub(p.start).Microseconds()))
+	}
+	return sample
+}
+
+func (p *OSPoller) readCgroupV2(data map[string]string) error {
+	stat, err := p.readKeyValues(p.cgroupPath(`cpu.stat`))
+	if err != nil {
+		return err
+	}
+	data[`cpu_usage_usec`] = stat[`usage_usec`]
+	if _, ok := stat[`nr_periods`]; ok {
+		data[`cpu_periods`] = stat[`nr_periods`]
+		data[`cpu_throttled_periods`] = stat[`nr_throttled`]
+	}
+
+	// `max 100000` or `<quota> <period>`
+	if fields := strings.Fields(p.readString(`cpu.max`)); len(fields) == 2 && fields[0] != `max` {
+		quota, qerr := strconv.ParseFloat(fields[0], 64)
+		period, perr := strconv.ParseFloat(fields[1], 64)
+		if qerr == nil && perr == nil && period > 0 {
+			data[`cpu_limit`] = formatFloat(quota / period)
+		}
+	}
+
+	data[`memory_usage_bytes`] = p.readString(`memory.current`)
+	if limit := p.readString(`memory.max`); limit != `max` && limit != `` {
+		data[`memory_limit_bytes`] = limit
+	}
+
+	// `8:0 rbytes=1 wbytes=2 rios=3 wios=4 ...` per device
+	if lines, err := p.readLines(p.cgroupPath(`io.stat`)); err == nil {
+		totals := make(map[string]float64)
+		for _, line := range lines {
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+			for _, field := range fields[1:] {
+				name, value, _ := strings.Cut(field, `=`)
+				if f, err := strconv.ParseFloat(value, 64); err == nil {
+					totals[name] += f
+				}
+			}
+		}
+		for key, name := range map[string]string{`io_read_bytes`: `rbytes`, `io_write_bytes`: `wbytes`, `io_reads`: `rios`, `io_writes`: `wios`} {
+			data[key] = formatFloat(totals[name])
+		}
+	}
+	return nil
+}
+
+func (p *OSPoller) readCgroupV1(data map[string]string) error {
+	usage, err := strconv.ParseFloat(p.readString(`cpuacct`, `cpuacct.usage`), 64)
+	if err != nil {
+		return errors.New("cannot read cpuacct.usage of cgroup v1")
+	}
+	data[`cpu_usage_usec`] = formatFloat(usage / 1000)
+	if stat, err := p.readKeyValues(p.cgroupPath(`cpu`, `cpu.stat`)); err == nil {
+		data[`cpu_periods`] = stat[`nr_periods`]
+		data[`cpu_throttled_periods`] = stat[`nr_throttled`]
+	}
+
+	quota, qerr := strconv.ParseFloat(p.readString(`cpu`, `cpu.cfs_quota_us`), 64)
+	period, perr := strconv.ParseFloat(p.readString(`cpu`, `cpu.cfs_period_us`), 64)
+	if qerr == nil && perr == nil && quota > 0 && period > 0 {
+		data[`cpu_limit`] = formatFloat(quota / period)
+	}
+
+	data[`memory_usage_bytes`] = p.readString(`memory`, `memory.usage_in_bytes`)
+	if limit, err := strconv.ParseFloat(p.readString(`memory`, `memory.limit_in_bytes`), 64); err == nil && limit < CGROUP_V1_UNLIMITED {
+		data[`memory_limit_bytes`] = formatFloat(limit)
+	}
+
+	// `8:0 Read 123` per device and operation, then a `Total`
+	for file, keys := range map[string][2]string{
+		`blkio.throttle.io_service_bytes`: {`io_read_bytes`, `io_write_bytes`},
+		`blkio.throttle.io_serviced`:      {`io_reads`, `io_writes`},
+	} {
+		lines, err := p.readLines(p.cgroupPath(`blkio`, file))
+		if err != nil {
+			continue
+		}
+		var read, write float64
+		for _, line := range lines {
+			fields := strings.Fields(line)
+			if len(fields) != 3 {
+				continue
+			}
+			value, _ := strconv.ParseFloat(fields[2], 64)
+			switch fields[1] {
+			case `Read`:
+				read += value
+			case `Write`:
+				write += value
+			}
+		}
+		data[keys[0]], data[keys[1]] = formatFloat(read), formatFloat(write)
+	}
+	return nil
+}
+
+// Host-wide CPU from /proc/stat and memory from /proc/meminfo
+func (p *OSPoller) readProc(data map[string]string) error {
+	lines, err := p.readLines(filepath.Join(p.procRoot, `stat`))
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		// cpu user nice system idle iowait irq softirq steal ...
+		fields := strings.Fields(line)
+		if len(fields) < 9 || fields[0] != `cpu` {
+			continue
+		}
+		var ticks float64
+		for _, i := range []int{1, 2, 3, 6, 7, 8} {
+			n, _ := strconv.ParseFloat(fields[i], 64)
+			ticks += n
+		}
+		data[`cpu_usage_usec`] = formatFloat(ticks / PROC_STAT_HZ * 1e6)
+	}
+
+	meminfo, err := p.readMeminfo()
+	if err != nil {
+		return err
+	}
+	data[`memory_usage_bytes`] = formatFloat(meminfo[`MemTotal`] - meminfo[`MemAvailable`])
+	data[`memory_limit_bytes`] = formatFloat(meminfo[`MemTotal`])
+	return nil
+}
+
+// The host's memory
+func (p *OSPoller) memTotal() (float64, error) {
+	meminfo, err := p.readMeminfo()
+	if err != nil {
+		return 0, err
+	}
+	return meminfo[`MemTotal`], nil
+}
+
+// /proc/meminfo values in bytes, by name
+func (p *OSPoller) readMeminfo() (map[string]float64, error) {
+	lines, err := p.readLines(filepath.Join(p.procRoot, `meminfo`))
+	if err != nil {
+		return nil, err
+	}
+	meminfo := make(map[string]float64)
+	for _, line := range lines {
+		// MemTotal:       16307764 kB
+		name, rest, found := strings.Cut(line, `:`)
+		fields := strings.Fields(rest)
+		if !found || len(fields) == 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 && fields[1] == `kB` {
+			value *= 1024
+		}
+		meminfo[name] = value
+	}
+	return meminfo, nil
+}
+
+func (p *OSPoller) cgroupPath(parts ...string) string {
+	return filepath.Join(append([]string{p.cgroupRoot}, parts...)...)
+}
+
+func (p *OSPoller) exists(parts ...string) bool {
+	_, err := os.Stat(p.cgroupPath(parts...))
+	return err == nil
+}
+
+// The trimmed contents of a cgroup file, empty if it can't be read
+func (p *OSPoller) readString(parts ...string) string {
+	content, err := os.ReadFile(p.cgroupPath(parts...))
+	if err != nil {
+		return ``
+	}
+	return strings.TrimSpace(string(content))
+}
+
+func (p *OSPoller) readLines(path string) (lines []string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// Files of `name value` lines, like cpu.stat
+func (p *OSPoller) readKeyValues(path string) (map[string]string, error) {
+	lines, err := p.readLines(path)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	for _, line := range lines {
+		if name, value, found := strings.Cut(line, ` `); found {
+			values[name] = strings.TrimSpace(value)
+		}
+	}
+	return values, nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
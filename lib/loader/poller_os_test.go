@@ -0,0 +1,136 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Write the files, relative to the dir
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func getTestOSPoller(t *testing.T, cgroup map[string]string) *OSPoller {
+	p := &OSPoller{cgroupRoot: t.TempDir(), procRoot: t.TempDir(), started: true, start: time.Unix(1000, 0)}
+	writeTestFiles(t, p.cgroupRoot, cgroup)
+	writeTestFiles(t, p.procRoot, map[string]string{
+		`meminfo`: "MemTotal:       16000 kB\nMemFree:         2000 kB\nMemAvailable:    4000 kB\n",
+		`stat`:    "cpu  100 10 50 1000 20 5 5 0 0 0\ncpu0 100 10 50 1000 20 5 5 0 0 0\n",
+	})
+	return p
+}
+
+func checkOSSample(t *testing.T, sample *Sample, expected map[string]string) {
+	if sample.Error() != nil {
+		t.Fatal(sample.Error())
+	}
+	for key, value := range expected {
+		if sample.Data[key] != value {
+			t.Errorf("%s: unexpected %s: %q", sample.Data[`cgroup`], key, sample.Data[key])
+		}
+	}
+}
+
+func TestOSPollerCgroupV2(t *testing.T) {
+	p := getTestOSPoller(t, map[string]string{
+		`cpu.stat`:       "usage_usec 5000000\nuser_usec 4000000\nsystem_usec 1000000\nnr_periods 100\nnr_throttled 7\nthrottled_usec 1234\n",
+		`cpu.max`:        "150000 100000\n",
+		`memory.current`: "1048576\n",
+		`memory.max`:     "4194304\n",
+		`io.stat`:        "8:0 rbytes=100 wbytes=200 rios=1 wios=2 dbytes=0 dios=0\n\n8:16 rbytes=1000 wbytes=2000 rios=10 wios=20 dbytes=0 dios=0\n",
+	})
+	checkOSSample(t, p.getSample(time.Unix(1010, 0)), map[string]string{
+		`cgroup`:                `v2`,
+		`cpu_usage_usec`:        `5000000`,
+		`cpu_limit`:             `1.5`,
+		`cpu_capacity_usec`:     `15000000`,
+		`cpu_periods`:           `100`,
+		`cpu_throttled_periods`: `7`,
+		`memory_usage_bytes`:    `1048576`,
+		`memory_limit_bytes`:    `4194304`,
+		`io_read_bytes`:         `1100`,
+		`io_write_bytes`:        `2200`,
+		`io_reads`:              `11`,
+		`io_writes`:             `22`,
+	})
+
+	// Without limits, the host's
+	writeTestFiles(t, p.cgroupRoot, map[string]string{`cpu.max`: "max 100000\n", `memory.max`: "max\n"})
+	checkOSSample(t, p.getSample(time.Unix(1010, 0)), map[string]string{
+		`cpu_limit`:          strconv.Itoa(runtime.NumCPU()),
+		`memory_limit_bytes`: `16384000`,
+	})
+}
+
+func TestOSPollerCgroupV1(t *testing.T) {
+	p := getTestOSPoller(t, map[string]string{
+		`cpuacct/cpuacct.usage`:                 "5000000000\n",
+		`cpu/cpu.stat`:                          "nr_periods 100\nnr_throttled 7\nthrottled_time 1234\n",
+		`cpu/cpu.cfs_quota_us`:                  "200000\n",
+		`cpu/cpu.cfs_period_us`:                 "100000\n",
+		`memory/memory.usage_in_bytes`:          "1048576\n",
+		`memory/memory.limit_in_bytes`:          "9223372036854771712\n",
+		`blkio/blkio.throttle.io_service_bytes`: "8:0 Read 100\n8:0 Write 200\n8:0 Total 300\nTotal 300\n",
+		`blkio/blkio.throttle.io_serviced`:      "8:0 Read 1\n8:0 Write 2\n8:0 Total 3\nTotal 3\n",
+	})
+	checkOSSample(t, p.getSample(time.Unix(1010, 0)), map[string]string{
+		`cgroup`:                `v1`,
+		`cpu_usage_usec`:        `5000000`,
+		`cpu_limit`:             `2`,
+		`cpu_capacity_usec`:     `20000000`,
+		`cpu_throttled_periods`: `7`,
+		`memory_usage_bytes`:    `1048576`,
+		`memory_limit_bytes`:    `16384000`,
+		`io_read_bytes`:         `100`,
+		`io_write_bytes`:        `200`,
+		`io_reads`:              `1`,
+		`io_writes`:             `2`,
+	})
+}
+
+func TestOSPollerProc(t *testing.T) {
+	p := getTestOSPoller(t, nil)
+	checkOSSample(t, p.getSample(time.Unix(1010, 0)), map[string]string{
+		`cgroup`:             `none`,
+		`cpu_usage_usec`:     `1700000`,
+		`memory_usage_bytes`: `12288000`,
+		`memory_limit_bytes`: `16384000`,
+	})
+
+	// Nothing to read
+	p.procRoot = t.TempDir()
+	if sample := p.getSample(time.Unix(1010, 0)); sample.Error() == nil {
+		t.Error("expected an error without /proc")
+	}
+}
+
+// The CPU counters start at 0
+func TestOSPollerFirstSample(t *testing.T) {
+	p := getTestOSPoller(t, nil)
+	p.started = false
+	checkOSSample(t, p.getSample(time.Unix(1010, 0)), map[string]string{
+		`cpu_usage_usec`:    `0`,
+		`cpu_capacity_usec`: `0`,
+	})
+	writeTestFiles(t, p.procRoot, map[string]string{`stat`: "cpu  200 10 50 1000 20 5 5 0 0 0\n"})
+	checkOSSample(t, p.getSample(time.Unix(1011, 0)), map[string]string{
+		`cpu_usage_usec`:    `1000000`,
+		`cpu_capacity_usec`: strconv.Itoa(runtime.NumCPU() * 1000000),
+	})
+}
+
+func TestOSPollerImplementsPoller(t *testing.T) {
+	var _ Poller = NewOSPoller()
+}
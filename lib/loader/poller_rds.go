@@ -0,0 +1,185 @@
+package loader
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jayjanssen/myq-tools/lib/aws"
+)
+
+const (
+	// CloudWatch publishes RDS metrics at 60s resolution
+	RDS_PERIOD time.Duration = 60 * time.Second
+
+	// How far back to look for datapoints, CloudWatch usually lags a few minutes
+	RDS_LOOKBACK time.Duration = 5 * time.Minute
+
+	// Default limit of GetMetricData calls per hour, i.e., one per RDS_PERIOD
+	DEFAULT_RDS_BUDGET int = 60
+)
+
+// The RDS CloudWatch metrics we collect, keys in the Sample are lowercase
+var rdsMetrics = []string{
+	`BurstBalance`,
+	`CPUUtilization`,
+	`FreeableMemory`,
+	`ReadIOPS`,
+	`WriteIOPS`,
+	`ReadThroughput`,
+	`WriteThroughput`,
+}
+
+// Polls CloudWatch for RDS metrics of a single instance (or Aurora cluster)
+type RDSPoller struct {
+	cw       *aws.CloudWatch
+	endpoint aws.RDSEndpoint
+
+	// Maximum GetMetricData calls per hour, and the times of the calls made in the last hour
+	budget int
+	calls  []time.Time
+
+	// The most recent datapoint of each metric, by query Id
+	cache map[string]aws.Datapoint
+
+	mu     sync.Mutex
+	latest *Sample
+}
+
+func NewRDSPoller(cw *aws.CloudWatch, endpoint aws.RDSEndpoint) *RDSPoller {
+	return &RDSPoller{
+		cw:       cw,
+		endpoint: endpoint,
+		budget:   DEFAULT_RDS_BUDGET,
+		cache:    make(map[string]aws.Datapoint),
+	}
+}
+
+// Set the maximum GetMetricData calls per hour, every call fetches all rdsMetrics
+func (p *RDSPoller) SetBudget(callsPerHour int) {
+	p.budget = callsPerHour
+}
+
+// How often to poll so we stay within the budget, never more often than RDS_PERIOD
+func (p *RDSPoller) pollInterval() time.Duration {
+	interval := RDS_PERIOD
+	if p.budget > 0 && time.Hour/time.Duration(p.budget) > interval {
+		interval = time.Hour / time.Duration(p.budget)
+	}
+	return interval
+}
+
+// Poll right away and then every pollInterval
+func (p *RDSPoller) Start() {
+	go func() {
+		p.poll(time.Now())
+		for now := range time.Tick(p.pollInterval()) {
+			p.poll(now)
+		}
+	}()
+}
+
+// The latest Sample, its Timestamp is that of the oldest datapoint in it
+func (p *RDSPoller) GetLatest() SampleReader {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.latest == nil {
+		return nil
+	}
+	return p.latest
+}
+
+// Call CloudWatch unless the cache is fresh or the budget is spent
+func (p *RDSPoller) poll(now time.Time) {
+	if p.cacheIsFresh(now) || !p.spendBudget(now) {
+		return
+	}
+	sample := p.getSample(now)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Keep serving the last good sample on a failure, it will show as stale
+	if sample.Error() != nil && p.latest != nil && p.latest.Error() == nil {
+		return
+	}
+	p.latest = sample
+}
+
+// Record a call if there is budget left for it in the last hour
+func (p *RDSPoller) spendBudget(now time.Time) bool {
+	// Forget calls older than an hour
+	for len(p.calls) > 0 && now.Sub(p.calls[0]) >= time.Hour {
+		p.calls = p.calls[1:]
+	}
+	if p.budget > 0 && len(p.calls) >= p.budget {
+		return false
+	}
+	p.calls = append(p.calls, now)
+	return true
+}
+
+// No newer datapoints can be published yet if every metric has one from the last RDS_PERIOD
+func (p *RDSPoller) cacheIsFresh(now time.Time) bool {
+	for _, metric := range rdsMetrics {
+		dp, ok := p.cache[strings.ToLower(metric)]
+		if !ok || now.Sub(dp.Timestamp) >= 2*RDS_PERIOD {
+			return false
+		}
+	}
+	return true
+}
+
+// Fetch all rdsMetrics in a single GetMetricData call, only asking for datapoints newer than the cached ones
+func (p *RDSPoller) getSample(now time.Time) *Sample {
+	var queries []aws.MetricQuery
+	for _, metric := range rdsMetrics {
+		queries = append(queries, aws.MetricQuery{
+			Id:         strings.ToLower(metric),
+			Namespace:  `AWS/RDS`,
+			MetricName: metric,
+			Dimensions: p.endpoint.Dimensions(),
+			Period:     int(RDS_PERIOD.Seconds()),
+			Stat:       `Average`,
+		})
+	}
+
+	start := now.Add(-RDS_LOOKBACK)
+	if len(p.cache) == len(rdsMetrics) {
+		start = now
+		for _, dp := range p.cache {
+			if dp.Timestamp.Before(start) {
+				start = dp.Timestamp
+			}
+		}
+		start = start.Add(time.Second)
+	}
+	if start.Before(now.Add(-RDS_LOOKBACK)) {
+		start = now.Add(-RDS_LOOKBACK)
+	}
+
+	datapoints, err := p.cw.GetMetricData(queries, start, now)
+	if err != nil {
+		return NewSampleErr(err)
+	}
+	for id, dp := range datapoints {
+		if dp.Timestamp.After(p.cache[id].Timestamp) {
+			p.cache[id] = dp
+		}
+	}
+	if len(p.cache) == 0 {
+		return NewSampleErr(errors.New("no CloudWatch datapoints for " + p.endpoint.Identifier))
+	}
+
+	sample := NewSample()
+	sample.Timestamp = now
+	for id, dp := range p.cache {
+		sample.Data[id] = strconv.FormatFloat(dp.Value, 'f', -1, 64)
+		if dp.Timestamp.Before(sample.Timestamp) {
+			sample.Timestamp = dp.Timestamp
+		}
+	}
+	return sample
+}
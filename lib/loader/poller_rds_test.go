@@ -0,0 +1,123 @@
+package loader
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jayjanssen/myq-tools/lib/aws"
+)
+
+func getTestRDSPoller(t *testing.T, status int, response string) *RDSPoller {
+	p, _ := getCountingRDSPoller(t, status, response)
+	return p
+}
+
+// Also return a pointer to the number of requests the server got
+func getCountingRDSPoller(t *testing.T, status int, response string) (*RDSPoller, *int) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(status)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+
+	cw := aws.NewCloudWatch(`us-east-1`, aws.Credentials{})
+	cw.Endpoint = server.URL
+	return NewRDSPoller(cw, aws.RDSEndpoint{Identifier: `mydb`, Region: `us-east-1`}), &requests
+}
+
+func TestRDSPollerImplementsPoller(t *testing.T) {
+	var _ Poller = NewRDSPoller(nil, aws.RDSEndpoint{})
+}
+
+func TestRDSPollerSample(t *testing.T) {
+	p := getTestRDSPoller(t, http.StatusOK, `<GetMetricDataResponse><GetMetricDataResult><MetricDataResults>
+<member><Id>burstbalance</Id><Timestamps><member>2023-01-01T00:02:00Z</member></Timestamps><Values><member>98.5</member></Values></member>
+<member><Id>readiops</Id><Timestamps><member>2023-01-01T00:01:00Z</member></Timestamps><Values><member>120</member></Values></member>
+</MetricDataResults></GetMetricDataResult></GetMetricDataResponse>`)
+
+	if p.GetLatest() != nil {
+		t.Fatal("sample before polling")
+	}
+	p.poll(time.Now())
+
+	sample := p.GetLatest()
+	if sample == nil || sample.Error() != nil {
+		t.Fatalf("unexpected sample: %v", sample)
+	}
+	if val, _ := sample.GetString(`burstbalance`); val != `98.5` {
+		t.Errorf("unexpected burstbalance: %s", val)
+	}
+	if val, _ := sample.GetString(`readiops`); val != `120` {
+		t.Errorf("unexpected readiops: %s", val)
+	}
+
+	// The sample is as old as its oldest datapoint
+	if !sample.GetTimeGenerated().Equal(time.Date(2023, 1, 1, 0, 1, 0, 0, time.UTC)) {
+		t.Errorf("unexpected timestamp: %v", sample.GetTimeGenerated())
+	}
+}
+
+func TestRDSPollerError(t *testing.T) {
+	p := getTestRDSPoller(t, http.StatusForbidden, ``)
+	p.poll(time.Now())
+
+	sample := p.GetLatest()
+	if sample == nil || sample.Error() == nil {
+		t.Errorf("expected an error sample: %v", sample)
+	}
+}
+
+func TestRDSPollerBudget(t *testing.T) {
+	p, requests := getCountingRDSPoller(t, http.StatusForbidden, ``)
+	p.SetBudget(2)
+
+	if p.pollInterval() != 30*time.Minute {
+		t.Errorf("unexpected poll interval: %v", p.pollInterval())
+	}
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		p.poll(now.Add(time.Duration(i) * time.Minute))
+	}
+	if *requests != 2 {
+		t.Errorf("unexpected requests within budget: %d", *requests)
+	}
+
+	// The budget frees up an hour after the first call
+	p.poll(now.Add(time.Hour))
+	if *requests != 3 {
+		t.Errorf("unexpected requests after an hour: %d", *requests)
+	}
+}
+
+func TestRDSPollerCache(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Minute)
+	response := `<GetMetricDataResponse><GetMetricDataResult><MetricDataResults>`
+	for _, metric := range rdsMetrics {
+		response += `<member><Id>` + strings.ToLower(metric) + `</Id><Timestamps><member>` +
+			now.Add(-time.Minute).Format(time.RFC3339) + `</member></Timestamps><Values><member>1</member></Values></member>`
+	}
+	response += `</MetricDataResults></GetMetricDataResult></GetMetricDataResponse>`
+
+	p, requests := getCountingRDSPoller(t, http.StatusOK, response)
+	p.poll(now)
+	p.poll(now.Add(30 * time.Second))
+	if *requests != 1 {
+		t.Errorf("unexpected requests with a fresh cache: %d", *requests)
+	}
+
+	// The cached datapoints are kept when CloudWatch has nothing newer
+	p.poll(now.Add(2 * time.Minute))
+	if *requests != 2 {
+		t.Errorf("unexpected requests with a stale cache: %d", *requests)
+	}
+	if val, _ := p.GetLatest().GetString(`readiops`); val != `1` {
+		t.Errorf("unexpected readiops: %s", val)
+	}
+}
@@ -2,6 +2,7 @@ package loader
 
 import (
 	"fmt"
+	"maps"
 	"regexp"
 	"strconv"
 	"time"
@@ -59,6 +60,47 @@ func (ssp *SampleSet) GetErrors() error {
 	return errs.ErrorOrNil()
 }
 
+// Was the Sample of the given Source collected without an error?
+func (ssp *SampleSet) SourceCollected(sn SourceName) bool {
+	sample, ok := ssp.Samples[sn]
+	return ok && sample != nil && sample.Error() == nil
+}
+
+// Did every Sample fail to collect?
+func (ssp *SampleSet) CollectionFailed() bool {
+	collected := 0
+	for _, sample := range ssp.Samples {
+		if sample == nil || sample.Error() != nil {
+			continue
+		}
+		collected += 1
+	}
+	return len(ssp.Samples) > 0 && collected == 0
+}
+
+// A copy of this Set with the same Samples, generated the given time and uptime later
+func (ssp *SampleSet) shifted(d time.Duration, uptime int64) *SampleSet {
+	shifted := *ssp
+	shifted.Timestamp = ssp.Timestamp.Add(d)
+	shifted.Uptime = ssp.Uptime + uptime
+	return &shifted
+}
+
+// A copy of this Set with the given Samples of the Sources it doesn't have, e.g. the last ones collected of Sources skipped in its interval
+func (ssp *SampleSet) withMissing(samples map[SourceName]SampleReader) *SampleSet {
+	if ssp == nil {
+		return nil
+	}
+	filled := *ssp
+	filled.Samples = maps.Clone(ssp.Samples)
+	for source, sample := range samples {
+		if _, ok := filled.Samples[source]; !ok {
+			filled.Samples[source] = sample
+		}
+	}
+	return &filled
+}
+
 // Get time data this Set was generated
 func (ssp *SampleSet) GetTimeGenerated() time.Time {
 	return ssp.Timestamp
@@ -67,6 +109,15 @@ func (ssp *SampleSet) GetUptime() int64 {
 	return ssp.Uptime
 }
 
+// Get the time the Sample for the given Source was generated
+func (ssp *SampleSet) GetSourceTime(sn SourceName) (time.Time, error) {
+	sp, ok := ssp.Samples[sn]
+	if !ok || sp == nil {
+		return time.Time{}, fmt.Errorf("source (%s) not found", sn)
+	}
+	return sp.GetTimeGenerated(), nil
+}
+
 // Fetch the string value of the the given SourceKey
 func (ssp *SampleSet) GetString(sk SourceKey) (string, error) {
 	sp, ok := ssp.Samples[sk.SourceName]
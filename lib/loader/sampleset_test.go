@@ -1,6 +1,7 @@
 package loader
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -110,6 +111,23 @@ func TestSampleGetErrors(t *testing.T) {
 	}
 }
 
+func TestSampleSetCollectionFailed(t *testing.T) {
+	ssp := NewSampleSet()
+	if ssp.CollectionFailed() {
+		t.Error("an empty set didn't fail")
+	}
+
+	ssp.SetSample(`status`, NewSampleErr(errors.New("gone")))
+	if !ssp.CollectionFailed() {
+		t.Error("expected failure")
+	}
+
+	ssp.SetSample(`variables`, NewSample())
+	if ssp.CollectionFailed() {
+		t.Error("a partial failure isn't a failed collection")
+	}
+}
+
 // GetStr
 func TestGetStr(t *testing.T) {
 	ssp := newTestSampleSet()
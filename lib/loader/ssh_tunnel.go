@@ -0,0 +1,131 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Forwards connections to a local port through an ssh jump host to a MySQL server it can reach, for when only the jump host is reachable.  Each connection runs `ssh -W`, so ssh must not prompt for a password.
+type SSHTunnel struct {
+	bastion  string
+	addr     string
+	listener net.Listener
+
+	// The ssh client to run
+	sshCommand string
+
+	mu       sync.Mutex
+	commands []*exec.Cmd
+	closed   bool
+}
+
+// Listen on a local port forwarding to addr (host:port) through the bastion (e.g. user@bastion)
+func NewSSHTunnel(bastion, addr string) (*SSHTunnel, error) {
+	return newSSHTunnel(bastion, addr, `ssh`)
+}
+
+// A tunnel running sshCommand as its ssh client
+func newSSHTunnel(bastion, addr, sshCommand string) (*SSHTunnel, error) {
+	listener, err := net.Listen(`tcp`, `127.0.0.1:0`)
+	if err != nil {
+		return nil, err
+	}
+	t := &SSHTunnel{bastion: bastion, addr: addr, listener: listener, sshCommand: sshCommand}
+	go t.accept()
+	return t, nil
+}
+
+// The local address to connect to
+func (t *SSHTunnel) Addr() string {
+	return t.listener.Addr().String()
+}
+
+// The config connecting through the tunnel instead.  TLS still verifies the server's hostname rather than the local address.
+func (t *SSHTunnel) Config(config *mysql.Config) *mysql.Config {
+	tunneled := config.Clone()
+	if tunneled.TLS != nil && tunneled.TLS.ServerName == `` {
+		if host, _, err := net.SplitHostPort(t.addr); err == nil {
+			tunneled.TLS.ServerName = host
+		}
+	}
+	tunneled.Net = `tcp`
+	tunneled.Addr = t.Addr()
+	return tunneled
+}
+
+func (t *SSHTunnel) accept() {
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			return
+		}
+		go t.forward(conn)
+	}
+}
+
+// Copy the connection to and from an ssh -W to the server, until either side closes
+func (t *SSHTunnel) forward(conn net.Conn) {
+	defer conn.Close()
+
+	cmd := exec.Command(t.sshCommand, `-o`, `BatchMode=yes`, `-W`, t.addr, t.bastion)
+	cmd.Stdin = conn
+	cmd.Stdout = conn
+	cmd.Stderr = os.Stderr
+
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		t.mu.Unlock()
+		fmt.Fprintf(os.Stderr, "ssh %s: %v\n", t.bastion, err)
+		return
+	}
+	t.commands = append(t.commands, cmd)
+	t.mu.Unlock()
+
+	// The driver closing the connection ends ssh's stdin
+	if err := cmd.Wait(); err != nil && !errors.Is(err, io.EOF) {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || !t.isClosed() {
+			fmt.Fprintf(os.Stderr, "ssh %s: %v\n", t.bastion, err)
+		}
+	}
+	t.remove(cmd)
+}
+
+func (t *SSHTunnel) isClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.closed
+}
+
+func (t *SSHTunnel) remove(cmd *exec.Cmd) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, c := range t.commands {
+		if c == cmd {
+			t.commands = append(t.commands[:i], t.commands[i+1:]...)
+			return
+		}
+	}
+}
+
+// Stop listening and end the ssh clients
+func (t *SSHTunnel) Close() error {
+	t.mu.Lock()
+	t.closed = true
+	for _, cmd := range t.commands {
+		cmd.Process.Kill()
+	}
+	t.mu.Unlock()
+	return t.listener.Close()
+}
@@ -0,0 +1,74 @@
+package loader
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// A tunnel whose ssh records its arguments and echoes the connection back
+func getTestSSHTunnel(t *testing.T) (*SSHTunnel, string) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, `ssh`)
+	args := filepath.Join(dir, `args`)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\necho \"$@\" > "+args+"\nexec cat\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	tunnel, err := newSSHTunnel(`me@bastion`, `db1:3306`, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tunnel.Close() })
+	return tunnel, args
+}
+
+func TestSSHTunnelForwards(t *testing.T) {
+	tunnel, args := getTestSSHTunnel(t)
+
+	conn, err := net.Dial(`tcp`, tunnel.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(`ping`)); err != nil {
+		t.Fatal(err)
+	}
+	reply := make([]byte, 4)
+	if _, err := io.ReadFull(conn, reply); err != nil || string(reply) != `ping` {
+		t.Fatalf("unexpected reply %q: %v", reply, err)
+	}
+
+	bytes, err := os.ReadFile(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(bytes)); got != `-o BatchMode=yes -W db1:3306 me@bastion` {
+		t.Errorf("unexpected ssh arguments: %s", got)
+	}
+}
+
+func TestSSHTunnelConfig(t *testing.T) {
+	tunnel, _ := getTestSSHTunnel(t)
+
+	config := mysql.NewConfig()
+	config.Net = `tcp`
+	config.Addr = `db1:3306`
+	config.TLS = &tls.Config{}
+
+	tunneled := tunnel.Config(config)
+	if tunneled.Addr != tunnel.Addr() || tunneled.Net != `tcp` {
+		t.Errorf("unexpected address: %s %s", tunneled.Net, tunneled.Addr)
+	}
+	if tunneled.TLS.ServerName != `db1` {
+		t.Errorf("TLS should verify the server, not the tunnel: %q", tunneled.TLS.ServerName)
+	}
+	if config.Addr != `db1:3306` || config.TLS.ServerName != `` {
+		t.Error("the original config should be unchanged")
+	}
+}
@@ -11,6 +11,33 @@ type State struct {
 
 	// Is this a Live state?
 	Live bool
+
+	// Number of the interval this State was collected for, starting at 1.  A jump in Seq means intervals were missed.
+	Seq uint64
+
+	// Intervals missed between the Previous and Current SampleSets
+	Missed uint64
+
+	// The server restarted since the last State, there is no Previous to compare to
+	Restarted bool
+
+	// The values are those of an earlier State, standing in for this interval's failed collection
+	Repeated bool
+
+	// Out-of-band messages about this State, e.g., a server restart
+	Annotations []string
+
+	// The State of each role a MultiLoader merged into this one
+	roles map[string]mergedRole
+
+	// The role of a State from RoleState
+	role string
+}
+
+// A role's State in a merged State, and whether it is new since the last merge
+type mergedRole struct {
+	state   StateReader
+	updated bool
 }
 
 func NewState() *State {
@@ -45,6 +72,24 @@ func (sp *State) SecondsDiff() float64 {
 	return float64(curUptime - prevUptime)
 }
 
+// Seconds between the Cur and Prev Samples of the given Source.  A live Sample older than its Set (collected in an earlier interval, e.g. under a budget) counts from when it was collected.
+func (sp *State) SourceSecondsDiff(sn SourceName) float64 {
+	seconds := sp.SecondsDiff()
+	if !sp.Live || sp.Previous == nil {
+		return seconds
+	}
+	return max(seconds+sampleAge(sp.Previous, sn)-sampleAge(sp.Current, sn), 0)
+}
+
+// Seconds the Sample of the Source was collected before its Set, 0 if it wasn't
+func sampleAge(ssp *SampleSet, sn SourceName) float64 {
+	sampleTime, err := ssp.GetSourceTime(sn)
+	if err != nil || !sampleTime.Before(ssp.Timestamp) {
+		return 0
+	}
+	return ssp.Timestamp.Sub(sampleTime).Seconds()
+}
+
 // Get what to print in the timestamp col
 func (sp *State) GetTimeString() string {
 	if sp.Live {
@@ -54,6 +99,56 @@ func (sp *State) GetTimeString() string {
 	}
 }
 
+func (sp *State) IsLive() bool {
+	return sp.Live
+}
+
+// Get the interval number of this State
+func (sp *State) GetSeq() uint64 {
+	return sp.Seq
+}
+
+// Get the number of intervals missed between the Previous and Current SampleSets
+func (sp *State) GetMissed() uint64 {
+	return sp.Missed
+}
+
+// Did the server restart since the last State?
+func (sp *State) HasRestarted() bool {
+	return sp.Restarted
+}
+
+// Are the values repeated from an earlier State?
+func (sp *State) IsRepeated() bool {
+	return sp.Repeated
+}
+
+// A copy of this State standing in for the given one, whose collection failed.  Both SampleSets are shifted to its time so values computed across them don't change.
+func (sp *State) RepeatAt(failed StateReader) *State {
+	repeat := *sp
+	repeat.Seq = failed.GetSeq()
+	repeat.Repeated = true
+	repeat.Annotations = failed.GetAnnotations()
+
+	shift := failed.GetCurrent().GetTimeGenerated().Sub(sp.Current.Timestamp)
+	uptimeShift := failed.GetCurrent().GetUptime() - sp.Current.Uptime
+	repeat.Current = sp.Current.shifted(shift, uptimeShift)
+	if sp.Previous != nil {
+		repeat.Previous = sp.Previous.shifted(shift, uptimeShift)
+	}
+	return &repeat
+}
+
+// Get any annotations to print before this State
+func (sp *State) GetAnnotations() []string {
+	return sp.Annotations
+}
+
+// Add an annotation to print before this State
+func (sp *State) AddAnnotation(annotation string) {
+	sp.Annotations = append(sp.Annotations, annotation)
+}
+
 // Get the Current and Previous Samplesets, could be nil!
 func (sp *State) GetCurrent() SampleSetReader {
 	return sp.Current
@@ -70,6 +165,28 @@ func (sp *State) SetPrevious(ssr *SampleSet) {
 	sp.Previous = ssr
 }
 
+// The status uptime goes back when the server restarts
+var uptimeKey = SourceKey{SourceName: `status`, Key: `uptime`}
+
+// Set the Previous SampleSet unless the server restarted since, rates across the restart would be garbage.  True if it restarted, the State then has no Previous.
+func (sp *State) setPreviousOrRestart(ssr *SampleSet) bool {
+	sp.SetPrevious(ssr)
+	if ssr == nil {
+		return false
+	}
+	cur, err := sp.Current.GetInt(uptimeKey)
+	if err != nil {
+		return false
+	}
+	prev, err := ssr.GetInt(uptimeKey)
+	if err != nil || cur >= prev {
+		return false
+	}
+	sp.SetPrevious(nil)
+	sp.Restarted = true
+	return true
+}
+
 // Get the interface to write to the Current SS
 func (sp *State) GetCurrentWriter() SampleSetWriter {
 	return sp.Current
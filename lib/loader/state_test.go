@@ -19,6 +19,46 @@ func TestStateSetPrev(t *testing.T) {
 	}
 }
 
+func TestStateSetPreviousOrRestart(t *testing.T) {
+	withUptime := func(uptime string) *SampleSet {
+		ssp := NewSampleSet()
+		sample := NewSample()
+		if uptime != "" {
+			sample.Data[`uptime`] = uptime
+		}
+		ssp.SetSample(`status`, sample)
+		return ssp
+	}
+
+	tests := []struct {
+		prev, cur string
+		restarted bool
+	}{
+		{`100`, `101`, false},
+		{`100`, `100`, false},
+		{`100`, `5`, true},
+		{``, `5`, false}, // can't tell without uptimes
+		{`100`, ``, false},
+	}
+	for _, test := range tests {
+		state := NewState()
+		state.Current = withUptime(test.cur)
+		prev := withUptime(test.prev)
+		if restarted := state.setPreviousOrRestart(prev); restarted != test.restarted || state.HasRestarted() != test.restarted {
+			t.Errorf(`%s -> %s: expected restarted %v`, test.prev, test.cur, test.restarted)
+		}
+		if (state.GetPrevious() == nil) != test.restarted {
+			t.Errorf(`%s -> %s: the previous should only be dropped on a restart`, test.prev, test.cur)
+		}
+	}
+
+	// The first State has nothing to compare to
+	state := NewState()
+	if state.setPreviousOrRestart(nil) || state.GetPrevious() != nil {
+		t.Error(`unexpected restart without a previous`)
+	}
+}
+
 func TestStateSecondsDiff(t *testing.T) {
 	state := NewState()
 	diff := state.SecondsDiff()
@@ -66,3 +106,70 @@ func TestStateGetTimeString(t *testing.T) {
 		t.Errorf("bad timestring: %s", ts)
 	}
 }
+
+func TestStateRepeatAt(t *testing.T) {
+	last := NewState()
+	last.Live = true
+	last.Seq = 3
+	prevssp := NewSampleSet()
+	prevssp.Timestamp = last.Current.Timestamp.Add(-time.Second)
+	last.SetPrevious(prevssp)
+
+	failed := NewState()
+	failed.Live = true
+	failed.Seq = 4
+	failed.Current.Timestamp = last.Current.Timestamp.Add(time.Second)
+	failed.AddAnnotation(`connection lost`)
+
+	repeat := last.RepeatAt(failed)
+	if !repeat.IsRepeated() || last.IsRepeated() {
+		t.Error("only the repeat should be repeated")
+	}
+	if repeat.GetSeq() != 4 || repeat.GetTimeString() != failed.GetTimeString() {
+		t.Errorf("unexpected seq or time: %d %s", repeat.GetSeq(), repeat.GetTimeString())
+	}
+	if math.Round(repeat.SecondsDiff()) != 1 {
+		t.Errorf("bad diff: %f", repeat.SecondsDiff())
+	}
+	if len(repeat.GetAnnotations()) != 1 {
+		t.Errorf("unexpected annotations: %v", repeat.GetAnnotations())
+	}
+	if !prevssp.Timestamp.Equal(last.Current.Timestamp.Add(-time.Second)) {
+		t.Error("the original Previous was shifted")
+	}
+}
+
+// A Source skipped in the Previous interval is filled in with its last Sample, its rates span the time since
+func TestSourceSecondsDiffAfterSkip(t *testing.T) {
+	start := time.Now()
+	last := NewSample()
+	last.Timestamp = start
+
+	skipped := NewSampleSet()
+	skipped.Timestamp = start.Add(time.Second)
+	skipped.SetSample(`status`, NewSample())
+	skipped.Samples[`status`].(*Sample).Timestamp = skipped.Timestamp
+
+	state := NewState()
+	state.Live = true
+	state.Current.Timestamp = start.Add(2 * time.Second)
+	state.Current.SetSample(`status`, &Sample{Timestamp: state.Current.Timestamp, Data: map[string]string{}})
+	state.Current.SetSample(`innodb_trx`, &Sample{Timestamp: state.Current.Timestamp, Data: map[string]string{}})
+	state.SetPrevious(skipped.withMissing(map[SourceName]SampleReader{`status`: NewSample(), `innodb_trx`: last}))
+
+	if state.GetPrevious().GetStr(SourceKey{`status`, `uptime`}) != `` || state.Previous.Samples[`status`] != skipped.Samples[`status`] {
+		t.Error(`collected Samples should not be replaced`)
+	}
+	if _, ok := skipped.Samples[`innodb_trx`]; ok {
+		t.Error(`the skipped Set should not change`)
+	}
+	if seconds := state.SecondsDiff(); seconds != 1 {
+		t.Errorf(`unexpected SecondsDiff: %f`, seconds)
+	}
+	if seconds := state.SourceSecondsDiff(`status`); seconds != 1 {
+		t.Errorf(`unexpected status SourceSecondsDiff: %f`, seconds)
+	}
+	if seconds := state.SourceSecondsDiff(`innodb_trx`); seconds != 2 {
+		t.Errorf(`unexpected innodb_trx SourceSecondsDiff: %f`, seconds)
+	}
+}
@@ -0,0 +1,44 @@
+package loader
+
+import "time"
+
+// Ticks for collecting every interval, and a func to stop them.  Aligned ticks land on the wall clock multiples of the interval (e.g., every minute at :00), so collections from several hosts and tools line up.
+func newTicks(interval time.Duration, align bool) (<-chan time.Time, func()) {
+	if !align {
+		ticker := time.NewTicker(interval)
+		return ticker.C, ticker.Stop
+	}
+
+	ch := make(chan time.Time, 1)
+	done := make(chan struct{})
+	go func() {
+		var last time.Time
+		for {
+			// The wall clock is checked again for every tick, so it doesn't drift
+			next := nextBoundary(time.Now(), last, interval)
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case now := <-timer.C:
+				last = next
+				// Drop ticks for a slow receiver, like a time.Ticker
+				select {
+				case ch <- now:
+				default:
+				}
+			case <-done:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+	return ch, func() { close(done) }
+}
+
+// The next wall clock multiple of the interval after now, and after the last one ticked in case the timer fired early
+func nextBoundary(now, last time.Time, interval time.Duration) time.Time {
+	next := now.Round(0).Truncate(interval).Add(interval)
+	if !last.IsZero() && !next.After(last) {
+		next = last.Add(interval)
+	}
+	return next
+}
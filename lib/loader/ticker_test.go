@@ -0,0 +1,39 @@
+package loader
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextBoundary(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		now, last time.Time
+		interval  time.Duration
+		expected  time.Time
+	}{
+		{base.Add(17 * time.Second), time.Time{}, time.Minute, base.Add(time.Minute)},
+		{base, time.Time{}, time.Minute, base.Add(time.Minute)},
+		{base.Add(1500 * time.Millisecond), time.Time{}, time.Second, base.Add(2 * time.Second)},
+
+		// The timer fired early, don't tick the same boundary twice
+		{base.Add(59990 * time.Millisecond), base.Add(time.Minute), time.Minute, base.Add(2 * time.Minute)},
+	}
+	for _, test := range tests {
+		if next := nextBoundary(test.now, test.last, test.interval); !next.Equal(test.expected) {
+			t.Errorf("%s: unexpected next boundary %s", test.now, next)
+		}
+	}
+}
+
+func TestAlignedTicks(t *testing.T) {
+	interval := 50 * time.Millisecond
+	ticks, stop := newTicks(interval, true)
+	defer stop()
+	for range 3 {
+		tick := <-ticks
+		if offset := tick.Round(0).Sub(tick.Round(0).Truncate(interval)); offset > 20*time.Millisecond {
+			t.Errorf("tick %s is %s past the boundary", tick, offset)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// The replicas connected to a source, SHOW SLAVE HOSTS before MySQL 8.0.22 and on MariaDB
+	REPLICAS_QUERY    string = "SHOW REPLICAS"
+	SLAVE_HOSTS_QUERY string = "SHOW SLAVE HOSTS"
+
+	// Who a server is, the same server can be reached by several addresses
+	TOPOLOGY_IDENTITY_QUERY string = "SELECT VERSION(), @@hostname, @@port"
+
+	// The client addresses of every node in a Galera cluster
+	WSREP_ADDRESSES_QUERY string = "SHOW GLOBAL STATUS LIKE 'wsrep_incoming_addresses'"
+
+	// Stop discovering after probing this many hosts
+	TOPOLOGY_MAX_HOSTS int = 100
+)
+
+// What a server tells about its place in the replication topology
+type TopologyProbe struct {
+	Version string
+
+	// @@hostname:@@port
+	Identity string
+
+	// SHOW REPLICA STATUS with the canonical keys (source_host, seconds_behind_source, ...), empty when the server isn't a replica.  Only the first channel of a multi-source replica.
+	Replica map[string]string
+
+	// The host:port of the replicas in SHOW REPLICAS, and the server_id of those that don't set report_host
+	Replicas   []string
+	Unreported []string
+
+	// The host:port of the other nodes of its Galera cluster, including itself
+	GaleraPeers []string
+}
+
+// The address (host:port) of the server's source, if it's a replica
+func (p *TopologyProbe) Source() (string, bool) {
+	host, ok := p.Replica[`source_host`]
+	if !ok || host == `` {
+		return ``, false
+	}
+	return net.JoinHostPort(host, p.Replica[`source_port`]), true
+}
+
+// Probes the server at the given host:port
+type TopologyProber func(addr string) (*TopologyProbe, error)
+
+// Find what the server tells about its place in the topology.  Initialize the LiveLoader with the replica Source first.
+func (l *LiveLoader) ProbeTopology() (*TopologyProbe, error) {
+	probe := &TopologyProbe{}
+
+	ctx, cancel := l.queryContext()
+	var hostname string
+	var port int
+	err := l.db.QueryRowContext(ctx, TOPOLOGY_IDENTITY_QUERY).Scan(&probe.Version, &hostname, &port)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("cannot run query (%s): %s", TOPOLOGY_IDENTITY_QUERY, err)
+	}
+	probe.Identity = net.JoinHostPort(hostname, strconv.Itoa(port))
+
+	sample := l.collectSource(l.queries[`replica`], l.queryTimeout)
+	if err := sample.Error(); err != nil {
+		return nil, err
+	}
+	sample.applyAliases(`replica`)
+	probe.Replica = sample.Data
+
+	query := REPLICAS_QUERY
+	if ParseServerFlavor(probe.Version) == MARIADB_FLAVOR || !l.version.AtLeast(ServerVersion{8, 0, 22}) {
+		query = SLAVE_HOSTS_QUERY
+	}
+	rows, err := l.getColumnRows(query, l.queryTimeout)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if row[`host`] == `` {
+			probe.Unreported = append(probe.Unreported, row[`server_id`])
+			continue
+		}
+		probe.Replicas = append(probe.Replicas, net.JoinHostPort(row[`host`], row[`port`]))
+	}
+
+	// Nothing when the server isn't a Galera node
+	wsrep := l.getSample(WSREP_ADDRESSES_QUERY, l.queryTimeout)
+	if err := wsrep.Error(); err != nil {
+		return nil, err
+	}
+	for _, addr := range strings.Split(wsrep.Data[`wsrep_incoming_addresses`], `,`) {
+		// Nodes that haven't joined yet are AUTO
+		if addr = strings.TrimSpace(addr); addr != `` && addr != `AUTO` {
+			probe.GaleraPeers = append(probe.GaleraPeers, addr)
+		}
+	}
+	return probe, nil
+}
+
+// A server in the replication topology, and the servers that replicate from it
+type TopologyNode struct {
+	Addr  string
+	Probe *TopologyProbe
+	Err   error
+
+	// The node is already in the tree above, e.g., in circular replication
+	Repeat bool
+
+	// Replicas first, then the other Galera nodes of the cluster
+	Children []*TopologyNode
+}
+
+// What the server does in the topology, like `replica+source` or `galera`
+func (n *TopologyNode) Role() string {
+	if n.Probe == nil {
+		return `unknown`
+	}
+	var roles []string
+	if len(n.Probe.GaleraPeers) > 0 {
+		roles = append(roles, `galera`)
+	}
+	if _, ok := n.Probe.Source(); ok {
+		roles = append(roles, `replica`)
+	}
+	if len(n.Probe.Replicas)+len(n.Probe.Unreported) > 0 {
+		roles = append(roles, `source`)
+	}
+	if len(roles) == 0 {
+		return `standalone`
+	}
+	return strings.Join(roles, `+`)
+}
+
+// How far behind its source a replica is, and which replication threads aren't running.  Empty when it isn't a replica.
+func (n *TopologyNode) Lag() string {
+	if n.Probe == nil {
+		return ``
+	}
+	if _, ok := n.Probe.Source(); !ok {
+		return ``
+	}
+
+	// NULL when replication is stopped
+	lag := `lag NULL`
+	if seconds, ok := n.Probe.Replica[`seconds_behind_source`]; ok {
+		lag = fmt.Sprintf("lag %ss", seconds)
+	}
+	for _, thread := range []string{`io`, `sql`} {
+		if state := n.Probe.Replica[`replica_`+thread+`_running`]; state != `Yes` {
+			lag += fmt.Sprintf(" (%s %s)", thread, state)
+		}
+	}
+	return lag
+}
+
+func (n *TopologyNode) String() string {
+	switch {
+	case n.Repeat:
+		return fmt.Sprintf("%s  (see above)", n.Addr)
+	case n.Err != nil:
+		// Connection errors span lines
+		return fmt.Sprintf("%s  error: %s", n.Addr, strings.ReplaceAll(n.Err.Error(), "\n", " "))
+	}
+	fields := []string{n.Addr, n.Role(), n.Probe.Version}
+	if lag := n.Lag(); lag != `` {
+		fields = append(fields, lag)
+	}
+	return strings.Join(fields, `  `)
+}
+
+// The tree as lines, each replica indented under its source
+func (n *TopologyNode) Lines() []string {
+	lines := []string{n.String()}
+	for _, child := range n.Children {
+		for _, line := range child.Lines() {
+			lines = append(lines, `  `+line)
+		}
+	}
+	return lines
+}
+
+// Discover the replication topology the server at the start address is in: climb to the top of its sources, then walk down the replicas (and Galera nodes) of each.  The same server reached by two addresses is only probed once.
+func DiscoverTopology(start string, probe TopologyProber) *TopologyNode {
+	d := &topologyDiscovery{
+		probe:    probe,
+		probes:   make(map[string]*TopologyProbe),
+		errs:     make(map[string]error),
+		climbed:  make(map[string][]string),
+		inTree:   make(map[string]bool),
+		inTreeID: make(map[string]bool),
+	}
+
+	// Climb to the top, remembering who we came from in case a source can't be probed
+	top := start
+	seen := map[string]bool{start: true}
+	for {
+		p, err := d.get(top)
+		if err != nil {
+			break
+		}
+		source, ok := p.Source()
+		if !ok || seen[source] {
+			break
+		}
+		seen[source] = true
+		d.climbed[source] = append(d.climbed[source], top)
+		top = source
+	}
+	return d.walk(top)
+}
+
+type topologyDiscovery struct {
+	probe TopologyProber
+
+	// The result of probing each address
+	probes map[string]*TopologyProbe
+	errs   map[string]error
+
+	// The replicas found climbing up to each source
+	climbed map[string][]string
+
+	// The addresses and identities already in the tree
+	inTree, inTreeID map[string]bool
+}
+
+// Probe an address once
+func (d *topologyDiscovery) get(addr string) (*TopologyProbe, error) {
+	if p, ok := d.probes[addr]; ok {
+		return p, nil
+	}
+	if err, ok := d.errs[addr]; ok {
+		return nil, err
+	}
+	if len(d.probes)+len(d.errs) >= TOPOLOGY_MAX_HOSTS {
+		return nil, fmt.Errorf("not probed, already probed %d hosts", TOPOLOGY_MAX_HOSTS)
+	}
+	p, err := d.probe(addr)
+	if err != nil {
+		d.errs[addr] = err
+		return nil, err
+	}
+	d.probes[addr] = p
+	return p, nil
+}
+
+// Has the server at this address been placed in the tree already?
+func (d *topologyDiscovery) placed(addr string) bool {
+	if d.inTree[addr] {
+		return true
+	}
+	p, err := d.get(addr)
+	return err == nil && d.inTreeID[p.Identity]
+}
+
+// Place the server at this address in the tree
+func (d *topologyDiscovery) place(addr string) {
+	d.inTree[addr] = true
+	if p, err := d.get(addr); err == nil {
+		d.inTreeID[p.Identity] = true
+	}
+}
+
+// The node at the address and everything below it
+func (d *topologyDiscovery) walk(addr string) *TopologyNode {
+	node := &TopologyNode{Addr: addr}
+	d.place(addr)
+	p, err := d.get(addr)
+	if err != nil {
+		node.Err = err
+		// We only know the replicas we climbed from
+		for _, replica := range d.climbed[addr] {
+			if !d.placed(replica) {
+				node.Children = append(node.Children, d.walk(replica))
+			}
+		}
+		return node
+	}
+	node.Probe = p
+
+	replicas := slices.Clone(p.Replicas)
+	for _, replica := range d.climbed[addr] {
+		if !slices.Contains(replicas, replica) {
+			replicas = append(replicas, replica)
+		}
+	}
+	slices.Sort(replicas)
+	for _, replica := range replicas {
+		if d.placed(replica) {
+			node.Children = append(node.Children, &TopologyNode{Addr: replica, Repeat: true})
+			continue
+		}
+		node.Children = append(node.Children, d.walk(replica))
+	}
+	for _, serverID := range p.Unreported {
+		node.Children = append(node.Children, &TopologyNode{
+			Addr: `server_id ` + serverID,
+			Err:  errors.New("the replica doesn't set report_host"),
+		})
+	}
+
+	// Every Galera node lists all of them, only show those we haven't.  They're placed before walking any, so they're siblings.
+	var peers []string
+	for _, peer := range p.GaleraPeers {
+		if !d.placed(peer) {
+			d.place(peer)
+			peers = append(peers, peer)
+		}
+	}
+	slices.Sort(peers)
+	for _, peer := range peers {
+		node.Children = append(node.Children, d.walk(peer))
+	}
+	return node
+}
@@ -0,0 +1,96 @@
+package loader
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestDiscoverTopology(t *testing.T) {
+	probes := map[string]*TopologyProbe{
+		`db1:3306`: {Version: `8.0.36`, Identity: `db1:3306`, Replicas: []string{`db2:3306`, `db3:3306`}},
+		`db2:3306`: {Version: `8.0.36`, Identity: `db2:3306`,
+			Replica:    map[string]string{`source_host`: `db1`, `source_port`: `3306`, `seconds_behind_source`: `0`, `replica_io_running`: `Yes`, `replica_sql_running`: `Yes`},
+			Replicas:   []string{`db4:3306`},
+			Unreported: []string{`5`},
+		},
+		`db3:3306`: {Version: `8.0.36`, Identity: `db3:3306`,
+			Replica: map[string]string{`source_host`: `db1`, `source_port`: `3306`, `replica_io_running`: `Connecting`, `replica_sql_running`: `Yes`},
+		},
+		`db4:3306`: {Version: `8.4.0`, Identity: `db4:3306`,
+			Replica: map[string]string{`source_host`: `db2`, `source_port`: `3306`, `seconds_behind_source`: `12`, `replica_io_running`: `Yes`, `replica_sql_running`: `Yes`},
+		},
+	}
+	probe := func(addr string) (*TopologyProbe, error) {
+		if p, ok := probes[addr]; ok {
+			return p, nil
+		}
+		return nil, errors.New("connection refused")
+	}
+
+	// Starting from a replica climbs to the top
+	expected := []string{
+		`db1:3306  source  8.0.36`,
+		`  db2:3306  replica+source  8.0.36  lag 0s`,
+		`    db4:3306  replica  8.4.0  lag 12s`,
+		`    server_id 5  error: the replica doesn't set report_host`,
+		`  db3:3306  replica  8.0.36  lag NULL (io Connecting)`,
+	}
+	if lines := DiscoverTopology(`db4:3306`, probe).Lines(); !slices.Equal(lines, expected) {
+		t.Errorf("unexpected topology:\n%q", lines)
+	}
+
+	// A source we can't reach still shows the replicas we came from
+	delete(probes, `db1:3306`)
+	expected = []string{
+		`db1:3306  error: connection refused`,
+		`  db2:3306  replica+source  8.0.36  lag 0s`,
+		`    db4:3306  replica  8.4.0  lag 12s`,
+		`    server_id 5  error: the replica doesn't set report_host`,
+	}
+	if lines := DiscoverTopology(`db4:3306`, probe).Lines(); !slices.Equal(lines, expected) {
+		t.Errorf("unexpected topology:\n%q", lines)
+	}
+}
+
+func TestDiscoverTopologyCircular(t *testing.T) {
+	probes := map[string]*TopologyProbe{
+		`a:3306`: {Version: `8.0.36`, Identity: `a:3306`, Replicas: []string{`b:3306`},
+			Replica: map[string]string{`source_host`: `b`, `source_port`: `3306`, `seconds_behind_source`: `0`, `replica_io_running`: `Yes`, `replica_sql_running`: `Yes`},
+		},
+		`b:3306`: {Version: `8.0.36`, Identity: `b:3306`, Replicas: []string{`a:3306`},
+			Replica: map[string]string{`source_host`: `a`, `source_port`: `3306`, `seconds_behind_source`: `1`, `replica_io_running`: `Yes`, `replica_sql_running`: `Yes`},
+		},
+	}
+	probe := func(addr string) (*TopologyProbe, error) { return probes[addr], nil }
+
+	expected := []string{
+		`b:3306  replica+source  8.0.36  lag 1s`,
+		`  a:3306  replica+source  8.0.36  lag 0s`,
+		`    b:3306  (see above)`,
+	}
+	if lines := DiscoverTopology(`a:3306`, probe).Lines(); !slices.Equal(lines, expected) {
+		t.Errorf("unexpected topology:\n%q", lines)
+	}
+}
+
+func TestDiscoverTopologyGalera(t *testing.T) {
+	peers := []string{`10.0.0.1:3306`, `10.0.0.2:3306`, `10.0.0.3:3306`}
+	probes := map[string]*TopologyProbe{
+		// The start is the same server as 10.0.0.1
+		`pxc1:3306`:     {Version: `8.0.35`, Identity: `pxc1:3306`, GaleraPeers: peers},
+		`10.0.0.1:3306`: {Version: `8.0.35`, Identity: `pxc1:3306`, GaleraPeers: peers},
+		`10.0.0.2:3306`: {Version: `8.0.35`, Identity: `pxc2:3306`, GaleraPeers: peers},
+		`10.0.0.3:3306`: {Version: `8.0.35`, Identity: `pxc3:3306`, GaleraPeers: peers},
+	}
+	probe := func(addr string) (*TopologyProbe, error) { return probes[addr], nil }
+
+	expected := []string{
+		`pxc1:3306  galera  8.0.35`,
+		`  10.0.0.2:3306  galera  8.0.35`,
+		`  10.0.0.3:3306  galera  8.0.35`,
+	}
+	if lines := DiscoverTopology(`pxc1:3306`, probe).Lines(); !slices.Equal(lines, expected) {
+		t.Errorf("unexpected topology:\n%q", lines)
+	}
+}
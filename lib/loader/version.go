@@ -0,0 +1,87 @@
+package loader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A MySQL server version, e.g. from `SELECT VERSION()` or the `version` variable
+type ServerVersion struct {
+	Major, Minor, Patch int
+}
+
+// Parse versions like `8.4.0`, `5.7.44-log` or `10.11.6-MariaDB-1`
+func ParseServerVersion(str string) (ServerVersion, error) {
+	var v ServerVersion
+	numbers, _, _ := strings.Cut(str, `-`)
+	parts := strings.SplitN(numbers, `.`, 3)
+	if len(parts) != 3 {
+		return v, fmt.Errorf("cannot parse server version: `%s`", str)
+	}
+
+	for i, dest := range []*int{&v.Major, &v.Minor, &v.Patch} {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return v, fmt.Errorf("cannot parse server version: `%s`", str)
+		}
+		*dest = n
+	}
+	return v, nil
+}
+
+// Is this version the same or newer than the other?  The zero version (unknown) is older than all others.
+func (v ServerVersion) AtLeast(other ServerVersion) bool {
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor > other.Minor
+	}
+	return v.Patch >= other.Patch
+}
+
+// Is the version unknown?
+func (v ServerVersion) IsZero() bool {
+	return v == ServerVersion{}
+}
+
+func (v ServerVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Allow versions in yaml as strings
+func (v *ServerVersion) UnmarshalText(text []byte) error {
+	parsed, err := ParseServerVersion(string(text))
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
+
+// The fork of MySQL a server is, MariaDB's versions and metrics have diverged from MySQL's
+type ServerFlavor string
+
+const (
+	MYSQL_FLAVOR   ServerFlavor = `mysql`
+	MARIADB_FLAVOR ServerFlavor = `mariadb`
+)
+
+// The flavor of a version string like `10.11.6-MariaDB-1`, MySQL (and its forks like Percona Server) unless it says otherwise
+func ParseServerFlavor(str string) ServerFlavor {
+	if strings.Contains(strings.ToLower(str), `mariadb`) {
+		return MARIADB_FLAVOR
+	}
+	return MYSQL_FLAVOR
+}
+
+// Allow flavors in yaml as strings
+func (f *ServerFlavor) UnmarshalText(text []byte) error {
+	switch flavor := ServerFlavor(strings.ToLower(string(text))); flavor {
+	case MYSQL_FLAVOR, MARIADB_FLAVOR:
+		*f = flavor
+		return nil
+	}
+	return fmt.Errorf("unknown server flavor: `%s`", text)
+}
@@ -0,0 +1,69 @@
+package loader
+
+import "testing"
+
+func TestParseServerVersion(t *testing.T) {
+	tests := map[string]ServerVersion{
+		`8.4.0`:             {8, 4, 0},
+		`9.1.0-commercial`:  {9, 1, 0},
+		`5.7.44-log`:        {5, 7, 44},
+		`10.11.6-MariaDB-1`: {10, 11, 6},
+	}
+	for str, expected := range tests {
+		v, err := ParseServerVersion(str)
+		if err != nil || v != expected {
+			t.Errorf("%s: unexpected version %v, %v", str, v, err)
+		}
+	}
+
+	if _, err := ParseServerVersion(`bogus`); err == nil {
+		t.Error("parsed a bogus version")
+	}
+}
+
+func TestServerVersionAtLeast(t *testing.T) {
+	v84 := ServerVersion{8, 4, 0}
+	if !v84.AtLeast(ServerVersion{8, 0, 22}) || !v84.AtLeast(v84) {
+		t.Error("8.4.0 should be at least 8.0.22")
+	}
+	if v84.AtLeast(ServerVersion{9, 0, 0}) || (ServerVersion{5, 7, 44}).AtLeast(ServerVersion{8, 0, 0}) {
+		t.Error("unexpected AtLeast")
+	}
+}
+
+func TestParseServerFlavor(t *testing.T) {
+	tests := map[string]ServerFlavor{
+		`8.4.0`:                      MYSQL_FLAVOR,
+		`8.0.35-27`:                  MYSQL_FLAVOR,
+		`10.11.6-MariaDB-1`:          MARIADB_FLAVOR,
+		`11.4.2-MariaDB-ubu2404-log`: MARIADB_FLAVOR,
+		``:                           MYSQL_FLAVOR,
+	}
+	for str, expected := range tests {
+		if flavor := ParseServerFlavor(str); flavor != expected {
+			t.Errorf("%s: unexpected flavor %s", str, flavor)
+		}
+	}
+
+	var flavor ServerFlavor
+	if err := flavor.UnmarshalText([]byte(`MariaDB`)); err != nil || flavor != MARIADB_FLAVOR {
+		t.Errorf("unexpected flavor %s, %v", flavor, err)
+	}
+	if err := flavor.UnmarshalText([]byte(`oracle`)); err == nil {
+		t.Error("parsed a bogus flavor")
+	}
+}
+
+// Old and new servers both have the new key names
+func TestFileLoaderAliases(t *testing.T) {
+	for _, file := range []string{"./testdata/mysql57.single", "./testdata/mysql84.single"} {
+		l := NewGoodFileLoader(t, file, "", "1s")
+		state := <-l.GetStateChannel()
+
+		for _, key := range []string{`replica_open_temp_tables`, `com_show_replica_status`, `rpl_semi_sync_source_status`} {
+			if _, err := state.GetCurrent().GetString(SourceKey{`status`, key}); err != nil {
+				t.Errorf("%s: missing %s", file, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package loader_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+	"github.com/jayjanssen/myq-tools/lib/viewer"
+)
+
+// Each view queries only what its Sources need, on a current server with performance_schema
+func TestDefaultViewLiveQueries(t *testing.T) {
+	err := viewer.LoadDefaultViews()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pfsProbe := []string{loader.PFS_ENABLED_QUERY}
+	statusOnly := []string{loader.STATUS_QUERY}
+	tests := map[string]struct{ probes, collect []string }{
+		`commands`:      {pfsProbe, statusOnly},
+		`coms`:          {pfsProbe, statusOnly},
+		`cttf`:          {pfsProbe, []string{loader.STATUS_QUERY, loader.VARIABLES_QUERY}},
+		`query`:         {pfsProbe, statusOnly},
+		`qcost`:         {pfsProbe, statusOnly},
+		`statusdiff`:    {pfsProbe, statusOnly},
+		`throughput`:    {pfsProbe, []string{loader.STATUS_QUERY, loader.VARIABLES_QUERY}},
+		`innodb`:        {pfsProbe, statusOnly},
+		`qcache`:        {pfsProbe, []string{loader.STATUS_QUERY, loader.VARIABLES_QUERY}},
+		`caches`:        {pfsProbe, []string{loader.STATUS_QUERY, loader.VARIABLES_QUERY}},
+		`flushing`:      {nil, []string{loader.INNODB_METRICS_QUERY}},
+		`innodb_status`: {nil, []string{loader.INNODB_STATUS_QUERY}},
+		`trx`:           {nil, []string{loader.INNODB_TRX_QUERY, loader.INNODB_METRICS_QUERY}},
+		`locks`:         {pfsProbe, []string{loader.METADATA_LOCKS_QUERY}},
+		`processlist`:   {pfsProbe, []string{loader.PROCESSLIST_SUMMARY + loader.PROCESSLIST_THREADS}},
+		`innodb_locks`:  {[]string{loader.VERSION_QUERY, loader.PFS_ENABLED_QUERY}, []string{loader.INNODB_LOCK_WAITS_QUERY, loader.STATUS_QUERY, loader.INNODB_METRICS_QUERY}},
+		`wsrep`:         {pfsProbe, []string{loader.STATUS_QUERY, loader.VARIABLES_QUERY}},
+		`wsrep_fc`:      {pfsProbe, statusOnly},
+		`digests`:       {pfsProbe, []string{loader.STATUS_QUERY, loader.STATEMENT_DIGESTS_QUERY}},
+		`io`:            {pfsProbe, []string{loader.FILE_IO_QUERY}},
+		`clients`:       {pfsProbe, []string{loader.STATUS_QUERY, loader.CLIENTS_SUMMARY + loader.CLIENTS_THREADS}},
+		`userstat`:      {pfsProbe, []string{loader.STATUS_QUERY, loader.USER_STATISTICS_QUERY}},
+		`tablestat`:     {pfsProbe, []string{loader.STATUS_QUERY, loader.TABLE_STATISTICS_QUERY}},
+		`rds`:           {pfsProbe, statusOnly},
+		`memory`:        {pfsProbe, []string{loader.MEMORY_QUERY, loader.STATUS_QUERY, loader.MEMORY_EVENTS_QUERY}},
+		`replworkers`:   {[]string{loader.VERSION_QUERY, loader.PFS_ENABLED_QUERY}, []string{loader.REPLICA_QUERY, loader.APPLIER_WORKERS_QUERY}},
+
+		// Read locally by a Poller
+		`os`: {nil, nil},
+
+		// -pair collects through role prefixed Sources on another loader
+		`repl`: {nil, nil},
+	}
+	for _, name := range viewer.ListViews() {
+		expected, ok := tests[name]
+		if !ok {
+			t.Errorf("no expected queries for view %s", name)
+			continue
+		}
+		view, _ := viewer.GetViewer(name)
+		sources, _ := view.GetSources()
+		probes, collect, err := loader.LiveQueries(sources, true, `8.4.0`)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(probes, expected.probes) {
+			t.Errorf("%s: unexpected probes: %q", name, probes)
+		}
+		if !reflect.DeepEqual(collect, expected.collect) {
+			t.Errorf("%s: unexpected queries: %q", name, collect)
+		}
+	}
+
+	// MariaDB's version is above 8.0.1, but it has no data_lock_waits
+	view, _ := viewer.GetViewer(`innodb_locks`)
+	sources, _ := view.GetSources()
+	_, collect, err := loader.LiveQueries(sources, true, `10.11.6-MariaDB-1`)
+	if err != nil || len(collect) == 0 || collect[0] != loader.INNODB_LOCK_WAITS_57_QUERY {
+		t.Errorf("unexpected innodb_locks queries on MariaDB: %q, %v", collect, err)
+	}
+}
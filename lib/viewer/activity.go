@@ -0,0 +1,98 @@
+package viewer
+
+import (
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// Hides the counter cols (rates and diffs) of a View that didn't move, e.g. the xa and prep cols of coms on most servers.  Like the WidthAdapter, the cols only change when Apply is called before a header: those that were zero for the whole period since the last one are left out of the next, and come back the header after they move again.
+type ActivityFilter struct {
+	// By col name, as in Records.  Cols seen in the period, and those that moved.
+	seen   map[string]bool
+	active map[string]bool
+}
+
+func NewActivityFilter() *ActivityFilter {
+	return &ActivityFilter{seen: make(map[string]bool), active: make(map[string]bool)}
+}
+
+// The value of a counter col in the State, ok is false for other cols
+func counterValue(sv Viewer, sr loader.StateReader) (value float64, ok bool) {
+	var err error
+	switch c := sv.(type) {
+	case RateCol:
+		value, err = c.getRate(sr)
+	case RateSumCol:
+		value, err = c.getRate(sr)
+	case DiffCol:
+		value, err = c.getDiff(sr)
+	default:
+		return 0, false
+	}
+	return value, err == nil
+}
+
+// Call f with each col of the Viewer and its name
+func walkCols(sv Viewer, prefix string, f func(name string, col Viewer)) {
+	switch v := sv.(type) {
+	case View:
+		for _, group := range v.Groups {
+			walkCols(group, prefix, f)
+		}
+		for _, col := range v.Cols {
+			walkCols(col, prefix, f)
+		}
+	case GroupCol:
+		for _, col := range v.Cols {
+			walkCols(col, prefix+v.Name+`/`, f)
+		}
+	default:
+		f(prefix+v.GetName(), v)
+	}
+}
+
+// Note which counter cols moved in the State, observe the whole View so hidden cols can come back
+func (af *ActivityFilter) Observe(sv Viewer, sr loader.StateReader) {
+	walkCols(sv, ``, func(name string, col Viewer) {
+		if value, ok := counterValue(col, sr); ok {
+			af.seen[name] = true
+			if value != 0 {
+				af.active[name] = true
+			}
+		}
+	})
+}
+
+// The Viewer without the counter cols that were zero since the last Apply, groups left without cols are left out too.  Starts the next period.
+func (af *ActivityFilter) Apply(sv Viewer) Viewer {
+	defer func() {
+		af.seen = make(map[string]bool)
+		af.active = make(map[string]bool)
+	}()
+
+	view, ok := sv.(View)
+	if !ok {
+		return sv
+	}
+	var groups []GroupCol
+	for _, group := range view.Groups {
+		group.Cols = af.filter(group.Cols, group.Name+`/`)
+		if len(group.Cols) > 0 {
+			groups = append(groups, group)
+		}
+	}
+	view.Groups = groups
+	view.Cols = af.filter(view.Cols, ``)
+	return view
+}
+
+func (af *ActivityFilter) filter(cols ViewerList, prefix string) ViewerList {
+	var kept ViewerList
+	for _, col := range cols {
+		name := prefix + col.GetName()
+		if af.seen[name] && !af.active[name] {
+			continue
+		}
+		kept = append(kept, col)
+	}
+	return kept
+}
@@ -0,0 +1,73 @@
+package viewer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// A State where connections moved by the given number, bytes received and threads connected didn't
+func getTestActivityState(connections string) loader.StateReader {
+	state := loader.NewState()
+	prev := loader.NewSampleSet()
+	prevSample := loader.NewSample()
+	prevSample.Data[`connections`] = `10`
+	prevSample.Data[`bytes_received`] = `100`
+	prev.SetSample(`status`, prevSample)
+	prev.SetUptime(1)
+
+	sample := loader.NewSample()
+	sample.Data[`connections`] = connections
+	sample.Data[`bytes_received`] = `100`
+	sample.Data[`threads_connect`] = `0`
+	state.GetCurrentWriter().SetSample(`status`, sample)
+	state.GetCurrentWriter().SetUptime(2)
+	state.SetPrevious(prev)
+	return state
+}
+
+func TestActivityFilter(t *testing.T) {
+	view := View{}
+	view.Name = "test"
+	group := GroupCol{}
+	group.Name = "Net"
+	group.Cols = ViewerList{getTestDiffCol()}
+	view.Groups = []GroupCol{group}
+	view.Cols = ViewerList{getTestRateCol(), getTestGaugeCol()}
+
+	names := func(sv Viewer) (names []string) {
+		walkCols(sv, ``, func(name string, _ Viewer) {
+			names = append(names, name)
+		})
+		return
+	}
+
+	// Nothing is hidden before anything was seen
+	af := NewActivityFilter()
+	if applied := af.Apply(view); !reflect.DeepEqual(names(applied), []string{`Net/recv`, `cons`, `conn`}) {
+		t.Errorf("unexpected cols: %q", names(applied))
+	}
+
+	// Gauges are always shown, the group without cols is left out
+	af.Observe(view, getTestActivityState(`10`))
+	af.Observe(view, getTestActivityState(`15`))
+	applied := af.Apply(view)
+	if !reflect.DeepEqual(names(applied), []string{`cons`, `conn`}) {
+		t.Errorf("unexpected cols: %q", names(applied))
+	}
+	if groups := applied.(View).Groups; len(groups) != 0 {
+		t.Errorf("unexpected groups: %v", groups)
+	}
+
+	// A new period
+	af.Observe(view, getTestActivityState(`10`))
+	if applied := af.Apply(view); !reflect.DeepEqual(names(applied), []string{`conn`}) {
+		t.Errorf("unexpected cols: %q", names(applied))
+	}
+
+	// The original is left alone
+	if !reflect.DeepEqual(names(view), []string{`Net/recv`, `cons`, `conn`}) {
+		t.Errorf("unexpected original cols: %q", names(view))
+	}
+}
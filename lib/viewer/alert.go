@@ -0,0 +1,140 @@
+package viewer
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// An Alert is a condition on a metric that must hold for a number of consecutive samples, e.g. `threads_running>200 for 3 samples`.  Unlike a Threshold it is on the State rather than a view's col, so it works whatever view is shown.
+type Alert struct {
+	Metric  loader.SourceKey // status/ when no source is given
+	Op      string           // >, <, >=, <=, == or !=
+	Value   float64
+	Samples int // consecutive samples the condition must hold, at least 1
+}
+
+var alertRegex = regexp.MustCompile(`^\s*([\w./-]+)\s*(>=|<=|==|!=|>|<)\s*(\S+?)\s*(?:for\s+(\d+)\s+samples?)?\s*$`)
+
+// Parse an alert like `<metric><op><value> [for <n> samples]`, the metric as in `myq_status -list-metrics`
+func ParseAlert(str string) (Alert, error) {
+	var a Alert
+	matches := alertRegex.FindStringSubmatch(str)
+	if matches == nil {
+		return a, fmt.Errorf("alert must be <metric><op><value> [for <n> samples]: `%s`", str)
+	}
+
+	metric := strings.ToLower(matches[1])
+	name, key, found := strings.Cut(metric, `/`)
+	if !found {
+		name, key = `status`, metric
+	}
+	value, err := strconv.ParseFloat(matches[3], 64)
+	if err != nil {
+		return a, fmt.Errorf("invalid alert value `%s`", matches[3])
+	}
+	a.Metric, a.Op, a.Value, a.Samples = loader.SourceKey{SourceName: loader.SourceName(name), Key: key}, matches[2], value, 1
+	if matches[4] != `` {
+		if a.Samples, err = strconv.Atoi(matches[4]); err != nil || a.Samples < 1 {
+			return a, fmt.Errorf("alert must hold for at least 1 sample: `%s`", str)
+		}
+	}
+	return a, nil
+}
+
+// Each Alert on every role's Source (e.g. `primary.status`) of a MultiLoader, Alerts already on a role's Source as they are
+func RoleAlerts(alerts []Alert, roles []string) (resolved []Alert) {
+	for _, a := range alerts {
+		if slices.ContainsFunc(roles, func(role string) bool { return strings.HasPrefix(string(a.Metric.SourceName), role+".") }) {
+			resolved = append(resolved, a)
+			continue
+		}
+		for _, role := range roles {
+			ra := a
+			ra.Metric.SourceName = loader.RoleSources(role, []loader.SourceName{a.Metric.SourceName})[0]
+			resolved = append(resolved, ra)
+		}
+	}
+	return
+}
+
+// Sources whose Samples have every one of their keys, so a key missing from one doesn't exist
+var completeSources = []string{`status`, `variables`}
+
+// An error if the Alert's metric is not a key of its Source, it would never fire.  Only told once the Source was collected, and for Sources that always have every key.
+func (a Alert) CheckKey(sr loader.StateReader) error {
+	source := string(a.Metric.SourceName)
+	base := source[strings.LastIndex(source, `.`)+1:]
+	if !slices.Contains(completeSources, base) || !sr.GetCurrent().SourceCollected(a.Metric.SourceName) {
+		return nil
+	}
+	if _, err := sr.GetCurrent().GetString(a.Metric); err != nil {
+		return fmt.Errorf("%s has no %s", source, a.Metric.Key)
+	}
+	return nil
+}
+
+func (a Alert) String() string {
+	str := fmt.Sprintf("%s/%s%s%s", a.Metric.SourceName, a.Metric.Key, a.Op, strconv.FormatFloat(a.Value, 'f', -1, 64))
+	if a.Samples > 1 {
+		str += fmt.Sprintf(" for %d samples", a.Samples)
+	}
+	return str
+}
+
+// The metric's current value in the State, an error if it wasn't collected or isn't a number
+func (a Alert) GetValue(sr loader.StateReader) (float64, error) {
+	return sr.GetCurrent().GetFloat(a.Metric)
+}
+
+// Does the condition hold in the State?  Metrics that are missing never do.
+func (a Alert) Holds(sr loader.StateReader) bool {
+	value, err := a.GetValue(sr)
+	if err != nil {
+		return false
+	}
+	switch a.Op {
+	case `>`:
+		return value > a.Value
+	case `<`:
+		return value < a.Value
+	case `>=`:
+		return value >= a.Value
+	case `<=`:
+		return value <= a.Value
+	case `==`:
+		return value == a.Value
+	case `!=`:
+		return value != a.Value
+	}
+	return false
+}
+
+// Counts consecutive samples for a set of Alerts to tell when they fire: once when one has held for its Samples, and again only after it stopped holding
+type AlertWatcher struct {
+	alerts []Alert
+	held   []int
+}
+
+func NewAlertWatcher(alerts []Alert) *AlertWatcher {
+	return &AlertWatcher{alerts: alerts, held: make([]int, len(alerts))}
+}
+
+// The Alerts that fire with this State
+func (aw *AlertWatcher) Fired(sr loader.StateReader) (fired []Alert) {
+	for i, a := range aw.alerts {
+		if !a.Holds(sr) {
+			aw.held[i] = 0
+			continue
+		}
+		aw.held[i] += 1
+		if aw.held[i] == a.Samples {
+			fired = append(fired, a)
+		}
+	}
+	return
+}
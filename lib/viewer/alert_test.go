@@ -0,0 +1,107 @@
+package viewer
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+func getTestAlertState(threadsRunning string) loader.StateReader {
+	state := loader.NewState()
+	sample := loader.NewSample()
+	sample.Data[`threads_running`] = threadsRunning
+	state.GetCurrentWriter().SetSample(`status`, sample)
+	return state
+}
+
+func TestParseAlert(t *testing.T) {
+	tests := map[string]Alert{
+		`threads_running>200 for 3 samples`:   {Metric: loader.SourceKey{SourceName: `status`, Key: `threads_running`}, Op: `>`, Value: 200, Samples: 3},
+		`Threads_Running >= 5`:                {Metric: loader.SourceKey{SourceName: `status`, Key: `threads_running`}, Op: `>=`, Value: 5, Samples: 1},
+		`variables/read_only==1 for 1 sample`: {Metric: loader.SourceKey{SourceName: `variables`, Key: `read_only`}, Op: `==`, Value: 1, Samples: 1},
+	}
+	for str, expected := range tests {
+		alert, err := ParseAlert(str)
+		if err != nil {
+			t.Error(err)
+		}
+		if alert != expected {
+			t.Errorf(`%s: unexpected alert %+v`, str, alert)
+		}
+	}
+	if str := (Alert{Metric: loader.SourceKey{SourceName: `status`, Key: `threads_running`}, Op: `>`, Value: 200, Samples: 3}).String(); str != `status/threads_running>200 for 3 samples` {
+		t.Errorf(`unexpected String(): %s`, str)
+	}
+	for _, str := range []string{`threads_running`, `>5`, `threads_running>x`, `threads_running=>5`, `threads_running>5 for 0 samples`, `threads_running>5 for ever`} {
+		if _, err := ParseAlert(str); err == nil {
+			t.Errorf(`expected error for %s`, str)
+		}
+	}
+}
+
+func TestAlertHolds(t *testing.T) {
+	state := getTestAlertState(`10`)
+	tests := map[string]bool{
+		`threads_running>5`:   true,
+		`threads_running>10`:  false,
+		`threads_running>=10`: true,
+		`threads_running<10`:  false,
+		`threads_running<=10`: true,
+		`threads_running==10`: true,
+		`threads_running!=10`: false,
+		`threads_cached<100`:  false, // not collected
+	}
+	for str, expected := range tests {
+		alert, err := ParseAlert(str)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if alert.Holds(state) != expected {
+			t.Errorf(`%s: expected %v`, str, expected)
+		}
+	}
+}
+
+func TestAlertWatcher(t *testing.T) {
+	alert, _ := ParseAlert(`threads_running>200 for 3 samples`)
+	aw := NewAlertWatcher([]Alert{alert})
+
+	// Fires on the third sample in a row, not again until it stopped holding
+	var fired []int
+	for i, value := range []string{`300`, `300`, `100`, `300`, `300`, `300`, `300`, `100`, `300`, `300`, `300`} {
+		if len(aw.Fired(getTestAlertState(value))) > 0 {
+			fired = append(fired, i)
+		}
+	}
+	if len(fired) != 2 || fired[0] != 5 || fired[1] != 10 {
+		t.Errorf(`unexpected samples fired: %v`, fired)
+	}
+}
+
+func TestRoleAlerts(t *testing.T) {
+	running, _ := ParseAlert(`threads_running>200`)
+	lag, _ := ParseAlert(`replica.replica/seconds_behind_source>60`)
+	var sources []loader.SourceName
+	for _, a := range RoleAlerts([]Alert{running, lag}, []string{`primary`, `replica`}) {
+		sources = append(sources, a.Metric.SourceName)
+	}
+	if !slices.Equal(sources, []loader.SourceName{`primary.status`, `replica.status`, `replica.replica`}) {
+		t.Errorf("unexpected role alert sources: %v", sources)
+	}
+}
+
+func TestAlertCheckKey(t *testing.T) {
+	state := getTestAlertState(`10`)
+	for str, known := range map[string]bool{
+		`threads_running>5`:        true,
+		`threads_runing>5`:         false,
+		`variables/read_only==1`:   true, // not collected yet
+		`innodb_trx/trx_count>100`: true, // not every key is in each sample
+	} {
+		alert, _ := ParseAlert(str)
+		if err := alert.CheckKey(state); (err == nil) != known {
+			t.Errorf("%s: unexpected error: %v", str, err)
+		}
+	}
+}
@@ -13,6 +13,9 @@ type defaultCol struct {
 	Type        string `yaml:"type"`
 	Length      int    `yaml:"length"`
 
+	// How to read the values, e.g. what is worrying
+	Guidance string `yaml:"guidance"`
+
 	Sources []loader.SourceName
 }
 
@@ -25,10 +28,12 @@ func (c defaultCol) GetShortHelp() string {
 	return fmt.Sprintf("%s: %s", c.Name, c.Description)
 }
 
-// Detailed help -- by default same as short help
+// Detailed help -- by default the short help and the guidance, if any
 func (c defaultCol) GetDetailedHelp() []string {
-	result := make([]string, 1)
-	result[0] = c.GetShortHelp()
+	result := []string{c.GetShortHelp()}
+	if c.Guidance != `` {
+		result = append(result, `  `+c.Guidance)
+	}
 	return result
 }
 
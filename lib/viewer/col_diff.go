@@ -16,8 +16,8 @@ func (c DiffCol) GetData(sr loader.StateReader) []string {
 	if err != nil {
 		str = FitString(`-`, c.Length)
 	} else {
-		num := c.fitNumber(raw, c.Precision)
-		str = FitString(num, c.Length) // adds padding if needed
+		// Mark values computed across missed intervals or a restart
+		str = c.forKey(c.Key.Key).fitMarkedNumber(raw, stateQuality(sr))
 	}
 	return []string{str}
 }
@@ -40,3 +40,26 @@ func (c DiffCol) getDiff(sr loader.StateReader) (float64, error) {
 	// Return the calculated rate
 	return calculateDiff(cur, prev), nil
 }
+
+// The change of a gauge since the previous sample, negative when it went down where getDiff takes a counter to have been reset
+func getChange(sr loader.StateReader, sk loader.SourceKey) (float64, error) {
+	cur, err := sr.GetCurrent().GetFloat(sk)
+	if err != nil {
+		return 0, err
+	}
+	var prev float64
+	if prevssp := sr.GetPrevious(); prevssp != nil {
+		prev = prevssp.GetF(sk)
+	}
+	return cur - prev, nil
+}
+
+// The SourceKeys this col reads
+func (c DiffCol) getKeys() []loader.SourceKey {
+	return []loader.SourceKey{c.Key}
+}
+
+// A list of sources that this col requires
+func (c DiffCol) GetSources() ([]loader.SourceName, error) {
+	return sourcesOf(c.getKeys()...), nil
+}
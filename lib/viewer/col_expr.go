@@ -0,0 +1,66 @@
+package viewer
+
+import (
+	"fmt"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// A col computed from an arithmetic expression over metrics, e.g. `(com_commit+com_rollback)/questions*100`, see exprNode for the syntax
+type ExprCol struct {
+	colNum `yaml:",inline"`
+	Expr   string `yaml:"expr"`
+
+	// Parsed from Expr when the col is loaded
+	expr exprNode
+}
+
+// A col of the given expression, like those of a view's yaml but with default units, a length to fit its name and signed, e.g. for -col
+func NewExprCol(name, expr string) (ExprCol, error) {
+	c := ExprCol{Expr: expr}
+	c.Name = name
+	c.Description = expr
+	c.Type = `Expr`
+	c.Length = max(len(name), 6)
+	c.Signed = true
+	return c, c.parse()
+}
+
+func (c *ExprCol) parse() (err error) {
+	c.expr, err = parseExpr(c.Expr)
+	return
+}
+
+// Data for this view based on the state
+func (c ExprCol) GetData(sr loader.StateReader) []string {
+	var str string
+	raw, err := c.getValue(sr)
+	if err != nil {
+		str = FitString(`-`, c.Length)
+	} else {
+		// Mark values computed across missed intervals or a restart
+		str = c.fitMarkedNumber(raw, stateQuality(sr))
+	}
+	return []string{str}
+}
+
+// Evaluates the expression for the given StateReader, returns an error if a metric is missing or it divides by zero
+func (c ExprCol) getValue(sr loader.StateReader) (float64, error) {
+	if c.expr == nil {
+		return 0, fmt.Errorf(`unparsed expression: %s`, c.Name)
+	}
+	return c.expr.eval(sr)
+}
+
+// The SourceKeys this col reads
+func (c ExprCol) getKeys() []loader.SourceKey {
+	if c.expr == nil {
+		return nil
+	}
+	return c.expr.keys()
+}
+
+// A list of sources that this col requires
+func (c ExprCol) GetSources() ([]loader.SourceName, error) {
+	return sourcesOf(c.getKeys()...), nil
+}
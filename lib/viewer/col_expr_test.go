@@ -0,0 +1,105 @@
+package viewer
+
+import (
+	"testing"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+	"gopkg.in/yaml.v3"
+)
+
+func getTestExprCol() ExprCol {
+	c, err := NewExprCol(`trx%`, `(com_commit+com_rollback)/questions*100`)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func TestExprColImplementsViewer(t *testing.T) {
+	var _ Viewer = getTestExprCol()
+}
+
+func TestExprColParse(t *testing.T) {
+	yaml_str := `---
+- name: trx%
+  description: Transactions of all queries
+  type: Expr
+  expr: (com_commit+com_rollback)/questions*100
+  units: Percent
+  length: 4
+`
+	var cols ViewerList
+	if err := yaml.Unmarshal([]byte(yaml_str), &cols); err != nil {
+		t.Fatal(err)
+	}
+	if len(cols) != 1 {
+		t.Fatalf("not enough cols parsed: %d", len(cols))
+	}
+	col, ok := cols[0].(ExprCol)
+	if !ok || col.expr == nil || col.Units != PERCENT {
+		t.Fatalf("unexpected col: %+v", cols[0])
+	}
+	if sources, _ := col.GetSources(); len(sources) != 1 || sources[0] != `status` {
+		t.Errorf("unexpected sources: %v", sources)
+	}
+
+	// Bad expressions are found when the view is loaded
+	if err := yaml.Unmarshal([]byte(`[{name: x, type: Expr, expr: "questions*"}]`), &cols); err == nil {
+		t.Error(`expected an error for a bad expression`)
+	}
+}
+
+func getTestExprState() loader.StateReader {
+	sp := loader.NewState()
+
+	cursamp := loader.NewSample()
+	cursamp.Data[`com_commit`] = `30`
+	cursamp.Data[`com_rollback`] = `10`
+	cursamp.Data[`questions`] = `200`
+	cursamp.Data[`threads_connected`] = `5`
+	sp.GetCurrentWriter().SetSample(`status`, cursamp)
+	sp.GetCurrentWriter().SetUptime(12)
+
+	prevss := loader.NewSampleSet()
+	prevsamp := loader.NewSample()
+	prevsamp.Data[`com_commit`] = `10`
+	prevsamp.Data[`com_rollback`] = `10`
+	prevsamp.Data[`questions`] = `100`
+	prevsamp.Data[`threads_connected`] = `8`
+	prevss.SetSample(`status`, prevsamp)
+	prevss.SetUptime(10)
+	sp.SetPrevious(prevss)
+
+	return sp
+}
+
+func TestExprColgetValue(t *testing.T) {
+	state := getTestExprState()
+
+	col := getTestExprCol()
+	value, err := col.getValue(state)
+	if err != nil {
+		t.Error(err)
+	}
+	if value != 20 {
+		t.Errorf(`unexpected value: %f`, value)
+	}
+	if data := col.GetData(state); data[0] != `    20` {
+		t.Errorf(`unexpected data: '%s'`, data)
+	}
+
+	// Over the interval
+	col, _ = NewExprCol(`trx%`, `diff(com_commit)/rate(questions)`)
+	if value, _ := col.getValue(state); value != 0.4 {
+		t.Errorf(`unexpected value: %f`, value)
+	}
+
+	// Missing metrics
+	col, _ = NewExprCol(`x`, `com_select+1`)
+	if _, err := col.getValue(state); err == nil {
+		t.Error(`expected an error for a missing metric`)
+	}
+	if data := col.GetData(state); data[0] != `     -` {
+		t.Errorf(`unexpected data: '%s'`, data)
+	}
+}
@@ -1,12 +1,17 @@
 package viewer
 
 import (
+	"time"
+
 	"github.com/jayjanssen/myq-tools/lib/loader"
 )
 
 type GaugeCol struct {
 	colNum `yaml:",inline"`
 	Key    loader.SourceKey `yaml:"key"`
+
+	// Mark the value when its Source was sampled longer ago than this (i.e., it is collected at a slower cadence)
+	Stale time.Duration `yaml:"stale"`
 }
 
 // Data for this view based on the state
@@ -14,16 +19,42 @@ func (c GaugeCol) GetData(sr loader.StateReader) []string {
 	// get cur, or else return an error
 	currssp := sr.GetCurrent()
 
-	var str string
+	// Mark the value if it is stale or repeated
+	var q Quality
+	if c.isStale(currssp) {
+		q = STALE
+	}
+	if sr.IsRepeated() {
+		q |= REPEAT
+	}
 
 	// Try parsing a float first, then a string, else report `-`
 	if val, err := currssp.GetFloat(c.Key); err == nil {
-		str = c.fitNumber(val, c.Precision)
+		return []string{c.forKey(c.Key.Key).fitMarkedNumber(val, q)}
 	} else if val, err := currssp.GetString(c.Key); err == nil {
-		str = val
-	} else {
-		str = `-`
+		return []string{fitMarked(val, c.Length, q)}
 	}
+	return []string{fitMarked(`-`, c.Length, q)}
+}
+
+// Is the Source sample older than our Stale setting?
+func (c GaugeCol) isStale(ssr loader.SampleSetReader) bool {
+	if c.Stale == 0 {
+		return false
+	}
+	sourceTime, err := ssr.GetSourceTime(c.Key.SourceName)
+	if err != nil {
+		return false
+	}
+	return ssr.GetTimeGenerated().Sub(sourceTime) > c.Stale
+}
+
+// The SourceKeys this col reads
+func (c GaugeCol) getKeys() []loader.SourceKey {
+	return []loader.SourceKey{c.Key}
+}
 
-	return []string{FitString(str, c.Length)}
+// A list of sources that this col requires
+func (c GaugeCol) GetSources() ([]loader.SourceName, error) {
+	return sourcesOf(c.getKeys()...), nil
 }
@@ -3,6 +3,7 @@ package viewer
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/jayjanssen/myq-tools/lib/loader"
 	"gopkg.in/yaml.v3"
@@ -114,3 +115,27 @@ func TestGaugeColGetData(t *testing.T) {
 	}
 
 }
+
+func TestGaugeColStale(t *testing.T) {
+	col := getTestGaugeCol()
+	col.Key = loader.SourceKey{SourceName: "aws.rds", Key: "burstbalance"}
+	col.Stale = 2 * time.Minute
+
+	sp := loader.NewState()
+	sample := loader.NewSample()
+	sample.Data[`burstbalance`] = `98`
+	sp.GetCurrentWriter().SetSample(`aws.rds`, sample)
+
+	// Fresh sample
+	outputs := col.GetData(sp)
+	if outputs[0] != `  98` {
+		t.Errorf(`unexpected GetData(): '%s'`, outputs[0])
+	}
+
+	// Old sample gets the marker
+	sample.Timestamp = sp.Current.Timestamp.Add(-3 * time.Minute)
+	outputs = col.GetData(sp)
+	if outputs[0] != ` 98*` {
+		t.Errorf(`unexpected GetData(): '%s'`, outputs[0])
+	}
+}
@@ -10,12 +10,76 @@ import (
 type GroupCol struct {
 	defaultCol `yaml:",inline"`
 	Cols       ViewerList `yaml:"cols"`
+
+	// Only show this Group when all of these Sources are being collected
+	Requires []loader.SourceName `yaml:"requires"`
+
+	// Hide this Group on MySQL servers of this version or newer, e.g. the feature was removed.  MariaDB versions are numbered differently, it is not hidden there.
+	Until loader.ServerVersion `yaml:"until"`
+
+	// Only show this Group on servers of this flavor, e.g. for MariaDB-specific metrics.  Servers of an unknown version are MySQL.
+	Flavor loader.ServerFlavor `yaml:"flavor"`
+
+	// Extra detail most don't need, only shown with SetExtended (-verbose)
+	Extended bool `yaml:"extended"`
+}
+
+// Show the Extended Groups, off by default.  Their Sources are then collected too.
+var extendedEnabled bool
+
+func SetExtended(on bool) {
+	extendedEnabled = on
+}
+
+// Is the Group shown, Extended Groups only when they are enabled?
+func (gc GroupCol) isShown() bool {
+	return !gc.Extended || extendedEnabled
+}
+
+// The server version is in the variables Source
+var versionKey = loader.SourceKey{SourceName: `variables`, Key: `version`}
+
+// Are we shown, are all our required Sources in the current state, and is the server version not too new?
+func (gc GroupCol) isAvailable(sr loader.StateReader) bool {
+	if !gc.isShown() {
+		return false
+	}
+	for _, source := range gc.Requires {
+		if !sr.GetCurrent().HasSource(source) {
+			return false
+		}
+	}
+	return gc.checkVersion(sr) == nil
+}
+
+// Error if the server is not of this Group's flavor, or its version (when known) is too new for this Group
+func (gc GroupCol) checkVersion(sr loader.StateReader) error {
+	if gc.Until.IsZero() && gc.Flavor == `` {
+		return nil
+	}
+	str := sr.GetCurrent().GetStr(versionKey)
+	flavor := loader.ParseServerFlavor(str)
+	if gc.Flavor != `` && gc.Flavor != flavor {
+		return fmt.Errorf("%s is only available on %s, not %s", gc.Name, gc.Flavor, flavor)
+	}
+	if gc.Until.IsZero() || flavor != loader.MYSQL_FLAVOR {
+		return nil
+	}
+	version, err := loader.ParseServerVersion(str)
+	if err != nil || !version.AtLeast(gc.Until) {
+		return nil
+	}
+	return fmt.Errorf("%s is not available on MySQL %s (removed in %s)", gc.Name, version, gc.Until)
 }
 
 // Get help for this view
 func (gc GroupCol) GetDetailedHelp() (output []string) {
 	// Gather and indent the lines
-	output = append(output, gc.GetShortHelp())
+	if gc.Extended {
+		output = append(output, gc.GetShortHelp()+` (-verbose)`)
+	} else {
+		output = append(output, gc.GetShortHelp())
+	}
 	for _, col := range gc.Cols {
 		for _, line := range col.GetDetailedHelp() {
 			output = append(output, fmt.Sprintf("   %s", line))
@@ -46,3 +110,12 @@ func (gc GroupCol) GetData(sr loader.StateReader) []string {
 	}
 	return pushColOutputUp(gc.Cols, getColOut)
 }
+
+// A list of sources that this group requires, the version is needed to check Until and Flavor
+func (gc GroupCol) GetSources() ([]loader.SourceName, error) {
+	sources, err := collectSources(gc.Cols)
+	if err == nil && (!gc.Until.IsZero() || gc.Flavor != ``) {
+		sources = appendSources(sources, versionKey.SourceName)
+	}
+	return sources, err
+}
@@ -1,6 +1,7 @@
 package viewer
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/jayjanssen/myq-tools/lib/loader"
@@ -76,3 +77,68 @@ func TestGroupColGetData(t *testing.T) {
 		t.Errorf(`unexpected GetData output: '%s'`, lines[0])
 	}
 }
+
+func TestGroupColRequires(t *testing.T) {
+	gc := getTestGroupCol()
+	sr := getTestGroupState()
+
+	if !gc.isAvailable(sr) {
+		t.Error(`group without requirements not available`)
+	}
+
+	gc.Requires = []loader.SourceName{`status`}
+	if !gc.isAvailable(sr) {
+		t.Error(`group requiring status not available`)
+	}
+
+	gc.Requires = []loader.SourceName{`status`, `aws.rds`}
+	if gc.isAvailable(sr) {
+		t.Error(`group requiring aws.rds is available`)
+	}
+}
+
+func TestGroupColFlavor(t *testing.T) {
+	gc := getTestGroupCol()
+	gc.Flavor = loader.MARIADB_FLAVOR
+
+	for version, available := range map[string]bool{`10.11.6-MariaDB-1`: true, `8.0.35`: false, ``: false} {
+		sr := getTestGroupState()
+		variables := loader.NewSample()
+		variables.Data[`version`] = version
+		sr.(*loader.State).GetCurrentWriter().SetSample(`variables`, variables)
+		if gc.isAvailable(sr) != available {
+			t.Errorf("%s: unexpected availability", version)
+		}
+	}
+
+	sources, _ := gc.GetSources()
+	if !slices.Contains(sources, `variables`) {
+		t.Errorf("unexpected sources: %v", sources)
+	}
+}
+
+func TestGroupColExtended(t *testing.T) {
+	gc := getTestGroupCol()
+	gc.Extended = true
+	sr := getTestGroupState()
+	defer SetExtended(false)
+
+	if gc.isAvailable(sr) {
+		t.Error(`extended group available without SetExtended`)
+	}
+	view := View{Groups: []GroupCol{gc}}
+	if sources, _ := view.GetSources(); len(sources) != 0 {
+		t.Errorf("unexpected sources: %v", sources)
+	}
+	if help := gc.GetDetailedHelp(); help[0] != `Connects: Connection related metrics (-verbose)` {
+		t.Errorf("unexpected help: %s", help[0])
+	}
+
+	SetExtended(true)
+	if !gc.isAvailable(sr) {
+		t.Error(`extended group not available with SetExtended`)
+	}
+	if sources, _ := view.GetSources(); !slices.Contains(sources, `status`) {
+		t.Errorf("unexpected sources: %v", sources)
+	}
+}
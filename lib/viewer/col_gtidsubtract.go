@@ -0,0 +1,51 @@
+package viewer
+
+import (
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// The number of transactions in one GTID set that are not in another, e.g. retrieved but not yet executed by a replica
+type GtidSubtractCol struct {
+	colNum  `yaml:",inline"`
+	Bigger  loader.SourceKey `yaml:"bigger"`
+	Smaller loader.SourceKey `yaml:"smaller"`
+}
+
+// Data for this view based on the state
+func (c GtidSubtractCol) GetData(sr loader.StateReader) []string {
+	var str string
+	raw, err := c.getSubtract(sr)
+	if err != nil {
+		str = FitString(`-`, c.Length)
+	} else {
+		str = c.fitMarkedNumber(raw, 0)
+	}
+	return []string{str}
+}
+
+// Counts the transactions in Bigger that are not in Smaller, returns an error if there's a data problem.
+func (c GtidSubtractCol) getSubtract(sr loader.StateReader) (float64, error) {
+	currssp := sr.GetCurrent()
+	sets := make([]loader.GtidSet, 2)
+	for i, sk := range []loader.SourceKey{c.Bigger, c.Smaller} {
+		str, err := currssp.GetString(sk)
+		if err != nil {
+			return 0, err
+		}
+		if sets[i], err = loader.ParseGtidSet(str); err != nil {
+			return 0, err
+		}
+	}
+
+	return float64(sets[0].Subtract(sets[1]).Count()), nil
+}
+
+// The SourceKeys this col reads
+func (c GtidSubtractCol) getKeys() []loader.SourceKey {
+	return []loader.SourceKey{c.Bigger, c.Smaller}
+}
+
+// A list of sources that this col requires
+func (c GtidSubtractCol) GetSources() ([]loader.SourceName, error) {
+	return sourcesOf(c.getKeys()...), nil
+}
@@ -0,0 +1,70 @@
+package viewer
+
+import (
+	"testing"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+	"gopkg.in/yaml.v3"
+)
+
+func getTestGtidSubtractCol() GtidSubtractCol {
+	col := GtidSubtractCol{}
+	col.Name = "bklg"
+	col.Description = "Retrieved but not executed"
+	col.Type = "GtidSubtract"
+	col.Bigger = loader.SourceKey{SourceName: "replica", Key: "retrieved_gtid_set"}
+	col.Smaller = loader.SourceKey{SourceName: "replica", Key: "executed_gtid_set"}
+	col.Units = NUMBER
+	col.Length = 5
+	col.Precision = 0
+
+	return col
+}
+
+func TestGtidSubtractColImplementsViewer(t *testing.T) {
+	var _ Viewer = getTestGtidSubtractCol()
+}
+
+func TestGtidSubtractColParse(t *testing.T) {
+	yaml_str := `---
+- name: bklg
+  type: GtidSubtract
+  bigger: replica/retrieved_gtid_set
+  smaller: replica/executed_gtid_set
+  length: 5
+`
+	var cols ViewerList
+	if err := yaml.Unmarshal([]byte(yaml_str), &cols); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cols[0].(GtidSubtractCol); !ok || len(cols) != 1 {
+		t.Errorf("unexpected cols: %+v", cols)
+	}
+}
+
+// Create a state reader to test with
+func getTestGtidSubtractState(retrieved, executed string) loader.StateReader {
+	sp := loader.NewState()
+	cursamp := loader.NewSample()
+	sp.GetCurrentWriter().SetSample(`replica`, cursamp)
+
+	cursamp.Data[`retrieved_gtid_set`] = retrieved
+	cursamp.Data[`executed_gtid_set`] = executed
+
+	return sp
+}
+
+func TestGtidSubtractColGetData(t *testing.T) {
+	col := getTestGtidSubtractCol()
+
+	state := getTestGtidSubtractState("a1:1-1500,\nb2:1-10", "a1:1-1000,\nb2:1-10,\nc3:1-5")
+	lines := col.GetData(state)
+	if len(lines) != 1 || lines[0] != `  500` {
+		t.Errorf("unexpected output: %q", lines)
+	}
+
+	state = getTestGtidSubtractState(`a1:1-5`, `a1:1-x`)
+	if lines := col.GetData(state); lines[0] != `    -` {
+		t.Errorf("unexpected output for a bad set: %q", lines)
+	}
+}
@@ -2,18 +2,23 @@ package viewer
 
 import (
 	"fmt"
-	"sort"
 	"strings"
-	"unicode/utf8"
 
 	"gopkg.in/yaml.v3"
 )
 
 // A colNum is an abstract object that contains Units and Precision values.  Implementation of those are left to the "subclasses"
 type colNum struct {
-	defaultCol `yaml:",inline"`
-	Units      UnitsType `yaml:"units"`
-	Precision  int       `yaml:"precision"`
+	defaultCol  `yaml:",inline"`
+	colorLevels `yaml:",inline"`
+	Units       UnitsType `yaml:"units"`
+	Precision   int       `yaml:"precision"`
+
+	// Values can be negative (e.g. the change of a gauge), show them with a sign rather than as not fitting
+	Signed bool `yaml:"signed"`
+
+	// How the values are fit into the length instead of the one of -units, e.g. raw for a count that is better read whole
+	Format NumberFormat `yaml:"format"`
 }
 
 // The type of numeric value
@@ -26,6 +31,9 @@ const (
 	MICROSECOND
 	NANOSECOND
 	PERCENT
+	MILLISECOND
+	// Infer the units from the metric name
+	AUTO
 )
 
 // Units Definitions allow us to collapse numbers and use a postfix instead
@@ -68,6 +76,12 @@ var unitsLookup = map[UnitsType]UnitsDef{
 	PERCENT: {
 		1: `%`,
 	},
+	MILLISECOND: {
+		1000000: `ks`,
+		1000:    `s`,
+		1:       `ms`,
+		0.001:   `µs`,
+	},
 }
 
 // Convert UnitTypes in yaml string form to our internal const representation
@@ -85,75 +99,45 @@ func (ut *UnitsType) UnmarshalYAML(value *yaml.Node) error {
 		*ut = NANOSECOND
 	case `Percent`:
 		*ut = PERCENT
+	case `Millisecond`:
+		*ut = MILLISECOND
+	case `Auto`:
+		*ut = AUTO
 	default:
 		return fmt.Errorf("invalid UnitType: %s", value.Value)
 	}
 	return nil
 }
 
-// Given the value, fit it into our Precision, Length, and Units
-// callers should pass the Col.Precision value as the second argument
-func (nc colNum) fitNumber(value float64, precision int) string {
-	// Get the units we will be using
-	units := unitsLookup[nc.Units]
-
-	// Load the factors from the given unit and sort them
-	var factors []float64
-	for k := range units {
-		factors = append(factors, k)
+// The help of the col, with its warn and crit levels
+func (nc colNum) GetDetailedHelp() []string {
+	help := nc.defaultCol.GetDetailedHelp()
+	if levels := nc.describe(); levels != `` {
+		help = append(help, `  `+levels)
 	}
-	sort.Float64s(factors)
-
-	// Starting from the smallest to the biggest factors
-	for _, factor := range factors {
-		unit := units[factor]
-		raw := value / factor
-		str := fmt.Sprintf(`%.*f%s`, precision, raw, unit)
-		left := nc.Length - utf8.RuneCountInString(str)
-
-		// fmt.Printf("%f, %d, %d, %s, %f, %s, %d\n", value, nc.Length, nc.Precision, unit, raw, str, left)
+	return help
+}
 
-		if raw >= 0 && (nc.Length+precision)-utf8.RuneCountInString(str) >= 0 {
-			// Our number is > 0 and fits into nc.Length + precision
-			if left < 0 {
-				if precision > 0 {
-					// No space left, try to chop the precision
-					return nc.fitNumber(value, precision-1)
-				} else {
-					// Nothing to chop, any bigger factors will be too wide, so return here.
-					return str
-				}
-			} else if left > 1 && factor != 1 {
-				// If we have space for some extra precision, use it
-				return fmt.Sprintf(`%.*f%s`, left-1, raw, unit)
-			} else {
-				if factor != 1 && raw < 1 && left > 0 && fmt.Sprintf(`%.1f`, raw) != `1.0` {
-					// Raw is < 1, therefore str is rounded up.  Let's print a decimal instead
-					return fmt.Sprintf(`%0.*f%s`, precision+left, raw, unit)[1:]
-				} else if factor != 1 && str == fmt.Sprintf("0%s", unit) {
-					if left > 0 {
-						// There's still some space left to print something intelligent
-						return fmt.Sprintf(`%.*f%s`, precision+1, raw, unit)[1:]
-					}
+// Resolve AUTO units for the given metric key, other units are left alone
+func (nc colNum) forKey(key string) colNum {
+	if nc.Units == AUTO {
+		nc.Units = inferUnits(key)
+	}
+	return nc
+}
 
-					// if we are returning 0m, 0k, etc, then we can't fit this number into the size given
-					return strings.Repeat(`#`, nc.Length)
-				} else {
-					// Just return what we've got
-					return str
-				}
-			}
+// Given the value, fit it into our Precision, Length, Units and Format
+// callers should pass the Col.Precision value as the second argument
+func (nc colNum) fitNumber(value float64, precision int) string {
+	// Negative numbers of Signed cols are fit in one char less, after the sign
+	if value < 0 && nc.Signed {
+		unsigned := nc
+		unsigned.Length -= 1
+		if str := unsigned.fitNumber(-value, precision); !strings.HasPrefix(str, `#`) {
+			return `-` + str
 		}
+		return strings.Repeat(`#`, nc.Length)
 	}
 
-	// We're past the highest factor and nothing fits
-	str := fmt.Sprintf(`%.*f`, precision, value)
-	if len(str) > nc.Length && precision > 0 {
-		// We can try chopping precision here for a fit
-		return nc.fitNumber(value, precision-1)
-	} else {
-		// Just print it (too wide)
-		// return str
-		return strings.Repeat(`#`, int(nc.Length))
-	}
+	return nc.formatter().fit(nc, value, precision)
 }
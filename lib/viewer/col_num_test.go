@@ -82,3 +82,24 @@ func TestNumbers(t *testing.T) {
 	assert(`zero en ess`, `0ns`, NANOSECOND, 0.000000, 0, 5)
 
 }
+
+func TestSignedNumbers(t *testing.T) {
+	assert := func(test_name, expected string, units UnitsType, val float64, width int) {
+		col := getTestcolNum(units, 0, width)
+		col.Signed = true
+		if str := col.fitNumber(val, col.Precision); str != expected {
+			t.Errorf("%s err: `%s` != `%s`", test_name, str, expected)
+		}
+	}
+
+	assert(`minus one`, `-1`, NUMBER, -1, 3)
+	assert(`minus twelve em`, `-12M`, MEMORY, -12300000, 5)
+	assert(`minus point five kay`, `-.5K`, MEMORY, -500, 4)
+	assert(`positive as usual`, `11.7M`, MEMORY, 12300000, 5)
+	assert(`cant fit -500 into two`, `##`, NUMBER, -500, 2)
+
+	// Without Signed, negative values don't fit
+	if str := getTestcolNum(NUMBER, 0, 3).fitNumber(-1, 0); str != `###` {
+		t.Errorf("unsigned negative: `%s`", str)
+	}
+}
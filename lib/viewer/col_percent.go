@@ -17,8 +17,7 @@ func (c PercentCol) GetData(sr loader.StateReader) []string {
 	if err != nil {
 		str = FitString(`-`, c.Length)
 	} else {
-		num := c.fitNumber(raw, c.Precision)
-		str = FitString(num, c.Length) // adds padding if needed
+		str = c.fitMarkedNumber(raw, 0)
 	}
 	return []string{str}
 }
@@ -39,3 +38,13 @@ func (c PercentCol) getPercent(sr loader.StateReader) (float64, error) {
 	// Return the calculated rate
 	return (numerator / denominator) * 100, nil
 }
+
+// The SourceKeys this col reads
+func (c PercentCol) getKeys() []loader.SourceKey {
+	return []loader.SourceKey{c.Numerator, c.Denominator}
+}
+
+// A list of sources that this col requires
+func (c PercentCol) GetSources() ([]loader.SourceName, error) {
+	return sourcesOf(c.getKeys()...), nil
+}
@@ -16,8 +16,8 @@ func (c RateCol) GetData(sr loader.StateReader) []string {
 	if err != nil {
 		str = FitString(`-`, c.Length)
 	} else {
-		num := c.fitNumber(raw, c.Precision)
-		str = FitString(num, c.Length) // adds padding if needed
+		// Mark values computed across missed intervals or a restart
+		str = c.forKey(c.Key.Key).fitMarkedNumber(raw, stateQuality(sr))
 	}
 	return []string{str}
 }
@@ -38,5 +38,15 @@ func (c RateCol) getRate(sr loader.StateReader) (float64, error) {
 	}
 
 	// Return the calculated rate
-	return calculateRate(cur, prev, sr.SecondsDiff()), nil
+	return calculateRate(cur, prev, sr.SourceSecondsDiff(c.Key.SourceName)), nil
+}
+
+// The SourceKeys this col reads
+func (c RateCol) getKeys() []loader.SourceKey {
+	return []loader.SourceKey{c.Key}
+}
+
+// A list of sources that this col requires
+func (c RateCol) GetSources() ([]loader.SourceName, error) {
+	return sourcesOf(c.getKeys()...), nil
 }
@@ -3,6 +3,7 @@ package viewer
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/jayjanssen/myq-tools/lib/loader"
 	"gopkg.in/yaml.v3"
@@ -191,3 +192,34 @@ func TestRateColgetRateNullPrev(t *testing.T) {
 	}
 
 }
+
+// A Source skipped in an interval (e.g. under a budget) has no rate then, and the rate after spans the time since its last Sample
+func TestRateColAfterSkip(t *testing.T) {
+	col := getTestRateCol()
+	start := time.Now()
+	setAt := func(offset time.Duration, connections string) *loader.SampleSet {
+		ss := loader.NewSampleSet()
+		ss.Timestamp = start.Add(offset)
+		if connections != `` {
+			sample := loader.NewSample()
+			sample.Timestamp = ss.Timestamp
+			sample.Data[`connections`] = connections
+			ss.SetSample(`status`, sample)
+		}
+		return ss
+	}
+	first, skipped, collected := setAt(0, `100`), setAt(time.Second, ``), setAt(2*time.Second, `300`)
+
+	state := &loader.State{Live: true, Current: skipped, Previous: first}
+	if outputs := col.GetData(state); outputs[0] != `   -` {
+		t.Errorf(`unexpected rate while skipped: '%s'`, outputs[0])
+	}
+
+	// The loader fills in the Previous with the last Sample collected
+	previous := *skipped
+	previous.Samples = map[loader.SourceName]loader.SampleReader{`status`: first.Samples[`status`]}
+	state = &loader.State{Live: true, Current: collected, Previous: &previous}
+	if rate, err := col.getRate(state); err != nil || rate != 100 {
+		t.Errorf(`unexpected rate after the skip: %f %v`, rate, err)
+	}
+}
@@ -0,0 +1,76 @@
+package viewer
+
+import (
+	"fmt"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// The percentage one rate is of another over the interval, e.g. cache hits of hits + misses.  Both sides are sums of counters.
+type RatePercentCol struct {
+	colNum      `yaml:",inline"`
+	Numerator   []loader.SourceKey `yaml:"numerator"`
+	Denominator []loader.SourceKey `yaml:"denominator"`
+}
+
+// Data for this view based on the state
+func (c RatePercentCol) GetData(sr loader.StateReader) []string {
+	var str string
+	raw, err := c.getPercent(sr)
+	if err != nil {
+		str = FitString(`-`, c.Length)
+	} else {
+		// Mark values computed across missed intervals or a restart
+		str = c.fitMarkedNumber(raw, stateQuality(sr))
+	}
+	return []string{str}
+}
+
+// The sum of the counters in a SampleSet, an error if any is missing
+func sumCounters(ssp loader.SampleSetReader, keys []loader.SourceKey) (float64, error) {
+	var total float64
+	for _, key := range keys {
+		value, err := ssp.GetFloat(key)
+		if err != nil {
+			return 0, err
+		}
+		total += value
+	}
+	return total, nil
+}
+
+// Calculates the percentage for the given StateReader, returns an error if there's a data problem or nothing happened in the denominator
+func (c RatePercentCol) getPercent(sr loader.StateReader) (float64, error) {
+	curNumerator, err := sumCounters(sr.GetCurrent(), c.Numerator)
+	if err != nil {
+		return 0, err
+	}
+	curDenominator, err := sumCounters(sr.GetCurrent(), c.Denominator)
+	if err != nil {
+		return 0, err
+	}
+
+	// Without a previous SampleSet, the percentage is since the server started
+	var prevNumerator, prevDenominator float64
+	if prevssp := sr.GetPrevious(); prevssp != nil {
+		prevNumerator, _ = sumCounters(prevssp, c.Numerator)
+		prevDenominator, _ = sumCounters(prevssp, c.Denominator)
+	}
+
+	numerator := calculateDiff(curNumerator, prevNumerator)
+	denominator := calculateDiff(curDenominator, prevDenominator)
+	if denominator == 0 {
+		return 0, fmt.Errorf(`no change in the denominator: %s`, c.Name)
+	}
+	return (numerator / denominator) * 100, nil
+}
+
+// The SourceKeys this col reads
+func (c RatePercentCol) getKeys() []loader.SourceKey {
+	return append(append([]loader.SourceKey{}, c.Numerator...), c.Denominator...)
+}
+
+// A list of sources that this col requires
+func (c RatePercentCol) GetSources() ([]loader.SourceName, error) {
+	return sourcesOf(c.getKeys()...), nil
+}
@@ -0,0 +1,106 @@
+package viewer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+	"gopkg.in/yaml.v3"
+)
+
+func getTestRatePercentCol() RatePercentCol {
+	rc := RatePercentCol{}
+	rc.Name = "hit%"
+	rc.Description = "Table open cache hit percentage"
+	rc.Type = "RatePercent"
+	rc.Numerator = []loader.SourceKey{{SourceName: "status", Key: "table_open_cache_hits"}}
+	rc.Denominator = []loader.SourceKey{
+		{SourceName: "status", Key: "table_open_cache_hits"},
+		{SourceName: "status", Key: "table_open_cache_misses"},
+	}
+	rc.Length = 4
+	rc.Units = PERCENT
+	rc.Precision = 0
+
+	return rc
+}
+
+func TestRatePercentColImplementsViewer(t *testing.T) {
+	var _ Viewer = getTestRatePercentCol()
+}
+
+func TestRatePercentColParse(t *testing.T) {
+	yaml_str := `---
+- name: hit%
+  description: Table open cache hit percentage
+  type: RatePercent
+  numerator:
+    - status/table_open_cache_hits
+  denominator:
+    - status/table_open_cache_hits
+    - status/table_open_cache_misses
+  units: Percent
+  length: 4
+  precision: 0
+`
+	var cols ViewerList
+	if err := yaml.Unmarshal([]byte(yaml_str), &cols); err != nil {
+		t.Fatal(err)
+	}
+	if len(cols) != 1 {
+		t.Fatalf("not enough cols parsed: %d", len(cols))
+	}
+	if rc := getTestRatePercentCol(); !reflect.DeepEqual(rc, cols[0]) {
+		t.Errorf("cols not matching: %+v, %+v", rc, cols[0])
+	}
+}
+
+func getTestRatePercentState(prevHits, prevMisses, curHits, curMisses string) loader.StateReader {
+	sp := loader.NewState()
+
+	cursamp := loader.NewSample()
+	cursamp.Data[`table_open_cache_hits`] = curHits
+	cursamp.Data[`table_open_cache_misses`] = curMisses
+	sp.GetCurrentWriter().SetSample(`status`, cursamp)
+
+	prevss := loader.NewSampleSet()
+	prevsamp := loader.NewSample()
+	prevsamp.Data[`table_open_cache_hits`] = prevHits
+	prevsamp.Data[`table_open_cache_misses`] = prevMisses
+	prevss.SetSample(`status`, prevsamp)
+	sp.SetPrevious(prevss)
+
+	return sp
+}
+
+func TestRatePercentColgetPercent(t *testing.T) {
+	col := getTestRatePercentCol()
+
+	// 90 hits and 10 misses over the interval, whatever happened before
+	state := getTestRatePercentState(`1000`, `1000`, `1090`, `1010`)
+	percent, err := col.getPercent(state)
+	if err != nil {
+		t.Error(err)
+	}
+	if percent != 90 {
+		t.Errorf(`unexpected percent: %f`, percent)
+	}
+	if data := col.GetData(state); data[0] != ` 90%` {
+		t.Errorf(`unexpected data: '%s'`, data)
+	}
+
+	// Nothing happened
+	state = getTestRatePercentState(`1000`, `1000`, `1000`, `1000`)
+	if _, err := col.getPercent(state); err == nil {
+		t.Error(`expected an error without a change in the denominator`)
+	}
+	if data := col.GetData(state); data[0] != `   -` {
+		t.Errorf(`unexpected data: '%s'`, data)
+	}
+
+	// Missing counters
+	state = getTestRatePercentState(`1000`, `1000`, `1090`, ``)
+	if _, err := col.getPercent(state); err == nil {
+		t.Error(`expected an error for a missing counter`)
+	}
+}
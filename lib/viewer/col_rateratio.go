@@ -0,0 +1,63 @@
+package viewer
+
+import (
+	"fmt"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// How much one rate is per unit of another over the interval, e.g. rows read per select.  Both sides are sums of counters.
+type RateRatioCol struct {
+	colNum      `yaml:",inline"`
+	Numerator   []loader.SourceKey `yaml:"numerator"`
+	Denominator []loader.SourceKey `yaml:"denominator"`
+}
+
+// Data for this view based on the state
+func (c RateRatioCol) GetData(sr loader.StateReader) []string {
+	var str string
+	raw, err := c.getRatio(sr)
+	if err != nil {
+		str = FitString(`-`, c.Length)
+	} else {
+		// Mark values computed across missed intervals or a restart
+		str = c.fitMarkedNumber(raw, stateQuality(sr))
+	}
+	return []string{str}
+}
+
+// Calculates the ratio for the given StateReader, returns an error if there's a data problem or nothing happened in the denominator
+func (c RateRatioCol) getRatio(sr loader.StateReader) (float64, error) {
+	curNumerator, err := sumCounters(sr.GetCurrent(), c.Numerator)
+	if err != nil {
+		return 0, err
+	}
+	curDenominator, err := sumCounters(sr.GetCurrent(), c.Denominator)
+	if err != nil {
+		return 0, err
+	}
+
+	// Without a previous SampleSet, the ratio is since the server started
+	var prevNumerator, prevDenominator float64
+	if prevssp := sr.GetPrevious(); prevssp != nil {
+		prevNumerator, _ = sumCounters(prevssp, c.Numerator)
+		prevDenominator, _ = sumCounters(prevssp, c.Denominator)
+	}
+
+	numerator := calculateDiff(curNumerator, prevNumerator)
+	denominator := calculateDiff(curDenominator, prevDenominator)
+	if denominator == 0 {
+		return 0, fmt.Errorf(`no change in the denominator: %s`, c.Name)
+	}
+	return numerator / denominator, nil
+}
+
+// The SourceKeys this col reads
+func (c RateRatioCol) getKeys() []loader.SourceKey {
+	return append(append([]loader.SourceKey{}, c.Numerator...), c.Denominator...)
+}
+
+// A list of sources that this col requires
+func (c RateRatioCol) GetSources() ([]loader.SourceName, error) {
+	return sourcesOf(c.getKeys()...), nil
+}
@@ -0,0 +1,114 @@
+package viewer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+	"gopkg.in/yaml.v3"
+)
+
+func getTestRateRatioCol() RateRatioCol {
+	rc := RateRatioCol{}
+	rc.Name = "tmp"
+	rc.Description = "Temporary tables created per query"
+	rc.Type = "RateRatio"
+	rc.Numerator = []loader.SourceKey{
+		{SourceName: "status", Key: "created_tmp_tables"},
+		{SourceName: "status", Key: "created_tmp_disk_tables"},
+	}
+	rc.Denominator = []loader.SourceKey{{SourceName: "status", Key: "questions"}}
+	rc.Length = 4
+	rc.Units = NUMBER
+	rc.Precision = 2
+
+	return rc
+}
+
+func TestRateRatioColImplementsViewer(t *testing.T) {
+	var _ Viewer = getTestRateRatioCol()
+}
+
+func TestRateRatioColParse(t *testing.T) {
+	yaml_str := `---
+- name: tmp
+  description: Temporary tables created per query
+  type: RateRatio
+  numerator:
+    - status/created_tmp_tables
+    - status/created_tmp_disk_tables
+  denominator:
+    - status/questions
+  units: Number
+  length: 4
+  precision: 2
+`
+	var cols ViewerList
+	if err := yaml.Unmarshal([]byte(yaml_str), &cols); err != nil {
+		t.Fatal(err)
+	}
+	if len(cols) != 1 {
+		t.Fatalf("not enough cols parsed: %d", len(cols))
+	}
+	if rc := getTestRateRatioCol(); !reflect.DeepEqual(rc, cols[0]) {
+		t.Errorf("cols not matching: %+v, %+v", rc, cols[0])
+	}
+}
+
+func getTestRateRatioState(prevTmp, prevQuestions, curTmp, curQuestions string) loader.StateReader {
+	sp := loader.NewState()
+
+	cursamp := loader.NewSample()
+	cursamp.Data[`created_tmp_tables`] = curTmp
+	cursamp.Data[`created_tmp_disk_tables`] = `5`
+	cursamp.Data[`questions`] = curQuestions
+	sp.GetCurrentWriter().SetSample(`status`, cursamp)
+
+	prevss := loader.NewSampleSet()
+	prevsamp := loader.NewSample()
+	prevsamp.Data[`created_tmp_tables`] = prevTmp
+	prevsamp.Data[`created_tmp_disk_tables`] = `5`
+	prevsamp.Data[`questions`] = prevQuestions
+	prevss.SetSample(`status`, prevsamp)
+	sp.SetPrevious(prevss)
+
+	return sp
+}
+
+func TestRateRatioColgetRatio(t *testing.T) {
+	col := getTestRateRatioCol()
+
+	// 25 temporary tables over 100 queries, whatever happened before
+	state := getTestRateRatioState(`1000`, `50000`, `1025`, `50100`)
+	ratio, err := col.getRatio(state)
+	if err != nil {
+		t.Error(err)
+	}
+	if ratio != 0.25 {
+		t.Errorf(`unexpected ratio: %f`, ratio)
+	}
+	if data := col.GetData(state); data[0] != `0.25` {
+		t.Errorf(`unexpected data: '%s'`, data)
+	}
+
+	// More than one per query
+	state = getTestRateRatioState(`1000`, `50000`, `1300`, `50100`)
+	if data := col.GetData(state); data[0] != `3.00` {
+		t.Errorf(`unexpected data: '%s'`, data)
+	}
+
+	// No queries
+	state = getTestRateRatioState(`1000`, `50000`, `1000`, `50000`)
+	if _, err := col.getRatio(state); err == nil {
+		t.Error(`expected an error without a change in the denominator`)
+	}
+	if data := col.GetData(state); data[0] != `   -` {
+		t.Errorf(`unexpected data: '%s'`, data)
+	}
+
+	// Missing counters
+	state = getTestRateRatioState(`1000`, `50000`, `1025`, ``)
+	if _, err := col.getRatio(state); err == nil {
+		t.Error(`expected an error for a missing counter`)
+	}
+}
@@ -18,8 +18,8 @@ func (rsc RateSumCol) GetData(sr loader.StateReader) []string {
 	if err != nil {
 		str = FitString(`-`, rsc.Length)
 	} else {
-		num := rsc.fitNumber(raw, rsc.Precision)
-		str = FitString(num, rsc.Length) // adds padding if needed
+		// Mark values computed across missed intervals or a restart
+		str = rsc.fitMarkedNumber(raw, stateQuality(sr))
 	}
 	return []string{str}
 }
@@ -44,5 +44,15 @@ func (rsc RateSumCol) getRate(sr loader.StateReader) (float64, error) {
 	}
 
 	// Return the calculated rate
-	return calculateRate(curSum, prevSum, sr.SecondsDiff()), nil
+	return calculateRate(curSum, prevSum, sr.SourceSecondsDiff(rsc.expandedKeys[0].SourceName)), nil
+}
+
+// The SourceKeys this col reads
+func (rsc RateSumCol) getKeys() []loader.SourceKey {
+	return rsc.Keys
+}
+
+// A list of sources that this col requires
+func (rsc RateSumCol) GetSources() ([]loader.SourceName, error) {
+	return sourcesOf(rsc.getKeys()...), nil
 }
@@ -1,6 +1,38 @@
 package viewer
 
-import "github.com/jayjanssen/myq-tools/lib/loader"
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// What the time col shows: by default the wall clock when live and the seconds since the first sample of a file
+const (
+	TIMECOL_DEFAULT  = ``
+	TIMECOL_RELATIVE = `relative` // seconds since the first sample
+	TIMECOL_ABSOLUTE = `absolute` // the wall clock when the sample was collected
+	TIMECOL_UPTIME   = `uptime`   // the server's uptime
+)
+
+var (
+	timeColMode string
+
+	// When the first live State was shown, relative times count from it
+	timeColStart time.Time
+	timeColMutex sync.Mutex
+)
+
+// Choose what the time col shows
+func SetTimeCol(mode string) error {
+	switch mode {
+	case TIMECOL_DEFAULT, TIMECOL_RELATIVE, TIMECOL_ABSOLUTE, TIMECOL_UPTIME:
+		timeColMode = mode
+		return nil
+	}
+	return fmt.Errorf("unknown time col `%s`, use %s, %s or %s", mode, TIMECOL_RELATIVE, TIMECOL_ABSOLUTE, TIMECOL_UPTIME)
+}
 
 type SampleTimeCol struct {
 	defaultCol
@@ -14,7 +46,41 @@ func NewSampleTimeCol() SampleTimeCol {
 	return tc
 }
 
-// Asks the StateReader for what time to print
+// The time of the State in the chosen mode
+func (c SampleTimeCol) getTime(sr loader.StateReader) string {
+	switch timeColMode {
+	case TIMECOL_ABSOLUTE:
+		return sr.GetCurrent().GetTimeGenerated().Format(`15:04:05`)
+	case TIMECOL_UPTIME:
+		if uptime, err := sr.GetCurrent().GetInt(uptimeKey); err == nil {
+			return formatTimeColUptime(uptime)
+		}
+		return `-`
+	case TIMECOL_RELATIVE:
+		// A file's samples are timed by their uptime since the first one
+		if !sr.IsLive() {
+			return fmt.Sprintf(`%ds`, sr.GetCurrent().GetUptime())
+		}
+		timeColMutex.Lock()
+		defer timeColMutex.Unlock()
+		generated := sr.GetCurrent().GetTimeGenerated()
+		if timeColStart.IsZero() {
+			timeColStart = generated
+		}
+		return fmt.Sprintf(`%.0fs`, generated.Sub(timeColStart).Seconds())
+	}
+	return sr.GetTimeString()
+}
+
+// Seconds like `04:05:06`, or `3d04:05` past a day, to fit the col
+func formatTimeColUptime(seconds int64) string {
+	if days := seconds / 86400; days > 0 {
+		return fmt.Sprintf("%dd%02d:%02d", days, seconds/3600%24, seconds/60%60)
+	}
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+// The time of the State, as chosen with SetTimeCol
 func (c SampleTimeCol) GetData(sr loader.StateReader) []string {
-	return []string{FitString(sr.GetTimeString(), c.Length)}
+	return []string{FitString(c.getTime(sr), c.Length)}
 }
@@ -2,6 +2,7 @@ package viewer
 
 import (
 	"testing"
+	"time"
 
 	"github.com/jayjanssen/myq-tools/lib/loader"
 )
@@ -45,3 +46,57 @@ func TestTimeColGetData(t *testing.T) {
 		t.Errorf(`got wrong time data: '%s'`, h[0])
 	}
 }
+
+func TestTimeColModes(t *testing.T) {
+	defer SetTimeCol(TIMECOL_DEFAULT)
+	if err := SetTimeCol(`wallclock`); err == nil {
+		t.Error("expected an error for an unknown time col")
+	}
+
+	// A file's State, collected just now
+	state := loader.NewState()
+	status := loader.NewSample()
+	status.Data[`uptime`] = `273906`
+	state.GetCurrentWriter().SetSample(`status`, status)
+	state.GetCurrentWriter().SetUptime(42)
+
+	tc := NewSampleTimeCol()
+	tests := map[string]string{
+		TIMECOL_DEFAULT:  `     42s`,
+		TIMECOL_RELATIVE: `     42s`,
+		TIMECOL_ABSOLUTE: FitString(state.GetCurrent().GetTimeGenerated().Format(`15:04:05`), 8),
+		TIMECOL_UPTIME:   ` 3d04:05`,
+	}
+	for mode, expected := range tests {
+		if err := SetTimeCol(mode); err != nil {
+			t.Fatal(err)
+		}
+		if data := tc.GetData(state); data[0] != expected {
+			t.Errorf("%s: unexpected time: %q", mode, data[0])
+		}
+	}
+
+	if uptime := formatTimeColUptime(3725); uptime != `01:02:05` {
+		t.Errorf("unexpected uptime: %s", uptime)
+	}
+}
+
+func TestTimeColRelativeLive(t *testing.T) {
+	SetTimeCol(TIMECOL_RELATIVE)
+	defer SetTimeCol(TIMECOL_DEFAULT)
+	timeColStart = time.Time{}
+
+	tc := NewSampleTimeCol()
+	first := loader.NewState()
+	first.Live = true
+	second := loader.NewState()
+	second.Live = true
+	second.Current.Timestamp = first.Current.Timestamp.Add(5 * time.Second)
+
+	if data := tc.GetData(first); data[0] != `      0s` {
+		t.Errorf("unexpected first time: %q", data[0])
+	}
+	if data := tc.GetData(second); data[0] != `      5s` {
+		t.Errorf("unexpected second time: %q", data[0])
+	}
+}
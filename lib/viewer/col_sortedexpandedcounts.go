@@ -11,8 +11,33 @@ type SortedExpandedCountsCol struct {
 	colNum       `yaml:",inline"`
 	Keys         []loader.SourceKey `yaml:"keys"`
 	expandedKeys []loader.SourceKey
+
+	// Also show each row's share of the interval total, ignored with Auto units
+	Percent bool `yaml:"percent"`
+
+	// Only show the rows with the most activity, zero for all
+	Limit int `yaml:"limit"`
+
+	// Show a sparkline of each row over this many intervals.  The keys of a row don't share a history, so they aren't grouped by diff.
+	History   int                 `yaml:"history"`
+	histories map[string]*History // by host (role), each has its own
+}
+
+// The History of the State's host, nil without History
+func (secc SortedExpandedCountsCol) hostHistory(sr loader.StateReader) *History {
+	if secc.histories == nil {
+		return nil
+	}
+	role := loader.StateRole(sr)
+	if secc.histories[role] == nil {
+		secc.histories[role] = NewHistory(secc.History)
+	}
+	return secc.histories[role]
 }
 
+// Width of the percent-of-total col
+const PERCENT_LENGTH = 4
+
 func (secc SortedExpandedCountsCol) GetData(sr loader.StateReader) (output []string) {
 	// Calculate expanded Keys once, because it's expensive
 	if len(secc.expandedKeys) == 0 {
@@ -22,11 +47,18 @@ func (secc SortedExpandedCountsCol) GetData(sr loader.StateReader) (output []str
 	if len(secc.expandedKeys) == 0 {
 		return []string{}
 	}
+	history := secc.hostHistory(sr)
 
-	// Go through all the expandedKeys and compute their diffs
+	// Go through all the expandedKeys and compute their diffs.  Keys are grouped by diff, and by units if those are inferred per key
+	type diffUnits struct {
+		diff  float64
+		units UnitsType
+		key   string // only with a History
+	}
 	var total_diff float64
-	var all_diffs []float64
-	diff_variables := map[float64][]string{}
+	var all_diffs []diffUnits
+	diff_variables := map[diffUnits][]string{}
+	diffs := map[string]float64{}
 	for _, sk := range secc.expandedKeys {
 		curr := sr.GetCurrent().GetF(sk)
 		// prev will be 0.0 if there is an error fetching it
@@ -41,29 +73,75 @@ func (secc SortedExpandedCountsCol) GetData(sr loader.StateReader) (output []str
 			continue
 		}
 		total_diff += diff
+		diffs[sk.Key] = diff
 
 		// Create the [] slice for a rate we haven't seen yet
-		if _, ok := diff_variables[diff]; !ok {
-			diff_variables[diff] = make([]string, 0)
-			all_diffs = append(all_diffs, diff) // record the diff the first time
+		du := diffUnits{diff, secc.forKey(sk.Key).Units, ``}
+		if history != nil {
+			du.key = sk.Key
+		}
+		if _, ok := diff_variables[du]; !ok {
+			diff_variables[du] = make([]string, 0)
+			all_diffs = append(all_diffs, du) // record the diff the first time
 		}
 
 		// Push the variable name onto the rate slice
-		diff_variables[diff] = append(diff_variables[diff], sk.Key)
+		diff_variables[du] = append(diff_variables[du], sk.Key)
 	}
 
+	if history != nil {
+		history.Add(sr, diffs)
+	}
+
+	// A total of mixed units is meaningless
+	showPercent := secc.Percent && secc.Units != AUTO
+
 	// output the total diff
-	numStr := FitString(secc.fitNumber(total_diff, 0), secc.Length)
-	line := fmt.Sprintf("%s %v", numStr, "total")
-	output = append(output, line)
+	if secc.Units != AUTO {
+		numStr := fitNumberString(secc.fitNumber(total_diff, 0), secc.Length)
+		if showPercent {
+			numStr += " " + FitString(``, PERCENT_LENGTH)
+		}
+		if history != nil {
+			numStr += " " + FitString(``, secc.History)
+		}
+		line := fmt.Sprintf("%s %v", numStr, "total")
+		output = append(output, line)
+	}
 
 	// Sort all the rates so we can iterate through them from big to small
-	sort.Sort(sort.Reverse(sort.Float64Slice(all_diffs)))
+	sort.SliceStable(all_diffs, func(i, j int) bool {
+		return all_diffs[i].diff > all_diffs[j].diff
+	})
+
+	if secc.Limit > 0 && len(all_diffs) > secc.Limit {
+		all_diffs = all_diffs[:secc.Limit]
+	}
 
-	for _, diff := range all_diffs {
-		numStr := FitString(secc.fitNumber(diff, 0), secc.Length)
-		line := fmt.Sprintf("%s %v", numStr, diff_variables[diff])
+	for _, du := range all_diffs {
+		nc := secc.colNum
+		nc.Units = du.units
+		numStr := fitNumberString(nc.fitNumber(du.diff, 0), secc.Length)
+		if showPercent {
+			// The row is all of its keys
+			share := du.diff * float64(len(diff_variables[du])) / total_diff * 100
+			numStr += " " + FitString(fmt.Sprintf("%.0f%%", share), PERCENT_LENGTH)
+		}
+		if history != nil {
+			numStr += " " + Sparkline(history.Get(du.key), secc.History)
+		}
+		line := fmt.Sprintf("%s %v", numStr, diff_variables[du])
 		output = append(output, line)
 	}
 	return
 }
+
+// The SourceKeys this col reads
+func (secc SortedExpandedCountsCol) getKeys() []loader.SourceKey {
+	return secc.Keys
+}
+
+// A list of sources that this col requires
+func (secc SortedExpandedCountsCol) GetSources() ([]loader.SourceName, error) {
+	return sourcesOf(secc.getKeys()...), nil
+}
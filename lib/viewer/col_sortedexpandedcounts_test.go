@@ -0,0 +1,100 @@
+package viewer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+func getTestSortedExpandedCountsCol() SortedExpandedCountsCol {
+	secc := SortedExpandedCountsCol{}
+	secc.Name = "counts"
+	secc.Description = "All commands"
+	secc.Type = "SortedExpandedCounts"
+	secc.Keys = []loader.SourceKey{{SourceName: "status", Key: "^com_"}}
+	secc.Length = 5
+	secc.Units = NUMBER
+	secc.Precision = 0
+
+	return secc
+}
+
+func TestSortedExpandedCountsColImplementsViewer(t *testing.T) {
+	var _ Viewer = getTestSortedExpandedCountsCol()
+}
+
+// Create a state reader to test with
+func getTestSortedExpandedCountsState() loader.StateReader {
+	sp := loader.NewState()
+	prevss := loader.NewSampleSet()
+
+	cursamp := loader.NewSample()
+	cursamp.Data[`com_select`] = `160`
+	cursamp.Data[`com_insert`] = `20`
+	cursamp.Data[`com_update`] = `20`
+	cursamp.Data[`com_delete`] = `0`
+	sp.GetCurrentWriter().SetSample(`status`, cursamp)
+
+	prevsamp := loader.NewSample()
+	prevsamp.Data[`com_select`] = `0`
+	prevsamp.Data[`com_insert`] = `0`
+	prevsamp.Data[`com_update`] = `0`
+	prevsamp.Data[`com_delete`] = `0`
+	prevss.SetSample(`status`, prevsamp)
+	sp.SetPrevious(prevss)
+
+	return sp
+}
+
+func TestSortedExpandedCountsColGetData(t *testing.T) {
+	col := getTestSortedExpandedCountsCol()
+	state := getTestSortedExpandedCountsState()
+
+	// Keys with the same diff are on one row
+	output := col.GetData(state)
+	if len(output) != 3 || output[0] != `  200 total` || output[1] != `  160 [com_select]` {
+		t.Errorf("unexpected output: %q", output)
+	}
+
+	col.Percent = true
+	output = col.GetData(state)
+	expected := []string{`  200      total`, `  160  80% [com_select]`}
+	if len(output) != 3 || !reflect.DeepEqual(output[:2], expected) {
+		t.Errorf("unexpected percent output: %q", output)
+	}
+	if output[2] != `   20  20% [com_insert com_update]` && output[2] != `   20  20% [com_update com_insert]` {
+		t.Errorf("unexpected percent row: %q", output[2])
+	}
+}
+
+func TestSortedExpandedCountsColLimit(t *testing.T) {
+	col := getTestSortedExpandedCountsCol()
+	col.Limit = 1
+
+	// The total is of every key, only the top row is shown
+	output := col.GetData(getTestSortedExpandedCountsState())
+	expected := []string{`  200 total`, `  160 [com_select]`}
+	if !reflect.DeepEqual(output, expected) {
+		t.Errorf("unexpected limited output: %q", output)
+	}
+}
+
+func TestSortedExpandedCountsColHistory(t *testing.T) {
+	col := getTestSortedExpandedCountsCol()
+	col.History = 3
+	col.histories = make(map[string]*History)
+
+	// The keys with the same diff get a row each
+	output := col.GetData(getTestSortedExpandedCountsState())
+	if len(output) != 4 || output[0] != `  200     total` || output[1] != `  160   █ [com_select]` || output[2] != `   20   █ [com_insert]` && output[2] != `   20   █ [com_update]` {
+		t.Errorf("unexpected history output: %q", output)
+	}
+
+	// Each host has its own
+	state := getTestSortedExpandedCountsState()
+	db1, db2 := col.hostHistory(loader.RoleState(state, `db1`)), col.hostHistory(loader.RoleState(state, `db2`))
+	if db1 == db2 || db1 != col.hostHistory(loader.RoleState(state, `db1`)) {
+		t.Error("expected a History per host")
+	}
+}
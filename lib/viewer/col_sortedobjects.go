@@ -0,0 +1,148 @@
+package viewer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// A row per object (e.g. a statement digest) of a keyed Source, whose keys are `<object>.<column>`, busiest first
+type SortedObjectsCol struct {
+	defaultCol `yaml:",inline"`
+	Source     loader.SourceName `yaml:"source"`
+
+	// The column whose diff sorts the objects, those without activity are left out
+	Sort string `yaml:"sort"`
+
+	// The columns are current values (e.g. connections) rather than counters, the fields show them as they are
+	Gauges bool `yaml:"gauges"`
+
+	// Only show this many objects, zero for all
+	Limit int `yaml:"limit"`
+
+	Fields []objectField `yaml:"fields"`
+}
+
+// A number shown for each object: the rate of its column, or its diff per diff of another (e.g. latency per execution).  With gauges, the column as it is, or its change since the previous sample.
+type objectField struct {
+	colNum `yaml:",inline"`
+	Column string `yaml:"column"`
+	Per    string `yaml:"per"`
+	Change bool   `yaml:"change"`
+}
+
+// The diff of the column in the State, or its current value if it's a gauge
+func columnDiff(sr loader.StateReader, sk loader.SourceKey, gauge bool) float64 {
+	if gauge {
+		return sr.GetCurrent().GetF(sk)
+	}
+	var prev float64
+	if prevssp := sr.GetPrevious(); prevssp != nil {
+		prev = prevssp.GetF(sk)
+	}
+	return calculateDiff(sr.GetCurrent().GetF(sk), prev)
+}
+
+// The value of the field for the object in the State
+func (soc SortedObjectsCol) value(sr loader.StateReader, f objectField, object string) float64 {
+	diff := func(column string) float64 {
+		return columnDiff(sr, loader.SourceKey{SourceName: soc.Source, Key: object + `.` + column}, soc.Gauges)
+	}
+
+	if soc.Gauges && f.Change {
+		change, _ := getChange(sr, loader.SourceKey{SourceName: soc.Source, Key: object + `.` + f.Column})
+		return change
+	}
+	if soc.Gauges && f.Per == `` {
+		return diff(f.Column)
+	}
+	if f.Per != `` {
+		if per := diff(f.Per); per > 0 {
+			return diff(f.Column) / per
+		}
+		return 0
+	}
+	if seconds := sr.SourceSecondsDiff(soc.Source); seconds > 0 {
+		return diff(f.Column) / seconds
+	}
+	return diff(f.Column)
+}
+
+// The objects with activity in the State, busiest first
+func (soc SortedObjectsCol) objects(sr loader.StateReader) []string {
+	type activity struct {
+		object string
+		diff   float64
+	}
+	var active []activity
+	for _, sk := range sr.GetCurrent().ExpandSourceKeys(soc.getKeys()) {
+		if diff := columnDiff(sr, sk, soc.Gauges); diff > 0 {
+			active = append(active, activity{strings.TrimSuffix(sk.Key, `.`+soc.Sort), diff})
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		if active[i].diff != active[j].diff {
+			return active[i].diff > active[j].diff
+		}
+		return active[i].object < active[j].object
+	})
+	if soc.Limit > 0 && len(active) > soc.Limit {
+		active = active[:soc.Limit]
+	}
+
+	objects := make([]string, len(active))
+	for i, a := range active {
+		objects[i] = a.object
+	}
+	return objects
+}
+
+// The field names, then the col name over the objects
+func (soc SortedObjectsCol) GetHeader(sr loader.StateReader) []string {
+	var names []string
+	for _, f := range soc.Fields {
+		names = append(names, FitString(f.Name, f.Length))
+	}
+	return []string{strings.Join(append(names, soc.Name), ` `)}
+}
+
+func (soc SortedObjectsCol) GetData(sr loader.StateReader) (output []string) {
+	for _, object := range soc.objects(sr) {
+		var numbers []string
+		for _, f := range soc.Fields {
+			numbers = append(numbers, fitNumberString(f.fitNumber(soc.value(sr, f, object), f.Precision), f.Length))
+		}
+		output = append(output, strings.Join(append(numbers, object), ` `))
+	}
+	return
+}
+
+func (soc SortedObjectsCol) GetBlank() string {
+	var blanks []string
+	for _, f := range soc.Fields {
+		blanks = append(blanks, f.GetBlank())
+	}
+	return strings.Join(blanks, ` `)
+}
+
+// Each field of the col
+func (soc SortedObjectsCol) GetDetailedHelp() []string {
+	help := []string{soc.GetShortHelp()}
+	for _, f := range soc.Fields {
+		help = append(help, fmt.Sprintf("  %s", f.GetShortHelp()))
+	}
+	return help
+}
+
+// The sort column of every object, the fields are read per object
+func (soc SortedObjectsCol) getKeys() []loader.SourceKey {
+	return []loader.SourceKey{{SourceName: soc.Source, Key: `\.` + regexp.QuoteMeta(soc.Sort) + `$`}}
+}
+
+// A list of sources that this col requires
+func (soc SortedObjectsCol) GetSources() ([]loader.SourceName, error) {
+	return sourcesOf(soc.getKeys()...), nil
+}
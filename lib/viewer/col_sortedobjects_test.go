@@ -0,0 +1,125 @@
+package viewer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+func getTestSortedObjectsCol() SortedObjectsCol {
+	soc := SortedObjectsCol{}
+	soc.Name = "digest"
+	soc.Type = "SortedObjects"
+	soc.Source = "statement_digests"
+	soc.Sort = "count_star"
+
+	exec := objectField{Column: "count_star"}
+	exec.Name = "exec"
+	exec.Length = 4
+	exec.Units = NUMBER
+	lat := objectField{Column: "sum_latency", Per: "count_star"}
+	lat.Name = "lat"
+	lat.Length = 5
+	lat.Units = NANOSECOND
+	soc.Fields = []objectField{exec, lat}
+	return soc
+}
+
+func TestSortedObjectsColImplementsViewer(t *testing.T) {
+	var _ Viewer = getTestSortedObjectsCol()
+}
+
+// Two seconds of three digests, one idle
+func getTestSortedObjectsState() loader.StateReader {
+	state := loader.NewState()
+
+	prev := loader.NewSample()
+	prev.Data[`a select.count_star`] = `10`
+	prev.Data[`a select.sum_latency`] = `1000`
+	prev.Data[`b insert.count_star`] = `10`
+	prev.Data[`b insert.sum_latency`] = `1000`
+	prev.Data[`c delete.count_star`] = `5`
+	prevss := loader.NewSampleSet()
+	prevss.SetSample(`statement_digests`, prev)
+	prevss.SetUptime(10)
+	state.SetPrevious(prevss)
+
+	cur := loader.NewSample()
+	cur.Data[`a select.count_star`] = `210`
+	cur.Data[`a select.sum_latency`] = `5001000`
+	cur.Data[`b insert.count_star`] = `30`
+	cur.Data[`b insert.sum_latency`] = `41000`
+	cur.Data[`c delete.count_star`] = `5`
+	state.GetCurrentWriter().SetSample(`statement_digests`, cur)
+	state.GetCurrentWriter().SetUptime(12)
+	return state
+}
+
+func TestSortedObjectsColGetData(t *testing.T) {
+	col := getTestSortedObjectsCol()
+	state := getTestSortedObjectsState()
+
+	if header := col.GetHeader(state); !reflect.DeepEqual(header, []string{`exec   lat digest`}) {
+		t.Errorf("unexpected header: %q", header)
+	}
+
+	expected := []string{` 100  25µs a select`, `  10 2.0µ b insert`}
+	if output := col.GetData(state); !reflect.DeepEqual(output, expected) {
+		t.Errorf("unexpected output: %q", output)
+	}
+
+	col.Limit = 1
+	if output := col.GetData(state); !reflect.DeepEqual(output, expected[:1]) {
+		t.Errorf("unexpected limited output: %q", output)
+	}
+
+	if sources, _ := col.GetSources(); !reflect.DeepEqual(sources, []loader.SourceName{`statement_digests`}) {
+		t.Errorf("unexpected sources: %v", sources)
+	}
+}
+
+func TestSortedObjectsColGauges(t *testing.T) {
+	col := getTestSortedObjectsCol()
+	col.Gauges = true
+	col.Fields = col.Fields[:1]
+
+	// The current values, sorted by them
+	expected := []string{` 210 a select`, `  30 b insert`, `   5 c delete`}
+	if output := col.GetData(getTestSortedObjectsState()); !reflect.DeepEqual(output, expected) {
+		t.Errorf("unexpected gauges output: %q", output)
+	}
+}
+
+func TestSortedObjectsColChange(t *testing.T) {
+	col := getTestSortedObjectsCol()
+	col.Gauges = true
+	change := objectField{Column: "sum_latency", Change: true}
+	change.Name = "chg"
+	change.Length = 5
+	change.Units = NUMBER
+	change.Signed = true
+	col.Fields = []objectField{col.Fields[0], change}
+
+	// sum_latency went up by 5000000 and 40000, c delete has none
+	expected := []string{` 210 5000k a select`, `  30 40000 b insert`, `   5     0 c delete`}
+	if output := col.GetData(getTestSortedObjectsState()); !reflect.DeepEqual(output, expected) {
+		t.Errorf("unexpected change output: %q", output)
+	}
+
+	// Gauges go down too
+	state := loader.NewState()
+	prev := loader.NewSample()
+	prev.Data[`a select.count_star`] = `1`
+	prev.Data[`a select.sum_latency`] = `5000`
+	prevss := loader.NewSampleSet()
+	prevss.SetSample(`statement_digests`, prev)
+	state.SetPrevious(prevss)
+	cur := loader.NewSample()
+	cur.Data[`a select.count_star`] = `1`
+	cur.Data[`a select.sum_latency`] = `1000`
+	state.GetCurrentWriter().SetSample(`statement_digests`, cur)
+	if output := col.GetData(state); !reflect.DeepEqual(output, []string{`   1 -4000 a select`}) {
+		t.Errorf("unexpected negative change output: %q", output)
+	}
+}
@@ -21,15 +21,27 @@ func (c StringCol) GetData(sr loader.StateReader) []string {
 	}
 
 	if len(str) > c.Length {
-		// Truncate the string
+		// Truncate the string, leaving room for the marker
+		length := c.Length - len(CLIPPED.marker())
 		if !c.Fromend {
 			// First Length chars
-			str = str[0:c.Length]
+			str = str[0:length]
 		} else {
 			// Last Length chars
-			str = str[len(str)-c.Length:]
+			str = str[len(str)-length:]
 		}
+		return []string{fitMarked(str, c.Length, CLIPPED)}
 	}
 
 	return []string{FitString(str, c.Length)}
 }
+
+// The SourceKeys this col reads
+func (c StringCol) getKeys() []loader.SourceKey {
+	return []loader.SourceKey{c.Key}
+}
+
+// A list of sources that this col requires
+func (c StringCol) GetSources() ([]loader.SourceName, error) {
+	return sourcesOf(c.getKeys()...), nil
+}
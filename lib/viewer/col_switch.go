@@ -25,9 +25,19 @@ func (c SwitchCol) GetData(sr loader.StateReader) []string {
 	} else {
 		// Truncate string if it's too long
 		if len(str) > c.Length {
-			str = str[0:c.Length]
+			return []string{fitMarked(str[0:c.Length-len(CLIPPED.marker())], c.Length, CLIPPED)}
 		}
 	}
 
 	return []string{FitString(str, c.Length)}
 }
+
+// The SourceKeys this col reads
+func (c SwitchCol) getKeys() []loader.SourceKey {
+	return []loader.SourceKey{c.Key}
+}
+
+// A list of sources that this col requires
+func (c SwitchCol) GetSources() ([]loader.SourceName, error) {
+	return sourcesOf(c.getKeys()...), nil
+}
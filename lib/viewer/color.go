@@ -0,0 +1,112 @@
+package viewer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Terminal colors for values past a col's warn or crit level
+const (
+	ANSI_YELLOW = "\x1b[33m"
+	ANSI_RED    = "\x1b[31m"
+	ANSI_RESET  = "\x1b[0m"
+)
+
+// Color values past their col's levels, off by default so output stays plain text
+var colorEnabled bool
+
+func SetColor(on bool) {
+	colorEnabled = on
+}
+
+// Levels of a numeric col at which its values are shown in yellow (warn) or red (crit) with -color.  Higher values are worse, unless crit is below warn.
+type colorLevels struct {
+	Warn *float64 `yaml:"warn"`
+	Crit *float64 `yaml:"crit"`
+}
+
+// Is the value at or past the level?
+func (cl colorLevels) past(value, level float64) bool {
+	if cl.Warn != nil && cl.Crit != nil && *cl.Crit < *cl.Warn {
+		return value <= level
+	}
+	return value >= level
+}
+
+// The levels in words, e.g. `worrying at 50 or more, critical at 100 or more`, empty without levels
+func (cl colorLevels) describe() string {
+	direction := `or more`
+	if cl.Warn != nil && cl.Crit != nil && *cl.Crit < *cl.Warn {
+		direction = `or less`
+	}
+	var levels []string
+	if cl.Warn != nil {
+		levels = append(levels, fmt.Sprintf("worrying at %s %s", strconv.FormatFloat(*cl.Warn, 'f', -1, 64), direction))
+	}
+	if cl.Crit != nil {
+		levels = append(levels, fmt.Sprintf("critical at %s %s", strconv.FormatFloat(*cl.Crit, 'f', -1, 64), direction))
+	}
+	return strings.Join(levels, `, `)
+}
+
+// Wrap the fitted string of the value in the color of the level it is past, if any
+func (cl colorLevels) colorize(value float64, str string) string {
+	if !colorEnabled {
+		return str
+	}
+	switch {
+	case cl.Crit != nil && cl.past(value, *cl.Crit):
+		return ANSI_RED + str + ANSI_RESET
+	case cl.Warn != nil && cl.past(value, *cl.Warn):
+		return ANSI_YELLOW + str + ANSI_RESET
+	}
+	return str
+}
+
+// ANSI escape sequences, which take no room on a terminal
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;?]*[A-Za-z]")
+
+// Does the string have escape sequences?  Strings without them are measured in bytes like they always were.
+func hasEscapes(s string) bool {
+	return strings.Contains(s, "\x1b")
+}
+
+// The width of the string on a terminal
+func visibleLen(s string) int {
+	return utf8.RuneCountInString(ansiEscape.ReplaceAllString(s, ``))
+}
+
+// Cut the string to the width on a terminal, keeping escape sequences whole and resetting the color if one was cut off
+func TruncateString(s string, width int) string {
+	if !hasEscapes(s) {
+		if len(s) > width {
+			return s[:width]
+		}
+		return s
+	}
+	if visibleLen(s) <= width {
+		return s
+	}
+
+	var out strings.Builder
+	var colored bool
+	for shown := 0; len(s) > 0 && shown < width; {
+		if loc := ansiEscape.FindStringIndex(s); loc != nil && loc[0] == 0 {
+			out.WriteString(s[:loc[1]])
+			colored = s[:loc[1]] != ANSI_RESET
+			s = s[loc[1]:]
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s)
+		out.WriteString(s[:size])
+		s = s[size:]
+		shown += 1
+	}
+	if colored {
+		out.WriteString(ANSI_RESET)
+	}
+	return out.String()
+}
@@ -0,0 +1,110 @@
+package viewer
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestColorize(t *testing.T) {
+	warn, crit := 50.0, 100.0
+	higher := colorLevels{Warn: &warn, Crit: &crit}
+	lower := colorLevels{Warn: &crit, Crit: &warn}
+
+	// Plain unless enabled
+	if str := higher.colorize(200, `200`); str != `200` {
+		t.Errorf("unexpected color without -color: %q", str)
+	}
+
+	SetColor(true)
+	defer SetColor(false)
+	tests := []struct {
+		levels   colorLevels
+		value    float64
+		expected string
+	}{
+		{higher, 10, `x`},
+		{higher, 50, ANSI_YELLOW + `x` + ANSI_RESET},
+		{higher, 150, ANSI_RED + `x` + ANSI_RESET},
+		{lower, 150, `x`},
+		{lower, 80, ANSI_YELLOW + `x` + ANSI_RESET},
+		{lower, 10, ANSI_RED + `x` + ANSI_RESET},
+		{colorLevels{Crit: &warn}, 60, ANSI_RED + `x` + ANSI_RESET},
+		{colorLevels{}, 1000, `x`},
+	}
+	for _, test := range tests {
+		if str := test.levels.colorize(test.value, `x`); str != test.expected {
+			t.Errorf("%v: unexpected %q", test.value, str)
+		}
+	}
+}
+
+func TestColorLevelsParse(t *testing.T) {
+	yaml_str := `---
+- name: run
+  description: Threads running
+  key: status/threads_running
+  type: Gauge
+  units: Number
+  length: 4
+  precision: 0
+  warn: 50
+  crit: 100
+`
+	var cols ViewerList
+	if err := yaml.Unmarshal([]byte(yaml_str), &cols); err != nil {
+		t.Fatal(err)
+	}
+	gauge := cols[0].(GaugeCol)
+	if gauge.Warn == nil || *gauge.Warn != 50 || gauge.Crit == nil || *gauge.Crit != 100 {
+		t.Errorf("unexpected levels: %+v", gauge.colorLevels)
+	}
+}
+
+// Escape sequences take no room when fitting
+func TestFitStringColored(t *testing.T) {
+	red := ANSI_RED + `12345` + ANSI_RESET
+	if str := FitString(red, 7); str != `  `+red {
+		t.Errorf("unexpected padding: %q", str)
+	}
+	if str := TruncateString(red, 3); str != ANSI_RED+`123`+ANSI_RESET {
+		t.Errorf("unexpected truncation: %q", str)
+	}
+	if str := TruncateString(`ab `+red, 4); str != `ab `+ANSI_RED+`1`+ANSI_RESET {
+		t.Errorf("unexpected truncation: %q", str)
+	}
+	if str := TruncateString(`abcdef`, 4); str != `abcd` {
+		t.Errorf("unexpected truncation: %q", str)
+	}
+}
+
+func TestColorLevelsDescribe(t *testing.T) {
+	warn, crit := 50.0, 2.5
+	tests := []struct {
+		levels   colorLevels
+		expected string
+	}{
+		{colorLevels{}, ``},
+		{colorLevels{Warn: &warn}, `worrying at 50 or more`},
+		{colorLevels{Warn: &crit, Crit: &warn}, `worrying at 2.5 or more, critical at 50 or more`},
+		{colorLevels{Warn: &warn, Crit: &crit}, `worrying at 50 or less, critical at 2.5 or less`},
+	}
+	for _, test := range tests {
+		if described := test.levels.describe(); described != test.expected {
+			t.Errorf("unexpected description: %q", described)
+		}
+	}
+}
+
+func TestColNumDetailedHelp(t *testing.T) {
+	warn := 50.0
+	col := colNum{colorLevels: colorLevels{Warn: &warn}}
+	col.Name = `run`
+	col.Description = `Threads running`
+	col.Guidance = `More than the CPUs means queuing`
+	expected := []string{`run: Threads running`, `  More than the CPUs means queuing`, `  worrying at 50 or more`}
+	if help := col.GetDetailedHelp(); !reflect.DeepEqual(help, expected) {
+		t.Errorf("unexpected help: %q", help)
+	}
+}
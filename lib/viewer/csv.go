@@ -0,0 +1,64 @@
+package viewer
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Writes the Records of a Viewer as CSV, with a single header row of the col names (group.col).  The cols are fixed by the Viewer, so a col whose Group is not available in a State has an empty value, and SortedExpandedCounts cols only have their total.
+type CSVWriter struct {
+	w        *csv.Writer
+	cols     []string // Record Values names
+	wroteHdr bool
+}
+
+func NewCSVWriter(w io.Writer, sv Viewer) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(w), cols: GetColNames(sv)}
+}
+
+// The header row: the sample time, then each col
+func (cw *CSVWriter) header() []string {
+	row := []string{`timestamp`}
+	for _, col := range cw.cols {
+		row = append(row, strings.ReplaceAll(col, `/`, `.`))
+	}
+	return row
+}
+
+// Write the Record as a row, preceded by the header row if this is the first
+func (cw *CSVWriter) Write(r Record) error {
+	if !cw.wroteHdr {
+		if err := cw.w.Write(cw.header()); err != nil {
+			return err
+		}
+		cw.wroteHdr = true
+	}
+
+	row := []string{r.Timestamp.Format(time.RFC3339)}
+	for _, col := range cw.cols {
+		value, ok := r.Values[col]
+		if !ok {
+			value = r.Values[col+`/total`]
+		}
+		row = append(row, formatCSVValue(value))
+	}
+	if err := cw.w.Write(row); err != nil {
+		return err
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// Numbers unformatted, values that could not be computed are empty
+func formatCSVValue(value any) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	}
+	return ``
+}
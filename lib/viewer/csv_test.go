@@ -0,0 +1,45 @@
+package viewer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	cw := NewCSVWriter(&buf, getTestGroupCol())
+	for i := 0; i < 2; i++ {
+		record := GetRecord(getTestGroupCol(), getTestGroupState())
+		if err := cw.Write(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a header and 2 rows: %q", lines)
+	}
+	if lines[0] != `timestamp,Connects.cons,Connects.conn` {
+		t.Errorf("unexpected header: %s", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], `,4`) || lines[1] != lines[2] {
+		t.Errorf("unexpected rows: %q", lines[1:])
+	}
+}
+
+func TestFormatCSVValue(t *testing.T) {
+	tests := map[string]any{
+		`1.5`:   1.5,
+		`100`:   100.0,
+		`ON`:    `ON`,
+		``:      nil,
+		`a, b`:  `a, b`,
+		`-0.25`: -0.25,
+	}
+	for expected, value := range tests {
+		if got := formatCSVValue(value); got != expected {
+			t.Errorf("%v: expected %q, got %q", value, expected, got)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package viewer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// How to collect a View embedded in another program, e.g. an ops dashboard
+type Options struct {
+	// Where the States come from, like a loader.NewLiveLoader(config).  It is initialized with the Sources of the View.
+	Loader loader.Loader
+
+	// Time between States, 1s if zero
+	Interval time.Duration
+}
+
+// A State rendered by an embedded View
+type Output struct {
+	// The lines of the header and data as myq_status prints them.  The header can change with the State (e.g., groups only available on some servers).
+	Header []string
+	Data   []string
+
+	// The unformatted values
+	Record Record
+
+	// The State the output was rendered from
+	State loader.StateReader
+
+	// The View is not available on the server, e.g., it is too old
+	Err error
+}
+
+// A View collecting from a Loader, so other programs can render views without myq_status's main
+type Embedded struct {
+	view Viewer
+	load loader.Loader
+}
+
+// Collect the named View with the given Options.  The default views are loaded if they haven't been.
+func New(name string, opts Options) (*Embedded, error) {
+	if views == nil {
+		if err := LoadDefaultViews(); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Loader == nil {
+		return nil, fmt.Errorf("no Loader to collect view %s from", name)
+	}
+	if opts.Interval == 0 {
+		opts.Interval = time.Second
+	}
+
+	view, err := GetViewer(name)
+	if err != nil {
+		return nil, err
+	}
+	sources, err := view.GetSources()
+	if err != nil {
+		return nil, err
+	}
+	if err := opts.Loader.Initialize(opts.Interval, sources); err != nil {
+		return nil, err
+	}
+	return &Embedded{view: view, load: opts.Loader}, nil
+}
+
+// The View being collected
+func (e *Embedded) View() Viewer {
+	return e.view
+}
+
+// Start collecting, the Output of every State is sent to the channel.  It is closed when the Loader's is (e.g., at the end of a file).
+func (e *Embedded) Start() <-chan Output {
+	ch := make(chan Output)
+	go func() {
+		for state := range e.load.GetStateChannel() {
+			ch <- Output{
+				Header: e.view.GetHeader(state),
+				Data:   e.view.GetData(state),
+				Record: GetRecord(e.view, state),
+				State:  state,
+				Err:    CheckVersion(e.view, state),
+			}
+		}
+		close(ch)
+	}()
+	return ch
+}
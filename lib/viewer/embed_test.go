@@ -0,0 +1,73 @@
+package viewer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// A Loader that sends the given States once
+type testStatesLoader struct {
+	states []loader.StateReader
+
+	interval time.Duration
+	sources  []loader.SourceName
+	err      error
+}
+
+func (l *testStatesLoader) Initialize(interval time.Duration, sources []loader.SourceName) error {
+	l.interval, l.sources = interval, sources
+	return l.err
+}
+
+func (l *testStatesLoader) GetStateChannel() <-chan loader.StateReader {
+	ch := make(chan loader.StateReader)
+	go func() {
+		for _, state := range l.states {
+			ch <- state
+		}
+		close(ch)
+	}()
+	return ch
+}
+
+func TestEmbedded(t *testing.T) {
+	load := &testStatesLoader{states: []loader.StateReader{getTestState()}}
+	e, err := New(`cttf`, Options{Loader: load})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.View().GetName() != `cttf` || load.interval != time.Second || len(load.sources) == 0 {
+		t.Errorf("unexpected view %s, interval %s or sources %v", e.View().GetName(), load.interval, load.sources)
+	}
+
+	var outputs []Output
+	for output := range e.Start() {
+		outputs = append(outputs, output)
+	}
+	if len(outputs) != 1 {
+		t.Fatalf("unexpected outputs: %d", len(outputs))
+	}
+	output := outputs[0]
+	if output.Err != nil || len(output.Header) != 2 || len(output.Data) != 1 || !strings.Contains(output.Header[1], `cons`) {
+		t.Errorf("unexpected output: %+v", output)
+	}
+	if output.Record.View != `cttf` || output.Record.Values[`Connects/cons`] != 5.0 {
+		t.Errorf("unexpected record: %+v", output.Record)
+	}
+}
+
+func TestEmbeddedErrors(t *testing.T) {
+	if _, err := New(`nosuchview`, Options{Loader: &testStatesLoader{}}); err == nil {
+		t.Error("expected an error for an unknown view")
+	}
+	if _, err := New(`cttf`, Options{}); err == nil {
+		t.Error("expected an error without a Loader")
+	}
+	if _, err := New(`cttf`, Options{Loader: &testStatesLoader{err: errors.New("cannot connect")}}); err == nil {
+		t.Error("expected the Loader's error")
+	}
+}
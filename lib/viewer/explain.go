@@ -0,0 +1,102 @@
+package viewer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// One line per col of the Viewer with the formula of its values, e.g. `Net/recv = rate(bytes_received)`.  Cols are named as in Records and only the Groups available in the State are explained, like the header.
+func Explain(sv Viewer, sr loader.StateReader) (lines []string) {
+	var prefix string
+	var svs ViewerList
+	switch v := sv.(type) {
+	case View:
+		svs = append(svs, v.getAvailableGroups(sr)...)
+		svs = append(svs, v.Cols...)
+	case GroupCol:
+		prefix = v.Name + "/"
+		svs = v.Cols
+	default:
+		return []string{fmt.Sprintf("%s = %s", sv.GetName(), getFormula(sv))}
+	}
+
+	for _, child := range svs {
+		for _, line := range Explain(child, sr) {
+			lines = append(lines, prefix+line)
+		}
+	}
+	return
+}
+
+// The formula of a col's values from its keys
+func getFormula(sv Viewer) string {
+	switch c := sv.(type) {
+	case GaugeCol:
+		return formulaKey(c.Key)
+	case StringCol:
+		return formulaKey(c.Key)
+	case SwitchCol:
+		return fmt.Sprintf("%s (named)", formulaKey(c.Key))
+	case RateCol:
+		return fmt.Sprintf("rate(%s)", formulaKey(c.Key))
+	case DiffCol:
+		return fmt.Sprintf("diff(%s)", formulaKey(c.Key))
+	case RateSumCol:
+		return fmt.Sprintf("rate(%s)", formulaSum(c.Keys))
+	case PercentCol:
+		return fmt.Sprintf("%s / %s * 100", formulaKey(c.Numerator), formulaKey(c.Denominator))
+	case RatePercentCol:
+		return fmt.Sprintf("diff(%s) / diff(%s) * 100", formulaSum(c.Numerator), formulaSum(c.Denominator))
+	case RateRatioCol:
+		return fmt.Sprintf("diff(%s) / diff(%s)", formulaSum(c.Numerator), formulaSum(c.Denominator))
+	case ExprCol:
+		return c.Expr
+	case SubtractCol:
+		return fmt.Sprintf("%s - %s", formulaKey(c.Bigger), formulaKey(c.Smaller))
+	case GtidSubtractCol:
+		return fmt.Sprintf("gtid_subtract(%s, %s)", formulaKey(c.Bigger), formulaKey(c.Smaller))
+	case SortedExpandedCountsCol:
+		var keys []string
+		for _, sk := range c.Keys {
+			keys = append(keys, formulaKey(sk))
+		}
+		return fmt.Sprintf("diff(each of %s), busiest first", strings.Join(keys, `, `))
+	case SortedObjectsCol:
+		var fields []string
+		for _, f := range c.Fields {
+			if c.Gauges && f.Change {
+				fields = append(fields, fmt.Sprintf("%s = change(%s)", f.Name, f.Column))
+			} else if c.Gauges {
+				fields = append(fields, fmt.Sprintf("%s = %s", f.Name, f.Column))
+			} else if f.Per != `` {
+				fields = append(fields, fmt.Sprintf("%s = diff(%s) / diff(%s)", f.Name, f.Column, f.Per))
+			} else {
+				fields = append(fields, fmt.Sprintf("%s = rate(%s)", f.Name, f.Column))
+			}
+		}
+		sort := fmt.Sprintf("diff(%s)", c.Sort)
+		if c.Gauges {
+			sort = c.Sort
+		}
+		return fmt.Sprintf("each %s object: %s, busiest by %s first", c.Source, strings.Join(fields, `, `), sort)
+	}
+	return `?`
+}
+
+// Keys of the status are well known, other Sources are named
+func formulaKey(sk loader.SourceKey) string {
+	if sk.SourceName == `status` {
+		return sk.Key
+	}
+	return fmt.Sprintf("%s/%s", sk.SourceName, sk.Key)
+}
+
+func formulaSum(sks []loader.SourceKey) string {
+	var terms []string
+	for _, sk := range sks {
+		terms = append(terms, formulaKey(sk))
+	}
+	return strings.Join(terms, ` + `)
+}
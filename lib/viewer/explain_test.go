@@ -0,0 +1,42 @@
+package viewer
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+func TestExplain(t *testing.T) {
+	view := getTestView()
+	view.Cols = append(view.Cols, getTestRatePercentCol())
+	rds := getTestGroupCol()
+	rds.Name = "RDS"
+	rds.Requires = []loader.SourceName{`aws.rds`}
+	view.Groups = append(view.Groups, rds)
+
+	expected := []string{
+		`Connects/cons = rate(connections)`,
+		`Connects/conn = threads_connect`,
+		`hit% = diff(table_open_cache_hits) / diff(table_open_cache_hits + table_open_cache_misses) * 100`,
+	}
+	if lines := Explain(view, getTestViewState()); !slices.Equal(lines, expected) {
+		t.Errorf("unexpected lines: %q", lines)
+	}
+}
+
+func TestGetFormula(t *testing.T) {
+	rate := getTestRateCol()
+	rate.Key = loader.SourceKey{SourceName: `innodb_metrics`, Key: `lock_deadlocks`}
+	tests := map[string]Viewer{
+		`diff(bytes_received)`: getTestDiffCol(),
+		`rate(com_set.*)`:      getTestRateSumCol(),
+		`innodb_buffer_pool_pages_dirty / innodb_buffer_pool_pages_total * 100`: getTestPercentCol(),
+		`rate(innodb_metrics/lock_deadlocks)`:                                   rate,
+	}
+	for expected, col := range tests {
+		if formula := getFormula(col); formula != expected {
+			t.Errorf("%T: unexpected formula: %s", col, formula)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package viewer
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// A parsed arithmetic expression over metrics, e.g. `(com_commit+com_rollback)/questions*100`.  Metrics are status keys, or `<source>/<key>` for other Sources like `variables/max_connections`, at their current value; rate(<metric>) and diff(<metric>) are computed like Rate and Diff cols, change(<metric>) is the change of a gauge that can go down.
+type exprNode interface {
+	eval(loader.StateReader) (float64, error)
+	keys() []loader.SourceKey
+}
+
+type exprNumber float64
+
+func (n exprNumber) eval(loader.StateReader) (float64, error) { return float64(n), nil }
+func (n exprNumber) keys() []loader.SourceKey                 { return nil }
+
+// A metric, as is or through rate(), diff() or change()
+type exprMetric struct {
+	function string // empty for the current value
+	key      loader.SourceKey
+}
+
+func (m exprMetric) eval(sr loader.StateReader) (float64, error) {
+	switch m.function {
+	case `rate`:
+		return RateCol{Key: m.key}.getRate(sr)
+	case `diff`:
+		return DiffCol{Key: m.key}.getDiff(sr)
+	case `change`:
+		return getChange(sr, m.key)
+	}
+	return sr.GetCurrent().GetFloat(m.key)
+}
+func (m exprMetric) keys() []loader.SourceKey { return []loader.SourceKey{m.key} }
+
+type exprNegate struct{ operand exprNode }
+
+func (n exprNegate) eval(sr loader.StateReader) (float64, error) {
+	value, err := n.operand.eval(sr)
+	return -value, err
+}
+func (n exprNegate) keys() []loader.SourceKey { return n.operand.keys() }
+
+type exprBinary struct {
+	op          byte
+	left, right exprNode
+}
+
+func (b exprBinary) eval(sr loader.StateReader) (float64, error) {
+	left, err := b.left.eval(sr)
+	if err != nil {
+		return 0, err
+	}
+	right, err := b.right.eval(sr)
+	if err != nil {
+		return 0, err
+	}
+	switch b.op {
+	case '+':
+		return left + right, nil
+	case '-':
+		return left - right, nil
+	case '*':
+		return left * right, nil
+	}
+	if right == 0 {
+		return 0, errors.New(`division by zero`)
+	}
+	return left / right, nil
+}
+func (b exprBinary) keys() []loader.SourceKey { return append(b.left.keys(), b.right.keys()...) }
+
+// Parses expressions by recursive descent, the usual precedence of * and / over + and -
+type exprParser struct {
+	str string
+	pos int
+}
+
+func parseExpr(str string) (exprNode, error) {
+	p := &exprParser{str: str}
+	node, err := p.parseSum()
+	if err != nil {
+		return nil, fmt.Errorf("expression `%s`: %w", str, err)
+	}
+	if p.skipSpace(); p.pos < len(p.str) {
+		return nil, fmt.Errorf("expression `%s`: unexpected `%s`", str, p.str[p.pos:])
+	}
+	return node, nil
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.str) && p.str[p.pos] == ' ' {
+		p.pos += 1
+	}
+}
+
+// The next operator if it is one of ops, which is then consumed
+func (p *exprParser) accept(ops string) (byte, bool) {
+	p.skipSpace()
+	if p.pos < len(p.str) && strings.IndexByte(ops, p.str[p.pos]) >= 0 {
+		p.pos += 1
+		return p.str[p.pos-1], true
+	}
+	return 0, false
+}
+
+func (p *exprParser) parseSum() (exprNode, error) {
+	node, err := p.parseProduct()
+	for err == nil {
+		op, ok := p.accept(`+-`)
+		if !ok {
+			break
+		}
+		var right exprNode
+		if right, err = p.parseProduct(); err == nil {
+			node = exprBinary{op, node, right}
+		}
+	}
+	return node, err
+}
+
+func (p *exprParser) parseProduct() (exprNode, error) {
+	node, err := p.parseUnary()
+	for err == nil {
+		op, ok := p.accept(`*/`)
+		if !ok {
+			break
+		}
+		var right exprNode
+		if right, err = p.parseUnary(); err == nil {
+			node = exprBinary{op, node, right}
+		}
+	}
+	return node, err
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if _, ok := p.accept(`-`); ok {
+		operand, err := p.parseUnary()
+		return exprNegate{operand}, err
+	}
+	if _, ok := p.accept(`(`); ok {
+		node, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept(`)`); !ok {
+			return nil, errors.New(`missing )`)
+		}
+		return node, nil
+	}
+	return p.parseOperand()
+}
+
+// A number, a metric or a function of a metric
+func (p *exprParser) parseOperand() (exprNode, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.str) && (isExprIdent(rune(p.str[p.pos])) || p.str[p.pos] == '.' || p.isSourceSlash(start)) {
+		p.pos += 1
+	}
+	word := p.str[start:p.pos]
+	if word == `` {
+		if p.pos == len(p.str) {
+			return nil, errors.New(`unexpected end`)
+		}
+		return nil, fmt.Errorf("unexpected `%c`", p.str[p.pos])
+	}
+	if unicode.IsDigit(rune(word[0])) || word[0] == '.' {
+		value, err := strconv.ParseFloat(word, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number `%s`", word)
+		}
+		return exprNumber(value), nil
+	}
+
+	if _, ok := p.accept(`(`); ok {
+		if word != `rate` && word != `diff` && word != `change` {
+			return nil, fmt.Errorf("unknown function `%s`, use rate, diff or change", word)
+		}
+		metric, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		m, ok := metric.(exprMetric)
+		if !ok || m.function != `` {
+			return nil, fmt.Errorf("%s() takes a metric", word)
+		}
+		if _, ok := p.accept(`)`); !ok {
+			return nil, errors.New(`missing )`)
+		}
+		m.function = word
+		return m, nil
+	}
+	return parseExprMetric(word)
+}
+
+// Is the `/` at the position part of the <source>/<key> of the metric that started at start, rather than a division?  It is when it has no spaces around it.
+func (p *exprParser) isSourceSlash(start int) bool {
+	if p.str[p.pos] != '/' || p.pos == start || p.pos+1 == len(p.str) || unicode.IsDigit(rune(p.str[start])) {
+		return false
+	}
+	return isExprIdent(rune(p.str[p.pos-1])) && isExprIdent(rune(p.str[p.pos+1]))
+}
+
+func isExprIdent(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// A status key, or <source>/<key> for other Sources.  Sources (e.g. role prefixed) and keys (e.g. of keyed Sources) may have dots.
+func parseExprMetric(word string) (exprNode, error) {
+	word = strings.ToLower(word)
+	source, key, found := strings.Cut(word, `/`)
+	if !found {
+		source, key = `status`, word
+	}
+	if source == `` || key == `` || strings.HasPrefix(word, `.`) || strings.HasSuffix(word, `.`) {
+		return nil, fmt.Errorf("invalid metric `%s`", word)
+	}
+	return exprMetric{key: loader.SourceKey{SourceName: loader.SourceName(source), Key: key}}, nil
+}
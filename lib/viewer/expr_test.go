@@ -0,0 +1,75 @@
+package viewer
+
+import (
+	"testing"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+func TestParseExpr(t *testing.T) {
+	state := getTestExprState()
+	tests := map[string]float64{
+		`1+2*3`:                                7,
+		`(1+2)*3`:                              9,
+		`10-4-3`:                               3,
+		`12/2/3`:                               2,
+		`-com_commit + 1.5`:                    -28.5,
+		`- -2`:                                 2,
+		`Com_Commit`:                           30,
+		`status/questions / 100`:               2,
+		`questions / status/questions`:         1,
+		`diff(questions)`:                      100,
+		`rate( status/questions )`:             50,
+		`diff(com_commit)*100/diff(questions)`: 20,
+		`change(threads_connected)`:            -3,
+		`change(questions)`:                    100,
+	}
+	for str, expected := range tests {
+		node, err := parseExpr(str)
+		if err != nil {
+			t.Errorf("%s: %v", str, err)
+			continue
+		}
+		if value, err := node.eval(state); err != nil || value != expected {
+			t.Errorf("%s: expected %f, got %f (%v)", str, expected, value, err)
+		}
+	}
+
+	for _, str := range []string{``, `1+`, `(1`, `1)`, `a b`, `sum(questions)`, `rate(1)`, `rate(diff(questions))`, `rate(questions`, `.status`, `status.`, `status/`, `/questions`, `1..2`, `questions % 2`} {
+		if _, err := parseExpr(str); err == nil {
+			t.Errorf("expected an error for `%s`", str)
+		}
+	}
+}
+
+func TestExprKeys(t *testing.T) {
+	node, err := parseExpr(`rate(com_commit) / variables/max_connections`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := node.keys()
+	expected := []loader.SourceKey{{SourceName: `status`, Key: `com_commit`}, {SourceName: `variables`, Key: `max_connections`}}
+	if len(keys) != 2 || keys[0] != expected[0] || keys[1] != expected[1] {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+
+	if _, err := node.eval(getTestExprState()); err == nil {
+		t.Error(`expected an error for an uncollected source`)
+	}
+	// Role prefixed Sources and keys of keyed Sources have dots and slashes
+	node, err = parseExpr(`primary.status/questions - memory_events/innodb/buf_buf_pool.current_bytes`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys = node.keys()
+	expected = []loader.SourceKey{{SourceName: `primary.status`, Key: `questions`}, {SourceName: `memory_events`, Key: `innodb/buf_buf_pool.current_bytes`}}
+	if len(keys) != 2 || keys[0] != expected[0] || keys[1] != expected[1] {
+		t.Errorf("unexpected keys: %v", keys)
+	}
+
+	if node, _ := parseExpr(`questions/(com_commit-30)`); node != nil {
+		if _, err := node.eval(getTestExprState()); err == nil {
+			t.Error(`expected an error dividing by zero`)
+		}
+	}
+}
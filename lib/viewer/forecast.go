@@ -0,0 +1,167 @@
+package viewer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How many values of each col a Forecaster fits its line through, older ones are dropped
+const FORECAST_HISTORY = 1000
+
+// Projections further out than this are reported as never reached
+const FORECAST_HORIZON = 365 * 24 * time.Hour
+
+// A Forecast projects when a col will reach a target value, e.g. `Checkpoint/age=1073741824`, or a multiple of its current value, e.g. `tmp/disk=2x`
+type Forecast struct {
+	Col      string // as named in Records
+	Target   float64
+	Multiple bool // Target is a multiple of the current value
+}
+
+// Parse a forecast like `<col>=<value>` or `<col>=<multiple>x`
+func ParseForecast(str string) (Forecast, error) {
+	var f Forecast
+	col, target, found := strings.Cut(str, `=`)
+	if !found || col == `` {
+		return f, fmt.Errorf("forecast must be <col>=<value> or <col>=<multiple>x: `%s`", str)
+	}
+
+	f.Col = col
+	if multiple, ok := strings.CutSuffix(target, `x`); ok {
+		f.Multiple, target = true, multiple
+	}
+	value, err := strconv.ParseFloat(target, 64)
+	if err != nil || (f.Multiple && value <= 0) {
+		return f, fmt.Errorf("invalid forecast target `%s`", target)
+	}
+	f.Target = value
+	return f, nil
+}
+
+func (f Forecast) String() string {
+	target := strconv.FormatFloat(f.Target, 'f', -1, 64)
+	if f.Multiple {
+		target += `x`
+	}
+	return fmt.Sprintf("%s=%s", f.Col, target)
+}
+
+// Error if the Forecast's col is not in the Viewer
+func (f Forecast) Check(sv Viewer) error {
+	if !hasCol(sv, f.Col) {
+		return fmt.Errorf("forecast %s: no col %s in view %s", f, f.Col, sv.GetName())
+	}
+	return nil
+}
+
+// A col's value at a point in the session, seconds since the Forecaster's first Record by the server's clock (a capture file replays in no time)
+type forecastPoint struct {
+	t, value float64
+}
+
+// Keeps the history of each Forecast's col over the session and fits a line through it (least squares) to project when the target will be reached
+type Forecaster struct {
+	forecasts []Forecast
+	history   [][]forecastPoint
+
+	// Seconds since the first Record, from the Records' intervals
+	elapsed float64
+	started bool
+}
+
+func NewForecaster(forecasts []Forecast) *Forecaster {
+	return &Forecaster{
+		forecasts: forecasts,
+		history:   make([][]forecastPoint, len(forecasts)),
+	}
+}
+
+// Add the Record's values to the history.  Repeated Records are left out, they would flatten the trend.
+func (fc *Forecaster) Add(r Record) {
+	if r.Repeated {
+		return
+	}
+	if fc.started {
+		fc.elapsed += r.Interval
+	}
+	fc.started = true
+	for i, f := range fc.forecasts {
+		value, ok := r.Values[f.Col].(float64)
+		if !ok {
+			continue
+		}
+		fc.history[i] = append(fc.history[i], forecastPoint{fc.elapsed, value})
+		if len(fc.history[i]) > FORECAST_HISTORY {
+			fc.history[i] = fc.history[i][1:]
+		}
+	}
+}
+
+// The least squares line through the points, false if there are too few or they are all at the same time
+func linearFit(points []forecastPoint) (slope, intercept float64, ok bool) {
+	n := float64(len(points))
+	if n < 2 {
+		return 0, 0, false
+	}
+	var sumT, sumV, sumTT, sumTV float64
+	for _, p := range points {
+		sumT += p.t
+		sumV += p.value
+		sumTT += p.t * p.t
+		sumTV += p.t * p.value
+	}
+	denominator := n*sumTT - sumT*sumT
+	if denominator == 0 {
+		return 0, 0, false
+	}
+	slope = (n*sumTV - sumT*sumV) / denominator
+	intercept = (sumV - slope*sumT) / n
+	return slope, intercept, true
+}
+
+// Describe when the Forecast's target will be reached given its history
+func (fc *Forecaster) project(i int) string {
+	f, points := fc.forecasts[i], fc.history[i]
+	slope, intercept, ok := linearFit(points)
+	if !ok {
+		return fmt.Sprintf("%s needs more data", f)
+	}
+
+	// Projected from the fitted line rather than the last value, which may be noisy
+	last := points[len(points)-1]
+	current := intercept + slope*last.t
+	target := f.Target
+	if f.Multiple {
+		target *= current
+	}
+	trend := fmt.Sprintf("%s/s", strconv.FormatFloat(slope, 'g', 3, 64))
+	if slope >= 0 {
+		trend = `+` + trend
+	}
+
+	switch {
+	case f.Multiple && current <= 0:
+		return fmt.Sprintf("%s unknown, currently %s (%s)", f, strconv.FormatFloat(current, 'g', 3, 64), trend)
+	case !f.Multiple && (points[0].value < target) != (last.value < target):
+		return fmt.Sprintf("%s reached (%s)", f, trend)
+	case slope == 0 || (target-current)/slope < 0:
+		return fmt.Sprintf("%s never (%s)", f, trend)
+	}
+	seconds := (target - current) / slope
+	if seconds > FORECAST_HORIZON.Seconds() {
+		return fmt.Sprintf("%s never (%s)", f, trend)
+	}
+	eta := time.Duration(seconds * float64(time.Second)).Round(time.Second)
+	return fmt.Sprintf("%s in %s (%s)", f, eta, trend)
+}
+
+// A line with the projection of every Forecast
+func (fc *Forecaster) Line() string {
+	var projections []string
+	for i := range fc.forecasts {
+		projections = append(projections, fc.project(i))
+	}
+	return `forecast: ` + strings.Join(projections, `; `)
+}
@@ -0,0 +1,79 @@
+package viewer
+
+import (
+	"testing"
+)
+
+func TestParseForecast(t *testing.T) {
+	tests := map[string]Forecast{
+		`Checkpoint/age=1073741824`: {Col: `Checkpoint/age`, Target: 1073741824},
+		`tmp/disk=2x`:               {Col: `tmp/disk`, Target: 2, Multiple: true},
+	}
+	for str, expected := range tests {
+		forecast, err := ParseForecast(str)
+		if err != nil {
+			t.Error(err)
+		}
+		if forecast != expected {
+			t.Errorf(`%s: unexpected forecast %+v`, str, forecast)
+		}
+		if forecast.String() != str {
+			t.Errorf(`unexpected String(): %s`, forecast)
+		}
+	}
+	for _, str := range []string{`age`, `=5`, `age=x`, `age=0x`} {
+		if _, err := ParseForecast(str); err == nil {
+			t.Errorf(`expected error for %s`, str)
+		}
+	}
+}
+
+func TestForecastCheck(t *testing.T) {
+	gc := getTestGroupCol()
+	if err := (Forecast{Col: `Connects/cons`}).Check(gc); err != nil {
+		t.Error(err)
+	}
+	if err := (Forecast{Col: `cons`}).Check(gc); err == nil {
+		t.Error(`expected error for a col outside its group`)
+	}
+}
+
+func TestLinearFit(t *testing.T) {
+	slope, intercept, ok := linearFit([]forecastPoint{{0, 1}, {1, 3}, {2, 5}})
+	if !ok || slope != 2 || intercept != 1 {
+		t.Errorf("unexpected fit: %v, %v, %v", slope, intercept, ok)
+	}
+	if _, _, ok := linearFit([]forecastPoint{{1, 1}, {1, 2}}); ok {
+		t.Error("expected no fit for points at the same time")
+	}
+}
+
+func TestForecaster(t *testing.T) {
+	forecasts := []Forecast{
+		{Col: `age`, Target: 100},
+		{Col: `age`, Target: 2, Multiple: true},
+		{Col: `age`, Target: 15},
+		{Col: `age`, Target: 5},
+		{Col: `free`, Target: 0},
+	}
+	fc := NewForecaster(forecasts)
+	if line := fc.Line(); line != `forecast: age=100 needs more data; age=2x needs more data; age=15 needs more data; age=5 needs more data; free=0 needs more data` {
+		t.Errorf("unexpected line: %s", line)
+	}
+
+	// age grows by 1/s from 10, free is flat
+	for i := range 10 {
+		fc.Add(Record{
+			Interval: 1,
+			Values:   map[string]any{`age`: float64(10 + i), `free`: 50.0},
+		})
+	}
+
+	// Repeated Records are not new data
+	fc.Add(Record{Interval: 60, Values: map[string]any{`age`: 1000.0}, Repeated: true})
+
+	expected := `forecast: age=100 in 1m21s (+1/s); age=2x in 19s (+1/s); age=15 reached (+1/s); age=5 never (+1/s); free=0 never (+0/s)`
+	if line := fc.Line(); line != expected {
+		t.Errorf("unexpected line:\n%s\nexpected:\n%s", line, expected)
+	}
+}
@@ -0,0 +1,68 @@
+package viewer
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// The values of keys over the last States in a ring buffer, for cols that show how they changed.  Each State is only added once however many times it is rendered, by its seq, so it is the history of a single host.
+type History struct {
+	ring []map[string]float64
+	next int    // where the next State goes
+	seq  uint64 // the last State added
+}
+
+// Keep the values of the last size States
+func NewHistory(size int) *History {
+	return &History{ring: make([]map[string]float64, 0, size)}
+}
+
+// Add the values of the State, unless it or a later one was already
+func (h *History) Add(sr loader.StateReader, values map[string]float64) {
+	if seq := sr.GetSeq(); seq != 0 {
+		if seq <= h.seq {
+			return
+		}
+		h.seq = seq
+	}
+	if len(h.ring) < cap(h.ring) {
+		h.ring = append(h.ring, values)
+		return
+	}
+	h.ring[h.next] = values
+	h.next = (h.next + 1) % len(h.ring)
+}
+
+// The values of the key, oldest first, zero in the States it had none
+func (h *History) Get(key string) []float64 {
+	values := make([]float64, 0, len(h.ring))
+	for _, states := range [][]map[string]float64{h.ring[h.next:], h.ring[:h.next]} {
+		for _, state := range states {
+			values = append(values, state[key])
+		}
+	}
+	return values
+}
+
+var sparks = []rune(`▁▂▃▄▅▆▇█`)
+
+// The values as bars scaled from zero to their max, right aligned in width
+func Sparkline(values []float64, width int) string {
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+	top := slices.Max(append([]float64{0}, values...))
+
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat(` `, width-len(values)))
+	for _, v := range values {
+		level := 0
+		if top > 0 && v > 0 {
+			level = min(int(v/top*float64(len(sparks)-1)+0.5), len(sparks)-1)
+		}
+		sb.WriteRune(sparks[level])
+	}
+	return sb.String()
+}
@@ -0,0 +1,50 @@
+package viewer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+func TestHistory(t *testing.T) {
+	h := NewHistory(3)
+	for seq, value := range []float64{1, 2, 3, 4} {
+		state := loader.NewState()
+		state.Seq = uint64(seq + 1)
+		h.Add(state, map[string]float64{`a`: value})
+
+		// Rendering a State again doesn't add it twice
+		h.Add(state, map[string]float64{`a`: value})
+	}
+
+	// Nor does an older one
+	state := loader.NewState()
+	state.Seq = 2
+	h.Add(state, map[string]float64{`a`: 9})
+
+	if values := h.Get(`a`); !reflect.DeepEqual(values, []float64{2, 3, 4}) {
+		t.Errorf("unexpected values: %v", values)
+	}
+	if values := h.Get(`b`); !reflect.DeepEqual(values, []float64{0, 0, 0}) {
+		t.Errorf("unexpected values of a missing key: %v", values)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		values   []float64
+		width    int
+		expected string
+	}{
+		{[]float64{0, 1, 2, 4, 8}, 5, `▁▂▃▅█`},
+		{[]float64{0, 0}, 4, `  ▁▁`},
+		{[]float64{1, 2, 3}, 2, `▆█`},
+		{nil, 3, `   `},
+	}
+	for _, test := range tests {
+		if spark := Sparkline(test.values, test.width); spark != test.expected {
+			t.Errorf("%v: unexpected sparkline: %q", test.values, spark)
+		}
+	}
+}
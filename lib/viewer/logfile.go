@@ -0,0 +1,123 @@
+package viewer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// When a LogFile is rotated: once it reaches Size bytes or is Age old, zero is never
+type LogRotation struct {
+	Size int64
+	Age  time.Duration
+}
+
+// Suffixes of -logrotate sizes
+var logSizeUnits = map[string]int64{
+	`K`: 1 << 10,
+	`M`: 1 << 20,
+	`G`: 1 << 30,
+}
+
+// Parse a rotation like `100M` (a size in bytes, with an optional K, M or G suffix) or `24h` (a duration)
+func ParseLogRotation(str string) (LogRotation, error) {
+	var r LogRotation
+	if age, err := time.ParseDuration(str); err == nil {
+		if age <= 0 {
+			return r, fmt.Errorf("log rotation must be > 0: `%s`", str)
+		}
+		r.Age = age
+		return r, nil
+	}
+
+	number, multiplier := str, int64(1)
+	if len(str) > 0 {
+		if m, ok := logSizeUnits[strings.ToUpper(str[len(str)-1:])]; ok {
+			number, multiplier = str[:len(str)-1], m
+		}
+	}
+	size, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || size <= 0 {
+		return r, fmt.Errorf("log rotation must be a size (example: 100M) or a duration (example: 24h): `%s`", str)
+	}
+	r.Size = size * multiplier
+	return r, nil
+}
+
+// A file the output is appended to, renamed with the time it was rotated (e.g. `myq.log.20240501-120000`) and started over per its LogRotation.  Files are only rotated between lines.
+type LogFile struct {
+	path     string
+	rotation LogRotation
+
+	f       *os.File
+	size    int64
+	opened  time.Time
+	lineEnd bool // the last write ended a line
+}
+
+// Open the file for appending
+func OpenLogFile(path string, rotation LogRotation) (*LogFile, error) {
+	lf := &LogFile{path: path, rotation: rotation}
+	return lf, lf.open()
+}
+
+func (lf *LogFile) open() error {
+	f, err := os.OpenFile(lf.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	lf.f, lf.size, lf.opened, lf.lineEnd = f, info.Size(), time.Now(), true
+	return nil
+}
+
+// Is it time to rotate?
+func (lf *LogFile) due(now time.Time) bool {
+	return (lf.rotation.Size > 0 && lf.size >= lf.rotation.Size) ||
+		(lf.rotation.Age > 0 && now.Sub(lf.opened) >= lf.rotation.Age)
+}
+
+// Rename the file out of the way and start a new one
+func (lf *LogFile) rotate(now time.Time) error {
+	if err := lf.f.Close(); err != nil {
+		return err
+	}
+
+	// Several rotations in the same second get a counter
+	rotated := lf.path + `.` + now.Format(`20060102-150405`)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(rotated); os.IsNotExist(err) {
+			break
+		}
+		rotated = fmt.Sprintf("%s.%s.%d", lf.path, now.Format(`20060102-150405`), i)
+	}
+	if err := os.Rename(lf.path, rotated); err != nil {
+		return err
+	}
+	return lf.open()
+}
+
+func (lf *LogFile) Write(p []byte) (int, error) {
+	if now := time.Now(); lf.lineEnd && lf.due(now) {
+		if err := lf.rotate(now); err != nil {
+			return 0, err
+		}
+	}
+	n, err := lf.f.Write(p)
+	lf.size += int64(n)
+	if n > 0 {
+		lf.lineEnd = bytes.HasSuffix(p[:n], []byte("\n"))
+	}
+	return n, err
+}
+
+func (lf *LogFile) Close() error {
+	return lf.f.Close()
+}
@@ -0,0 +1,62 @@
+package viewer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLogRotation(t *testing.T) {
+	tests := map[string]LogRotation{
+		`100M`: {Size: 100 << 20},
+		`2g`:   {Size: 2 << 30},
+		`5000`: {Size: 5000},
+		`24h`:  {Age: 24 * time.Hour},
+	}
+	for str, expected := range tests {
+		rotation, err := ParseLogRotation(str)
+		if err != nil {
+			t.Error(err)
+		}
+		if rotation != expected {
+			t.Errorf(`%s: unexpected rotation %+v`, str, rotation)
+		}
+	}
+	for _, str := range []string{``, `M`, `5x`, `0`, `-1h`} {
+		if _, err := ParseLogRotation(str); err == nil {
+			t.Errorf(`expected error for %s`, str)
+		}
+	}
+}
+
+func TestLogFileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), `myq.log`)
+	lf, err := OpenLogFile(path, LogRotation{Size: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A line is never split across files
+	for _, s := range []string{"12345", "67890\n", "abcdefghijk\n", "z\n"} {
+		if _, err := lf.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lf.Close()
+
+	matches, _ := filepath.Glob(path + `*`)
+	if len(matches) != 3 {
+		t.Fatalf("unexpected files: %q", matches)
+	}
+	var contents []string
+	for _, match := range matches {
+		content, _ := os.ReadFile(match)
+		contents = append(contents, string(content))
+	}
+	// The current file sorts first, then the rotated ones in order
+	if strings.Join(contents, `|`) != "z\n|1234567890\n|abcdefghijk\n" {
+		t.Errorf("unexpected contents: %q", contents)
+	}
+}
@@ -0,0 +1,137 @@
+package viewer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// Why a value may not be a plain measurement, a value can have several
+type Quality uint8
+
+const (
+	GAP     Quality = 1 << iota // computed across missed intervals
+	RESTART                     // first value after a server restart
+	STALE                       // its Source was sampled longer ago than the col allows
+	CLIPPED                     // too wide for the col
+	REPEAT                      // repeated from an earlier interval because this one's collection failed
+)
+
+// Names of the Qualities in the -markers policy and the legend, in order of precedence when a value has several
+var qualityNames = []struct {
+	quality Quality
+	name    string
+}{
+	{REPEAT, `repeat`},
+	{RESTART, `restart`},
+	{STALE, `stale`},
+	{GAP, `gap`},
+	{CLIPPED, `clipped`},
+}
+
+// The default marker policy, clipped strings are usually obvious enough
+const DEFAULT_MARKERS string = `repeat=^,restart=!,stale=*,gap=~`
+
+// The marker appended to values of each Quality, a missing Quality isn't marked
+var markers = mustParseMarkers(DEFAULT_MARKERS)
+
+// Set the markers from a policy like `gap=~,stale=*`, Qualities not in the policy are not marked.  `none` disables markers.
+func SetMarkers(policy string) error {
+	parsed, err := parseMarkers(policy)
+	if err != nil {
+		return err
+	}
+	markers = parsed
+	return nil
+}
+
+func parseMarkers(policy string) (map[Quality]string, error) {
+	parsed := make(map[Quality]string)
+	if policy == `none` || policy == `` {
+		return parsed, nil
+	}
+
+	for _, pair := range strings.Split(policy, `,`) {
+		name, marker, found := strings.Cut(pair, `=`)
+		if !found || len(marker) != 1 {
+			return nil, fmt.Errorf("marker must be name=<single character>: `%s`", pair)
+		}
+
+		known := false
+		for _, qn := range qualityNames {
+			if qn.name == name {
+				parsed[qn.quality] = marker
+				known = true
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown marker `%s`", name)
+		}
+	}
+	return parsed, nil
+}
+
+func mustParseMarkers(policy string) map[Quality]string {
+	parsed, err := parseMarkers(policy)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// Explain the markers in use, or an empty string if there are none
+func GetLegend() string {
+	var entries []string
+	for _, qn := range qualityNames {
+		if marker, ok := markers[qn.quality]; ok {
+			entries = append(entries, fmt.Sprintf("%s %s", marker, qn.name))
+		}
+	}
+	if len(entries) == 0 {
+		return ``
+	}
+	return `markers: ` + strings.Join(entries, `, `)
+}
+
+// The marker of the Quality with the most precedence, if any
+func (q Quality) marker() string {
+	for _, qn := range qualityNames {
+		if q&qn.quality != 0 {
+			if marker, ok := markers[qn.quality]; ok {
+				return marker
+			}
+		}
+	}
+	return ``
+}
+
+// Qualities of values computed from both the Current and Previous SampleSets
+func stateQuality(sr loader.StateReader) (q Quality) {
+	if sr.GetMissed() > 0 {
+		q |= GAP
+	}
+	if sr.HasRestarted() {
+		q |= RESTART
+	}
+	if sr.IsRepeated() {
+		q |= REPEAT
+	}
+	return
+}
+
+// Fit the string to the length, making room for the marker of the Quality if it has one
+func fitMarked(str string, length int, q Quality) string {
+	marker := q.marker()
+	if marker == `` {
+		return FitString(str, length)
+	}
+	return FitString(str, length-len(marker)) + marker
+}
+
+// Fit the value into the col, making room for the marker of the Quality if it has one, in color if it is past a level
+func (nc colNum) fitMarkedNumber(value float64, q Quality) string {
+	marker := q.marker()
+	nc.Length -= len(marker)
+	return nc.colorize(value, fitNumberString(nc.fitNumber(value, nc.Precision), nc.Length)+marker)
+}
@@ -0,0 +1,68 @@
+package viewer
+
+import (
+	"testing"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+func TestSetMarkers(t *testing.T) {
+	defer SetMarkers(DEFAULT_MARKERS)
+
+	if GetLegend() != `markers: ^ repeat, ! restart, * stale, ~ gap` {
+		t.Errorf("unexpected default legend: %s", GetLegend())
+	}
+
+	if err := SetMarkers(`gap=?,clipped=>`); err != nil {
+		t.Fatal(err)
+	}
+	if GetLegend() != `markers: ? gap, > clipped` {
+		t.Errorf("unexpected legend: %s", GetLegend())
+	}
+	if (GAP | STALE).marker() != `?` {
+		t.Errorf("unexpected marker: %s", (GAP | STALE).marker())
+	}
+
+	if err := SetMarkers(`none`); err != nil || GetLegend() != `` {
+		t.Errorf("markers not disabled: %v %s", err, GetLegend())
+	}
+
+	for _, bad := range []string{`gap`, `gap=~~`, `bogus=~`} {
+		if err := SetMarkers(bad); err == nil {
+			t.Errorf("no error for `%s`", bad)
+		}
+	}
+}
+
+func TestRateColGapMarker(t *testing.T) {
+	col := getTestRateCol()
+	state := getTestRateState(`10`, `15`).(*loader.State)
+
+	state.Missed = 2
+	if output := col.GetData(state); output[0] != `  5~` {
+		t.Errorf("unexpected gap output: '%s'", output[0])
+	}
+
+	state.Missed = 0
+	state.Restarted = true
+	if output := col.GetData(state); output[0] != `  5!` {
+		t.Errorf("unexpected restart output: '%s'", output[0])
+	}
+
+	state.Repeated = true
+	if output := col.GetData(state); output[0] != `  5^` {
+		t.Errorf("unexpected repeat output: '%s'", output[0])
+	}
+}
+
+func TestStringColClippedMarker(t *testing.T) {
+	defer SetMarkers(DEFAULT_MARKERS)
+	SetMarkers(`clipped=>`)
+
+	col := getTestStringCol()
+	state := getTestStringState(`ThisIsAVeryLongString`)
+	output := col.GetData(state)
+	if len(output[0]) != col.Length || output[0][col.Length-1] != '>' {
+		t.Errorf("unexpected clipped output: '%s'", output[0])
+	}
+}
@@ -0,0 +1,230 @@
+package viewer
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// How the numbers of cols are fit into their length
+type NumberFormat int
+
+const (
+	// A col without a format uses the one of -units
+	FORMAT_DEFAULT NumberFormat = iota
+	// Collapsed with a unit suffix, memory in powers of 1024 (K, M, G)
+	FORMAT_IEC
+	// Collapsed with a unit suffix, memory in powers of 1000 (k, M, G)
+	FORMAT_SI
+	// The whole number in the base unit without a suffix, e.g. for piping into other tools
+	FORMAT_RAW
+)
+
+// Parse a format like `iec`, `si` or `raw`
+func ParseNumberFormat(str string) (NumberFormat, error) {
+	switch strings.ToLower(str) {
+	case `iec`:
+		return FORMAT_IEC, nil
+	case `si`:
+		return FORMAT_SI, nil
+	case `raw`:
+		return FORMAT_RAW, nil
+	}
+	return FORMAT_DEFAULT, fmt.Errorf("invalid number format %s, expected raw, si or iec", str)
+}
+
+func (nf *NumberFormat) UnmarshalYAML(value *yaml.Node) (err error) {
+	*nf, err = ParseNumberFormat(value.Value)
+	return err
+}
+
+// The format of cols that don't set one, and what raw numbers are grouped with
+var defaultNumberFormat = FORMAT_IEC
+var thousandsSeparator string
+
+func SetNumberFormat(format NumberFormat) {
+	defaultNumberFormat = format
+}
+
+// Group the digits of raw numbers, e.g. `,` for 1,234,567 on a terminal.  Empty doesn't group them.
+func SetThousandsSeparator(separator string) {
+	thousandsSeparator = separator
+}
+
+// The thousands separator of the locale in LC_ALL, LC_NUMERIC or LANG, `,` if it isn't one we know
+func LocaleThousandsSeparator() string {
+	for _, name := range []string{`LC_ALL`, `LC_NUMERIC`, `LANG`} {
+		locale := os.Getenv(name)
+		if locale == `` {
+			continue
+		}
+		fields := strings.FieldsFunc(locale, func(r rune) bool { return r == '_' || r == '.' || r == '@' })
+		if len(fields) == 0 {
+			return `,`
+		}
+		lang := strings.ToLower(fields[0])
+		if separator, ok := localeSeparators[lang]; ok {
+			return separator
+		}
+		return `,`
+	}
+	return `,`
+}
+
+// Languages that don't group digits with `,`
+var localeSeparators = map[string]string{
+	`da`: `.`, `de`: `.`, `el`: `.`, `es`: `.`, `id`: `.`, `it`: `.`, `nl`: `.`, `pt`: `.`, `ro`: `.`, `tr`: `.`,
+	`cs`: ` `, `fi`: ` `, `fr`: ` `, `nb`: ` `, `pl`: ` `, `ru`: ` `, `sk`: ` `, `sv`: ` `, `uk`: ` `,
+}
+
+// Fits a number into the length of a col
+type numberFormatter interface {
+	fit(nc colNum, value float64, precision int) string
+}
+
+// The formatter of the col's format, or of -units if it has none
+func (nc colNum) formatter() numberFormatter {
+	format := nc.Format
+	if format == FORMAT_DEFAULT {
+		format = defaultNumberFormat
+	}
+	switch format {
+	case FORMAT_RAW:
+		return rawFormatter{separator: thousandsSeparator}
+	case FORMAT_SI:
+		return collapsingFormatter{memory: siMemoryUnits}
+	}
+	return collapsingFormatter{memory: unitsLookup[MEMORY]}
+}
+
+// Memory in powers of 1000
+var siMemoryUnits = UnitsDef{
+	1:             `b`,
+	1000:          `k`,
+	1000000:       `M`,
+	1000000000:    `G`,
+	1000000000000: `T`,
+}
+
+// Prints the whole number, even if it doesn't fit (the col is widened at the next header)
+type rawFormatter struct {
+	separator string
+}
+
+func (rf rawFormatter) fit(nc colNum, value float64, precision int) string {
+	str := fmt.Sprintf(`%.*f`, precision, value)
+	if rf.separator != `` {
+		str = groupThousands(str, rf.separator)
+	}
+	if utf8.RuneCountInString(str) > nc.Length && precision > 0 {
+		return rf.fit(nc, value, precision-1)
+	}
+	return str
+}
+
+// Right align a number from fitNumber in the length, raw numbers wider than it are printed whole rather than cut
+func fitNumberString(str string, length int) string {
+	if utf8.RuneCountInString(str) > length {
+		return str
+	}
+	return FitString(str, length)
+}
+
+// Separate the thousands of the integer part of a number like `1234567.8` or `-1234`
+func groupThousands(str, separator string) string {
+	sign := ``
+	if strings.HasPrefix(str, `-`) || strings.HasPrefix(str, `+`) {
+		sign, str = str[:1], str[1:]
+	}
+	integer, fraction, hasFraction := strings.Cut(str, `.`)
+	var grouped strings.Builder
+	grouped.WriteString(sign)
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			grouped.WriteString(separator)
+		}
+		grouped.WriteRune(digit)
+	}
+	if hasFraction {
+		return grouped.String() + `.` + fraction
+	}
+	return grouped.String()
+}
+
+// Collapses the number into a bigger unit with a suffix until it fits, e.g. `12k`
+type collapsingFormatter struct {
+	// The units of MEMORY cols
+	memory UnitsDef
+}
+
+func (cf collapsingFormatter) fit(nc colNum, value float64, precision int) string {
+	// Get the units we will be using
+	units := unitsLookup[nc.Units]
+	if nc.Units == MEMORY {
+		units = cf.memory
+	}
+
+	// Load the factors from the given unit and sort them
+	var factors []float64
+	for k := range units {
+		factors = append(factors, k)
+	}
+	sort.Float64s(factors)
+
+	// Starting from the smallest to the biggest factors
+	for _, factor := range factors {
+		unit := units[factor]
+		raw := value / factor
+		str := fmt.Sprintf(`%.*f%s`, precision, raw, unit)
+		left := nc.Length - utf8.RuneCountInString(str)
+
+		// fmt.Printf("%f, %d, %d, %s, %f, %s, %d\n", value, nc.Length, nc.Precision, unit, raw, str, left)
+
+		if raw >= 0 && (nc.Length+precision)-utf8.RuneCountInString(str) >= 0 {
+			// Our number is > 0 and fits into nc.Length + precision
+			if left < 0 {
+				if precision > 0 {
+					// No space left, try to chop the precision
+					return cf.fit(nc, value, precision-1)
+				} else {
+					// Nothing to chop, any bigger factors will be too wide, so return here.
+					return str
+				}
+			} else if left > 1 && factor != 1 {
+				// If we have space for some extra precision, use it
+				return fmt.Sprintf(`%.*f%s`, left-1, raw, unit)
+			} else {
+				if factor != 1 && raw < 1 && left > 0 && fmt.Sprintf(`%.1f`, raw) != `1.0` {
+					// Raw is < 1, therefore str is rounded up.  Let's print a decimal instead
+					return fmt.Sprintf(`%0.*f%s`, precision+left, raw, unit)[1:]
+				} else if factor != 1 && str == fmt.Sprintf("0%s", unit) {
+					if left > 0 {
+						// There's still some space left to print something intelligent
+						return fmt.Sprintf(`%.*f%s`, precision+1, raw, unit)[1:]
+					}
+
+					// if we are returning 0m, 0k, etc, then we can't fit this number into the size given
+					return strings.Repeat(`#`, nc.Length)
+				} else {
+					// Just return what we've got
+					return str
+				}
+			}
+		}
+	}
+
+	// We're past the highest factor and nothing fits
+	str := fmt.Sprintf(`%.*f`, precision, value)
+	if len(str) > nc.Length && precision > 0 {
+		// We can try chopping precision here for a fit
+		return cf.fit(nc, value, precision-1)
+	} else {
+		// Just print it (too wide)
+		// return str
+		return strings.Repeat(`#`, int(nc.Length))
+	}
+}
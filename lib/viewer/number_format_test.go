@@ -0,0 +1,96 @@
+package viewer
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestNumberFormats(t *testing.T) {
+	defer SetNumberFormat(FORMAT_IEC)
+	defer SetThousandsSeparator(``)
+
+	assert := func(test_name, expected string, format NumberFormat, units UnitsType, val float64, precision, width int) {
+		col := getTestcolNum(units, precision, width)
+		col.Format = format
+		str := col.fitNumber(val, col.Precision)
+		if str != expected {
+			t.Errorf("%s err: `%s` != `%s`", test_name, str, expected)
+		}
+	}
+
+	assert(`iec kay`, `12K`, FORMAT_IEC, MEMORY, 12300, 0, 4)
+	assert(`si kay`, `12k`, FORMAT_SI, MEMORY, 12300, 0, 4)
+	assert(`si em`, `12.3M`, FORMAT_SI, MEMORY, 12300000, 0, 5)
+	assert(`si numbers as before`, `12m`, FORMAT_SI, NUMBER, 12300000, 0, 4)
+
+	assert(`raw count`, `12300000`, FORMAT_RAW, NUMBER, 12300000, 0, 8)
+	assert(`raw memory`, `12300`, FORMAT_RAW, MEMORY, 12300, 0, 6)
+	assert(`raw too wide`, `12300000`, FORMAT_RAW, NUMBER, 12300000, 0, 5)
+	assert(`raw chops precision`, `12.3`, FORMAT_RAW, SECOND, 12.345, 2, 4)
+	assert(`raw signed`, `-123`, FORMAT_RAW, NUMBER, -123, 0, 4)
+
+	SetThousandsSeparator(`,`)
+	assert(`raw grouped`, `12,300,000`, FORMAT_RAW, NUMBER, 12300000, 0, 10)
+	assert(`raw grouped too wide`, `12,300,000`, FORMAT_RAW, NUMBER, 12300000, 0, 8)
+	SetThousandsSeparator(``)
+
+	// Cols without a format use the default one
+	SetNumberFormat(FORMAT_RAW)
+	assert(`default raw`, `12300`, FORMAT_DEFAULT, MEMORY, 12300, 0, 6)
+	assert(`col overrides default`, `12K`, FORMAT_IEC, MEMORY, 12300, 0, 4)
+}
+
+func TestGroupThousands(t *testing.T) {
+	tests := map[string]string{
+		`1`:         `1`,
+		`123`:       `123`,
+		`1234`:      `1.234`,
+		`123456`:    `123.456`,
+		`1234567.8`: `1.234.567.8`,
+		`-123`:      `-123`,
+		`-1234.5`:   `-1.234.5`,
+		`-123456`:   `-123.456`,
+	}
+	for str, expected := range tests {
+		if grouped := groupThousands(str, `.`); grouped != expected {
+			t.Errorf("%s: unexpected %s", str, grouped)
+		}
+	}
+}
+
+func TestLocaleThousandsSeparator(t *testing.T) {
+	tests := []struct{ lcAll, lang, expected string }{
+		{``, ``, `,`},
+		{``, `en_US.UTF-8`, `,`},
+		{``, `de_DE.UTF-8`, `.`},
+		{``, `fr_FR`, ` `},
+		{`C`, `de_DE.UTF-8`, `,`},
+		{`pt_BR.UTF-8`, `en_US.UTF-8`, `.`},
+		{`.`, `de_DE.UTF-8`, `,`},
+	}
+	for _, test := range tests {
+		t.Setenv(`LC_ALL`, test.lcAll)
+		t.Setenv(`LC_NUMERIC`, ``)
+		t.Setenv(`LANG`, test.lang)
+		if separator := LocaleThousandsSeparator(); separator != test.expected {
+			t.Errorf("%s/%s: unexpected %q", test.lcAll, test.lang, separator)
+		}
+	}
+}
+
+func TestParseNumberFormat(t *testing.T) {
+	for str, expected := range map[string]NumberFormat{`raw`: FORMAT_RAW, `SI`: FORMAT_SI, `iec`: FORMAT_IEC} {
+		if format, err := ParseNumberFormat(str); err != nil || format != expected {
+			t.Errorf("%s: unexpected %d: %v", str, format, err)
+		}
+	}
+	if _, err := ParseNumberFormat(`binary`); err == nil {
+		t.Error("expected an error")
+	}
+
+	var col GaugeCol
+	if err := yaml.Unmarshal([]byte("name: size\nunits: Memory\nformat: raw\n"), &col); err != nil || col.Format != FORMAT_RAW {
+		t.Errorf("unexpected format %d: %v", col.Format, err)
+	}
+}
@@ -0,0 +1,279 @@
+package viewer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// A Record is the structured output of a View for a single State.  Values are the unformatted values of each col keyed by its name (prefixed by its Group name if it has one), numbers are float64s, strings are strings, and values that could not be computed are nil.
+type Record struct {
+	View string `json:"view"`
+	Seq  uint64 `json:"seq"`
+	Time string `json:"time"` // as in the time col
+
+	// When the State was collected (in UTC), and the seconds since the previous one
+	Timestamp time.Time `json:"timestamp"`
+	Interval  float64   `json:"interval"`
+
+	Values map[string]any `json:"values"`
+
+	// The values are repeated from an earlier State because this one's collection failed
+	Repeated bool `json:"repeated,omitempty"`
+
+	// Out-of-band messages about the State, and tags describing where it came from
+	Annotations []string          `json:"annotations,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+}
+
+// Build the Record of the Viewer for the given State
+func GetRecord(sv Viewer, sr loader.StateReader) Record {
+	record := Record{
+		View:        sv.GetName(),
+		Seq:         sr.GetSeq(),
+		Time:        timeCol.getTime(sr),
+		Timestamp:   sr.GetCurrent().GetTimeGenerated().UTC(),
+		Interval:    sr.SecondsDiff(),
+		Values:      make(map[string]any),
+		Repeated:    sr.IsRepeated(),
+		Annotations: sr.GetAnnotations(),
+	}
+	recordViewer(record.Values, ``, sv, sr)
+	return record
+}
+
+// Recursively add the values of the given Viewer to the values map
+func recordViewer(values map[string]any, prefix string, sv Viewer, sr loader.StateReader) {
+	// Store a value, or nil if it could not be computed (JSON has no NaN or Inf)
+	set := func(name string, value any, err error) {
+		if f, ok := value.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+			err = fmt.Errorf("not a number: %f", f)
+		}
+		if err != nil {
+			value = nil
+		}
+		values[prefix+name] = value
+	}
+
+	// A col that panics has no value, the rest of the Record is still built
+	defer func() {
+		if r := recover(); r != nil {
+			reportRenderError(prefix+sv.GetName(), r)
+			values[prefix+sv.GetName()] = nil
+		}
+	}()
+
+	switch c := sv.(type) {
+	case View:
+		for _, child := range c.getAvailableGroups(sr) {
+			recordViewer(values, prefix, child, sr)
+		}
+		for _, child := range c.Cols {
+			recordViewer(values, prefix, child, sr)
+		}
+	case GroupCol:
+		for _, child := range c.Cols {
+			recordViewer(values, prefix+c.Name+`/`, child, sr)
+		}
+	case RateCol:
+		value, err := c.getRate(sr)
+		set(c.Name, value, err)
+	case RateSumCol:
+		value, err := c.getRate(sr)
+		set(c.Name, value, err)
+	case DiffCol:
+		value, err := c.getDiff(sr)
+		set(c.Name, value, err)
+	case PercentCol:
+		value, err := c.getPercent(sr)
+		set(c.Name, value, err)
+	case RatePercentCol:
+		value, err := c.getPercent(sr)
+		set(c.Name, value, err)
+	case RateRatioCol:
+		value, err := c.getRatio(sr)
+		set(c.Name, value, err)
+	case ExprCol:
+		value, err := c.getValue(sr)
+		set(c.Name, value, err)
+	case SubtractCol:
+		value, err := c.getSubtract(sr)
+		set(c.Name, value, err)
+	case GtidSubtractCol:
+		value, err := c.getSubtract(sr)
+		set(c.Name, value, err)
+	case GaugeCol:
+		if value, err := sr.GetCurrent().GetFloat(c.Key); err == nil {
+			set(c.Name, value, nil)
+		} else {
+			value, err := sr.GetCurrent().GetString(c.Key)
+			set(c.Name, value, err)
+		}
+	case StringCol:
+		value, err := sr.GetCurrent().GetString(c.Key)
+		set(c.Name, value, err)
+	case SwitchCol:
+		value, err := sr.GetCurrent().GetString(c.Key)
+		if mapped, ok := c.Cases[value]; ok && err == nil {
+			value = mapped
+		}
+		set(c.Name, value, err)
+	case SortedExpandedCountsCol:
+		// Every key with activity, and the total
+		var total float64
+		for _, sk := range sr.GetCurrent().ExpandSourceKeys(c.Keys) {
+			var prev float64
+			if prevssp := sr.GetPrevious(); prevssp != nil {
+				prev = prevssp.GetF(sk)
+			}
+			if diff := calculateDiff(sr.GetCurrent().GetF(sk), prev); diff > 0 {
+				set(c.Name+`/`+sk.Key, diff, nil)
+				total += diff
+			}
+		}
+		set(c.Name+`/total`, total, nil)
+	case SortedObjectsCol:
+		// Every field of the objects with activity
+		for _, object := range c.objects(sr) {
+			for _, f := range c.Fields {
+				set(c.Name+`/`+object+`/`+f.Name, c.value(sr, f, object), nil)
+			}
+		}
+	}
+}
+
+// Read newline delimited JSON Records
+func ReadRecords(r io.Reader) (records []Record, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == `` {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// How far apart two numeric values can be and still match: within Absolute of each other, or within Relative (a fraction) of the bigger one
+type Tolerance struct {
+	Absolute float64
+	Relative float64
+}
+
+// Parse a tolerance like `5%`, `0.05` (both relative) or `+10` (absolute)
+func ParseTolerance(str string) (Tolerance, error) {
+	var t Tolerance
+	var err error
+	if abs, found := strings.CutPrefix(str, `+`); found {
+		t.Absolute, err = strconv.ParseFloat(abs, 64)
+	} else if pct, found := strings.CutSuffix(str, `%`); found {
+		t.Relative, err = strconv.ParseFloat(pct, 64)
+		t.Relative /= 100
+	} else {
+		t.Relative, err = strconv.ParseFloat(str, 64)
+	}
+	if err != nil || t.Absolute < 0 || t.Relative < 0 {
+		return t, fmt.Errorf("invalid tolerance `%s`", str)
+	}
+	return t, nil
+}
+
+// Do the numbers match within the Tolerance?
+func (t Tolerance) matches(a, b float64) bool {
+	delta := math.Abs(a - b)
+	return delta <= t.Absolute || delta <= t.Relative*math.Max(math.Abs(a), math.Abs(b))
+}
+
+// A value that differs between two Records
+type RecordDiff struct {
+	Seq  uint64 // of the first Record
+	Time string // of the first Record
+	Col  string
+	A, B any
+}
+
+func (rd RecordDiff) String() string {
+	format := func(value any) string {
+		switch v := value.(type) {
+		case nil:
+			return `-`
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		return fmt.Sprintf("%q", value)
+	}
+	line := fmt.Sprintf("%d %s %s: %s != %s", rd.Seq, rd.Time, rd.Col, format(rd.A), format(rd.B))
+	if a, ok := rd.A.(float64); ok {
+		if b, ok := rd.B.(float64); ok && a != 0 {
+			line += fmt.Sprintf(" (%+.1f%%)", (b-a)/math.Abs(a)*100)
+		}
+	}
+	return line
+}
+
+// Compares two recorded sessions Record by Record and col by col.  Cols can have their own Tolerance, keyed by col name or Group name, otherwise the default Tolerance is used.
+type RecordComparer struct {
+	Default Tolerance
+	Cols    map[string]Tolerance
+}
+
+// The Tolerance for a col, the col's own first, then its Group's
+func (rc RecordComparer) tolerance(col string) Tolerance {
+	for name := col; ; {
+		if t, ok := rc.Cols[name]; ok {
+			return t
+		}
+		i := strings.LastIndex(name, `/`)
+		if i < 0 {
+			return rc.Default
+		}
+		name = name[:i]
+	}
+}
+
+// Compare the Records in order.  A col missing from one of the Records compares like a value that could not be computed.
+func (rc RecordComparer) Compare(a, b []Record) (diffs []RecordDiff) {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		var cols []string
+		for col := range a[i].Values {
+			cols = append(cols, col)
+		}
+		for col := range b[i].Values {
+			if _, ok := a[i].Values[col]; !ok {
+				cols = append(cols, col)
+			}
+		}
+		sort.Strings(cols)
+
+		for _, col := range cols {
+			va, vb := a[i].Values[col], b[i].Values[col]
+			if rc.valuesMatch(col, va, vb) {
+				continue
+			}
+			diffs = append(diffs, RecordDiff{Seq: a[i].Seq, Time: a[i].Time, Col: col, A: va, B: vb})
+		}
+	}
+	return
+}
+
+func (rc RecordComparer) valuesMatch(col string, a, b any) bool {
+	fa, aok := a.(float64)
+	fb, bok := b.(float64)
+	if aok && bok {
+		return rc.tolerance(col).matches(fa, fb)
+	}
+	return a == b
+}
@@ -0,0 +1,103 @@
+package viewer
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestGetRecord(t *testing.T) {
+	gc := getTestGroupCol()
+	sr := getTestGroupState()
+
+	record := GetRecord(gc, sr)
+	if record.View != `Connects` {
+		t.Errorf(`unexpected view: %s`, record.View)
+	}
+	if len(record.Values) != 2 {
+		t.Fatalf(`unexpected values: %v`, record.Values)
+	}
+	if value, ok := record.Values[`Connects/conn`].(float64); !ok || value != 4 {
+		t.Errorf(`unexpected Connects/conn: %v`, record.Values[`Connects/conn`])
+	}
+	if _, ok := record.Values[`Connects/cons`]; !ok {
+		t.Errorf(`missing Connects/cons: %v`, record.Values)
+	}
+
+	// Values that can't be computed are null
+	record = GetRecord(getTestGaugeCol(), getTestRateState(`1`, `2`))
+	if value, ok := record.Values[`conn`]; !ok || value != nil {
+		t.Errorf(`expected nil conn: %v`, record.Values)
+	}
+}
+
+func TestReadRecords(t *testing.T) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for i := 0; i < 3; i++ {
+		encoder.Encode(GetRecord(getTestGroupCol(), getTestGroupState()))
+	}
+
+	records, err := ReadRecords(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf(`unexpected records: %d`, len(records))
+	}
+	if records[2].Values[`Connects/conn`] != 4.0 {
+		t.Errorf(`unexpected value: %v`, records[2].Values)
+	}
+
+	if _, err := ReadRecords(bytes.NewBufferString("{}\nnope\n")); err == nil {
+		t.Error(`expected error for a bad line`)
+	}
+}
+
+func TestParseTolerance(t *testing.T) {
+	tests := map[string]Tolerance{
+		`5%`:   {Relative: 0.05},
+		`0.1`:  {Relative: 0.1},
+		`+100`: {Absolute: 100},
+	}
+	for str, expected := range tests {
+		tolerance, err := ParseTolerance(str)
+		if err != nil {
+			t.Error(err)
+		}
+		if tolerance != expected {
+			t.Errorf(`%s: unexpected tolerance %+v`, str, tolerance)
+		}
+	}
+	for _, str := range []string{`x`, `-5%`, `+`} {
+		if _, err := ParseTolerance(str); err == nil {
+			t.Errorf(`expected error for %s`, str)
+		}
+	}
+}
+
+func TestRecordComparer(t *testing.T) {
+	a := []Record{{Seq: 1, Values: map[string]any{`g/qps`: 100.0, `g/lat`: 10.0, `state`: `ON`, `gone`: 1.0}}}
+	b := []Record{{Seq: 1, Values: map[string]any{`g/qps`: 104.0, `g/lat`: 12.0, `state`: `ON`, `new`: nil}}}
+
+	rc := RecordComparer{
+		Default: Tolerance{Relative: 0.05},
+		Cols:    map[string]Tolerance{`g/lat`: {Absolute: 1}},
+	}
+	diffs := rc.Compare(a, b)
+	if len(diffs) != 2 {
+		t.Fatalf(`unexpected diffs: %v`, diffs)
+	}
+	if diffs[0].Col != `g/lat` || diffs[1].Col != `gone` {
+		t.Errorf(`unexpected diffs: %v`, diffs)
+	}
+	if diffs[0].String() != `1  g/lat: 10 != 12 (+20.0%)` {
+		t.Errorf(`unexpected diff string: %s`, diffs[0])
+	}
+
+	// A Group tolerance covers its cols
+	rc.Cols = map[string]Tolerance{`g`: {Relative: 0.5}}
+	if diffs := rc.Compare(a, b); len(diffs) != 1 {
+		t.Errorf(`unexpected diffs: %v`, diffs)
+	}
+}
@@ -0,0 +1,45 @@
+package viewer
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// What a col shows instead of its output when rendering it panicked
+const RENDER_ERROR = `ERR`
+
+// A panic while rendering a col, recovered so the rest of the View keeps going
+type RenderError struct {
+	Col   string // Name of the col, prefixed by its Group name if known
+	Value any    // What was passed to panic
+	Stack []byte // Of the goroutine when it panicked
+}
+
+func (re RenderError) Error() string {
+	return fmt.Sprintf("col %s panicked: %v", re.Col, re.Value)
+}
+
+// Called for every RenderError, nothing is reported by default
+var renderErrorHandler func(RenderError)
+
+func SetRenderErrorHandler(handler func(RenderError)) {
+	renderErrorHandler = handler
+}
+
+// Report a recovered panic of the named col
+func reportRenderError(col string, value any) {
+	if renderErrorHandler != nil {
+		renderErrorHandler(RenderError{Col: col, Value: value, Stack: debug.Stack()})
+	}
+}
+
+// The output of the Viewer, or RENDER_ERROR in its width if getting it panicked
+func renderCol(sv Viewer, getColOut func(sv Viewer) []string) (output []string) {
+	defer func() {
+		if r := recover(); r != nil {
+			reportRenderError(sv.GetName(), r)
+			output = []string{FitString(RENDER_ERROR, len(sv.GetBlank()))}
+		}
+	}()
+	return getColOut(sv)
+}
@@ -0,0 +1,109 @@
+package viewer
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// A StateReader that panics on every call, to inject a fault into any col
+type panicState struct {
+	loader.StateReader
+}
+
+// A col with a bug
+type panicCol struct {
+	defaultCol
+}
+
+func (c panicCol) GetData(sr loader.StateReader) []string {
+	panic(`bug`)
+}
+
+// Collect the RenderErrors reported while the test runs
+func captureRenderErrors(t *testing.T) *[]RenderError {
+	var errs []RenderError
+	SetRenderErrorHandler(func(re RenderError) {
+		errs = append(errs, re)
+	})
+	t.Cleanup(func() { SetRenderErrorHandler(nil) })
+	return &errs
+}
+
+func TestRenderColRecovers(t *testing.T) {
+	cols := ViewerList{
+		getTestDiffCol(),
+		getTestExprCol(),
+		getTestGaugeCol(),
+		getTestGtidSubtractCol(),
+		getTestPercentCol(),
+		getTestRateCol(),
+		getTestRatePercentCol(),
+		getTestRateRatioCol(),
+		getTestRateSumCol(),
+		NewSampleTimeCol(),
+		getTestSortedExpandedCountsCol(),
+		getTestSortedObjectsCol(),
+		getTestStringCol(),
+		getTestSubtractCol(),
+		getTestSwitchCol(),
+	}
+	for _, col := range cols {
+		errs := captureRenderErrors(t)
+		lines := pushColOutputUp(ViewerList{col}, func(sv Viewer) []string {
+			return sv.GetData(panicState{})
+		})
+		if expected := FitString(RENDER_ERROR, len(col.GetBlank())); len(lines) != 1 || lines[0] != expected {
+			t.Errorf("%T: unexpected output: %q", col, lines)
+		}
+		if len(*errs) != 1 || (*errs)[0].Col != col.GetName() || len((*errs)[0].Stack) == 0 {
+			t.Errorf("%T: unexpected errors: %v", col, *errs)
+		}
+
+		// Records get no value for the col
+		values := make(map[string]any)
+		recordViewer(values, ``, col, panicState{})
+		for name, value := range values {
+			if value != nil {
+				t.Errorf("%T: unexpected value for %s: %v", col, name, value)
+			}
+		}
+	}
+
+	// Every col type the default views use is covered
+	if err := LoadDefaultViews(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range ListViews() {
+		view, _ := GetViewer(name)
+		walkCols(view, ``, func(colName string, col Viewer) {
+			if !slices.ContainsFunc(cols, func(c Viewer) bool { return reflect.TypeOf(c) == reflect.TypeOf(col) }) {
+				t.Errorf("%s: %T is not covered", name, col)
+			}
+		})
+	}
+}
+
+func TestViewRenderError(t *testing.T) {
+	errs := captureRenderErrors(t)
+	view := getTestView()
+	boom := panicCol{}
+	boom.Name = `boom`
+	boom.Length = 4
+	view.Groups[0].Cols = append(view.Groups[0].Cols, boom)
+	sr := getTestViewState()
+
+	// Only the col with the bug is affected
+	if lines := view.GetData(sr); lines[0] != `      0s    5    4  ERR` {
+		t.Errorf(`unexpected data: '%s'`, lines[0])
+	}
+	if header := view.GetHeader(sr); header[1] != `    time cons conn boom` {
+		t.Errorf(`unexpected header: '%s'`, header[1])
+	}
+
+	if len(*errs) != 1 || (*errs)[0].Error() != `col boom panicked: bug` {
+		t.Errorf("unexpected errors: %v", *errs)
+	}
+}
@@ -0,0 +1,49 @@
+package viewer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// Where the server line of a header comes from
+var (
+	hostnameKey = loader.SourceKey{SourceName: `variables`, Key: `hostname`}
+	portKey     = loader.SourceKey{SourceName: `variables`, Key: `port`}
+	uptimeKey   = loader.SourceKey{SourceName: `status`, Key: `uptime`}
+)
+
+// Start every header with a line about the server, off by default.  Views then also need the variables and status Sources.
+var serverHeaderEnabled bool
+
+func SetServerHeader(on bool) {
+	serverHeaderEnabled = on
+}
+
+// The server the State is from, like `db1:3306 (8.0.36, mysql) up 3d 04:05:06`, empty if the variables weren't collected
+func ServerHeader(sr loader.StateReader) string {
+	current := sr.GetCurrent()
+	if !current.HasSource(hostnameKey.SourceName) {
+		return ``
+	}
+
+	line := fmt.Sprintf("%s:%s", current.GetStr(hostnameKey), current.GetStr(portKey))
+	if version := current.GetStr(versionKey); version != `` {
+		number, _, _ := strings.Cut(version, `-`)
+		line += fmt.Sprintf(" (%s, %s)", number, loader.ParseServerFlavor(version))
+	}
+	if uptime, err := current.GetInt(uptimeKey); err == nil {
+		line += ` up ` + formatUptime(uptime)
+	}
+	return line
+}
+
+// Seconds like `3d 04:05:06`, the days only if there are any
+func formatUptime(seconds int64) string {
+	clock := fmt.Sprintf("%02d:%02d:%02d", seconds/3600%24, seconds/60%60, seconds%60)
+	if days := seconds / 86400; days > 0 {
+		return fmt.Sprintf("%dd %s", days, clock)
+	}
+	return clock
+}
@@ -0,0 +1,58 @@
+package viewer
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+func getTestServerState(version string) loader.StateReader {
+	state := loader.NewState()
+	variables := loader.NewSample()
+	variables.Data[`hostname`] = `db1`
+	variables.Data[`port`] = `3306`
+	variables.Data[`version`] = version
+	state.GetCurrentWriter().SetSample(`variables`, variables)
+	status := loader.NewSample()
+	status.Data[`uptime`] = `273906`
+	state.GetCurrentWriter().SetSample(`status`, status)
+	return state
+}
+
+func TestServerHeader(t *testing.T) {
+	tests := map[string]string{
+		`8.0.36`:            `db1:3306 (8.0.36, mysql) up 3d 04:05:06`,
+		`10.11.6-MariaDB-1`: `db1:3306 (10.11.6, mariadb) up 3d 04:05:06`,
+	}
+	for version, expected := range tests {
+		if line := ServerHeader(getTestServerState(version)); line != expected {
+			t.Errorf("%s: unexpected line: %q", version, line)
+		}
+	}
+
+	if line := ServerHeader(loader.NewState()); line != `` {
+		t.Errorf("no line without variables: %q", line)
+	}
+	if uptime := formatUptime(59); uptime != `00:00:59` {
+		t.Errorf("unexpected uptime: %s", uptime)
+	}
+}
+
+func TestViewServerHeader(t *testing.T) {
+	view := getTestView()
+	state := getTestServerState(`8.0.36`)
+	plain := view.GetHeader(state)
+
+	SetServerHeader(true)
+	defer SetServerHeader(false)
+
+	header := view.GetHeader(state)
+	if len(header) != len(plain)+1 || header[0] != `db1:3306 (8.0.36, mysql) up 3d 04:05:06` {
+		t.Errorf("unexpected header: %q", header)
+	}
+	sources, _ := view.GetSources()
+	if !slices.Contains(sources, `variables`) || !slices.Contains(sources, `status`) {
+		t.Errorf("the server line needs variables and status: %v", sources)
+	}
+}
@@ -0,0 +1,128 @@
+package viewer
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The percentile in a Summarizer's lines
+const SUMMARY_PERCENTILE = 95
+
+// Accumulates the numeric values of each col over a session, to summarize them (min/avg/max/p95) on exit
+type Summarizer struct {
+	// The cols in the order of the view, others (e.g. the keys of SortedExpandedCounts) go after them
+	cols []string
+
+	values  map[string][]float64
+	records int
+}
+
+// Summarize the given cols, as named in Records
+func NewSummarizer(cols []string) *Summarizer {
+	return &Summarizer{cols: cols, values: make(map[string][]float64)}
+}
+
+// Add the Record's numeric values.  Repeated Records are left out, they would count the same values twice.
+func (s *Summarizer) Add(r Record) {
+	if r.Repeated {
+		return
+	}
+	s.records += 1
+	for name, value := range r.Values {
+		if f, ok := value.(float64); ok {
+			s.values[name] = append(s.values[name], f)
+		}
+	}
+}
+
+// The value at the given percentile of sorted values, by the nearest rank
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+func formatSummaryValue(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
+
+// The summary of a col's numeric values over a session
+type ColSummary struct {
+	Min   float64 `json:"min"`
+	Avg   float64 `json:"avg"`
+	Max   float64 `json:"max"`
+	P95   float64 `json:"p95"`
+	Count int     `json:"count"`
+}
+
+// The cols with a value, in the order of the view then the others sorted
+func (s *Summarizer) names() (names []string) {
+	for _, name := range s.cols {
+		if len(s.values[name]) > 0 {
+			names = append(names, name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.values)) {
+		if !slices.Contains(s.cols, name) {
+			names = append(names, name)
+		}
+	}
+	return
+}
+
+// The summary of each col that had a value, by name
+func (s *Summarizer) Cols() map[string]ColSummary {
+	summaries := make(map[string]ColSummary)
+	for name, values := range s.values {
+		values = slices.Sorted(slices.Values(values))
+		var sum float64
+		for _, v := range values {
+			sum += v
+		}
+		summaries[name] = ColSummary{
+			Min:   values[0],
+			Avg:   sum / float64(len(values)),
+			Max:   values[len(values)-1],
+			P95:   percentile(values, SUMMARY_PERCENTILE),
+			Count: len(values),
+		}
+	}
+	return summaries
+}
+
+// A table of the min, avg, max and p95 of each col that had a value, with a header line
+func (s *Summarizer) Lines() []string {
+	summaries := s.Cols()
+	rows := [][]string{{`col`, `min`, `avg`, `max`, fmt.Sprintf("p%d", SUMMARY_PERCENTILE), `n`}}
+	for _, name := range s.names() {
+		cs := summaries[name]
+		rows = append(rows, []string{
+			name,
+			formatSummaryValue(cs.Min),
+			formatSummaryValue(cs.Avg),
+			formatSummaryValue(cs.Max),
+			formatSummaryValue(cs.P95),
+			strconv.Itoa(cs.Count),
+		})
+	}
+
+	// The names are left aligned and the numbers right aligned
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, field := range row {
+			widths[i] = max(widths[i], len(field))
+		}
+	}
+	lines := []string{fmt.Sprintf("-- summary of %d samples --", s.records)}
+	for _, row := range rows {
+		fields := []string{fmt.Sprintf("%-*s", widths[0], row[0])}
+		for i, field := range row[1:] {
+			fields = append(fields, fmt.Sprintf("%*s", widths[i+1], field))
+		}
+		lines = append(lines, strings.Join(fields, `  `))
+	}
+	return lines
+}
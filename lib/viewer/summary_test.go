@@ -0,0 +1,39 @@
+package viewer
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPercentile(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}
+	if p := percentile(values, 95); p != 19 {
+		t.Errorf("unexpected p95: %v", p)
+	}
+	if p := percentile([]float64{7}, 95); p != 7 {
+		t.Errorf("unexpected p95: %v", p)
+	}
+}
+
+func TestSummarizer(t *testing.T) {
+	s := NewSummarizer([]string{`Connects/cons`, `time`, `Net/recv`})
+	s.Add(Record{Values: map[string]any{`Connects/cons`: 10.0, `time`: `12:00:00`, `Net/recv`: nil, `cmds/total`: 3.0}})
+	s.Add(Record{Values: map[string]any{`Connects/cons`: 20.0, `time`: `12:00:01`, `Net/recv`: 1024.0}})
+	s.Add(Record{Values: map[string]any{`Connects/cons`: 99.0}, Repeated: true})
+	s.Add(Record{Values: map[string]any{`Connects/cons`: 0.5, `time`: `12:00:02`, `Net/recv`: 2048.0}})
+
+	expected := []string{
+		`-- summary of 3 samples --`,
+		`col             min    avg   max   p95  n`,
+		`Connects/cons   0.5  10.17    20    20  3`,
+		`Net/recv       1024   1536  2048  2048  2`,
+		`cmds/total        3      3     3     3  1`,
+	}
+	if lines := s.Lines(); !slices.Equal(lines, expected) {
+		t.Errorf("unexpected lines:\n%q", lines)
+	}
+
+	if cols := s.Cols(); cols[`Net/recv`] != (ColSummary{Min: 1024, Avg: 1536, Max: 2048, P95: 2048, Count: 2}) || len(cols) != 3 {
+		t.Errorf("unexpected cols: %+v", cols)
+	}
+}
@@ -0,0 +1,117 @@
+package viewer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A Threshold is a limit on the value of a col, e.g. `Connects/cons>100`
+type Threshold struct {
+	Col   string // as named in Records
+	Above bool   // breached above Value, else below it
+	Value float64
+}
+
+// Parse a threshold like `<col>><value>` or `<col><<value>`
+func ParseThreshold(str string) (Threshold, error) {
+	var t Threshold
+	i := strings.LastIndexAny(str, `<>`)
+	if i <= 0 {
+		return t, fmt.Errorf("threshold must be <col>><value> or <col><<value>: `%s`", str)
+	}
+
+	value, err := strconv.ParseFloat(str[i+1:], 64)
+	if err != nil {
+		return t, fmt.Errorf("invalid threshold value `%s`", str[i+1:])
+	}
+	t.Col, t.Above, t.Value = str[:i], str[i] == '>', value
+	return t, nil
+}
+
+func (t Threshold) String() string {
+	op := `<`
+	if t.Above {
+		op = `>`
+	}
+	return fmt.Sprintf("%s%s%s", t.Col, op, strconv.FormatFloat(t.Value, 'f', -1, 64))
+}
+
+// Error if the Threshold's col is not in the Viewer
+func (t Threshold) Check(sv Viewer) error {
+	if !hasCol(sv, t.Col) {
+		return fmt.Errorf("threshold %s: no col %s in view %s", t, t.Col, sv.GetName())
+	}
+	return nil
+}
+
+// Is the col, as named in Records, in the Viewer?  Cols with several values (e.g. SortedExpandedCounts) are matched by prefix.
+func hasCol(sv Viewer, col string) bool {
+	for _, name := range GetColNames(sv) {
+		if col == name || strings.HasPrefix(col, name+`/`) {
+			return true
+		}
+	}
+	return false
+}
+
+// Is the col's value in the Record beyond the Threshold?  Missing and non-numeric values never are.
+func (t Threshold) Breached(r Record) bool {
+	value, ok := r.Values[t.Col].(float64)
+	if !ok {
+		return false
+	}
+	if t.Above {
+		return value > t.Value
+	}
+	return value < t.Value
+}
+
+// Tracks a set of Thresholds across Records to report when they are crossed, rather than on every Record while they stay breached
+type ThresholdWatcher struct {
+	thresholds []Threshold
+	breached   []bool
+
+	// How many times each Threshold was crossed, and how many Records breached it
+	crossings, breaches []int
+}
+
+func NewThresholdWatcher(thresholds []Threshold) *ThresholdWatcher {
+	return &ThresholdWatcher{
+		thresholds: thresholds,
+		breached:   make([]bool, len(thresholds)),
+		crossings:  make([]int, len(thresholds)),
+		breaches:   make([]int, len(thresholds)),
+	}
+}
+
+// The Thresholds newly breached by this Record
+func (tw *ThresholdWatcher) Crossed(r Record) (crossed []Threshold) {
+	for i, t := range tw.thresholds {
+		breached := t.Breached(r)
+		if breached && !tw.breached[i] {
+			crossed = append(crossed, t)
+			tw.crossings[i] += 1
+		}
+		if breached {
+			tw.breaches[i] += 1
+		}
+		tw.breached[i] = breached
+	}
+	return
+}
+
+// How often a Threshold was breached over a session
+type ThresholdSummary struct {
+	Threshold string `json:"threshold"`
+	Crossed   int    `json:"crossed"`  // times it became breached
+	Breached  int    `json:"breached"` // Records beyond it
+}
+
+// How often each Threshold was breached so far
+func (tw *ThresholdWatcher) Summary() (summaries []ThresholdSummary) {
+	for i, t := range tw.thresholds {
+		summaries = append(summaries, ThresholdSummary{Threshold: t.String(), Crossed: tw.crossings[i], Breached: tw.breaches[i]})
+	}
+	return
+}
@@ -0,0 +1,58 @@
+package viewer
+
+import (
+	"testing"
+)
+
+func TestParseThreshold(t *testing.T) {
+	tests := map[string]Threshold{
+		`Connects/cons>100`: {Col: `Connects/cons`, Above: true, Value: 100},
+		`hit<99.5`:          {Col: `hit`, Value: 99.5},
+	}
+	for str, expected := range tests {
+		threshold, err := ParseThreshold(str)
+		if err != nil {
+			t.Error(err)
+		}
+		if threshold != expected {
+			t.Errorf(`%s: unexpected threshold %+v`, str, threshold)
+		}
+		if threshold.String() != str {
+			t.Errorf(`unexpected String(): %s`, threshold)
+		}
+	}
+	for _, str := range []string{`cons`, `>5`, `cons>x`} {
+		if _, err := ParseThreshold(str); err == nil {
+			t.Errorf(`expected error for %s`, str)
+		}
+	}
+}
+
+func TestThresholdCheck(t *testing.T) {
+	gc := getTestGroupCol()
+	if err := (Threshold{Col: `Connects/cons`}).Check(gc); err != nil {
+		t.Error(err)
+	}
+	if err := (Threshold{Col: `cons`}).Check(gc); err == nil {
+		t.Error(`expected error for a col outside its group`)
+	}
+}
+
+func TestThresholdWatcher(t *testing.T) {
+	tw := NewThresholdWatcher([]Threshold{{Col: `qps`, Above: true, Value: 10}})
+	record := func(value any) Record {
+		return Record{Values: map[string]any{`qps`: value}}
+	}
+
+	crossings := 0
+	for _, value := range []any{5.0, 11.0, 12.0, nil, 20.0, 1.0} {
+		crossings += len(tw.Crossed(record(value)))
+	}
+	// 11 crosses, 12 stays breached, nil resets, 20 crosses again
+	if crossings != 2 {
+		t.Errorf(`unexpected crossings: %d`, crossings)
+	}
+	if summary := tw.Summary(); len(summary) != 1 || summary[0] != (ThresholdSummary{Threshold: `qps>10`, Crossed: 2, Breached: 3}) {
+		t.Errorf(`unexpected summary: %+v`, summary)
+	}
+}
@@ -0,0 +1,75 @@
+package viewer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+var (
+	wsrepStateCommentKey = loader.SourceKey{SourceName: `status`, Key: `wsrep_local_state_comment`}
+	wsrepISTStatusKey    = loader.SourceKey{SourceName: `status`, Key: `wsrep_ist_receive_status`}
+	wsrepDesyncKey       = loader.SourceKey{SourceName: `variables`, Key: `wsrep_desync`}
+	wsrepSSTMethodKey    = loader.SourceKey{SourceName: `variables`, Key: `wsrep_sst_method`}
+)
+
+// Does the Viewer show the Galera node state, i.e., should it show state transfers?
+func ShowsStateTransfers(sv Viewer) bool {
+	for _, key := range GetSourceKeys(sv, wsrepStateCommentKey.SourceName) {
+		if key == wsrepStateCommentKey.Key {
+			return true
+		}
+	}
+	return false
+}
+
+// Tracks Galera state transfers (SST or IST) across States, to show a line about the transfer in place of the regular data while one is in progress
+type TransferTracker struct {
+	active  bool
+	elapsed float64 // seconds
+}
+
+func NewTransferTracker() *TransferTracker {
+	return &TransferTracker{}
+}
+
+// The node's role in a state transfer in progress, if any.  A donor is Donor/Desynced, but so is a node desynced by hand.
+func transferRole(sr loader.StateReader) string {
+	comment := sr.GetCurrent().GetStr(wsrepStateCommentKey)
+	switch {
+	case strings.HasPrefix(comment, `Joining`):
+		return `joiner`
+	case comment == `Donor/Desynced` && sr.GetCurrent().GetStr(wsrepDesyncKey) != `ON`:
+		return `donor`
+	}
+	return ``
+}
+
+// A line describing the transfer in progress in this State, or false if there isn't one
+func (tt *TransferTracker) Line(sr loader.StateReader) (string, bool) {
+	role := transferRole(sr)
+	if role == `` {
+		tt.active, tt.elapsed = false, 0
+		return ``, false
+	}
+	if tt.active {
+		tt.elapsed += sr.SecondsDiff()
+	}
+	tt.active = true
+
+	details := []string{role}
+	if comment := sr.GetCurrent().GetStr(wsrepStateCommentKey); role == `joiner` && comment != `Joining` {
+		details = append(details, strings.TrimPrefix(comment, `Joining: `))
+	}
+	if method := sr.GetCurrent().GetStr(wsrepSSTMethodKey); method != `` {
+		details = append(details, `sst method `+method)
+	}
+	if ist := sr.GetCurrent().GetStr(wsrepISTStatusKey); ist != `` {
+		details = append(details, `IST `+ist)
+	}
+	details = append(details, `for `+(time.Duration(tt.elapsed)*time.Second).String())
+
+	return fmt.Sprintf("%s state transfer: %s", timeCol.GetData(sr)[0], strings.Join(details, `, `)), true
+}
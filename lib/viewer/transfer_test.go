@@ -0,0 +1,69 @@
+package viewer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// A State of a Galera node in the given state, uptime seconds in
+func getTestTransferState(comment, desync, ist string, uptime int64) loader.StateReader {
+	sp := loader.NewState()
+	status := loader.NewSample()
+	status.Data[`wsrep_local_state_comment`] = comment
+	if ist != `` {
+		status.Data[`wsrep_ist_receive_status`] = ist
+	}
+	variables := loader.NewSample()
+	variables.Data[`wsrep_desync`] = desync
+	variables.Data[`wsrep_sst_method`] = `xtrabackup-v2`
+	sp.GetCurrentWriter().SetSample(`status`, status)
+	sp.GetCurrentWriter().SetSample(`variables`, variables)
+	sp.GetCurrentWriter().SetUptime(uptime)
+
+	prevss := loader.NewSampleSet()
+	prevss.SetUptime(uptime - 5)
+	sp.SetPrevious(prevss)
+	return sp
+}
+
+func TestTransferTracker(t *testing.T) {
+	tt := NewTransferTracker()
+
+	if _, ok := tt.Line(getTestTransferState(`Synced`, `OFF`, ``, 10)); ok {
+		t.Error("a synced node isn't transferring")
+	}
+	if _, ok := tt.Line(getTestTransferState(`Donor/Desynced`, `ON`, ``, 15)); ok {
+		t.Error("a node desynced by hand isn't a donor")
+	}
+
+	line, ok := tt.Line(getTestTransferState(`Joining: receiving State Transfer`, `OFF`, ``, 20))
+	if !ok || line != `     20s state transfer: joiner, receiving State Transfer, sst method xtrabackup-v2, for 0s` {
+		t.Errorf("unexpected line: '%s'", line)
+	}
+
+	line, _ = tt.Line(getTestTransferState(`Joining: receiving State Transfer`, `OFF`, `3% complete, received seqno 1086 of 1100-1537`, 25))
+	if !strings.HasSuffix(line, `IST 3% complete, received seqno 1086 of 1100-1537, for 5s`) {
+		t.Errorf("unexpected line: '%s'", line)
+	}
+
+	// Elapsed starts over with the next transfer
+	tt.Line(getTestTransferState(`Joined`, `OFF`, ``, 30))
+	line, _ = tt.Line(getTestTransferState(`Donor/Desynced`, `OFF`, ``, 35))
+	if !strings.HasSuffix(line, `state transfer: donor, sst method xtrabackup-v2, for 0s`) {
+		t.Errorf("unexpected line: '%s'", line)
+	}
+}
+
+func TestShowsStateTransfers(t *testing.T) {
+	if err := LoadDefaultViews(); err != nil {
+		t.Fatal(err)
+	}
+	if cttf, _ := GetViewer(`cttf`); ShowsStateTransfers(cttf) {
+		t.Error("cttf doesn't show the node state")
+	}
+	if wsrep, err := GetViewer(`wsrep`); err == nil && !ShowsStateTransfers(wsrep) {
+		t.Error("wsrep shows the node state")
+	}
+}
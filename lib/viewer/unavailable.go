@@ -0,0 +1,45 @@
+package viewer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// Remembers the cols that read metrics the server doesn't have, so the `-` they show is explained once rather than taken for a collection problem
+type UnavailableCols struct {
+	// `<view>/<col>` already found
+	found map[string]bool
+}
+
+func NewUnavailableCols() *UnavailableCols {
+	return &UnavailableCols{found: make(map[string]bool)}
+}
+
+// The cols of the Viewer shown in the State that read a metric missing from a Source it collected, like `Memory/size (variables/query_cache_size)`.  Each col is only returned the first time.
+func (uc *UnavailableCols) Check(sv Viewer, sr loader.StateReader) (cols []string) {
+	// Only the Groups that are shown
+	if view, ok := sv.(View); ok {
+		var groups []GroupCol
+		for _, group := range view.Groups {
+			if group.isAvailable(sr) {
+				groups = append(groups, group)
+			}
+		}
+		view.Groups = groups
+		sv = view
+	}
+
+	for _, name := range CheckMetrics(sv, sr).Missing {
+		source, key, _ := strings.Cut(name, `/`)
+		for _, use := range findMetricInViewer(sv, loader.SourceName(source), key) {
+			if use.Pattern || uc.found[sv.GetName()+`/`+use.Col] {
+				continue
+			}
+			uc.found[sv.GetName()+`/`+use.Col] = true
+			cols = append(cols, fmt.Sprintf("%s (%s)", use.Col, name))
+		}
+	}
+	return
+}
@@ -0,0 +1,37 @@
+package viewer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+func TestUnavailableCols(t *testing.T) {
+	gauge := func(name, source, key string) GaugeCol {
+		gc := GaugeCol{Key: loader.SourceKey{SourceName: loader.SourceName(source), Key: key}}
+		gc.Name = name
+		return gc
+	}
+	group := GroupCol{Cols: ViewerList{gauge(`conn`, `status`, `threads_connected`), gauge(`size`, `status`, `query_cache_size`)}}
+	group.Name = `Cache`
+	view := View{GroupCol: GroupCol{Cols: ViewerList{gauge(`trx`, `innodb_trx`, `active`)}}, Groups: []GroupCol{group}}
+	view.Name = `test`
+
+	state := loader.NewState()
+	status := loader.NewSample()
+	status.Data[`threads_connected`] = `5`
+	state.GetCurrentWriter().SetSample(`status`, status)
+
+	// innodb_trx failed to collect, its metric isn't missing from the server
+	state.GetCurrentWriter().SetSample(`innodb_trx`, loader.NewSampleErr(errors.New(`cannot run query`)))
+
+	uc := NewUnavailableCols()
+	if cols := uc.Check(view, state); !reflect.DeepEqual(cols, []string{`Cache/size (status/query_cache_size)`}) {
+		t.Errorf("unexpected unavailable cols: %q", cols)
+	}
+	if cols := uc.Check(view, state); len(cols) != 0 {
+		t.Errorf("cols found again: %q", cols)
+	}
+}
@@ -0,0 +1,71 @@
+package viewer
+
+import "strings"
+
+// The metrics dictionary: units of metrics whose names don't follow the suffix conventions below
+var metricUnits = map[string]UnitsType{
+	`bytes_received`:                 MEMORY,
+	`bytes_sent`:                     MEMORY,
+	`binlog_bytes_written`:           MEMORY,
+	`innodb_buffer_pool_bytes_data`:  MEMORY,
+	`innodb_buffer_pool_bytes_dirty`: MEMORY,
+	`innodb_data_read`:               MEMORY,
+	`innodb_data_written`:            MEMORY,
+	`innodb_os_log_written`:          MEMORY,
+	`innodb_checkpoint_age`:          MEMORY,
+	`innodb_checkpoint_max_age`:      MEMORY,
+	`innodb_page_size`:               MEMORY,
+	`innodb_row_lock_time`:           MILLISECOND,
+	`innodb_row_lock_time_avg`:       MILLISECOND,
+	`innodb_row_lock_time_max`:       MILLISECOND,
+	`memory_used`:                    MEMORY,
+	`uptime`:                         SECOND,
+	`uptime_since_flush_status`:      SECOND,
+}
+
+// Units by metric name prefix, checked in order
+var prefixUnits = []struct {
+	prefix string
+	units  UnitsType
+}{
+	{`innodb_lsn_`, MEMORY},
+	{`innodb_mem_`, MEMORY},
+}
+
+// Units by metric name suffix, checked in order
+var suffixUnits = []struct {
+	suffix string
+	units  UnitsType
+}{
+	{`_bytes`, MEMORY},
+	{`_memory`, MEMORY},
+	{`_ns`, NANOSECOND},
+	{`_us`, MICROSECOND},
+	{`_usec`, MICROSECOND},
+	{`_ms`, MILLISECOND},
+	{`_msec`, MILLISECOND},
+	{`_time`, SECOND},
+	{`_seconds`, SECOND},
+	{`_secs`, SECOND},
+	{`_pct`, PERCENT},
+	{`_percent`, PERCENT},
+}
+
+// Guess the units of a metric from its name, plain numbers if we can't tell
+func inferUnits(key string) UnitsType {
+	key = strings.ToLower(key)
+	if units, ok := metricUnits[key]; ok {
+		return units
+	}
+	for _, pu := range prefixUnits {
+		if strings.HasPrefix(key, pu.prefix) {
+			return pu.units
+		}
+	}
+	for _, su := range suffixUnits {
+		if strings.HasSuffix(key, su.suffix) {
+			return su.units
+		}
+	}
+	return NUMBER
+}
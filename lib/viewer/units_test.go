@@ -0,0 +1,39 @@
+package viewer
+
+import "testing"
+
+func TestInferUnits(t *testing.T) {
+	expected := map[string]UnitsType{
+		`bytes_received`:               MEMORY,
+		`wsrep_replicated_bytes`:       MEMORY,
+		`innodb_lsn_flushed`:           MEMORY,
+		`innodb_descriptors_memory`:    MEMORY,
+		`innodb_row_lock_time`:         MILLISECOND,
+		`wsrep_flow_control_paused_ns`: NANOSECOND,
+		`uptime`:                       SECOND,
+		`ssl_session_cache_time`:       SECOND,
+		`Com_select`:                   NUMBER,
+		`wsrep_cluster_size`:           NUMBER,
+	}
+	for key, units := range expected {
+		if inferred := inferUnits(key); inferred != units {
+			t.Errorf("unexpected units for %s: %d", key, inferred)
+		}
+	}
+}
+
+func TestAutoUnits(t *testing.T) {
+	col := getTestcolNum(AUTO, 0, 5)
+	if str := col.forKey(`bytes_sent`).fitNumber(2048, 0); str != `2048b` {
+		t.Errorf("unexpected auto memory: %s", str)
+	}
+	if str := col.forKey(`com_select`).fitNumber(2048, 0); str != `2048` {
+		t.Errorf("unexpected auto number: %s", str)
+	}
+
+	// Explicit units are left alone
+	col = getTestcolNum(NUMBER, 0, 5)
+	if col.forKey(`bytes_sent`).Units != NUMBER {
+		t.Error("explicit units were overridden")
+	}
+}
@@ -4,16 +4,22 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"slices"
 	"strconv"
 	"strings"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
 )
 
-// this needs some error handling and testing love
+// The height and width of our terminal, zeros if there isn't one (e.g., under cron)
 func GetTermSize() (int, int) {
 	cmd := exec.Command("stty", "size")
 	cmd.Stdin = os.Stdin
-	out, _ := cmd.Output()
-	vals := strings.Split(strings.TrimSpace(string(out)), " ")
+	out, err := cmd.Output()
+	vals := strings.Fields(string(out))
+	if err != nil || len(vals) != 2 {
+		return 0, 0
+	}
 
 	height, _ := strconv.ParseInt(vals[0], 10, 64)
 	width, _ := strconv.ParseInt(vals[1], 10, 64)
@@ -21,6 +27,18 @@ func GetTermSize() (int, int) {
 	return int(height), int(width)
 }
 
+// Is the file a terminal rather than a pipe, regular file or /dev/null?
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	// Like GetTermSize, let stty tell terminals from other devices
+	cmd := exec.Command("stty", "-g")
+	cmd.Stdin = f
+	return cmd.Run() == nil
+}
+
 // // Set OS-specific SysProcAttrs if they exist
 // func cleanupSubcmd(c *exec.Cmd) {
 // 	// Send the subprocess a SIGTERM when we exit
@@ -59,6 +77,12 @@ func calculateRate(bigger, smaller, seconds float64) float64 {
 
 // helper function to fit a plain string to our Length
 func FitString(input string, length int) string {
+	if hasEscapes(input) {
+		if width := visibleLen(input); width < length {
+			return strings.Repeat(` `, length-width) + input
+		}
+		return TruncateString(input, length)
+	}
 	if len(input) > int(length) {
 		return input[0:length] // First width characters
 	} else {
@@ -81,7 +105,7 @@ func pushColOutputDown(svs ViewerList, getColOut func(sv Viewer) []string) (resu
 	colsOutput := make([][]string, len(svs))
 	maxLines := 0
 	for i, c := range svs {
-		colsOutput[i] = getColOut(c)
+		colsOutput[i] = renderCol(c, getColOut)
 		if maxLines < len(colsOutput[i]) {
 			maxLines = len(colsOutput[i])
 		}
@@ -121,7 +145,7 @@ func pushColOutputUp(svs ViewerList, getColOut func(sv Viewer) []string) (result
 	colsOutput := make([][]string, len(svs))
 	maxLines := 0
 	for i, c := range svs {
-		colsOutput[i] = getColOut(c)
+		colsOutput[i] = renderCol(c, getColOut)
 		if maxLines < len(colsOutput[i]) {
 			maxLines = len(colsOutput[i])
 		}
@@ -152,3 +176,126 @@ func pushColOutputUp(svs ViewerList, getColOut func(sv Viewer) []string) (result
 	}
 	return
 }
+
+// Source functions
+
+// Get the unique Sources of the given SourceKeys
+func sourcesOf(keys ...loader.SourceKey) (sources []loader.SourceName) {
+	for _, key := range keys {
+		sources = appendSources(sources, key.SourceName)
+	}
+	return
+}
+
+// Append the given Sources to the list, unless they are already in it
+func appendSources(sources []loader.SourceName, add ...loader.SourceName) []loader.SourceName {
+	for _, source := range add {
+		if !slices.Contains(sources, source) {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// Get the unique Sources required by all the given Viewers
+func collectSources(svs ViewerList) (sources []loader.SourceName, err error) {
+	for _, sv := range svs {
+		svSources, err := sv.GetSources()
+		if err != nil {
+			return nil, err
+		}
+		sources = appendSources(sources, svSources...)
+	}
+	return
+}
+
+// Get the names of all the cols in the given Viewer that require the given Source, cols in groups are prefixed by the group name
+func GetColsUsingSource(sv Viewer, source loader.SourceName) (names []string) {
+	var prefix string
+	var svs ViewerList
+	switch v := sv.(type) {
+	case View:
+		for _, group := range v.Groups {
+			svs = append(svs, group)
+		}
+		svs = append(svs, v.Cols...)
+	case GroupCol:
+		prefix = v.Name + "/"
+		svs = v.Cols
+	default:
+		sources, _ := sv.GetSources()
+		if slices.Contains(sources, source) {
+			names = append(names, sv.GetName())
+		}
+		return
+	}
+
+	for _, child := range svs {
+		for _, name := range GetColsUsingSource(child, source) {
+			if !slices.Contains(names, prefix+name) {
+				names = append(names, prefix+name)
+			}
+		}
+	}
+	return
+}
+
+// Get the keys the given Viewer reads from the given Source.  Patterns are left out, only literal keys are returned.
+func GetSourceKeys(sv Viewer, source loader.SourceName) (keys []string) {
+	var svs ViewerList
+	switch v := sv.(type) {
+	case View:
+		for _, group := range v.Groups {
+			svs = append(svs, group)
+		}
+		svs = append(svs, v.Cols...)
+	case GroupCol:
+		svs = v.Cols
+	case SortedExpandedCountsCol, SortedObjectsCol:
+		return
+	case interface{ getKeys() []loader.SourceKey }:
+		for _, key := range v.getKeys() {
+			if key.SourceName == source && !slices.Contains(keys, key.Key) {
+				keys = append(keys, key.Key)
+			}
+		}
+		return
+	}
+
+	for _, child := range svs {
+		for _, key := range GetSourceKeys(child, source) {
+			if !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return
+}
+
+// Get the names of all the cols in the given Viewer, cols in groups are prefixed by the group name
+func GetColNames(sv Viewer) (names []string) {
+	var prefix string
+	var svs ViewerList
+	switch v := sv.(type) {
+	case View:
+		for _, group := range v.Groups {
+			svs = append(svs, group)
+		}
+		svs = append(svs, v.Cols...)
+	case GroupCol:
+		prefix = v.Name + "/"
+		svs = v.Cols
+	default:
+		return []string{sv.GetName()}
+	}
+
+	// Groups for different server flavors can share col names
+	for _, child := range svs {
+		for _, name := range GetColNames(child) {
+			if !slices.Contains(names, prefix+name) {
+				names = append(names, prefix+name)
+			}
+		}
+	}
+	return
+}
@@ -1,6 +1,9 @@
 package viewer
 
-import "testing"
+import (
+	"os"
+	"testing"
+)
 
 func TestcalculateDiff(t *testing.T) {
 	diff := calculateDiff(200, 100)
@@ -48,3 +51,16 @@ func TestfitStringLeft(t *testing.T) {
 		t.Errorf("padded string left improperly: '%s'", out)
 	}
 }
+
+func TestIsTerminal(t *testing.T) {
+	for _, path := range []string{`utils.go`, os.DevNull} {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if IsTerminal(f) {
+			t.Errorf("%s is not a terminal", path)
+		}
+		f.Close()
+	}
+}
@@ -0,0 +1,48 @@
+package viewer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// Global variables worth tracking, a change in one usually explains a change in the metrics
+const SUGGESTED_TRACKED_VARIABLES = `innodb_buffer_pool_size,innodb_flush_log_at_trx_commit,max_connections,read_only,super_read_only,sync_binlog`
+
+// Parse a comma separated list of variables to track, empty for none
+func ParseTrackedVariables(str string) (names []string) {
+	for _, name := range strings.Split(str, `,`) {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != `` {
+			names = append(names, name)
+		}
+	}
+	return
+}
+
+// Tracks the values of some global variables across States, to annotate when they change
+type VariableTracker struct {
+	names []string
+
+	// The last value seen of each variable
+	values map[string]string
+}
+
+func NewVariableTracker(names []string) *VariableTracker {
+	return &VariableTracker{names: names, values: make(map[string]string)}
+}
+
+// The changes of the tracked variables since they were last seen, like `read_only: OFF -> ON`.  The first values seen aren't changes, and States without a variable (e.g., its Source wasn't collected this interval) don't change it.
+func (vt *VariableTracker) Changes(sr loader.StateReader) (changes []string) {
+	for _, name := range vt.names {
+		value, err := sr.GetCurrent().GetString(loader.SourceKey{SourceName: `variables`, Key: name})
+		if err != nil {
+			continue
+		}
+		if last, ok := vt.values[name]; ok && last != value {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", name, last, value))
+		}
+		vt.values[name] = value
+	}
+	return
+}
@@ -0,0 +1,49 @@
+package viewer
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+func getTestVariablesState(variables map[string]string) loader.StateReader {
+	state := loader.NewState()
+	if variables != nil {
+		sample := loader.NewSample()
+		sample.Data = variables
+		state.GetCurrentWriter().SetSample(`variables`, sample)
+	}
+	return state
+}
+
+func TestParseTrackedVariables(t *testing.T) {
+	if names := ParseTrackedVariables(` Read_Only, sync_binlog,,`); !slices.Equal(names, []string{`read_only`, `sync_binlog`}) {
+		t.Errorf("unexpected names: %q", names)
+	}
+	if names := ParseTrackedVariables(``); len(names) != 0 {
+		t.Errorf("unexpected names: %q", names)
+	}
+}
+
+func TestVariableTracker(t *testing.T) {
+	vt := NewVariableTracker([]string{`read_only`, `sync_binlog`})
+
+	// The first values aren't changes
+	if changes := vt.Changes(getTestVariablesState(map[string]string{`read_only`: `OFF`, `sync_binlog`: `1`})); len(changes) != 0 {
+		t.Errorf("unexpected changes: %q", changes)
+	}
+
+	// Variables weren't collected
+	if changes := vt.Changes(getTestVariablesState(nil)); len(changes) != 0 {
+		t.Errorf("unexpected changes: %q", changes)
+	}
+
+	changes := vt.Changes(getTestVariablesState(map[string]string{`read_only`: `ON`, `sync_binlog`: `0`, `max_connections`: `10`}))
+	if !slices.Equal(changes, []string{`read_only: OFF -> ON`, `sync_binlog: 1 -> 0`}) {
+		t.Errorf("unexpected changes: %q", changes)
+	}
+	if changes := vt.Changes(getTestVariablesState(map[string]string{`read_only`: `ON`, `sync_binlog`: `0`})); len(changes) != 0 {
+		t.Errorf("unexpected changes: %q", changes)
+	}
+}
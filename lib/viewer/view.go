@@ -2,6 +2,9 @@ package viewer
 
 import (
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/jayjanssen/myq-tools/lib/loader"
 )
@@ -13,6 +16,12 @@ type View struct {
 
 	// Usually a view would have Groups OR Cols, but not both.  If both, print groups first, then individual cols
 	Groups []GroupCol `yaml:"groups"`
+
+	// Canned output shown in the view's help
+	Example string `yaml:"example"`
+
+	// The interval the view is meant for when its Sources are published less often than every second (e.g., CloudWatch every minute), the default -interval with it
+	Interval time.Duration `yaml:"interval"`
 }
 
 // How to print out the time with our output
@@ -34,15 +43,61 @@ func (v View) GetDetailedHelp() (output []string) {
 			output = append(output, fmt.Sprintf("   %s", line))
 		}
 	}
+	if v.Example != `` {
+		output = append(output, ``, `Example:`)
+		for _, line := range strings.Split(strings.TrimRight(v.Example, "\n"), "\n") {
+			output = append(output, fmt.Sprintf("   %s", line))
+		}
+	}
 	return
 }
 
-// A list of sources that this view requires
+// A list of sources that this view requires, not those of Groups that aren't shown
 func (v View) GetSources() ([]loader.SourceName, error) {
-	return []loader.SourceName{}, nil
-	// return []*loader.Source{
-	// 	&loader.Source{},
-	// }, nil
+	var svs ViewerList
+	for _, group := range v.Groups {
+		if group.isShown() {
+			svs = append(svs, group)
+		}
+	}
+	svs = append(svs, v.Cols...)
+	sources, err := collectSources(svs)
+	if err == nil && (!v.Until.IsZero() || v.Flavor != ``) {
+		sources = appendSources(sources, versionKey.SourceName)
+	}
+	if err == nil && serverHeaderEnabled {
+		sources = appendSources(sources, hostnameKey.SourceName, uptimeKey.SourceName)
+	}
+	if err == nil && timeColMode == TIMECOL_UPTIME {
+		sources = appendSources(sources, uptimeKey.SourceName)
+	}
+	return sources, err
+}
+
+// The interval the Viewer is meant for, zero if any
+func GetInterval(sv Viewer) time.Duration {
+	if view, ok := sv.(View); ok {
+		return view.Interval
+	}
+	return 0
+}
+
+// The View with the given cols after its own, e.g. those of -col
+func AddCols(sv Viewer, cols ...Viewer) (Viewer, error) {
+	view, ok := sv.(View)
+	if !ok {
+		return sv, fmt.Errorf("can't add cols to %s", sv.GetName())
+	}
+	view.Cols = append(slices.Clone(view.Cols), cols...)
+	return view, nil
+}
+
+// Error if the View is not available on the server's version
+func CheckVersion(sv Viewer, sr loader.StateReader) error {
+	if view, ok := sv.(View); ok {
+		return view.checkVersion(sr)
+	}
+	return nil
 }
 
 // Header for this view, unclear if state is needed
@@ -50,9 +105,7 @@ func (v View) GetHeader(sr loader.StateReader) []string {
 	// Collect all the Viewers for this view
 	var svs ViewerList
 	svs = append(svs, timeCol)
-	for _, group := range v.Groups {
-		svs = append(svs, group)
-	}
+	svs = append(svs, v.getAvailableGroups(sr)...)
 	svs = append(svs, v.Cols...)
 
 	// Get the header output of all those svs
@@ -65,6 +118,11 @@ func (v View) GetHeader(sr loader.StateReader) []string {
 		v.Length = len(colOuts[0])
 	}
 
+	if serverHeaderEnabled {
+		if line := ServerHeader(sr); line != `` {
+			return append([]string{line}, colOuts...)
+		}
+	}
 	return colOuts
 }
 
@@ -73,9 +131,7 @@ func (v View) GetData(sr loader.StateReader) (result []string) {
 	// Collect all the Viewers for this view
 	var svs ViewerList
 	svs = append(svs, timeCol)
-	for _, group := range v.Groups {
-		svs = append(svs, group)
-	}
+	svs = append(svs, v.getAvailableGroups(sr)...)
 	svs = append(svs, v.Cols...)
 
 	// Get the data output of all those svs
@@ -83,3 +139,28 @@ func (v View) GetData(sr loader.StateReader) (result []string) {
 		return sv.GetData(sr)
 	})
 }
+
+// The Sources the Viewer needs that aren't in the State, groups that require a missing Source are left out anyway
+func MissingSources(sv Viewer, sr loader.StateReader) (missing []loader.SourceName) {
+	svs := ViewerList{sv}
+	if view, ok := sv.(View); ok {
+		svs = append(view.getAvailableGroups(sr), view.Cols...)
+	}
+	sources, _ := collectSources(svs)
+	for _, source := range sources {
+		if !sr.GetCurrent().HasSource(source) {
+			missing = append(missing, source)
+		}
+	}
+	return
+}
+
+// Groups whose required Sources are in the state
+func (v View) getAvailableGroups(sr loader.StateReader) (svs ViewerList) {
+	for _, group := range v.Groups {
+		if group.isAvailable(sr) {
+			svs = append(svs, group)
+		}
+	}
+	return
+}
@@ -4,6 +4,9 @@ import (
 	"embed"
 	"fmt"
 	"io/fs"
+	"maps"
+	"os"
+	"slices"
 
 	"gopkg.in/yaml.v3"
 )
@@ -11,41 +14,105 @@ import (
 var (
 	viewNames []string
 	views     map[string]View
+
+	// Embedded view files by set, each set registers itself unless it is built out
+	viewSets = make(map[string]fs.FS)
+
+	// The views each set loaded
+	viewSetViews map[string][]string
 )
 
-//go:embed views/*.yaml
-var viewFiles embed.FS
+// The set of views that can't be built out
+const CORE_VIEW_SET = `core`
+
+// Register a set of views from the yaml files in the given directory of the embedded files.  Sets other than core are in their own files with a build tag to leave them out (e.g., `-tags no_galera`).
+func registerViewSet(name string, files embed.FS, dir string) {
+	sub, err := fs.Sub(files, dir)
+	if err != nil {
+		panic(err)
+	}
+	viewSets[name] = sub
+}
+
+// The registered view sets, core first
+func ListViewSets() []string {
+	names := slices.Sorted(maps.Keys(viewSets))
+	if i := slices.Index(names, CORE_VIEW_SET); i > 0 {
+		names = append([]string{CORE_VIEW_SET}, slices.Delete(names, i, i+1)...)
+	}
+	return names
+}
+
+// The names of the Views in the given set
+func ListViewSet(name string) []string {
+	return viewSetViews[name]
+}
 
-// Load the default views from the embedded files
+// Load the default views from the embedded files of every registered set
 func LoadDefaultViews() error {
+	viewNames = nil
+	views = make(map[string]View)
+	viewSetViews = make(map[string][]string)
+	for _, set := range ListViewSets() {
+		if err := loadViewSet(set, viewSets[set]); err != nil {
+			return fmt.Errorf("view set %s: %w", set, err)
+		}
+	}
+	return nil
+}
+
+func loadViewSet(set string, files fs.FS) error {
 	// get the list of files
-	fileNames, err := fs.Glob(viewFiles, "views/*.yaml")
+	fileNames, err := fs.Glob(files, "*.yaml")
 	if err != nil {
 		return err
 	}
 
 	// read and parse each file and add it to the Views map
-	views = make(map[string]View)
 	for _, fileName := range fileNames {
-		bytes, err := fs.ReadFile(viewFiles, fileName)
+		bytes, err := fs.ReadFile(files, fileName)
 		if err != nil {
 			return err
 		}
-
-		// Each file could have multiple views
-		var parsedViews []View
-		err = yaml.Unmarshal(bytes, &parsedViews)
-		if err != nil {
+		if err := addViews(set, bytes); err != nil {
 			return err
 		}
+	}
 
-		// Add the parsed views to the global map
-		for _, view := range parsedViews {
-			viewNames = append(viewNames, view.Name)
-			views[view.Name] = view
+	return nil
+}
+
+// Parse the views of a yaml file, each file could have multiple, and add them to the global map
+func addViews(set string, bytes []byte) error {
+	var parsedViews []View
+	err := yaml.Unmarshal(bytes, &parsedViews)
+	if err != nil {
+		return err
+	}
+
+	for _, view := range parsedViews {
+		if _, dup := views[view.Name]; dup {
+			return fmt.Errorf("view %s is already defined", view.Name)
 		}
+		viewNames = append(viewNames, view.Name)
+		viewSetViews[set] = append(viewSetViews[set], view.Name)
+		views[view.Name] = view
 	}
+	return nil
+}
+
+// The set of views loaded from files, e.g. those of the config file
+const CUSTOM_VIEW_SET = `custom`
 
+// Load the views in a yaml file, written like the default ones, after the default views.  They can't have the name of another view.
+func LoadViewFile(path string) error {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := addViews(CUSTOM_VIEW_SET, bytes); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
 	return nil
 }
 
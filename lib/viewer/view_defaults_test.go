@@ -2,6 +2,7 @@ package viewer
 
 import (
 	"reflect"
+	"slices"
 	"testing"
 
 	"github.com/jayjanssen/myq-tools/lib/loader"
@@ -75,3 +76,109 @@ func TestGetViewer(t *testing.T) {
 		t.Error("expected error fetching bad view")
 	}
 }
+
+func TestListViewSets(t *testing.T) {
+	if err := LoadDefaultViews(); err != nil {
+		t.Fatal(err)
+	}
+
+	sets := ListViewSets()
+	if len(sets) == 0 || sets[0] != CORE_VIEW_SET {
+		t.Fatalf("core isn't the first set: %v", sets)
+	}
+	if !slices.Contains(ListViewSet(CORE_VIEW_SET), `cttf`) {
+		t.Errorf("cttf isn't in core: %v", ListViewSet(CORE_VIEW_SET))
+	}
+
+	// Every view is in exactly one set
+	var all []string
+	for _, set := range sets {
+		all = append(all, ListViewSet(set)...)
+	}
+	if !reflect.DeepEqual(all, ListViews()) {
+		t.Errorf("sets don't add up to the views: %v != %v", all, ListViews())
+	}
+}
+
+func TestDefaultViewSources(t *testing.T) {
+	err := LoadDefaultViews()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wsrep, err := GetViewer(`wsrep`)
+	if err != nil {
+		t.Skip("galera views are built out")
+	}
+	sources, err := wsrep.GetSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sources, []loader.SourceName{`status`, `variables`}) {
+		t.Errorf("unexpected wsrep sources: %v", sources)
+	}
+
+	cols := GetColsUsingSource(wsrep, `variables`)
+	if len(cols) != 1 || cols[0] != `Apply/%ef` {
+		t.Errorf("unexpected cols using variables: %v", cols)
+	}
+}
+
+func TestDefaultViewSourceKeys(t *testing.T) {
+	err := LoadDefaultViews()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flushing, _ := GetViewer(`flushing`)
+	keys := GetSourceKeys(flushing, `innodb_metrics`)
+	if len(keys) != 6 || keys[0] != `buffer_flush_adaptive_total_pages` {
+		t.Errorf("unexpected innodb_metrics keys: %v", keys)
+	}
+
+	processlist, _ := GetViewer(`processlist`)
+	if sources, _ := processlist.GetSources(); !reflect.DeepEqual(sources, []loader.SourceName{`processlist`}) {
+		t.Errorf("unexpected processlist sources: %v", sources)
+	}
+	if keys := GetSourceKeys(processlist, `processlist`); len(keys) != 15 || keys[0] != `threads` {
+		t.Errorf("unexpected processlist keys: %v", keys)
+	}
+
+	innodbLocks, _ := GetViewer(`innodb_locks`)
+	if sources, _ := innodbLocks.GetSources(); !reflect.DeepEqual(sources, []loader.SourceName{`innodb_lock_waits`, `status`, `innodb_metrics`}) {
+		t.Errorf("unexpected innodb_locks sources: %v", sources)
+	}
+
+	// Patterns are left out
+	commands, _ := GetViewer(`commands`)
+	if keys := GetSourceKeys(commands, `status`); !reflect.DeepEqual(keys, []string{`queries`}) {
+		t.Errorf("unexpected commands status keys: %v", keys)
+	}
+}
+
+// Obsolete views are not available on newer servers
+func TestDefaultViewVersions(t *testing.T) {
+	err := LoadDefaultViews()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	qcache, _ := GetViewer(`qcache`)
+	sources, _ := qcache.GetSources()
+	if !reflect.DeepEqual(sources, []loader.SourceName{`status`, `variables`}) {
+		t.Errorf("unexpected qcache sources: %v", sources)
+	}
+
+	for version, available := range map[string]bool{`5.7.44-log`: true, `8.4.0`: false, `10.11.6-MariaDB-1`: true, ``: true} {
+		state := loader.NewState()
+		variables := loader.NewSample()
+		if version != `` {
+			variables.Data[`version`] = version
+		}
+		state.GetCurrentWriter().SetSample(`variables`, variables)
+
+		if err := CheckVersion(qcache, state); (err == nil) != available {
+			t.Errorf("%s: unexpected availability: %v", version, err)
+		}
+	}
+}
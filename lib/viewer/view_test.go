@@ -1,7 +1,9 @@
 package viewer
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/jayjanssen/myq-tools/lib/loader"
 )
@@ -82,3 +84,68 @@ func TestViewGetData(t *testing.T) {
 		}
 	}
 }
+
+func TestViewHidesUnavailableGroups(t *testing.T) {
+	view := getTestView()
+	rds := getTestGroupCol()
+	rds.Name = "RDS"
+	rds.Requires = []loader.SourceName{`aws.rds`}
+	view.Groups = append(view.Groups, rds)
+
+	sr := getTestViewState()
+	header := view.GetHeader(sr)
+	if header[1] != `    time cons conn` {
+		t.Errorf(`unexpected header: '%s'`, header[1])
+	}
+
+	lines := view.GetData(sr)
+	if lines[0] != `      0s    5    4` {
+		t.Errorf(`unexpected data: '%s'`, lines[0])
+	}
+}
+
+func TestViewInterval(t *testing.T) {
+	if err := LoadDefaultViews(); err != nil {
+		t.Fatal(err)
+	}
+	rds, _ := GetViewer(`rds`)
+	if interval := GetInterval(rds); interval != time.Minute {
+		t.Errorf("unexpected rds interval: %v", interval)
+	}
+	if interval := GetInterval(getTestView()); interval != 0 {
+		t.Errorf("unexpected default interval: %v", interval)
+	}
+	if interval := GetInterval(getTestGroupCol()); interval != 0 {
+		t.Errorf("a group has no interval: %v", interval)
+	}
+}
+
+func TestMissingSources(t *testing.T) {
+	view := getTestView()
+	state := getTestViewState()
+	if missing := MissingSources(view, state); len(missing) != 0 {
+		t.Errorf("unexpected missing sources: %v", missing)
+	}
+
+	rds := getTestGroupCol()
+	rds.Name = `RDS`
+	rds.Requires = []loader.SourceName{`aws.rds`}
+	view.Groups = append(view.Groups, rds)
+	if missing := MissingSources(view, state); len(missing) != 0 {
+		t.Errorf("groups requiring missing sources should be left out: %v", missing)
+	}
+
+	if missing := MissingSources(view, loader.NewState()); !reflect.DeepEqual(missing, []loader.SourceName{`status`}) {
+		t.Errorf("unexpected missing sources: %v", missing)
+	}
+}
+
+func TestViewHelpExample(t *testing.T) {
+	view := getTestView()
+	view.Example = "    time  conn\n      1s     5\n"
+	help := view.GetDetailedHelp()
+	expected := []string{``, `Example:`, `       time  conn`, `         1s     5`}
+	if len(help) < len(expected) || !reflect.DeepEqual(help[len(help)-len(expected):], expected) {
+		t.Errorf("unexpected help: %q", help)
+	}
+}
@@ -91,12 +91,36 @@ func (svl *ViewerList) UnmarshalYAML(value *yaml.Node) error {
 				return err
 			}
 			newlist = append(newlist, c)
+		case `RatePercent`:
+			c := RatePercentCol{}
+			err = content.Decode(&c)
+			if err != nil {
+				return err
+			}
+			newlist = append(newlist, c)
+		case `RateRatio`:
+			c := RateRatioCol{}
+			err = content.Decode(&c)
+			if err != nil {
+				return err
+			}
+			newlist = append(newlist, c)
 		case `SortedExpandedCounts`:
 			c := SortedExpandedCountsCol{}
 			err = content.Decode(&c)
 			if err != nil {
 				return err
 			}
+			if c.History > 0 {
+				c.histories = make(map[string]*History)
+			}
+			newlist = append(newlist, c)
+		case `SortedObjects`:
+			c := SortedObjectsCol{}
+			err = content.Decode(&c)
+			if err != nil {
+				return err
+			}
 			newlist = append(newlist, c)
 		case `Switch`:
 			c := SwitchCol{}
@@ -112,6 +136,23 @@ func (svl *ViewerList) UnmarshalYAML(value *yaml.Node) error {
 				return err
 			}
 			newlist = append(newlist, c)
+		case `GtidSubtract`:
+			c := GtidSubtractCol{}
+			err := content.Decode(&c)
+			if err != nil {
+				return err
+			}
+			newlist = append(newlist, c)
+		case `Expr`:
+			c := ExprCol{}
+			err := content.Decode(&c)
+			if err != nil {
+				return err
+			}
+			if err := c.parse(); err != nil {
+				return fmt.Errorf("col %s: %w", c.Name, err)
+			}
+			newlist = append(newlist, c)
 		default:
 			return fmt.Errorf("invalid column type: %s", typeobj.Type)
 		}
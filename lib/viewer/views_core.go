@@ -0,0 +1,10 @@
+package viewer
+
+import "embed"
+
+//go:embed views/core/*.yaml
+var coreViewFiles embed.FS
+
+func init() {
+	registerViewSet(CORE_VIEW_SET, coreViewFiles, `views/core`)
+}
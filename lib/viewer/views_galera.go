@@ -0,0 +1,12 @@
+//go:build !no_galera
+
+package viewer
+
+import "embed"
+
+//go:embed views/galera/*.yaml
+var galeraViewFiles embed.FS
+
+func init() {
+	registerViewSet(`galera`, galeraViewFiles, `views/galera`)
+}
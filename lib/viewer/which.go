@@ -0,0 +1,186 @@
+package viewer
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// A MetricUse is a single col in a View that reads a given metric
+type MetricUse struct {
+	View string // Name of the View
+	Col  string // Name of the col, prefixed by its Group name if it has one
+
+	// The key of the col that matched, and whether it matched as a pattern
+	Key     loader.SourceKey
+	Pattern bool
+}
+
+func (mu MetricUse) String() string {
+	if mu.Pattern {
+		return fmt.Sprintf("%s: %s (pattern %s/%s)", mu.View, mu.Col, mu.Key.SourceName, mu.Key.Key)
+	}
+	return fmt.Sprintf("%s: %s", mu.View, mu.Col)
+}
+
+// Find every col in every View that reads the given metric, either directly or via a pattern.  The metric can be a bare key (e.g. innodb_buffer_pool_reads) or a source/key.
+func FindMetric(metric string) (uses []MetricUse) {
+	metric = strings.ToLower(metric)
+	var source loader.SourceName
+	if name, key, found := strings.Cut(metric, `/`); found {
+		source, metric = loader.SourceName(name), key
+	}
+
+	for _, viewName := range ListViews() {
+		for _, use := range findMetricInViewer(views[viewName], source, metric) {
+			use.View = viewName
+			uses = append(uses, use)
+		}
+	}
+	return
+}
+
+// Recursively search the given Viewer for cols reading the given metric.  An empty source matches any source.
+func findMetricInViewer(sv Viewer, source loader.SourceName, metric string) (uses []MetricUse) {
+	var prefix string
+	var svs ViewerList
+	var keys []loader.SourceKey
+	var patterns bool
+	switch v := sv.(type) {
+	case View:
+		for _, group := range v.Groups {
+			svs = append(svs, group)
+		}
+		svs = append(svs, v.Cols...)
+	case GroupCol:
+		prefix = v.Name + "/"
+		svs = v.Cols
+	case SortedExpandedCountsCol:
+		// These keys are always expanded as regexes against the sample
+		keys, patterns = v.getKeys(), true
+	case RateSumCol:
+		keys, patterns = v.getKeys(), true
+	case SortedObjectsCol:
+		keys, patterns = v.getKeys(), true
+	case interface{ getKeys() []loader.SourceKey }:
+		keys = v.getKeys()
+	}
+
+	for _, key := range keys {
+		if source != "" && key.SourceName != source {
+			continue
+		}
+		if key.Key == metric {
+			uses = append(uses, MetricUse{Col: sv.GetName(), Key: key})
+			break
+		}
+		if !patterns {
+			continue
+		}
+		if re, err := regexp.Compile(key.Key); err == nil && re.MatchString(metric) {
+			uses = append(uses, MetricUse{Col: sv.GetName(), Key: key, Pattern: true})
+			break
+		}
+	}
+
+	for _, child := range svs {
+		for _, use := range findMetricInViewer(child, source, metric) {
+			use.Col = prefix + use.Col
+			uses = append(uses, use)
+		}
+	}
+	return
+}
+
+// How many of a View's metrics a server has, from a State with some of its Sources
+type MetricCoverage struct {
+	View string
+
+	// The source/keys the View reads from the Sources in the State, present or not
+	Present []string
+	Missing []string
+
+	// Sources the View reads that are not in the State (or failed to collect), their metrics weren't checked
+	Unchecked []loader.SourceName
+}
+
+func (mc MetricCoverage) String() string {
+	total := len(mc.Present) + len(mc.Missing)
+	var parts []string
+	switch {
+	case total == 0:
+	case len(mc.Missing) == 0:
+		parts = append(parts, fmt.Sprintf("usable, %d of %d metrics", len(mc.Present), total))
+	case len(mc.Present) == 0:
+		parts = append(parts, fmt.Sprintf("unusable, none of %d metrics", total))
+	default:
+		parts = append(parts, fmt.Sprintf("partial, %d of %d metrics", len(mc.Present), total))
+	}
+	if len(mc.Missing) > 0 {
+		parts = append(parts, "missing "+strings.Join(mc.Missing, ` `))
+	}
+	if len(mc.Unchecked) > 0 {
+		var names []string
+		for _, source := range mc.Unchecked {
+			names = append(names, string(source))
+		}
+		parts = append(parts, "not checked: "+strings.Join(names, ` `))
+	}
+	return fmt.Sprintf("%s: %s", mc.View, strings.Join(parts, `; `))
+}
+
+// Check which of the View's metrics are in the State.  SortedExpandedCounts match any number of metrics, so they aren't checked, other patterns are present if they match a metric.
+func CheckMetrics(sv Viewer, sr loader.StateReader) (mc MetricCoverage) {
+	mc.View = sv.GetName()
+
+	// Groups for another server flavor or version don't count
+	if view, ok := sv.(View); ok {
+		var groups []GroupCol
+		for _, group := range view.Groups {
+			if group.checkVersion(sr) == nil {
+				groups = append(groups, group)
+			}
+		}
+		view.Groups = groups
+		sv = view
+	}
+	sources, _ := sv.GetSources()
+	for _, source := range sources {
+		if !sr.GetCurrent().SourceCollected(source) {
+			mc.Unchecked = append(mc.Unchecked, source)
+			continue
+		}
+		for _, key := range GetSourceKeys(sv, source) {
+			name := string(source) + `/` + key
+			sk := loader.SourceKey{SourceName: source, Key: key}
+			if _, err := sr.GetCurrent().GetString(sk); err == nil {
+				mc.Present = append(mc.Present, name)
+			} else if regexp.QuoteMeta(key) != key && len(sr.GetCurrent().ExpandSourceKeys([]loader.SourceKey{sk})) > 0 {
+				// A pattern (e.g. of RateSum) is present if it matches anything
+				mc.Present = append(mc.Present, name)
+			} else {
+				mc.Missing = append(mc.Missing, name)
+			}
+		}
+	}
+	return
+}
+
+// The metrics of the Source in the State that no View reads, as sorted source/keys.  Patterns that match anything (like statusdiff's) don't count as reading a metric.
+func UnmappedMetrics(sr loader.StateReader, source loader.SourceName) (unmapped []string) {
+	all := sr.GetCurrent().ExpandSourceKeys([]loader.SourceKey{{SourceName: source, Key: `.*`}})
+	for _, sk := range all {
+		name := string(source) + `/` + sk.Key
+		mapped := slices.ContainsFunc(FindMetric(name), func(use MetricUse) bool {
+			return !use.Pattern || !regexp.MustCompile(use.Key.Key).MatchString(``)
+		})
+		if !mapped {
+			unmapped = append(unmapped, name)
+		}
+	}
+	slices.Sort(unmapped)
+	return
+}
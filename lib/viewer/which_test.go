@@ -0,0 +1,102 @@
+package viewer
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+func TestFindMetric(t *testing.T) {
+	err := LoadDefaultViews()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uses := FindMetric(`Innodb_buffer_pool_reads`)
+	found := false
+	for _, use := range uses {
+		if use.View == `innodb` && use.Col == `Buffer pool/read` && !use.Pattern {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("innodb Buffer pool/read not found: %v", uses)
+	}
+
+	// statusdiff matches every status variable via a pattern
+	found = false
+	for _, use := range FindMetric(`status/com_select`) {
+		if use.View == `statusdiff` && use.Pattern {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("statusdiff pattern not found for com_select")
+	}
+
+	if uses := FindMetric(`variables/innodb_buffer_pool_reads`); len(uses) != 0 {
+		t.Errorf("unexpected uses for wrong source: %v", uses)
+	}
+}
+
+func getTestMetricsState() loader.StateReader {
+	state := loader.NewState()
+	status := loader.NewSample()
+	status.Data[`connections`] = `10`
+	status.Data[`queries`] = `100`
+	status.Data[`com_insert_select`] = `3`
+	status.Data[`tokudb_cachetable_miss`] = `5`
+	state.GetCurrentWriter().SetSample(`status`, status)
+	return state
+}
+
+func TestCheckMetrics(t *testing.T) {
+	err := LoadDefaultViews()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cttf, _ := GetViewer(`cttf`)
+	mc := CheckMetrics(cttf, getTestMetricsState())
+	if !slices.Contains(mc.Present, `status/connections`) || len(mc.Present) != 1 {
+		t.Errorf("unexpected present metrics: %v", mc.Present)
+	}
+	if !slices.Contains(mc.Missing, `status/threads_running`) {
+		t.Errorf("unexpected missing metrics: %v", mc.Missing)
+	}
+	if !strings.HasPrefix(mc.String(), `cttf: partial, 1 of `) {
+		t.Errorf("unexpected String(): %s", mc)
+	}
+
+	// Patterns are present if they match a metric
+	coms, _ := GetViewer(`coms`)
+	mc = CheckMetrics(coms, getTestMetricsState())
+	if !slices.Contains(mc.Present, `status/com_insert.*`) || !slices.Contains(mc.Missing, `status/com_update.*`) {
+		t.Errorf("unexpected coverage: %+v", mc)
+	}
+
+	// Sources that weren't collected aren't checked
+	flushing, _ := GetViewer(`flushing`)
+	mc = CheckMetrics(flushing, getTestMetricsState())
+	if len(mc.Present)+len(mc.Missing) != 0 || !slices.Equal(mc.Unchecked, []loader.SourceName{`innodb_metrics`}) {
+		t.Errorf("unexpected coverage: %+v", mc)
+	}
+	if mc.String() != `flushing: not checked: innodb_metrics` {
+		t.Errorf("unexpected String(): %s", mc)
+	}
+}
+
+func TestUnmappedMetrics(t *testing.T) {
+	err := LoadDefaultViews()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// statusdiff's catch-all pattern doesn't count
+	unmapped := UnmappedMetrics(getTestMetricsState(), `status`)
+	if !slices.Equal(unmapped, []string{`status/tokudb_cachetable_miss`}) {
+		t.Errorf("unexpected unmapped metrics: %v", unmapped)
+	}
+}
@@ -0,0 +1,132 @@
+package viewer
+
+import (
+	"strings"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// How many times the values of a col must not fit before it is widened
+const ADAPTIVE_OVERFLOWS = 3
+
+// Cols are never widened past this
+const ADAPTIVE_MAX_LENGTH = 16
+
+// A col whose length can be changed
+type resizable interface {
+	Viewer
+	getLength() int
+	withLength(int) Viewer
+}
+
+func (c defaultCol) getLength() int { return c.Length }
+
+func (c DiffCol) withLength(length int) Viewer         { c.Length = length; return c }
+func (c ExprCol) withLength(length int) Viewer         { c.Length = length; return c }
+func (c GaugeCol) withLength(length int) Viewer        { c.Length = length; return c }
+func (c PercentCol) withLength(length int) Viewer      { c.Length = length; return c }
+func (c RateCol) withLength(length int) Viewer         { c.Length = length; return c }
+func (c RatePercentCol) withLength(length int) Viewer  { c.Length = length; return c }
+func (c RateRatioCol) withLength(length int) Viewer    { c.Length = length; return c }
+func (c RateSumCol) withLength(length int) Viewer      { c.Length = length; return c }
+func (c SubtractCol) withLength(length int) Viewer     { c.Length = length; return c }
+func (c GtidSubtractCol) withLength(length int) Viewer { c.Length = length; return c }
+
+// Does the line of a col not fit its length?  Numbers that don't fit are printed as `#`, wider than the col, or as a fraction of a bigger unit (e.g. `.1m` for 123456).
+func overflows(line string, length int) bool {
+	plain := strings.TrimSpace(ansiEscape.ReplaceAllString(line, ``))
+	return visibleLen(line) > length || strings.Contains(plain, `##`) || strings.HasPrefix(plain, `.`)
+}
+
+// Widens the cols of a View whose values repeatedly don't fit, e.g. a 5 digit threads_running printed as `####`.  The lengths only change when Apply is called, before a header, so the data lines keep lining up with it.
+type WidthAdapter struct {
+	// By col name, as in Records
+	overflows map[string]int
+	lengths   map[string]int
+}
+
+func NewWidthAdapter() *WidthAdapter {
+	return &WidthAdapter{overflows: make(map[string]int), lengths: make(map[string]int)}
+}
+
+// Call f with each resizable col of the Viewer and its name
+func walkResizable(sv Viewer, prefix string, f func(name string, col resizable)) {
+	switch v := sv.(type) {
+	case View:
+		for _, group := range v.Groups {
+			walkResizable(group, prefix, f)
+		}
+		for _, col := range v.Cols {
+			walkResizable(col, prefix, f)
+		}
+	case GroupCol:
+		for _, col := range v.Cols {
+			walkResizable(col, prefix+v.Name+`/`, f)
+		}
+	case resizable:
+		f(prefix+v.GetName(), v)
+	}
+}
+
+// Check the values of the State against the lengths of the cols, and how wide those that don't fit would need to be
+func (wa *WidthAdapter) Observe(sv Viewer, sr loader.StateReader) {
+	walkResizable(sv, ``, func(name string, col resizable) {
+		lines := observeCol(col, sr)
+		if len(lines) != 1 || !overflows(lines[0], col.getLength()) {
+			return
+		}
+		wa.overflows[name] += 1
+		for length := col.getLength() + 1; length <= ADAPTIVE_MAX_LENGTH; length++ {
+			if lines := observeCol(col.withLength(length), sr); len(lines) == 1 && !overflows(lines[0], length) {
+				wa.lengths[name] = max(wa.lengths[name], length)
+				return
+			}
+		}
+		wa.lengths[name] = ADAPTIVE_MAX_LENGTH
+	})
+}
+
+// The output of the col, nothing if it panicked.  Rendering the col reports the panic, no need to twice.
+func observeCol(col Viewer, sr loader.StateReader) (lines []string) {
+	defer func() {
+		if recover() != nil {
+			lines = nil
+		}
+	}()
+	return col.GetData(sr)
+}
+
+// The Viewer with the cols that didn't fit often enough widened
+func (wa *WidthAdapter) Apply(sv Viewer) Viewer {
+	return wa.apply(sv, ``)
+}
+
+func (wa *WidthAdapter) apply(sv Viewer, prefix string) Viewer {
+	switch v := sv.(type) {
+	case View:
+		groups := make([]GroupCol, len(v.Groups))
+		for i, group := range v.Groups {
+			groups[i] = wa.apply(group, prefix).(GroupCol)
+		}
+		v.Groups = groups
+		v.Cols = wa.applyCols(v.Cols, prefix)
+		return v
+	case GroupCol:
+		v.Cols = wa.applyCols(v.Cols, prefix+v.Name+`/`)
+		return v
+	case resizable:
+		name := prefix + v.GetName()
+		if wa.overflows[name] >= ADAPTIVE_OVERFLOWS && wa.lengths[name] > v.getLength() {
+			return v.withLength(wa.lengths[name])
+		}
+	}
+	return sv
+}
+
+func (wa *WidthAdapter) applyCols(cols ViewerList, prefix string) ViewerList {
+	applied := make(ViewerList, len(cols))
+	for i, col := range cols {
+		applied[i] = wa.apply(col, prefix)
+	}
+	return applied
+}
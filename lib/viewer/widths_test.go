@@ -0,0 +1,65 @@
+package viewer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+func TestWidthAdapter(t *testing.T) {
+	view := View{}
+	view.Name = "test"
+	group := GroupCol{}
+	group.Name = "Threads"
+	col := getTestGaugeCol()
+	col.Length = 3
+	group.Cols = ViewerList{col}
+	view.Groups = []GroupCol{group}
+
+	state := loader.NewState()
+	sample := loader.NewSample()
+	sample.Data[`threads_connect`] = `123456`
+	state.GetCurrentWriter().SetSample(`status`, sample)
+
+	// 123k is too wide
+	wa := NewWidthAdapter()
+	if data := view.GetData(state); !reflect.DeepEqual(data, []string{`      0s .1m`}) {
+		t.Fatalf("unexpected data before widening: %q", data)
+	}
+
+	// Not until it didn't fit a few times
+	for i := 0; i < ADAPTIVE_OVERFLOWS; i++ {
+		if widened := wa.Apply(view); !reflect.DeepEqual(widened.GetData(state), view.GetData(state)) {
+			t.Fatalf("widened after %d overflows", i)
+		}
+		wa.Observe(view, state)
+	}
+
+	widened := wa.Apply(view)
+	if data := widened.GetData(state); !reflect.DeepEqual(data, []string{`      0s 123k`}) {
+		t.Errorf("unexpected widened data: %q", data)
+	}
+	if header := widened.GetHeader(state); !reflect.DeepEqual(header, []string{`         Thre`, `    time conn`}) {
+		t.Errorf("unexpected widened header: %q", header)
+	}
+
+	// The original is left alone
+	if header := view.GetHeader(state); !reflect.DeepEqual(header, []string{`         Thr`, `    time con`}) {
+		t.Errorf("unexpected original header: %q", header)
+	}
+}
+
+// A col that panics keeps its width and doesn't end the session
+func TestWidthAdapterRecovers(t *testing.T) {
+	errs := captureRenderErrors(t)
+	view := View{}
+	view.Name = "test"
+	view.Cols = ViewerList{getTestRateCol()}
+
+	wa := NewWidthAdapter()
+	wa.Observe(view, panicState{})
+	if len(wa.lengths) != 0 || len(*errs) != 0 {
+		t.Errorf("unexpected widths %v or errors %v", wa.lengths, *errs)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/jayjanssen/myq-tools/lib/clientconf"
+	"github.com/jayjanssen/myq-tools/lib/loader"
+	"github.com/jayjanssen/myq-tools/lib/viewer"
+)
+
+// Exit codes
+const (
+	OK int = iota
+	BAD_ARGS
+	LOADER_ERROR
+)
+
+// Current Version (passed in on build)
+var build_version string
+var build_timestamp string
+
+func main() {
+	// Parse arguments
+	help := flag.Bool("help", false, "this help text")
+	version := flag.Bool("version", false, "print the version")
+	interval := flag.Duration("interval", 10*time.Second, "time between the two samples (example: 10s or 1m)")
+	flag.DurationVar(interval, "i", 10*time.Second, "short for -interval")
+	queryTimeout := flag.Duration("query-timeout", loader.DEFAULT_QUERY_TIMEOUT, "timeout for each query (0 for none)")
+
+	clientconf.SetMySQLFlags()
+
+	flag.Parse()
+
+	if *version {
+		fmt.Printf("myq-tools %s (%s)\n", build_version, build_timestamp)
+		os.Exit(OK)
+	}
+
+	if err := viewer.LoadDefaultViews(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error: could not load the default views:", err)
+		os.Exit(BAD_ARGS)
+	}
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "myq-tools %s (%s)\n\n", build_version, build_timestamp)
+
+		fmt.Fprintln(os.Stderr, "Usage:\n  myq_snapshot [flags] [view ...]")
+		fmt.Fprintln(os.Stderr, "Description:\n  Take two samples -interval apart, print every view (or the given ones) once in a single report and exit, e.g. to attach to an incident ticket.")
+
+		fmt.Fprintln(os.Stderr, "Options:")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nViews:\n   %s\n", strings.Join(viewer.ListViews(), ", "))
+		os.Exit(BAD_ARGS)
+	}
+
+	if *help {
+		flag.Usage()
+	}
+	if interval.Seconds() < 1 {
+		fmt.Fprintln(os.Stderr, "Error: interval must be >= 1s")
+		flag.Usage()
+	}
+
+	names := flag.Args()
+	if len(names) == 0 {
+		names = viewer.ListViews()
+	}
+	var views []viewer.Viewer
+	var sources []loader.SourceName
+	for _, name := range names {
+		view, err := viewer.GetViewer(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			flag.Usage()
+		}
+		viewSources, err := view.GetSources()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: view %s: %v\n", name, err)
+			os.Exit(BAD_ARGS)
+		}
+		for _, source := range viewSources {
+			if !slices.Contains(sources, source) {
+				sources = append(sources, source)
+			}
+		}
+		views = append(views, view)
+	}
+
+	config, err := clientconf.GenerateConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v", err)
+	}
+	liveLoader := loader.NewLiveLoader(config)
+	liveLoader.SetQueryTimeout(*queryTimeout)
+	if slices.Contains(sources, `os`) {
+		// OS metrics are of the host we run on, which should be mysqld's
+		liveLoader.AddPoller(`os`, loader.NewOSPoller())
+	}
+	defer liveLoader.Close()
+
+	// Sources that can't be collected (missing grants, performance_schema off) leave those views' cols empty, the report is still useful without them
+	var warnings []string
+	if err := liveLoader.Initialize(*interval, sources); err != nil {
+		var merr *multierror.Error
+		if !errors.As(err, &merr) {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(LOADER_ERROR)
+		}
+		for _, err := range merr.Errors {
+			warnings = append(warnings, err.Error())
+		}
+	}
+
+	// The second State has the rates over the interval
+	states := liveLoader.GetStateChannel()
+	first, ok := <-states
+	if !ok {
+		fmt.Fprintln(os.Stderr, "Error: the loader stopped before the first sample")
+		os.Exit(LOADER_ERROR)
+	}
+	fmt.Fprintf(os.Stderr, "Sampling %s for %v...\n", config.Addr, *interval)
+	state, ok := <-states
+	if !ok {
+		fmt.Fprintln(os.Stderr, "Error: the loader stopped before the second sample")
+		os.Exit(LOADER_ERROR)
+	}
+
+	fmt.Printf("myq-snapshot of %s at %s, %v between samples\n", config.Addr, first.GetCurrent().GetTimeGenerated().Format(time.RFC3339), state.SecondsDiff())
+	for _, warning := range warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	if err := state.GetCurrent().GetErrors(); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	for _, annotation := range state.GetAnnotations() {
+		fmt.Printf("-- %s --\n", annotation)
+	}
+
+	for _, view := range views {
+		fmt.Printf("\n== %s ==\n", view.GetShortHelp())
+		if err := viewer.CheckVersion(view, state); err != nil {
+			fmt.Printf("skipped: %v\n", err)
+			continue
+		}
+		viewSources, _ := view.GetSources()
+		missing := viewer.MissingSources(view, state)
+		if len(missing) > 0 && len(missing) == len(viewSources) {
+			fmt.Printf("skipped: none of its sources were collected (%s)\n", joinSources(missing))
+			continue
+		} else if len(missing) > 0 {
+			fmt.Printf("not collected: %s\n", joinSources(missing))
+		}
+		for _, line := range view.GetHeader(state) {
+			fmt.Println(line)
+		}
+		for _, line := range view.GetData(state) {
+			fmt.Println(line)
+		}
+	}
+	os.Exit(OK)
+}
+
+func joinSources(sources []loader.SourceName) string {
+	var names []string
+	for _, source := range sources {
+		names = append(names, string(source))
+	}
+	return strings.Join(names, ", ")
+}
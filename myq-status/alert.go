@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+	"github.com/jayjanssen/myq-tools/lib/viewer"
+)
+
+// Rings the terminal bell and/or sends a desktop notification when a Threshold is crossed
+type alerter struct {
+	watcher *viewer.ThresholdWatcher
+	bell    bool
+	notify  bool
+
+	// Where the bell goes, usually the output
+	out io.Writer
+}
+
+// Alert if the Record crossed any Thresholds
+func (a *alerter) check(record viewer.Record) {
+	crossed := a.watcher.Crossed(record)
+	if len(crossed) == 0 {
+		return
+	}
+
+	if a.bell {
+		fmt.Fprint(a.out, "\a")
+	}
+	if a.notify {
+		var breaches []string
+		for _, t := range crossed {
+			breaches = append(breaches, fmt.Sprintf("%s (%v)", t, record.Values[t.Col]))
+		}
+		a.sendNotification(fmt.Sprintf("%s %s", record.View, record.Time), strings.Join(breaches, ", "))
+	}
+}
+
+// Send a desktop notification in the background, giving up on notifications if we can't
+func (a *alerter) sendNotification(title, body string) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("osascript", "-e",
+			fmt.Sprintf("display notification %q with title %q", body, "myq_status: "+title))
+	} else {
+		cmd = exec.Command("notify-send", "myq_status: "+title, body)
+	}
+
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: -notify disabled:", err)
+		a.notify = false
+		return
+	}
+	go cmd.Wait()
+}
+
+// Acts on -alert expressions: reports and runs a hook when one fires, and asks to exit if it should
+type watchdog struct {
+	alerts   []viewer.Alert
+	watchers map[string]*viewer.AlertWatcher // each host counts its own samples
+	cmd      string                          // run with sh -c
+	exit     bool
+}
+
+func newWatchdog(alerts []viewer.Alert, cmd string, exit bool) *watchdog {
+	return &watchdog{alerts: alerts, watchers: make(map[string]*viewer.AlertWatcher), cmd: cmd, exit: exit}
+}
+
+// Report the Alerts the host's State fired and run the hook for each, true if we should now exit.  An error for an Alert on a metric the State shows doesn't exist.
+func (w *watchdog) check(host string, sr loader.StateReader) (bool, error) {
+	for _, a := range w.alerts {
+		if err := a.CheckKey(sr); err != nil {
+			return false, err
+		}
+	}
+	if w.watchers[host] == nil {
+		w.watchers[host] = viewer.NewAlertWatcher(w.alerts)
+	}
+	fired := w.watchers[host].Fired(sr)
+	for _, a := range fired {
+		value, _ := a.GetValue(sr)
+		message := fmt.Sprintf("%s (%s)", a, strconv.FormatFloat(value, 'f', -1, 64))
+		if host != "" {
+			message = host + ": " + message
+		}
+		fmt.Fprintln(os.Stderr, "Alert:", message)
+		if w.cmd != "" {
+			w.runHook(host, a, value, sr.GetTimeString())
+		}
+	}
+	return w.exit && len(fired) > 0, nil
+}
+
+// Run the hook with the alert in its environment.  It is waited for only when we exit after it, so a page goes out before we do.
+func (w *watchdog) runHook(host string, a viewer.Alert, value float64, time string) {
+	cmd := exec.Command("sh", "-c", w.cmd)
+	cmd.Env = append(os.Environ(),
+		"MYQ_ALERT="+a.String(),
+		"MYQ_VALUE="+strconv.FormatFloat(value, 'f', -1, 64),
+		"MYQ_HOST="+host,
+		"MYQ_TIME="+time,
+	)
+	// Keep the hook's output out of ours
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+
+	if w.exit {
+		if err := cmd.Run(); err != nil {
+			fmt.Fprintln(os.Stderr, "Warning: -alert-cmd:", err)
+		}
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: -alert-cmd:", err)
+		return
+	}
+	go cmd.Wait()
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+	"github.com/jayjanssen/myq-tools/lib/viewer"
+)
+
+// A request to show another view, answered by the main loop
+type viewSwitch struct {
+	view viewer.Viewer
+	err  chan error
+}
+
+// Serves the running session over HTTP so scripts and dashboards can interrogate it:
+//
+//	GET  /sample    the latest Record of the view (?host= with -hosts)
+//	GET  /view      the view shown and those it can switch to
+//	POST /view      switch to {"view": "<name>"}
+//	GET  /interval  the interval
+//	POST /interval  collect every {"interval": "<duration>"}
+//	GET  /stats     samples, errors and drops so far, like -summary-out
+type apiServer struct {
+	mutex    sync.Mutex
+	records  map[string]viewer.Record // by host, "" without -hosts
+	hosts    []string
+	stats    sessionSummary
+	view     string
+	interval time.Duration
+
+	// The views that can be switched to, none if the flags in fixedBy keep the view's cols for the session
+	views    []viewer.Viewer
+	fixedBy  []string
+	switches chan viewSwitch
+
+	// Nil if the loader's interval can't change, e.g. reading -file
+	setter loader.IntervalSetter
+
+	server *http.Server
+}
+
+func newAPIServer(view viewer.Viewer, views []viewer.Viewer, hosts []string, interval time.Duration, setter loader.IntervalSetter) *apiServer {
+	return &apiServer{
+		records:  make(map[string]viewer.Record),
+		hosts:    hosts,
+		view:     view.GetName(),
+		interval: interval,
+		views:    views,
+		switches: make(chan viewSwitch),
+		setter:   setter,
+	}
+}
+
+// Serve on the address in the background.  Anyone who can connect can switch the view and interval, so without a host (e.g. :7523) only localhost can.
+func (a *apiServer) listen(address string) (net.Addr, error) {
+	if host, port, err := net.SplitHostPort(address); err == nil && host == `` {
+		address = net.JoinHostPort(`127.0.0.1`, port)
+	}
+	listener, err := net.Listen(`tcp`, address)
+	if err != nil {
+		return nil, fmt.Errorf("cannot listen on %s: %v", address, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(`GET /sample`, a.getSample)
+	mux.HandleFunc(`GET /view`, a.getView)
+	mux.HandleFunc(`POST /view`, a.postView)
+	mux.HandleFunc(`GET /interval`, a.getInterval)
+	mux.HandleFunc(`POST /interval`, a.postInterval)
+	mux.HandleFunc(`GET /stats`, a.getStats)
+	a.server = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go a.server.Serve(listener)
+	return listener.Addr(), nil
+}
+
+func (a *apiServer) close() {
+	a.server.Close()
+}
+
+// Keep the latest Record of a host
+func (a *apiServer) update(host string, record viewer.Record) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.records[host] = record
+}
+
+// Keep the session's statistics and the view they are of
+func (a *apiServer) setStats(stats sessionSummary) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.stats = stats
+	a.view = stats.View
+}
+
+func writeJSON(w http.ResponseWriter, code int, value any) {
+	w.Header().Set(`Content-Type`, `application/json`)
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{`error`: err.Error()})
+}
+
+func (a *apiServer) getSample(w http.ResponseWriter, r *http.Request) {
+	host := r.URL.Query().Get(`host`)
+	if host == "" && len(a.hosts) > 0 {
+		host = a.hosts[0]
+	}
+
+	a.mutex.Lock()
+	record, ok := a.records[host]
+	a.mutex.Unlock()
+	if !ok && host != "" && !slices.Contains(a.hosts, host) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown host %s", host))
+	} else if !ok {
+		writeError(w, http.StatusServiceUnavailable, fmt.Errorf("no sample yet"))
+	} else {
+		writeJSON(w, http.StatusOK, record)
+	}
+}
+
+type apiView struct {
+	View  string   `json:"view"`
+	Views []string `json:"views,omitempty"` // that it can switch to
+}
+
+func (a *apiServer) getView(w http.ResponseWriter, r *http.Request) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	response := apiView{View: a.view}
+	for _, view := range a.views {
+		response.Views = append(response.Views, view.GetName())
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// Switch views in the main loop, which knows if the server has the view
+func (a *apiServer) postView(w http.ResponseWriter, r *http.Request) {
+	var request apiView
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(a.views) == 0 {
+		writeError(w, http.StatusConflict, fmt.Errorf("the view can't be switched with %s", strings.Join(a.fixedBy, ", ")))
+		return
+	}
+	i := slices.IndexFunc(a.views, func(view viewer.Viewer) bool { return view.GetName() == request.View })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("view %s not found or its sources are not collected", request.View))
+		return
+	}
+
+	sw := viewSwitch{view: a.views[i], err: make(chan error, 1)}
+	select {
+	case a.switches <- sw:
+	case <-r.Context().Done():
+		return
+	}
+	if err := <-sw.err; err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	a.mutex.Lock()
+	a.view = request.View
+	a.mutex.Unlock()
+	writeJSON(w, http.StatusOK, apiView{View: request.View})
+}
+
+// The flags that keep the view's cols for the whole session: csv has one header, the summaries, recordings and thresholds are of the first view's cols, and -tui switches views itself
+func viewFixedBy(output string, tui, summary bool, summaryOut, recordView, grafanaSnapshot string, thresholds, forecasts bool) (flags []string) {
+	if output == OUTPUT_CSV {
+		flags = append(flags, `-output csv`)
+	}
+	for flag, set := range map[string]bool{
+		`-tui`:              tui,
+		`-summary`:          summary,
+		`-summary-out`:      summaryOut != ``,
+		`-record-view`:      recordView != ``,
+		`-grafana-snapshot`: grafanaSnapshot != ``,
+		`-threshold`:        thresholds,
+		`-forecast`:         forecasts,
+	} {
+		if set {
+			flags = append(flags, flag)
+		}
+	}
+	slices.Sort(flags)
+	return
+}
+
+type apiInterval struct {
+	Interval string `json:"interval"`
+}
+
+func (a *apiServer) getInterval(w http.ResponseWriter, r *http.Request) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	writeJSON(w, http.StatusOK, apiInterval{a.interval.String()})
+}
+
+func (a *apiServer) postInterval(w http.ResponseWriter, r *http.Request) {
+	var request apiInterval
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	interval, err := time.ParseDuration(request.Interval)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	} else if interval < time.Second {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("interval must be >= 1s"))
+		return
+	}
+	if a.setter == nil {
+		writeError(w, http.StatusConflict, fmt.Errorf("the interval can't change with this loader"))
+		return
+	}
+
+	a.setter.SetInterval(interval)
+	a.mutex.Lock()
+	a.interval = interval
+	a.mutex.Unlock()
+	writeJSON(w, http.StatusOK, apiInterval{interval.String()})
+}
+
+func (a *apiServer) getStats(w http.ResponseWriter, r *http.Request) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	stats := a.stats
+	stats.Duration = time.Since(stats.Start).Seconds()
+	writeJSON(w, http.StatusOK, stats)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jayjanssen/myq-tools/lib/viewer"
+)
+
+func TestAPISwitchView(t *testing.T) {
+	if err := viewer.LoadDefaultViews(); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := viewer.GetViewer(`innodb`)
+	second, _ := viewer.GetViewer(`cttf`)
+
+	postView := func(api *apiServer, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		api.postView(w, httptest.NewRequest(http.MethodPost, `/view`, strings.NewReader(body)))
+		return w
+	}
+
+	// The main loop takes the switch
+	api := newAPIServer(first, []viewer.Viewer{first, second}, nil, time.Second, nil)
+	go func() {
+		sw := <-api.switches
+		if sw.view.GetName() != `cttf` {
+			t.Errorf("unexpected view switched to: %s", sw.view.GetName())
+		}
+		sw.err <- nil
+	}()
+	if w := postView(api, `{"view": "cttf"}`); w.Code != http.StatusOK {
+		t.Errorf("unexpected switch response: %d %s", w.Code, w.Body)
+	}
+	if api.view != `cttf` {
+		t.Errorf("unexpected view after the switch: %s", api.view)
+	}
+	if w := postView(api, `{"view": "nope"}`); w.Code != http.StatusNotFound {
+		t.Errorf("unexpected unknown view response: %d %s", w.Code, w.Body)
+	}
+
+	// The summary is of the first view's cols
+	fixedBy := viewFixedBy(OUTPUT_TEXT, false, true, ``, `records.ndjson`, ``, false, false)
+	if strings.Join(fixedBy, ` `) != `-record-view -summary` {
+		t.Errorf("unexpected fixed by: %q", fixedBy)
+	}
+	api = newAPIServer(first, nil, nil, time.Second, nil)
+	api.fixedBy = fixedBy
+	w := postView(api, `{"view": "cttf"}`)
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), `-record-view, -summary`) {
+		t.Errorf("unexpected switch response with fixed cols: %d %s", w.Code, w.Body)
+	}
+
+	if fixedBy := viewFixedBy(OUTPUT_JSON, false, false, ``, ``, ``, false, false); len(fixedBy) != 0 {
+		t.Errorf("unexpected fixed by: %q", fixedBy)
+	}
+}
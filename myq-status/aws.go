@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jayjanssen/myq-tools/lib/aws"
+)
+
+// The AWS region given, else the one in the RDS hostname, else the one in the environment
+func resolveAWSRegion(region, host string) string {
+	if region == "" {
+		if endpoint, ok := aws.ParseRDSEndpoint(host); ok {
+			region = endpoint.Region
+		}
+	}
+	for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region == "" {
+			region = os.Getenv(env)
+		}
+	}
+	return region
+}
+
+// The host of a TCP config, empty for a socket
+func tcpHost(config *mysql.Config) string {
+	host, _, err := net.SplitHostPort(config.Addr)
+	if err != nil || config.Net != `tcp` {
+		return ``
+	}
+	return host
+}
+
+// How to verify the TLS that carries AWS credentials to the server
+type awsTLS struct {
+	caBundle   string
+	skipVerify bool
+}
+
+// Encrypt the connection if no ssl flags or cnf options did, verifying the server's certificate for its host with the system's and RDS's CAs.  Only with -aws-tls-skip-verify is it not verified, like ssl-mode=REQUIRED.
+func (at awsTLS) require(config *mysql.Config) error {
+	if config.TLS != nil || (config.TLSConfig != `` && config.TLSConfig != `false`) {
+		return nil
+	}
+	if at.skipVerify {
+		config.TLS = &tls.Config{InsecureSkipVerify: true}
+		return nil
+	}
+	host := tcpHost(config)
+	roots, err := aws.NewRDSCABundle(at.caBundle).Pool()
+	if err != nil {
+		return fmt.Errorf("cannot load the RDS CA bundle to verify %s (see -aws-ca-bundle and -aws-tls-skip-verify): %v", host, err)
+	}
+	config.TLS = &tls.Config{ServerName: host, RootCAs: roots}
+	return nil
+}
+
+// Connect as the user and password of a Secrets Manager secret (name or ARN), with TLS to RDS endpoints
+func useAWSSecret(config *mysql.Config, id, region string, at awsTLS) error {
+	if secretRegion := aws.SecretRegion(id); secretRegion != "" {
+		region = secretRegion
+	}
+	region = resolveAWSRegion(region, tcpHost(config))
+	if region == "" {
+		return errors.New("-aws-secret: cannot determine the AWS region, use -aws-region or the secret's ARN")
+	}
+
+	creds, err := aws.LoadCredentials()
+	if err != nil {
+		return fmt.Errorf("-aws-secret: %v", err)
+	}
+	secret, err := aws.NewSecretsManager(region, creds).GetRDSSecret(id)
+	if err != nil {
+		return fmt.Errorf("-aws-secret: %v", err)
+	}
+
+	config.User = secret.Username
+	config.Passwd = secret.Password
+	if _, ok := aws.ParseRDSEndpoint(tcpHost(config)); ok {
+		if err := at.require(config); err != nil {
+			return fmt.Errorf("-aws-secret: %v", err)
+		}
+	}
+	return nil
+}
+
+// A config authenticating with IAM database auth tokens over TLS.  Tokens expire after 15 minutes, so each connection (e.g., a reconnect) signs a fresh one.
+func useAWSIAMAuth(config *mysql.Config, region string, at awsTLS) (*mysql.Config, error) {
+	host := tcpHost(config)
+	if host == "" {
+		return nil, errors.New("-aws-iam-auth needs a TCP host, not a socket")
+	}
+	region = resolveAWSRegion(region, host)
+	if region == "" {
+		return nil, errors.New("-aws-iam-auth: cannot determine the AWS region, use -aws-region")
+	}
+	creds, err := aws.LoadCredentials()
+	if err != nil {
+		return nil, fmt.Errorf("-aws-iam-auth: %v", err)
+	}
+
+	iamConfig := config.Clone()
+	iamConfig.AllowCleartextPasswords = true
+	if err := at.require(iamConfig); err != nil {
+		return nil, fmt.Errorf("-aws-iam-auth: %v", err)
+	}
+
+	// The token is for the server's address, even through an -ssh tunnel
+	endpoint, user := config.Addr, config.User
+	err = iamConfig.Apply(mysql.BeforeConnect(func(ctx context.Context, cfg *mysql.Config) error {
+		cfg.Passwd = creds.RDSAuthToken(endpoint, region, user, time.Now())
+		return nil
+	}))
+	return iamConfig, err
+}
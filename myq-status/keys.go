@@ -0,0 +1,58 @@
+package main
+
+const KEYS_HELP = "keys: space pause, h header, m mark"
+
+// Reads the keys pressed during the scrolling text output, which pause it, reprint the header or mark it.  Output is held while paused and shown when resumed.
+type keyReader struct {
+	keys chan string
+
+	paused  bool
+	held    []func()
+	dropped int
+
+	// The terminal settings to restore on exit
+	sttySaved string
+}
+
+// Read keys from the terminal without echoing them
+func newKeyReader() (*keyReader, error) {
+	saved, err := rawTerminal()
+	if err != nil {
+		return nil, err
+	}
+	k := &keyReader{
+		keys:      make(chan string),
+		sttySaved: saved,
+	}
+	go readKeys(k.keys)
+	return k, nil
+}
+
+// Restore the terminal as it was
+func (k *keyReader) close() {
+	stty(k.sttySaved)
+}
+
+// Print the output now, or hold it until resumed.  Only the latest TUI_SCROLLBACK are held.  Without keys (nil) it is always printed.
+func (k *keyReader) show(output func()) {
+	if k == nil || !k.paused {
+		output()
+		return
+	}
+	k.held = append(k.held, output)
+	if len(k.held) > TUI_SCROLLBACK {
+		k.held = k.held[1:]
+		k.dropped += 1
+	}
+}
+
+// Pause, or resume returning the output held and how much of it was dropped
+func (k *keyReader) togglePause() (held []func(), dropped int) {
+	k.paused = !k.paused
+	if k.paused {
+		return nil, 0
+	}
+	held, dropped = k.held, k.dropped
+	k.held, k.dropped = nil, 0
+	return held, dropped
+}
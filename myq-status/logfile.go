@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jayjanssen/myq-tools/lib/viewer"
+)
+
+// Copies the output to -logfile.  If the log fails we warn and stop logging, the output goes on.
+type logTee struct {
+	out io.Writer
+	log *viewer.LogFile
+
+	failed bool
+}
+
+func (t *logTee) Write(p []byte) (int, error) {
+	if !t.failed {
+		if _, err := t.log.Write(p); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: -logfile: %v, not writing any more\n", err)
+			t.failed = true
+		}
+	}
+	return t.out.Write(p)
+}
@@ -1,16 +1,30 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"maps"
 	"math"
+	"net"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime/pprof"
+	"slices"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/go-sql-driver/mysql"
+	"github.com/hashicorp/go-multierror"
+	"github.com/jayjanssen/myq-tools/lib/aws"
 	"github.com/jayjanssen/myq-tools/lib/clientconf"
+	"github.com/jayjanssen/myq-tools/lib/exporter"
 	"github.com/jayjanssen/myq-tools/lib/loader"
 	"github.com/jayjanssen/myq-tools/lib/viewer"
 )
@@ -21,8 +35,91 @@ const (
 	BAD_ARGS
 	LOADER_ERROR
 	SOURCES_ERROR
+	DATA_DROPPED
+	RECORDS_DIFFER
+	ALERT_FIRED
+	OUTPUT_FAILED
 )
 
+// Output formats
+const (
+	OUTPUT_TEXT = "text"
+	OUTPUT_JSON = "json"
+	OUTPUT_CSV  = "csv"
+)
+
+// -missed-interval policies
+const (
+	MISSED_BLANK  = "blank"
+	MISSED_REPEAT = "repeat"
+	MISSED_SKIP   = "skip"
+)
+
+// How far apart numbers in diff-view can be without a -tolerance
+const DEFAULT_TOLERANCE = "1%"
+
+// A flag that can be given more than once
+type stringList []string
+
+func (sl *stringList) String() string {
+	return strings.Join(*sl, ",")
+}
+
+func (sl *stringList) Set(value string) error {
+	*sl = append(*sl, value)
+	return nil
+}
+
+// A role=host[,role=host] flag, e.g., primary=host1,replica=host2
+type roleHosts struct {
+	roles []string
+	hosts map[string]string
+}
+
+func (rh *roleHosts) String() string {
+	var pairs []string
+	for _, role := range rh.roles {
+		pairs = append(pairs, role+"="+rh.hosts[role])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (rh *roleHosts) Set(value string) error {
+	rh.roles, rh.hosts = nil, make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		role, host, found := strings.Cut(pair, "=")
+		if !found || role == "" || host == "" {
+			return fmt.Errorf("expected role=host, got `%s`", pair)
+		}
+		if _, ok := rh.hosts[role]; ok {
+			return fmt.Errorf("role %s given twice", role)
+		}
+		rh.roles = append(rh.roles, role)
+		rh.hosts[role] = host
+	}
+	return nil
+}
+
+// A State to render as its own row, labeled with its host in multi-host mode
+type hostState struct {
+	host  string
+	state loader.StateReader
+}
+
+// The State of each host in a merged State, or just the State when there are no hosts.  With updatedOnly, hosts without a new State since the last one are left out (a -compare-file capture taken less often).
+func splitHosts(state loader.StateReader, hosts []string, updatedOnly bool) (rows []hostState) {
+	if len(hosts) == 0 {
+		return []hostState{{state: state}}
+	}
+	for _, host := range hosts {
+		if updatedOnly && !loader.RoleUpdated(state, host) {
+			continue
+		}
+		rows = append(rows, hostState{host, loader.RoleState(state, host)})
+	}
+	return
+}
+
 // Current Version (passed in on build)
 var build_version string
 var build_timestamp string
@@ -31,38 +128,143 @@ func main() {
 	// Parse arguments
 	help := flag.Bool("help", false, "this help text")
 	version := flag.Bool("version", false, "print the version")
+	listMetricsFlag := flag.Bool("list-metrics", false, "don't render a view, collect status and variables once and print which views the server has the metrics for and which metrics no view reads")
+	listFeatures := flag.Bool("list-features", false, "print the view sets compiled in and their views")
 
 	profile := flag.String("profile", "", "enable profiling and store the result in this file")
-	header := flag.Int("header", 0, "repeat the header after this many data points (default: 0, autocalculates)")
+	header := flag.Int("header", 0, "repeat the header after this many data points (default: 0, the terminal's height, or only once when output is not a terminal)")
 	width := flag.Bool("width", false, "Truncate the output based on the width of the terminal")
+	onlyActive := flag.Bool("only-active", false, "at each header, leave out the rate and diff cols that were zero since the last one (they come back at the header after they move)")
+	units := flag.String("units", "iec", "how numbers are fit into their cols: iec (memory in powers of 1024 like 12K), si (powers of 1000 like 12k) or raw (whole numbers without a suffix, grouped by thousands on a terminal), a col's format: in its view overrides it")
+	fixedWidths := flag.Bool("fixed-widths", false, "never widen cols whose values don't fit (by default they are widened at the next header once they didn't fit a few times)")
+	output := flag.String("output", OUTPUT_TEXT, "output format: text (the view's columns) json (a JSON object per sample with every col's value, for jq and log shippers) or csv (a header row of group.col names, then a row per sample, for spreadsheets)")
+	logFile := flag.String("logfile", "", "also append the rendered output to this file, e.g. to keep the history of a long session")
+	logRotate := flag.String("logrotate", "", "rotate -logfile once it reaches this size (example: 100M) or age (example: 24h), renaming it with the time")
+	sinkFlag := flag.String("sink", "", "also send the view's values every interval as gauges to statsd://host:port or dogstatsd://host:port (Datadog's agent, with -aws-tags and -hosts as tags), named like myq.<view>.<col> (myq.<host>.<view>.<col> for -hosts to plain statsd)")
+	timeColFlag := flag.String("timecol", "", "what the time col shows: relative (seconds since the first sample), absolute (the wall clock when it was collected) or uptime (the server's), by default the wall clock when live and relative with -file")
+	verbose := flag.Bool("verbose", false, "also show the view's extended groups, the extra detail most don't need (marked in the view's help)")
+	flag.BoolVar(verbose, "extended", false, "same as -verbose")
+	serverHeader := flag.Bool("server-header", false, "start each header with the server's host:port, version, flavor and uptime (the variables are then collected every interval)")
+	recordView := flag.String("record-view", "", "also write the view's unformatted values to this file as newline delimited JSON, for comparing sessions with diff-view")
+	grafanaSnapshot := flag.String("grafana-snapshot", "", fmt.Sprintf("on exit, write the session (its last %d samples) as a Grafana dashboard snapshot (JSON for POST /api/snapshots) to this file", exporter.GRAFANA_SNAPSHOT_RECORDS))
+	var tolerances stringList
+	flag.Var(&tolerances, "tolerance", "for diff-view, how far apart numbers can be as a fraction, percent or +absolute (example: 5%), prefix with <col>= or <group>= for a col's own tolerance (repeatable)")
+	var colFlags stringList
+	flag.Var(&colFlags, "col", "add a col to the view computed from metrics, as <name>=<expression> with + - * / and rate(<metric>), diff(<metric>) or change(<metric>), metrics of sources other than status as <source>/<key> (divide with spaces around /) (example: 'commit%=(com_commit+com_rollback)/questions*100', repeatable)")
+	var thresholdFlags stringList
+	flag.Var(&thresholdFlags, "threshold", "alert when a col crosses this threshold, as <col>><value> or <col><<value> (example: Connects/cons>100, repeatable)")
+	bell := flag.Bool("bell", false, "ring the terminal bell when a -threshold is crossed")
+	notify := flag.Bool("notify", false, "send a desktop notification (notify-send or osascript) when a -threshold is crossed")
+	var alertFlags stringList
+	flag.Var(&alertFlags, "alert", "act as a watchdog on a metric, as <metric><op><value> [for <n> samples] with an op of >, <, >=, <=, == or !=, with -pair on both hosts unless the metric is a role's like primary.status/threads_running (example: 'threads_running>200 for 3 samples', repeatable)")
+	alertCmd := flag.String("alert-cmd", "", "when an -alert fires, run this shell command with MYQ_ALERT, MYQ_VALUE, MYQ_HOST and MYQ_TIME in its environment")
+	alertExit := flag.Bool("alert-exit", false, fmt.Sprintf("when an -alert fires, exit with code %d (after -alert-cmd), the default without -alert-cmd", ALERT_FIRED))
+	var forecastFlags stringList
+	flag.Var(&forecastFlags, "forecast", "project when a col will reach a value from its trend over the session, as <col>=<value> or <col>=<multiple>x of its current value (example: Checkpoint/age=1073741824, repeatable)")
+	forecastEvery := flag.Int("forecast-every", 10, "print the -forecast line every this many intervals")
+	trackVariables := flag.String("track-variables", "", "annotate the output when any of these comma separated global variables change, e.g. "+viewer.SUGGESTED_TRACKED_VARIABLES+" (views without variables then also collect them every interval)")
+	summary := flag.Bool("summary", false, "on exit, print the min, avg, max and p95 of every col over the session to stderr")
+	summaryOut := flag.String("summary-out", "", "on exit, write a JSON summary of the session (duration, samples, missed, the min/avg/max/p95 of every col and how often each -threshold was breached) to this file, for checks after load tests")
+	explain := flag.Bool("explain", false, "after the first header, print the formula of each col (example: Net/recv = rate(bytes_received))")
+	color := flag.Bool("color", false, "show values past their col's warn or crit level (set in the view) in yellow or red")
+	markers := flag.String("markers", viewer.DEFAULT_MARKERS, "characters appended to values that are not plain measurements, as name=char pairs of repeat (see -missed-interval), restart, stale, gap (spans missed intervals) and clipped, or none")
+	missedInterval := flag.String("missed-interval", MISSED_BLANK, "when an interval's collection fails entirely: blank (print what could be computed, usually -), repeat (the last values, marked) or skip (no row, JSON object or CSV row)")
 
 	interval := flag.Duration("interval", time.Second, "Time between samples (example: 1s or 1h30m)")
+	align := flag.Bool("align", false, "collect on the wall clock multiples of -interval (e.g., every minute at :00) so outputs from several hosts and tools line up, the first sample waits for the first one")
 	flag.DurationVar(interval, "i", time.Second, "short for -interval")
+	count := flag.Int("count", 0, "exit after this many samples, like iostat's count (0 runs until interrupted or the -file ends)")
 
-	statusfile := flag.String("file", "", "parse mysqladmin ext output file instead of connecting to mysql")
-	flag.StringVar(statusfile, "f", "", "short for -file")
+	var statusfiles stringList
+	flag.Var(&statusfiles, "file", "parse mysqladmin ext output, mysql batch output or a pt-stalk status capture instead of connecting to mysql (repeat to read several captures in order, e.g. pt-mysql-summary's mysql-status and mysql-status-defer)")
+	flag.Var(&statusfiles, "f", "short for -file")
 	varfile := flag.String("varfile", "", "parse mysqladmin variables file instead of connecting to mysql, for optional use with -file")
 	flag.StringVar(varfile, "vf", "", "short for -varfile")
+	var compareFiles stringList
+	flag.Var(&compareFiles, "compare-file", "with -file, compare with another capture (e.g. after a config change): each row of -file is followed by this capture's at the same uptime since its first sample, labeled with the file names (take both at the same interval, repeatable like -file)")
+	compareVarfile := flag.String("compare-varfile", "", "the variables of the -compare-file capture, like -varfile")
+	strict := flag.Bool("strict", false, "with -file, stop with an error on a malformed or truncated sample instead of skipping it")
+	replaySpeed := flag.String("speed", "", "with -file, replay the capture this many times faster than it was taken (example: 10x, 1x for real time) instead of as fast as it is read")
+	replaySeek := flag.String("seek", "", "with -file, start this far into the capture by uptime (example: 00:45:00 or 45m)")
 	clientconf.SetMySQLFlags()
+	clientconf.SetConfigFileFlags()
+
+	backoff := loader.DefaultBackoff()
+	flag.DurationVar(&backoff.Initial, "reconnect-initial", backoff.Initial, "delay before collecting again after a failed live collection")
+	flag.DurationVar(&backoff.Max, "reconnect-max", backoff.Max, "maximum delay between live collection retries")
+	flag.Float64Var(&backoff.Multiplier, "reconnect-multiplier", backoff.Multiplier, "growth of the retry delay after each consecutive failure")
+	queryTimeout := flag.Duration("query-timeout", loader.DEFAULT_QUERY_TIMEOUT, "timeout for each live collection query (0 for none)")
+	var sourceTimeoutFlags stringList
+	flag.Var(&sourceTimeoutFlags, "source-timeout", "timeout for the queries of one source instead of -query-timeout, as <source>=<duration> (example: innodb_trx=1s, repeatable)")
+	parallel := flag.Int("parallel", 1, "collect up to this many sources at once over as many connections, so a slow one doesn't delay the others (the extra connections show up in the processlist and clients views)")
+
+	enableInnodbMetrics := flag.Bool("enable-innodb-metrics", false, "turn on the information_schema.innodb_metrics counters the view uses with SET GLOBAL innodb_monitor_enable (changes server settings, they stay on after exit)")
+
+	locksDetail := flag.Duration("locks-detail", 0, "annotate the output with who is waiting on whom when a metadata lock wait is at least this old (example: 10s, needs performance_schema)")
+
+	listen := flag.String("listen", "", "render status samples pushed by a remote agent instead of connecting to mysql, listening on host:port (TCP) or udp://host:port")
+	promURL := flag.String("prom-url", "", "replay the mysqld_exporter metrics a Prometheus server has between -from and -to instead of connecting to mysql (example: http://prometheus:9090), only status, variables and replica status")
+	promInstance := flag.String("prom-instance", "", "with -prom-url, the instance label of the mysqld_exporter to replay, needed when Prometheus has several")
+	promFrom := flag.String("from", "1h", "with -prom-url, replay from this time: RFC3339, 2006-01-02 15:04:05 (local time) or a duration ago like 2h")
+	promTo := flag.String("to", "0s", "with -prom-url, replay until this time, like -from")
+	pushTo := flag.String("push", "", "don't render a view, push status samples from the mysql server to a myq_status -listen at host:port (TCP) or udp://host:port")
+
+	exporterAddr := flag.String("exporter", "", "also serve the view's values as a Prometheus /metrics endpoint on this address (example: :9105)")
+	exporterCompat := flag.String("exporter-compat", "", "with -exporter, also export status, variables and replica status under the names of another exporter so its dashboards work (supported: mysqld, for prometheus/mysqld_exporter)")
+	exporterSources := flag.Bool("exporter-sources", false, "with -exporter, also export every numeric value collected from every source, not just the view's")
+	apiAddr := flag.String("api", "", "serve the session over HTTP on this address (example: :7523, on localhost without a host): GET /sample, /view, /interval and /stats, POST /view and /interval to switch the view (not with -output csv, -tui, or the summaries, recordings, thresholds and forecasts of its cols) or change the interval.  There is no auth, anyone who can connect can POST")
+
+	var pair roleHosts
+	budgetFlag := flag.String("budget", "", "limit the load on the server each interval, sources other than status that don't fit are collected less often (example: queries=5,time=50ms)")
+	tuiMode := flag.Bool("tui", false, "full-screen mode with the header on top, scrollback, pause and switching to other views with the same sources (press q to quit, space to pause, tab to switch)")
+	keysFlag := flag.Bool("keys", false, "in the scrolling text output on a terminal, read keys: space pauses (the samples are shown when resumed), h reprints the header and m prints a timestamped mark line.  Not for background jobs, reading the terminal stops them")
+	viaSSH := flag.String("via-ssh", "", "collect status and variables by running the mysql client on this host over ssh (e.g. user@host) instead of connecting to mysql, the remote client uses its own config (~/.my.cnf)")
+	sshBastion := flag.String("ssh", "", "connect to mysql through an ssh tunnel via this jump host (e.g. user@bastion), for hosts not reachable directly, ssh must not prompt for a password")
+	var hostsFlag stringList
+	flag.Var(&hostsFlag, "hosts", "collect from several hosts at once and render a row for each, labeled with the host (example: host1,host2:3307, or repeat -hosts), other connection settings are shared")
+	flag.Var(&pair, "pair", "collect from a primary and a replica at once for the repl view (example: primary=host1,replica=host2), other connection settings are shared")
+
+	awsRDS := flag.Bool("aws", false, "also collect CloudWatch metrics for an RDS/Aurora host (uses AWS credentials from the environment or ~/.aws/credentials)")
+	awsRegion := flag.String("aws-region", "", "AWS region for -aws, -aws-iam-auth and -aws-secret, defaults to the region in the RDS hostname or $AWS_REGION")
+	awsIAMAuth := flag.Bool("aws-iam-auth", false, "authenticate to RDS/Aurora with IAM database auth tokens instead of a password, over TLS (uses AWS credentials like -aws)")
+	awsSecret := flag.String("aws-secret", "", "connect with the username and password of this Secrets Manager secret, name or ARN (e.g. the one RDS manages for the master user)")
+	awsCABundle := flag.String("aws-ca-bundle", "", "PEM file of the CAs that verify the server's certificate for -aws-iam-auth and -aws-secret, defaults to the RDS global bundle downloaded once from "+aws.RDS_CA_BUNDLE_URL)
+	awsTLSSkipVerify := flag.Bool("aws-tls-skip-verify", false, "encrypt the connection for -aws-iam-auth and -aws-secret without verifying the server's certificate, which lets a man in the middle capture the credentials")
+	awsInstance := flag.String("aws-instance", "", "RDS DB instance identifier for -aws, defaults to the one in the RDS hostname")
+	awsTags := flag.Bool("aws-tags", false, "tag the output with the region, az and instance-id from EC2 instance metadata and the RDS endpoint we connect to")
+	awsBudget := flag.Int("aws-budget", loader.DEFAULT_RDS_BUDGET, "maximum CloudWatch GetMetricData calls per hour for -aws, polling slows down to stay within it (0 for no limit)")
 
 	flag.Parse()
 
+	// The config file sets the flags that weren't given
+	configFile, err := clientconf.ReadConfigFile()
+	if err == nil && configFile != nil {
+		err = configFile.ApplyFlags(flag.CommandLine)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: -config:", err)
+		os.Exit(BAD_ARGS)
+	}
+
+	// Before any view's Sources are gathered
+	viewer.SetExtended(*verbose)
+
+	numberFormat, err := viewer.ParseNumberFormat(*units)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: -units:", err)
+		os.Exit(BAD_ARGS)
+	}
+	viewer.SetNumberFormat(numberFormat)
+
+	// Everything that needs cleaning up on exit registers with the session
+	sess := newSession()
+
 	// Enable profiling if set
 	if *profile != "" {
 		fmt.Println("Starting profiling to:", *profile)
 		f, _ := os.Create(*profile)
 		pprof.StartCPUProfile(f)
-		defer pprof.StopCPUProfile()
-
-		// Need to trap interrupts in order for the profile to flush
-		sigs := make(chan os.Signal, 1)
-		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-		go func() {
-			<-sigs
-			pprof.StopCPUProfile()
-			os.Exit(OK)
-		}()
-
+		sess.onExit(pprof.StopCPUProfile)
 	}
 
 	if *version {
@@ -70,18 +272,33 @@ func main() {
 		os.Exit(OK)
 	}
 
-	// Load default Views
-	err := viewer.LoadDefaultViews()
+	// Load default Views, then those of the config file
+	err = viewer.LoadDefaultViews()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading default views: %s\n", err)
 		os.Exit(LOADER_ERROR)
 	}
+	if configFile != nil {
+		for _, path := range configFile.ViewFiles() {
+			if err := viewer.LoadViewFile(path); err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading views: %s\n", err)
+				os.Exit(LOADER_ERROR)
+			}
+		}
+	}
+
+	if *listFeatures {
+		for _, set := range viewer.ListViewSets() {
+			fmt.Printf("%s: %s\n", set, strings.Join(viewer.ListViewSet(set), " "))
+		}
+		os.Exit(OK)
+	}
 
 	// Define standard usage output
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "myq-tools %s (%s)\n\n", build_version, build_timestamp)
 
-		fmt.Fprintln(os.Stderr, "Usage:\n  myq_status [flags] <view>")
+		fmt.Fprintln(os.Stderr, "Usage:\n  myq_status [flags] <view>\n  myq_status which <metric>\n  myq_status -push <host:port> [mysql flags]\n  myq_status [-tolerance ...] diff-view <a.ndjson> <b.ndjson>\n  myq_status grafana-snapshot <records.ndjson>\n  myq_status -list-metrics [mysql flags]")
 		fmt.Fprintln(os.Stderr, "Description:\n  iostat-like views for MySQL servers")
 
 		fmt.Fprintln(os.Stderr, "Options:")
@@ -95,8 +312,97 @@ func main() {
 		os.Exit(BAD_ARGS)
 	}
 
-	// Print usage if we don't have exactly one non-flag cli arg
-	if flag.NArg() != 1 {
+	// List the views and cols using a metric
+	if flag.NArg() == 2 && flag.Arg(0) == "which" {
+		uses := viewer.FindMetric(flag.Arg(1))
+		if len(uses) == 0 {
+			fmt.Fprintf(os.Stderr, "No views use %s\n", flag.Arg(1))
+			os.Exit(BAD_ARGS)
+		}
+		for _, use := range uses {
+			fmt.Println(use)
+		}
+		os.Exit(OK)
+	}
+
+	// Compare two sessions recorded with -record-view
+	if flag.NArg() == 3 && flag.Arg(0) == "diff-view" {
+		os.Exit(diffView(flag.Arg(1), flag.Arg(2), tolerances))
+	}
+
+	// Convert a session recorded with -record-view
+	if flag.NArg() == 2 && flag.Arg(0) == "grafana-snapshot" {
+		os.Exit(grafanaSnapshotOf(flag.Arg(1)))
+	}
+
+	// Be an agent for a -listen elsewhere
+	if *pushTo != "" && flag.NArg() == 0 {
+		if err := backoff.Validate(); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			flag.Usage()
+		}
+		os.Exit(push(*pushTo, *interval, *align, backoff, *queryTimeout))
+	}
+
+	// Report what the server has for the views
+	if *listMetricsFlag && flag.NArg() == 0 {
+		var load loader.Loader
+		if len(statusfiles) > 0 {
+			load = loader.NewFileLoader(statusfiles, *varfile)
+		} else if *viaSSH != "" {
+			load = loader.NewSSHLoader(*viaSSH)
+		} else {
+			config, err := clientconf.GenerateConfig()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%v", err)
+			}
+			liveLoader := loader.NewLiveLoader(config)
+			liveLoader.SetQueryTimeout(*queryTimeout)
+			load = liveLoader
+		}
+		os.Exit(listMetrics(load, *interval))
+	}
+
+	// Print usage if we don't have exactly one non-flag cli arg, or a view in the config file
+	viewName := flag.Arg(0)
+	if flag.NArg() == 0 && configFile != nil {
+		viewName = configFile.View()
+	}
+	if flag.NArg() > 1 || viewName == "" {
+		flag.Usage()
+	}
+
+	viewer.SetColor(*color)
+	if err := viewer.SetTimeCol(*timeColFlag); err != nil {
+		fmt.Fprintln(os.Stderr, "Error: -timecol:", err)
+		flag.Usage()
+	}
+
+	// A col that panics shows ERR instead of ending the session, its details are logged the first time.  Sinks render in their own goroutines.
+	var reportedMutex sync.Mutex
+	reportedCols := make(map[string]bool)
+	viewer.SetRenderErrorHandler(func(re viewer.RenderError) {
+		reportedMutex.Lock()
+		defer reportedMutex.Unlock()
+		if !reportedCols[re.Col] {
+			reportedCols[re.Col] = true
+			fmt.Fprintf(os.Stderr, "Error: %v\n%s", re, re.Stack)
+		}
+	})
+	if err := viewer.SetMarkers(*markers); err != nil {
+		fmt.Fprintln(os.Stderr, "Error: -markers:", err)
+		flag.Usage()
+	}
+
+	if *output != OUTPUT_TEXT && *output != OUTPUT_JSON && *output != OUTPUT_CSV {
+		fmt.Fprintf(os.Stderr, "Error: -output must be %s, %s or %s\n", OUTPUT_TEXT, OUTPUT_JSON, OUTPUT_CSV)
+		flag.Usage()
+	}
+
+	switch *missedInterval {
+	case MISSED_BLANK, MISSED_REPEAT, MISSED_SKIP:
+	default:
+		fmt.Fprintf(os.Stderr, "Error: -missed-interval must be %s, %s or %s\n", MISSED_BLANK, MISSED_REPEAT, MISSED_SKIP)
 		flag.Usage()
 	}
 
@@ -110,34 +416,421 @@ func main() {
 	}
 
 	// Look for the requested view
-	viewName := flag.Arg(0)
 	view, err := viewer.GetViewer(viewName)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		flag.Usage()
 	}
+	for _, str := range colFlags {
+		name, expr, found := strings.Cut(str, "=")
+		if !found || name == "" {
+			fmt.Fprintf(os.Stderr, "Error: -col must be <name>=<expression>: `%s`\n", str)
+			flag.Usage()
+		}
+		col, err := viewer.NewExprCol(name, expr)
+		if err == nil {
+			view, err = viewer.AddCols(view, col)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: -col:", err)
+			flag.Usage()
+		}
+	}
+
+	// Views blending Sources published less often (e.g., CloudWatch every minute) default to their own interval, a shorter one repeats those values
+	if viewInterval := viewer.GetInterval(view); viewInterval > 0 {
+		intervalGiven := false
+		flag.Visit(func(f *flag.Flag) {
+			intervalGiven = intervalGiven || f.Name == "interval" || f.Name == "i"
+		})
+		if !intervalGiven {
+			*interval = viewInterval
+		} else if *interval < viewInterval {
+			fmt.Fprintf(os.Stderr, "Warning: view %s is meant for an interval of at least %v, some values will repeat\n", view.GetName(), viewInterval)
+		}
+	}
+
+	// Parse and check the thresholds against the view
+	var thresholds []viewer.Threshold
+	for _, str := range thresholdFlags {
+		threshold, err := viewer.ParseThreshold(str)
+		if err == nil {
+			err = threshold.Check(view)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: -threshold:", err)
+			flag.Usage()
+		}
+		thresholds = append(thresholds, threshold)
+	}
+	if (*bell || *notify) && len(thresholds) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: -bell and -notify need at least one -threshold")
+		flag.Usage()
+	} else if len(thresholds) > 0 && !*bell && !*notify && *summaryOut == "" {
+		fmt.Fprintln(os.Stderr, "Warning: -threshold has no effect without -bell, -notify or -summary-out")
+	}
+
+	// Alerts are on metrics rather than the view's cols
+	var alerts []viewer.Alert
+	for _, str := range alertFlags {
+		alert, err := viewer.ParseAlert(str)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: -alert:", err)
+			flag.Usage()
+		}
+		alerts = append(alerts, alert)
+	}
+	if (*alertCmd != "" || *alertExit) && len(alerts) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: -alert-cmd and -alert-exit need at least one -alert")
+		flag.Usage()
+	}
+
+	// Parse and check the forecasts against the view
+	var forecasts []viewer.Forecast
+	for _, str := range forecastFlags {
+		forecast, err := viewer.ParseForecast(str)
+		if err == nil {
+			err = forecast.Check(view)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: -forecast:", err)
+			flag.Usage()
+		}
+		forecasts = append(forecasts, forecast)
+	}
+	if *forecastEvery < 1 {
+		fmt.Fprintln(os.Stderr, "Error: -forecast-every must be >= 1")
+		flag.Usage()
+	}
+	if *count < 0 {
+		fmt.Fprintln(os.Stderr, "Error: -count must be >= 0")
+		flag.Usage()
+	}
+	var rotation viewer.LogRotation
+	if *logRotate != "" {
+		if rotation, err = viewer.ParseLogRotation(*logRotate); err != nil {
+			fmt.Fprintln(os.Stderr, "Error: -logrotate:", err)
+			flag.Usage()
+		}
+		if *logFile == "" {
+			fmt.Fprintln(os.Stderr, "Warning: -logrotate has no effect without -logfile")
+		}
+	}
 
 	// Print help for the requested view
 	if *help {
 		for _, helpst := range view.GetDetailedHelp() {
 			fmt.Fprintln(os.Stderr, helpst)
 		}
+		sources, _ := view.GetSources()
+		for _, source := range sources {
+			if loader.SourceRequiresPFS(source) {
+				fmt.Fprintf(os.Stderr, "Requires performance_schema (%s)\n", source)
+			}
+		}
 		os.Exit(OK)
 	}
 
 	// The Loader and Timecol we will use
 	var load loader.Loader
+	var fileLoaders []*loader.FileLoader
+	var promLoader *loader.PromLoader
+
+	// Tags describing where the output came from
+	var tags map[string]string
+
+	// Multi-host mode renders and records each host on its own
+	var hosts []string
+	for _, value := range hostsFlag {
+		for _, host := range strings.Split(value, ",") {
+			if host == "" || slices.Contains(hosts, host) {
+				fmt.Fprintf(os.Stderr, "Error: -hosts: empty or repeated host in `%s`\n", value)
+				flag.Usage()
+			}
+			hosts = append(hosts, host)
+		}
+	}
+	if len(hosts) > 0 && (*listen != "" || len(statusfiles) > 0 || len(pair.roles) > 0 || *exporterAddr != "" || len(thresholds) > 0 || len(forecasts) > 0) {
+		fmt.Fprintln(os.Stderr, "Error: -hosts cannot be combined with -listen, -file, -pair, -exporter, -threshold or -forecast")
+		flag.Usage()
+	}
+
+	if len(compareFiles) > 0 && (len(statusfiles) == 0 || *exporterAddr != "" || len(thresholds) > 0 || len(forecasts) > 0 || *tuiMode) {
+		fmt.Fprintln(os.Stderr, "Error: -compare-file needs -file, and cannot be combined with -exporter, -threshold, -forecast or -tui")
+		flag.Usage()
+	}
+	if *compareVarfile != "" && len(compareFiles) == 0 {
+		fmt.Fprintln(os.Stderr, "Warning: -compare-varfile has no effect without -compare-file")
+	}
+	if (*replaySpeed != "" || *replaySeek != "") && len(statusfiles) == 0 {
+		fmt.Fprintln(os.Stderr, "Warning: -speed and -seek have no effect without -file")
+	}
+	if *promURL == "" {
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "prom-instance" || f.Name == "from" || f.Name == "to" {
+				fmt.Fprintf(os.Stderr, "Warning: -%s has no effect without -prom-url\n", f.Name)
+			}
+		})
+	}
+
+	if *tuiMode && (*output != OUTPUT_TEXT || len(hosts) > 0) {
+		fmt.Fprintln(os.Stderr, "Error: -tui cannot be combined with -output or -hosts")
+		flag.Usage()
+	}
+
+	if *viaSSH != "" && (*listen != "" || len(statusfiles) > 0 || len(pair.roles) > 0 || len(hosts) > 0 || *awsRDS) {
+		fmt.Fprintln(os.Stderr, "Error: -via-ssh cannot be combined with -listen, -file, -pair, -hosts or -aws")
+		flag.Usage()
+	}
+
+	if (*awsIAMAuth || *awsSecret != "") && (*viaSSH != "" || *listen != "" || len(statusfiles) > 0) {
+		fmt.Fprintln(os.Stderr, "Error: -aws-iam-auth and -aws-secret cannot be combined with -via-ssh, -listen or -file")
+		flag.Usage()
+	}
+
+	if *sshBastion != "" && (*viaSSH != "" || *listen != "" || len(statusfiles) > 0) {
+		fmt.Fprintln(os.Stderr, "Error: -ssh cannot be combined with -via-ssh, -listen or -file")
+		flag.Usage()
+	}
 
-	if *statusfile == "" {
-		// No file given, this is a live collection and we use timestamps
-		config, err := clientconf.GenerateConfig()
+	if *viaSSH != "" {
+		// The mysql client runs remotely, this is like a live collection
+		sshLoader := loader.NewSSHLoader(*viaSSH)
+		sshLoader.SetAlign(*align)
+		load = sshLoader
+	} else if *listen != "" {
+		// Samples come from an agent, this is like a live collection
+		if len(statusfiles) > 0 || len(pair.roles) > 0 || *awsRDS {
+			fmt.Fprintln(os.Stderr, "Error: -listen cannot be combined with -file, -pair or -aws")
+			flag.Usage()
+		}
+		if *align {
+			fmt.Fprintln(os.Stderr, "Warning: -align is ignored with -listen, use it with -push")
+		}
+		network, address := loader.ParseNetworkAddress(*listen)
+		listenLoader := loader.NewListenLoader(network, address)
+		sess.onExit(func() { listenLoader.Close() })
+		load = listenLoader
+	} else if *promURL != "" {
+		// Samples come from Prometheus, timed like a live collection by when they were scraped
+		if len(statusfiles) > 0 || len(pair.roles) > 0 || len(hosts) > 0 || *awsRDS {
+			fmt.Fprintln(os.Stderr, "Error: -prom-url cannot be combined with -file, -pair, -hosts or -aws")
+			flag.Usage()
+		}
+		now := time.Now()
+		from, err := loader.ParsePromTime(*promFrom, now)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: -from:", err)
+			flag.Usage()
+		}
+		to, err := loader.ParsePromTime(*promTo, now)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: -to:", err)
+			flag.Usage()
+		}
+		promLoader = loader.NewPromLoader(*promURL, *promInstance, from, to)
+		load = promLoader
+	} else if len(statusfiles) == 0 {
+		// No file given, this is a live collection and we use timestamps.  With -hosts or -pair the settings are for those hosts, not a local server.
+		generateConfig := clientconf.GenerateConfig
+		if len(hosts) > 0 || len(pair.roles) > 0 {
+			generateConfig = clientconf.GenerateHostsConfig
+		}
+		config, err := generateConfig()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%v", err)
 		}
-		load = loader.NewLiveLoader(config)
+		if err := backoff.Validate(); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			flag.Usage()
+		}
+		var budget loader.Budget
+		if *budgetFlag != "" {
+			if budget, err = loader.ParseBudget(*budgetFlag); err != nil {
+				fmt.Fprintln(os.Stderr, "Error: -budget:", err)
+				flag.Usage()
+			}
+		}
+
+		sourceTimeouts := make(map[loader.SourceName]time.Duration)
+		for _, str := range sourceTimeoutFlags {
+			source, timeout, err := loader.ParseSourceTimeout(str)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Error: -source-timeout:", err)
+				flag.Usage()
+			}
+			sourceTimeouts[source] = timeout
+		}
+
+		if *awsSecret != "" {
+			if err := useAWSSecret(config, *awsSecret, *awsRegion, awsTLS{*awsCABundle, *awsTLSSkipVerify}); err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+				os.Exit(1)
+			}
+		}
+
+		if *awsTags && len(hosts) == 0 {
+			tags = discoverAWSTags(config)
+		}
+
+		newLiveLoader := func(config *mysql.Config) *loader.LiveLoader {
+			if *awsIAMAuth {
+				iamConfig, err := useAWSIAMAuth(config, *awsRegion, awsTLS{*awsCABundle, *awsTLSSkipVerify})
+				if err != nil {
+					fmt.Fprintln(os.Stderr, "Error:", err)
+					os.Exit(1)
+				}
+				config = iamConfig
+			}
+			if *sshBastion != "" {
+				// Each host gets its own tunnel
+				if config.Net != `tcp` {
+					fmt.Fprintln(os.Stderr, "Error: -ssh needs a TCP host, not a socket")
+					flag.Usage()
+				}
+				tunnel, err := loader.NewSSHTunnel(*sshBastion, config.Addr)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, "Error: -ssh:", err)
+					os.Exit(1)
+				}
+				sess.onExit(func() { tunnel.Close() })
+				config = tunnel.Config(config)
+			}
+			liveLoader := loader.NewLiveLoader(config)
+			liveLoader.SetBackoff(backoff)
+			liveLoader.SetQueryTimeout(*queryTimeout)
+			for source, timeout := range sourceTimeouts {
+				liveLoader.SetSourceTimeout(source, timeout)
+			}
+			liveLoader.SetParallel(*parallel)
+			liveLoader.SetLockWaitDetail(*locksDetail)
+			liveLoader.SetBudget(budget)
+			liveLoader.SetAlign(*align)
+			return liveLoader
+		}
+
+		if len(pair.roles) > 0 {
+			// One loader per host, the primary drives the interval
+			if len(pair.roles) != 2 || pair.hosts["primary"] == "" || pair.hosts["replica"] == "" {
+				fmt.Fprintln(os.Stderr, "Error: -pair needs exactly primary=<host>,replica=<host>")
+				flag.Usage()
+			}
+			multiLoader := loader.NewMultiLoader()
+			for _, role := range []string{"primary", "replica"} {
+				multiLoader.AddLoader(role, newLiveLoader(clientconf.ConfigForHost(config, pair.hosts[role])))
+			}
+			if *awsRDS {
+				fmt.Fprintln(os.Stderr, "Warning: -aws is ignored with -pair")
+			}
+			load = multiLoader
+		} else if len(hosts) > 0 {
+			// One loader per host, the first drives the interval
+			multiLoader := loader.NewMultiLoader()
+			for _, host := range hosts {
+				multiLoader.AddLoader(host, newLiveLoader(clientconf.ConfigForHost(config, host)))
+			}
+			if *awsRDS || *awsTags {
+				fmt.Fprintln(os.Stderr, "Warning: -aws and -aws-tags are ignored with -hosts")
+			}
+			load = multiLoader
+		} else {
+			liveLoader := newLiveLoader(config)
+			if *enableInnodbMetrics {
+				counters := viewer.GetSourceKeys(view, `innodb_metrics`)
+				if len(counters) == 0 {
+					fmt.Fprintf(os.Stderr, "Warning: view %s uses no innodb_metrics counters\n", view.GetName())
+				} else {
+					fmt.Fprintf(os.Stderr, "Enabling innodb_metrics counters: %s\n", strings.Join(counters, ", "))
+				}
+				liveLoader.SetInnodbMonitors(counters)
+			}
+			if *awsRDS {
+				poller, err := newRDSPoller(config, *awsRegion, *awsInstance)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(LOADER_ERROR)
+				}
+				poller.SetBudget(*awsBudget)
+				liveLoader.AddPoller(`aws.rds`, poller)
+			}
+			// OS metrics are of the container or host we run in, which should be mysqld's
+			if viewSources, _ := view.GetSources(); slices.Contains(viewSources, `os`) {
+				liveLoader.AddPoller(`os`, loader.NewOSPoller())
+			}
+			load = liveLoader
+		}
 	} else {
 		// File given, load it (and the optional varfile)
-		load = loader.NewFileLoader(*statusfile, *varfile)
+		fileLoader := loader.NewFileLoader(statusfiles, *varfile)
+		fileLoaders = append(fileLoaders, fileLoader)
+		load = fileLoader
+
+		// The captures are rendered like -hosts, labeled by file name
+		if len(compareFiles) > 0 {
+			hosts = []string{filepath.Base(statusfiles[0]), filepath.Base(compareFiles[0])}
+			if hosts[0] == hosts[1] {
+				hosts = []string{statusfiles[0], compareFiles[0]}
+			}
+			if hosts[0] == hosts[1] {
+				fmt.Fprintln(os.Stderr, "Error: -compare-file is the same as -file")
+				flag.Usage()
+			}
+			compareFileLoader := loader.NewFileLoader(compareFiles, *compareVarfile)
+			fileLoaders = append(fileLoaders, compareFileLoader)
+			compareLoader := loader.NewCompareLoader()
+			compareLoader.AddLoader(hosts[0], fileLoader)
+			compareLoader.AddLoader(hosts[1], compareFileLoader)
+			load = compareLoader
+		}
+
+		var speed float64
+		if *replaySpeed != "" {
+			var err error
+			if speed, err = loader.ParseReplaySpeed(*replaySpeed); err != nil {
+				fmt.Fprintln(os.Stderr, "Error: -speed:", err)
+				flag.Usage()
+			}
+		}
+		var seek time.Duration
+		if *replaySeek != "" {
+			var err error
+			if seek, err = loader.ParseReplayOffset(*replaySeek); err != nil {
+				fmt.Fprintln(os.Stderr, "Error: -seek:", err)
+				flag.Usage()
+			}
+		}
+
+		// Only the -file capture is paced, the compare loader reads the other to match it
+		fileLoader.SetSpeed(speed)
+		for _, fileLoader := range fileLoaders {
+			fileLoader.SetStrict(*strict)
+			fileLoader.SetSeek(seek)
+			sess.onExit(func() {
+				if skipped := fileLoader.Skipped(); skipped > 0 {
+					fmt.Fprintf(os.Stderr, "Skipped %d malformed samples\n", skipped)
+				}
+			})
+		}
+
+		if *awsRDS {
+			fmt.Fprintln(os.Stderr, "Warning: -aws is ignored with -file")
+		}
+		if *align {
+			fmt.Fprintln(os.Stderr, "Warning: -align is ignored with -file")
+		}
+		if len(pair.roles) > 0 {
+			fmt.Fprintln(os.Stderr, "Warning: -pair is ignored with -file")
+		}
+		if *awsTags {
+			fmt.Fprintln(os.Stderr, "Warning: -aws-tags is ignored with -file")
+		}
+	}
+
+	// One header is printed for every host, so it can't name them
+	if *serverHeader && (len(hosts) > 0 || len(pair.roles) > 0) {
+		fmt.Fprintln(os.Stderr, "Warning: -server-header is ignored with -hosts, -pair and -compare-file")
+	} else {
+		viewer.SetServerHeader(*serverHeader)
 	}
 
 	sources, err := view.GetSources()
@@ -145,18 +838,114 @@ func main() {
 		fmt.Fprint(os.Stderr, err)
 		os.Exit(SOURCES_ERROR)
 	}
+	// The primary's and replica's Sources only come from a loader per role, anything else would render blank rows
+	if (len(pair.roles) == 0 || len(statusfiles) > 0) && slices.ContainsFunc(sources, func(s loader.SourceName) bool {
+		return strings.HasPrefix(string(s), "primary.") || strings.HasPrefix(string(s), "replica.")
+	}) {
+		fmt.Fprintf(os.Stderr, "Error: view %s needs -pair primary=<host>,replica=<host>\n", view.GetName())
+		os.Exit(BAD_ARGS)
+	}
+	if *listen != "" {
+		for _, source := range sources {
+			if source != `status` {
+				fmt.Fprintf(os.Stderr, "Warning: -listen only receives status, cols using %s will be empty\n", source)
+			}
+		}
+	}
+	if *promURL != "" {
+		for _, source := range sources {
+			if source != `status` && source != `variables` && source != `replica` {
+				fmt.Fprintf(os.Stderr, "Warning: mysqld_exporter only exports status, variables and replica status, cols using %s will be empty\n", source)
+			}
+		}
+	}
+	if *viaSSH != "" {
+		for _, source := range sources {
+			if source != `status` && source != `variables` {
+				fmt.Fprintf(os.Stderr, "Warning: -via-ssh only collects status and variables, cols using %s will be empty\n", source)
+			}
+		}
+	}
+	if *locksDetail > 0 && !slices.Contains(sources, "metadata_locks") {
+		fmt.Fprintf(os.Stderr, "Warning: -locks-detail needs a view with metadata locks, e.g. locks, not %s\n", view.GetName())
+	}
+
+	// Tracked variables are collected along with the server's status even if the view doesn't show them, -listen only receives status
+	trackedVariables := viewer.ParseTrackedVariables(*trackVariables)
+	if len(trackedVariables) > 0 && *listen == "" && slices.Contains(sources, `status`) && !slices.Contains(sources, `variables`) {
+		sources = append(sources, `variables`)
+	}
+	// Under -pair alerts are on each role's Sources, and an alert on a Source the loader can't collect would never fire
+	if len(pair.roles) > 0 && len(statusfiles) == 0 {
+		alerts = viewer.RoleAlerts(alerts, pair.roles)
+	}
+	if lister, ok := load.(loader.SourceLister); ok {
+		for _, alert := range alerts {
+			source := alert.Metric.SourceName
+			if len(hosts) > 0 {
+				source = loader.RoleSources(hosts[0], []loader.SourceName{source})[0]
+			}
+			if !lister.CanCollect(source) {
+				fmt.Fprintf(os.Stderr, "Error: -alert: unknown source %s\n", alert.Metric.SourceName)
+				os.Exit(BAD_ARGS)
+			}
+		}
+	}
+	// Alerts are collected along with the view
+	for _, alert := range alerts {
+		if !slices.Contains(sources, alert.Metric.SourceName) {
+			sources = append(sources, alert.Metric.SourceName)
+		}
+	}
+	if len(hosts) > 0 {
+		var hostSources []loader.SourceName
+		for _, host := range hosts {
+			hostSources = append(hostSources, loader.RoleSources(host, sources)...)
+		}
+		sources = hostSources
+	}
+
+	// The exporter may need more than the view
+	var promExporter *exporter.Exporter
+	if *exporterAddr != "" {
+		promExporter = exporter.NewExporter()
+		promExporter.SetSources(*exporterSources)
+		if *exporterCompat != "" {
+			if err := promExporter.SetCompat(*exporterCompat); err != nil {
+				fmt.Fprintln(os.Stderr, "Error: -exporter-compat:", err)
+				flag.Usage()
+			}
+		}
+		for _, source := range promExporter.CompatSources() {
+			if !slices.Contains(sources, source) {
+				sources = append(sources, source)
+			}
+		}
+	} else if *exporterCompat != "" || *exporterSources {
+		fmt.Fprintln(os.Stderr, "Warning: -exporter-compat and -exporter-sources need -exporter")
+	}
 
 	// Initialize the loader
 	err = load.Initialize(*interval, sources)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
+		printInitializeError(view, err)
 		os.Exit(LOADER_ERROR)
 	}
 
-	// How big is our terminal?
-	termheight, termwidth := viewer.GetTermSize()
+	// How big is our terminal?  Only text output cares, json and csv are usually piped.  Piped text output (cron, less, grep) is plain: nothing is fit to a width and the header is printed once.
+	var termheight, termwidth int
+	plain := !viewer.IsTerminal(os.Stdout)
+	if *output == OUTPUT_TEXT && !plain {
+		termheight, termwidth = viewer.GetTermSize()
 
-	// How many lines before printing a new header
+		// Raw numbers are for reading here, not for piping
+		viewer.SetThousandsSeparator(viewer.LocaleThousandsSeparator())
+	}
+
+	// Keys pressed in the scrolling text output, set up with the -tui's
+	var keyInput *keyReader
+
+	// How many lines before printing a new header, 0 is never again
 	headerRepeat := termheight
 	if *header != 0 {
 		// Use the specified --header count
@@ -166,35 +955,155 @@ func main() {
 	// Apply selected view to output each sample
 	linesSinceHeader := 0
 
+	// Output is flushed after every State and on exit, the log is closed after that
+	var dest io.Writer = os.Stdout
+	if *logFile != "" {
+		log, err := viewer.OpenLogFile(*logFile, rotation)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: -logfile:", err)
+			os.Exit(BAD_ARGS)
+		}
+		sess.onExit(func() { log.Close() })
+		dest = &logTee{out: dest, log: log}
+		if *tuiMode {
+			fmt.Fprintln(os.Stderr, "Warning: -logfile is not written with -tui")
+		}
+	}
+	out := bufio.NewWriter(dest)
+	sess.onExit(func() { out.Flush() })
+
 	printOutput := func(s string) {
-		if *width {
+		if *width && termwidth > 0 {
 			s = viewer.FitString(s, termwidth)
 		}
-		fmt.Println(s)
+		fmt.Fprintln(out, s)
 	}
 
-	// Main loop through loader States
-	for state := range load.GetStateChannel() {
-		// Reprint a header whenever lines == 0
-		if linesSinceHeader == 0 {
-			for _, headerLn := range view.GetHeader(state) {
-				printOutput(headerLn)
+	// Render a State with the view
+	legendPrinted := false
+	explained := false
+
+	// Cols whose values don't fit are widened at the next header
+	textView := view
+	var widths *viewer.WidthAdapter
+	if !*fixedWidths {
+		widths = viewer.NewWidthAdapter()
+	}
+
+	// Cols that don't move are left out at the next header
+	var activity *viewer.ActivityFilter
+	if *onlyActive {
+		if *output != OUTPUT_TEXT || *tuiMode {
+			fmt.Fprintln(os.Stderr, "Warning: -only-active only applies to the scrolling text output")
+		} else {
+			activity = viewer.NewActivityFilter()
+		}
+	}
+
+	// Galera state transfers replace the view's data until they complete, each host has its own
+	transfers := make(map[string]*viewer.TransferTracker)
+	showTransfers := viewer.ShowsStateTransfers(view)
+
+	// Hosts label their rows, the header is indented to match
+	labelWidth := 0
+	for _, host := range hosts {
+		labelWidth = max(labelWidth, len(host))
+	}
+	label := func(host, line string) string {
+		if labelWidth == 0 {
+			return line
+		}
+		return fmt.Sprintf("%-*s %s", labelWidth, host, line)
+	}
+
+	// Out-of-band messages come before the header or data, they don't count toward a header that is due
+	printNotice := func(line string) {
+		printOutput(line)
+		if linesSinceHeader > 0 {
+			linesSinceHeader += 1
+		}
+	}
+	annotate := func(state loader.StateReader) {
+		for _, annotation := range state.GetAnnotations() {
+			printNotice(fmt.Sprintf("-- %s --", annotation))
+		}
+	}
+
+	// The first header explains the markers (only on a terminal, piped output is parsed) and the keys
+	keysExplained := false
+	var lastRendered loader.StateReader
+	printHeader := func(state loader.StateReader) {
+		textView = view
+		if activity != nil {
+			textView = activity.Apply(textView)
+		}
+		if widths != nil {
+			textView = widths.Apply(textView)
+		}
+		for _, headerLn := range textView.GetHeader(state) {
+			printOutput(label("", headerLn))
+			linesSinceHeader += 1
+		}
+		if legend := viewer.GetLegend(); legend != "" && !legendPrinted && !plain {
+			printOutput(fmt.Sprintf("-- %s --", legend))
+			linesSinceHeader += 1
+			legendPrinted = true
+		}
+		if keyInput != nil && !keysExplained {
+			printOutput(fmt.Sprintf("-- %s --", KEYS_HELP))
+			linesSinceHeader += 1
+			keysExplained = true
+		}
+		if *explain && !explained {
+			for _, line := range viewer.Explain(view, state) {
+				printOutput(label("", "-- "+line))
 				linesSinceHeader += 1
 			}
+			explained = true
 		}
+	}
 
-		// Output data
-		for _, dataLn := range view.GetData(state) {
-			printOutput(dataLn)
-			linesSinceHeader += 1
+	render := func(state loader.StateReader, rows []hostState) {
+		annotate(state)
+
+		// Reprint a header whenever lines == 0
+		if linesSinceHeader == 0 {
+			printHeader(rows[0].state)
+		}
+		lastRendered = rows[0].state
+
+		// Output data, or the state transfer in progress
+		for _, row := range rows {
+			var transferLn string
+			if showTransfers {
+				if transfers[row.host] == nil {
+					transfers[row.host] = viewer.NewTransferTracker()
+				}
+				transferLn, _ = transfers[row.host].Line(row.state)
+			}
+			if transferLn != "" {
+				printOutput(label(row.host, transferLn))
+				linesSinceHeader += 1
+				continue
+			}
+			for _, dataLn := range textView.GetData(row.state) {
+				printOutput(label(row.host, dataLn))
+				linesSinceHeader += 1
+			}
+			if widths != nil {
+				widths.Observe(textView, row.state)
+			}
+			if activity != nil {
+				activity.Observe(view, row.state)
+			}
 		}
 
 		// Determine if we need to reset lines to 0 (and trigger a header)
-		if linesSinceHeader/headerRepeat >= 1 {
+		if headerRepeat > 0 && linesSinceHeader >= headerRepeat {
 			linesSinceHeader = 0
 
 			// Recalculate terminal size if this affects our width or headerRepeat
-			if *width || *header == 0 {
+			if !plain && (*width || *header == 0) {
 				// Recalculate the size of the terminal now too
 				termheight, termwidth = viewer.GetTermSize()
 				if *header == 0 {
@@ -204,5 +1113,660 @@ func main() {
 		}
 	}
 
-	os.Exit(OK)
+	// Write the Records alongside the output
+	var recorder *sink
+	if *recordView != "" {
+		f, err := os.Create(*recordView)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(BAD_ARGS)
+		}
+		recordOut := bufio.NewWriter(f)
+		sess.onExit(func() {
+			recordOut.Flush()
+			f.Close()
+		})
+		encoder := json.NewEncoder(recordOut)
+		recorder = newSink("-record-view", func(record viewer.Record, _ loader.StateReader) error {
+			return encoder.Encode(record)
+		})
+		sess.addSink(recorder)
+	}
+
+	// Ship the values to statsd
+	var statsdSink *sink
+	if *sinkFlag != "" {
+		statsd, err := exporter.NewStatsD(*sinkFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: -sink:", err)
+			os.Exit(BAD_ARGS)
+		}
+		sess.onExit(func() { statsd.Close() })
+		statsdSink = newSink("-sink", func(record viewer.Record, _ loader.StateReader) error {
+			return statsd.Write(record)
+		})
+		sess.addSink(statsdSink)
+	}
+
+	// Keep the latest Records for a snapshot on exit
+	var snapshotter *sink
+	if *grafanaSnapshot != "" {
+		f, err := os.Create(*grafanaSnapshot)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(BAD_ARGS)
+		}
+		var records []viewer.Record
+		dropped := 0
+		snapshotter = newSink("-grafana-snapshot", func(record viewer.Record, _ loader.StateReader) error {
+			records = append(records, record)
+			if len(records) > exporter.GRAFANA_SNAPSHOT_RECORDS {
+				records = records[1:]
+				dropped += 1
+			}
+			return nil
+		})
+		// Closers run in reverse, so the sink is drained before the snapshot is written
+		sess.onExit(func() {
+			if dropped > 0 {
+				fmt.Fprintf(os.Stderr, "Warning: -grafana-snapshot only has the last %d samples, record the session with -record-view for all of it\n", len(records))
+			}
+			encoder := json.NewEncoder(f)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(exporter.NewGrafanaSnapshot(records, viewer.GetColNames(view))); err != nil {
+				fmt.Fprintln(os.Stderr, "Error: -grafana-snapshot:", err)
+			}
+			f.Close()
+		})
+		sess.addSink(snapshotter)
+	}
+
+	// Alert on thresholds, or just count their breaches for -summary-out
+	var alert *alerter
+	if len(thresholds) > 0 && (*bell || *notify || *summaryOut != "") {
+		alert = &alerter{
+			watcher: viewer.NewThresholdWatcher(thresholds),
+			bell:    *bell,
+			notify:  *notify,
+			out:     out,
+		}
+	}
+
+	var dog *watchdog
+	if len(alerts) > 0 {
+		dog = newWatchdog(alerts, *alertCmd, *alertExit || *alertCmd == "")
+	}
+
+	if *explain && (*output != OUTPUT_TEXT || *tuiMode) {
+		fmt.Fprintln(os.Stderr, "Warning: -explain is only shown in the scrolling text output")
+	}
+
+	// Project cols from their trend, in a footer every few intervals
+	var forecaster *viewer.Forecaster
+	if len(forecasts) > 0 {
+		if *output != OUTPUT_TEXT || *tuiMode {
+			fmt.Fprintln(os.Stderr, "Warning: -forecast is only shown in the scrolling text output")
+		} else {
+			forecaster = viewer.NewForecaster(forecasts)
+		}
+	}
+
+	// Accumulate the values for a summary on exit, each host has its own
+	summarizers := make(map[string]*viewer.Summarizer)
+	if *summary {
+		sess.onExit(func() {
+			for _, host := range slices.Sorted(maps.Keys(summarizers)) {
+				for _, line := range summarizers[host].Lines() {
+					fmt.Fprintln(os.Stderr, label(host, line))
+				}
+			}
+		})
+	}
+	if *summaryOut != "" {
+		f, err := os.Create(*summaryOut)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(BAD_ARGS)
+		}
+		// Written last, so it has the exit code and what the sinks dropped
+		sess.onReport(func(code int) {
+			summary := sess.getSummary(view.GetName(), code)
+			for host, summarizer := range summarizers {
+				if host == "" {
+					summary.Cols = summarizer.Cols()
+					continue
+				}
+				if summary.Hosts == nil {
+					summary.Hosts = make(map[string]map[string]viewer.ColSummary)
+				}
+				summary.Hosts[host] = summarizer.Cols()
+			}
+			if alert != nil {
+				summary.Thresholds = alert.watcher.Summary()
+			}
+			encoder := json.NewEncoder(f)
+			encoder.SetIndent("", "  ")
+			encoder.SetEscapeHTML(false)
+			if err := encoder.Encode(summary); err != nil {
+				fmt.Fprintln(os.Stderr, "Error: -summary-out:", err)
+			}
+			f.Close()
+		})
+	}
+
+	// Serve the values to Prometheus too
+	var exporterSink *sink
+	if promExporter != nil {
+		promExporter.SetLabels(tags)
+		if _, err := promExporter.Listen(*exporterAddr); err != nil {
+			fmt.Fprintln(os.Stderr, "Error: -exporter:", err)
+			os.Exit(BAD_ARGS)
+		}
+		sess.onExit(func() { promExporter.Close() })
+		exporterSink = newSink("-exporter", func(record viewer.Record, state loader.StateReader) error {
+			promExporter.Update(record, state)
+			return nil
+		})
+		sess.addSink(exporterSink)
+	}
+
+	// Each sample is a JSON object or CSV row instead
+	var jsonOut *json.Encoder
+	var csvOut *viewer.CSVWriter
+	switch *output {
+	case OUTPUT_JSON:
+		jsonOut = json.NewEncoder(out)
+	case OUTPUT_CSV:
+		csvOut = viewer.NewCSVWriter(out, view)
+	}
+
+	// Scripts can ask for the latest sample, switch views (unless the cols are fixed for the session, or -tui has its own) and change the interval
+	var api *apiServer
+	var apiSwitches chan viewSwitch
+	if *apiAddr != "" {
+		var views []viewer.Viewer
+		fixedBy := viewFixedBy(*output, *tuiMode, *summary, *summaryOut, *recordView, *grafanaSnapshot, len(thresholds) > 0, len(forecasts) > 0)
+		if len(fixedBy) == 0 {
+			views = switchableViews(view, sources)
+		}
+		setter, _ := load.(loader.IntervalSetter)
+		api = newAPIServer(view, views, hosts, *interval, setter)
+		api.fixedBy = fixedBy
+		if _, err := api.listen(*apiAddr); err != nil {
+			fmt.Fprintln(os.Stderr, "Error: -api:", err)
+			os.Exit(BAD_ARGS)
+		}
+		api.setStats(sess.getSummary(view.GetName(), OK))
+		sess.onExit(api.close)
+		apiSwitches = api.switches
+	}
+
+	// Tags go before everything else, json has them in every object and csv has only the header row
+	if len(tags) > 0 && *output == OUTPUT_TEXT {
+		printOutput(fmt.Sprintf("-- tags: %s --", formatTags(tags)))
+	}
+
+	// Trap interrupts so we can exit cleanly between States
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+
+	// The full-screen UI replaces the scrolling output
+	var ui *tui
+	var keys <-chan string
+	if *tuiMode {
+		if ui, err = newTUI(view, sources); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(BAD_ARGS)
+		}
+		sess.onExit(ui.close)
+		keys = ui.keys
+	} else if *keysFlag && *output == OUTPUT_TEXT && !plain && viewer.IsTerminal(os.Stdin) {
+		if keyInput, err = newKeyReader(); err != nil {
+			fmt.Fprintln(os.Stderr, "Warning: -keys:", err)
+		} else {
+			sess.onExit(keyInput.close)
+			keys = keyInput.keys
+
+			// Output still held at exit is shown before it is flushed
+			sess.onExit(func() {
+				if keyInput.paused {
+					held, _ := keyInput.togglePause()
+					for _, output := range held {
+						output()
+					}
+				}
+			})
+		}
+	}
+	marks := 0
+
+	// Main loop through loader States, the last one that was collected stands in for failed ones under -missed-interval repeat
+	states := load.GetStateChannel()
+	var lastCollected *loader.State
+	variableTrackers := make(map[string]*viewer.VariableTracker)
+
+	// The cols whose metrics a host doesn't have are annotated once, their - isn't a collection problem
+	unavailableCols := make(map[string]*viewer.UnavailableCols)
+	var forecastIntervals int
+	for {
+		select {
+		case state, ok := <-states:
+			if !ok {
+				for _, fileLoader := range fileLoaders {
+					if fileLoader.Err() != nil {
+						out.Flush()
+						fmt.Fprintln(os.Stderr, "Error:", fileLoader.Err())
+						sess.exit(LOADER_ERROR)
+					}
+				}
+				if promLoader != nil && promLoader.Err() != nil {
+					out.Flush()
+					fmt.Fprintln(os.Stderr, "Error:", promLoader.Err())
+					sess.exit(LOADER_ERROR)
+				}
+				sess.exit(OK)
+			}
+			// The server version is only known once we have collected from it
+			if sess.samples == 0 {
+				for _, row := range splitHosts(state, hosts, len(compareFiles) > 0) {
+					if err := viewer.CheckVersion(view, row.state); err != nil {
+						fmt.Fprintln(os.Stderr, "Error: view", label(row.host, err.Error()))
+						sess.exit(BAD_ARGS)
+					}
+				}
+			}
+			sess.record(state)
+			last := *count > 0 && sess.samples >= *count
+			if !state.GetCurrent().CollectionFailed() {
+				lastCollected = state.(*loader.State)
+			} else if *missedInterval == MISSED_SKIP {
+				// Skipped States still tell what happened, e.g. the connection was lost
+				if ui == nil && *output == OUTPUT_TEXT {
+					keyInput.show(func() { annotate(state) })
+					out.Flush()
+				}
+				if last {
+					sess.exit(OK)
+				}
+				continue
+			} else if *missedInterval == MISSED_REPEAT && lastCollected != nil {
+				state = lastCollected.RepeatAt(state)
+			}
+			rows := splitHosts(state, hosts, len(compareFiles) > 0)
+
+			// Annotate changes of the tracked variables, each host has its own
+			if writer, ok := state.(*loader.State); ok && len(trackedVariables) > 0 {
+				for _, row := range rows {
+					if variableTrackers[row.host] == nil {
+						variableTrackers[row.host] = viewer.NewVariableTracker(trackedVariables)
+					}
+					for _, change := range variableTrackers[row.host].Changes(row.state) {
+						if row.host != "" {
+							change = row.host + ": " + change
+						}
+						writer.AddAnnotation(change)
+					}
+				}
+			}
+			if writer, ok := state.(*loader.State); ok {
+				for _, row := range rows {
+					if unavailableCols[row.host] == nil {
+						unavailableCols[row.host] = viewer.NewUnavailableCols()
+					}
+					if cols := unavailableCols[row.host].Check(view, row.state); len(cols) > 0 {
+						annotation := "not on this server, shown as -: " + strings.Join(cols, ", ")
+						if row.host != "" {
+							annotation = row.host + ": " + annotation
+						}
+						writer.AddAnnotation(annotation)
+					}
+				}
+			}
+
+			if ui != nil {
+				ui.add(state)
+			} else if *output == OUTPUT_TEXT {
+				keyInput.show(func() { render(state, rows) })
+			}
+			for _, row := range rows {
+				if recorder == nil && statsdSink == nil && snapshotter == nil && alert == nil && forecaster == nil && promExporter == nil && api == nil && !*summary && *summaryOut == "" && *output == OUTPUT_TEXT {
+					break
+				}
+				record := viewer.GetRecord(view, row.state)
+				record.Tags = tags
+				if row.host != "" {
+					record.Tags = map[string]string{"host": row.host}
+					maps.Copy(record.Tags, tags)
+				}
+				if jsonOut != nil {
+					if err := jsonOut.Encode(record); err != nil {
+						fmt.Fprintln(os.Stderr, "Error: -output json:", err)
+						sess.exit(OUTPUT_FAILED)
+					}
+				}
+				if csvOut != nil {
+					if err := csvOut.Write(record); err != nil {
+						fmt.Fprintln(os.Stderr, "Error: -output csv:", err)
+						sess.exit(OUTPUT_FAILED)
+					}
+				}
+				if exporterSink != nil {
+					exporterSink.send(record, row.state)
+				}
+				if alert != nil {
+					alert.check(record)
+				}
+				if api != nil {
+					api.update(row.host, record)
+				}
+				if forecaster != nil {
+					forecaster.Add(record)
+				}
+				if *summary || *summaryOut != "" {
+					if summarizers[row.host] == nil {
+						summarizers[row.host] = viewer.NewSummarizer(viewer.GetColNames(view))
+					}
+					summarizers[row.host].Add(record)
+				}
+				if recorder != nil {
+					recorder.send(record, row.state)
+				}
+				if statsdSink != nil {
+					statsdSink.send(record, row.state)
+				}
+				if snapshotter != nil {
+					snapshotter.send(record, row.state)
+				}
+			}
+			if forecaster != nil {
+				forecastIntervals += 1
+				if forecastIntervals%*forecastEvery == 0 {
+					line := fmt.Sprintf("-- %s --", forecaster.Line())
+					keyInput.show(func() { printNotice(line) })
+				}
+			}
+			// The records are only written out here, e.g. failing on a closed pipe
+			if err := out.Flush(); err != nil && (jsonOut != nil || csvOut != nil) {
+				fmt.Fprintf(os.Stderr, "Error: -output %s: %v\n", *output, err)
+				sess.exit(OUTPUT_FAILED)
+			}
+			if api != nil {
+				api.setStats(sess.getSummary(view.GetName(), OK))
+			}
+			if dog != nil {
+				fired := false
+				for _, row := range rows {
+					exit, err := dog.check(row.host, row.state)
+					if err != nil {
+						fmt.Fprintln(os.Stderr, "Error: -alert:", err)
+						sess.exit(BAD_ARGS)
+					}
+					fired = fired || exit
+				}
+				if fired {
+					sess.exit(ALERT_FIRED)
+				}
+			}
+			if last {
+				sess.exit(OK)
+			}
+		case sw := <-apiSwitches:
+			// The server may not have what the view needs
+			if lastCollected != nil {
+				if err := viewer.CheckVersion(sw.view, lastCollected); err != nil {
+					sw.err <- err
+					continue
+				}
+			}
+			view = sw.view
+			showTransfers = viewer.ShowsStateTransfers(view)
+			linesSinceHeader = 0
+			sw.err <- nil
+		case key := <-keys:
+			if ui != nil {
+				if !ui.handleKey(key) {
+					sess.exit(OK)
+				}
+				continue
+			}
+			switch key {
+			case " ", "p":
+				held, dropped := keyInput.togglePause()
+				if keyInput.paused {
+					printNotice("-- paused, press space to resume --")
+					break
+				}
+				if dropped > 0 {
+					printNotice(fmt.Sprintf("-- resumed, dropped the %d oldest samples held while paused --", dropped))
+				} else {
+					printNotice("-- resumed --")
+				}
+				for _, output := range held {
+					output()
+				}
+			case "h", "H":
+				linesSinceHeader = 0
+				if lastRendered != nil {
+					printHeader(lastRendered)
+				}
+			case "m", "M":
+				// Marks go where they were made, after what was held
+				marks += 1
+				mark := fmt.Sprintf("%s-- mark %d at %s --%s", ANSI_REVERSE, marks, time.Now().Format("15:04:05"), ANSI_RESET)
+				keyInput.show(func() { printNotice(mark) })
+			}
+			out.Flush()
+		case <-sigs:
+			sess.exit(OK)
+		}
+	}
+}
+
+// Print a Grafana snapshot of a session recorded with -record-view, returning the exit code
+func grafanaSnapshotOf(path string) int {
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return BAD_ARGS
+	}
+	records, err := viewer.ReadRecords(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s: %v\n", path, err)
+		return BAD_ARGS
+	}
+
+	// The view's col order if we know the view
+	var cols []string
+	if len(records) > 0 {
+		if view, err := viewer.GetViewer(records[0].View); err == nil {
+			cols = viewer.GetColNames(view)
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(exporter.NewGrafanaSnapshot(records, cols)); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return LOADER_ERROR
+	}
+	return OK
+}
+
+// Collect status and variables once and print which views the server has the metrics for and the metrics no view reads, returning the exit code
+func listMetrics(load loader.Loader, interval time.Duration) int {
+	sources := []loader.SourceName{`status`, `variables`}
+	if err := load.Initialize(interval, sources); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return LOADER_ERROR
+	}
+	state, ok := <-load.GetStateChannel()
+	if !ok {
+		fmt.Fprintln(os.Stderr, "Error: no status collected")
+		return LOADER_ERROR
+	}
+	if err := state.GetCurrent().GetErrors(); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning:", err)
+	}
+
+	fmt.Println("Views:")
+	for _, name := range viewer.ListViews() {
+		view, _ := viewer.GetViewer(name)
+		fmt.Printf("  %s\n", viewer.CheckMetrics(view, state))
+	}
+
+	// Plugin and flavor metrics (tokudb_*, rocksdb_*, wsrep_*, ...) usually end up here
+	for _, source := range sources {
+		unmapped := viewer.UnmappedMetrics(state, source)
+		fmt.Printf("\nUnmapped %s (%d), not read by any view:\n", source, len(unmapped))
+		for _, name := range unmapped {
+			fmt.Printf("  %s\n", name)
+		}
+	}
+	return OK
+}
+
+// Compare two recorded sessions and print the values that differ, returning the exit code
+func diffView(pathA, pathB string, tolerances []string) int {
+	comparer := viewer.RecordComparer{Cols: make(map[string]viewer.Tolerance)}
+	comparer.Default, _ = viewer.ParseTolerance(DEFAULT_TOLERANCE)
+	for _, str := range tolerances {
+		col, tolStr, found := strings.Cut(str, "=")
+		tolerance, err := viewer.ParseTolerance(tolStr)
+		if !found {
+			tolerance, err = viewer.ParseTolerance(col)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: -tolerance:", err)
+			return BAD_ARGS
+		}
+		if found {
+			comparer.Cols[col] = tolerance
+		} else {
+			comparer.Default = tolerance
+		}
+	}
+
+	var sessions [2][]viewer.Record
+	for i, path := range []string{pathA, pathB} {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return BAD_ARGS
+		}
+		sessions[i], err = viewer.ReadRecords(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", path, err)
+			return BAD_ARGS
+		}
+	}
+	a, b := sessions[0], sessions[1]
+
+	if len(a) > 0 && len(b) > 0 && a[0].View != b[0].View {
+		fmt.Fprintf(os.Stderr, "Warning: comparing view %s to view %s\n", a[0].View, b[0].View)
+	}
+
+	diffs := comparer.Compare(a, b)
+	for _, diff := range diffs {
+		fmt.Println(diff)
+	}
+	fmt.Fprintf(os.Stderr, "%d values differ in %d records\n", len(diffs), min(len(a), len(b)))
+
+	if len(a) != len(b) {
+		fmt.Fprintf(os.Stderr, "%s has %d records, %s has %d\n", pathA, len(a), pathB, len(b))
+		return RECORDS_DIFFER
+	}
+	if len(diffs) > 0 {
+		return RECORDS_DIFFER
+	}
+	return OK
+}
+
+// Tags from the EC2 instance we run on (if any) and the RDS endpoint we connect to (if it is one)
+func discoverAWSTags(config *mysql.Config) map[string]string {
+	tags, err := aws.NewIMDS().Tags()
+	if err != nil {
+		tags = make(map[string]string)
+	}
+
+	// The server's region wins over ours
+	host, _, _ := net.SplitHostPort(config.Addr)
+	if endpoint, ok := aws.ParseRDSEndpoint(host); ok && config.Net == `tcp` {
+		for tag, value := range endpoint.Tags() {
+			tags[tag] = value
+		}
+	}
+
+	if len(tags) == 0 {
+		fmt.Fprintln(os.Stderr, "Warning: -aws-tags found no EC2 instance metadata or RDS endpoint")
+	}
+	return tags
+}
+
+// Tags as sorted key=value pairs
+func formatTags(tags map[string]string) string {
+	var pairs []string
+	for tag, value := range tags {
+		pairs = append(pairs, tag+"="+value)
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, " ")
+}
+
+// Build a CloudWatch poller for the RDS instance we are connecting to
+func newRDSPoller(config *mysql.Config, region, instance string) (*loader.RDSPoller, error) {
+	host := tcpHost(config)
+
+	// Fill in anything not given from the RDS endpoint hostname
+	endpoint, _ := aws.ParseRDSEndpoint(host)
+	if instance != "" {
+		endpoint.Identifier = instance
+		endpoint.Cluster = false
+	}
+	if endpoint.Identifier == "" {
+		return nil, fmt.Errorf("-aws: cannot determine the RDS instance from host `%s`, use -aws-instance", host)
+	}
+
+	region = resolveAWSRegion(region, host)
+	if region == "" {
+		return nil, errors.New("-aws: cannot determine the AWS region, use -aws-region")
+	}
+	endpoint.Region = region
+
+	creds, err := aws.LoadCredentials()
+	if err != nil {
+		return nil, fmt.Errorf("-aws: %v", err)
+	}
+
+	return loader.NewRDSPoller(aws.NewCloudWatch(region, creds), endpoint), nil
+}
+
+// Report Loader initialization errors, missing privileges are explained per view column
+func printInitializeError(view viewer.Viewer, err error) {
+	errs := []error{err}
+	if merr, ok := err.(*multierror.Error); ok {
+		errs = merr.Errors
+	}
+
+	for _, err := range errs {
+		var perr *loader.PrivilegeError
+		var pfsErr *loader.PerformanceSchemaError
+		var verErr *loader.ServerVersionError
+		if errors.As(err, &perr) {
+			cols := viewer.GetColsUsingSource(view, perr.Source)
+			fmt.Fprintf(os.Stderr, "Error: missing GRANT %s for view %s columns: %s\n  (%v)\n",
+				perr.Grant, view.GetName(), strings.Join(cols, ", "), perr.Err)
+		} else if errors.As(err, &pfsErr) {
+			cols := viewer.GetColsUsingSource(view, pfsErr.Source)
+			fmt.Fprintf(os.Stderr, "Error: view %s columns %s require performance_schema, which is disabled on this server\n",
+				view.GetName(), strings.Join(cols, ", "))
+		} else if errors.As(err, &verErr) {
+			cols := viewer.GetColsUsingSource(view, verErr.Source)
+			fmt.Fprintf(os.Stderr, "Error: view %s columns %s need MySQL %s+\n",
+				view.GetName(), strings.Join(cols, ", "), verErr.Since)
+		} else {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
 }
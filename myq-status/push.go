@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jayjanssen/myq-tools/lib/clientconf"
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// Collect status from the local server every interval and push it to a myq_status -listen, returning the exit code
+func push(target string, interval time.Duration, align bool, backoff loader.Backoff, queryTimeout time.Duration) int {
+	config, err := clientconf.GenerateConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v", err)
+	}
+
+	liveLoader := loader.NewLiveLoader(config)
+	liveLoader.SetBackoff(backoff)
+	liveLoader.SetQueryTimeout(queryTimeout)
+	liveLoader.SetAlign(align)
+	if err := liveLoader.Initialize(interval, []loader.SourceName{`status`}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return LOADER_ERROR
+	}
+
+	network, address := loader.ParseNetworkAddress(target)
+	var conn net.Conn
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+
+	states := liveLoader.GetStateChannel()
+	for {
+		select {
+		case state := <-states:
+			sample := state.(*loader.State).Current.Samples[`status`]
+			if sample == nil || state.GetCurrent().GetErrors() != nil {
+				fmt.Fprintln(os.Stderr, "Warning: -push: not sending a failed collection:", state.GetCurrent().GetErrors())
+				continue
+			}
+
+			// (Re)connect as needed, samples collected while we can't are dropped
+			if conn == nil {
+				if conn, err = net.DialTimeout(network, address, interval); err != nil {
+					fmt.Fprintln(os.Stderr, "Warning: -push:", err)
+					continue
+				}
+			}
+			conn.SetWriteDeadline(time.Now().Add(interval))
+			if err := loader.WriteSample(conn, sample); err != nil {
+				fmt.Fprintln(os.Stderr, "Warning: -push:", err)
+				conn.Close()
+				conn = nil
+			}
+		case <-sigs:
+			return OK
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+	"github.com/jayjanssen/myq-tools/lib/viewer"
+)
+
+// Tracks what happened during this run so we can clean up and summarize on exit
+type session struct {
+	start time.Time
+
+	// States rendered, how many of those had collection errors, and the intervals missed between them
+	samples int
+	errored int
+	missed  uint64
+
+	// Records the sinks couldn't write, by sink name
+	dropped map[string]int
+
+	// Run on exit, in reverse order of registration
+	closers []func()
+
+	// Run on exit after the closers, with the exit code
+	reporters []func(code int)
+}
+
+func newSession() *session {
+	return &session{start: time.Now(), dropped: make(map[string]int)}
+}
+
+// Register a function to run on exit, e.g. to flush output
+func (s *session) onExit(f func()) {
+	s.closers = append(s.closers, f)
+}
+
+// Register a function to report on the session once everything is closed
+func (s *session) onReport(f func(code int)) {
+	s.reporters = append(s.reporters, f)
+}
+
+// Close the sink on exit, after anything registered before it and before anything registered after it
+func (s *session) addSink(sk *sink) {
+	s.onExit(func() {
+		if dropped := sk.close(); dropped > 0 {
+			s.dropped[sk.name] = dropped
+		}
+	})
+}
+
+// Count a rendered State
+func (s *session) record(state loader.StateReader) {
+	s.samples += 1
+	s.missed += state.GetMissed()
+	if state.GetCurrent().GetErrors() != nil {
+		s.errored += 1
+	}
+}
+
+// The summary written by -summary-out, its fields are stable for scripts to check after a run
+type sessionSummary struct {
+	View     string    `json:"view"`
+	Start    time.Time `json:"start"`
+	Duration float64   `json:"duration"` // seconds
+	ExitCode int       `json:"exit_code"`
+
+	Samples int            `json:"samples"`
+	Errored int            `json:"errored"`
+	Missed  uint64         `json:"missed"`
+	Dropped map[string]int `json:"dropped"` // by sink
+
+	// The cols' values, by host with -hosts
+	Cols  map[string]viewer.ColSummary            `json:"cols,omitempty"`
+	Hosts map[string]map[string]viewer.ColSummary `json:"hosts,omitempty"`
+
+	Thresholds []viewer.ThresholdSummary `json:"thresholds,omitempty"`
+}
+
+// Summarize the session so far
+func (s *session) getSummary(view string, code int) sessionSummary {
+	return sessionSummary{
+		View:     view,
+		Start:    s.start.UTC(),
+		Duration: time.Since(s.start).Seconds(),
+		ExitCode: code,
+		Samples:  s.samples,
+		Errored:  s.errored,
+		Missed:   s.missed,
+		Dropped:  s.dropped,
+	}
+}
+
+// A single line describing this session
+func (s *session) summary() string {
+	line := fmt.Sprintf("%d samples in %s, %d with collection errors",
+		s.samples, time.Since(s.start).Round(time.Second), s.errored)
+	for _, name := range slices.Sorted(maps.Keys(s.dropped)) {
+		line += fmt.Sprintf(", %d dropped by %s", s.dropped[name], name)
+	}
+	return line
+}
+
+// Run the closers, print the summary and exit.  An OK exit becomes DATA_DROPPED if any samples had errors or were dropped by a sink.
+func (s *session) exit(code int) {
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		s.closers[i]()
+	}
+
+	if s.samples > 0 {
+		fmt.Fprintln(os.Stderr, s.summary())
+	}
+
+	if code == OK && (s.errored > 0 || len(s.dropped) > 0) {
+		code = DATA_DROPPED
+	}
+	for _, report := range s.reporters {
+		report(code)
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+	"github.com/jayjanssen/myq-tools/lib/viewer"
+)
+
+// How many Records a sink can fall behind before it drops them
+const SINK_QUEUE_SIZE = 100
+
+// A Record and the State it was built from
+type sinkItem struct {
+	record viewer.Record
+	state  loader.StateReader
+}
+
+// A destination for Records (a file, the exporter) written by its own goroutine, so a slow one can't hold up rendering or the other sinks.  Records that don't fit in its queue are dropped, and it stops writing after an error.
+type sink struct {
+	name  string
+	write func(viewer.Record, loader.StateReader) error
+
+	queue chan sinkItem
+	done  sync.WaitGroup
+
+	// Written by the sink's goroutine, read once it is done
+	failed  error
+	dropped int
+
+	// Records dropped because the queue was full, only touched by send
+	overflowed int
+}
+
+func newSink(name string, write func(viewer.Record, loader.StateReader) error) *sink {
+	s := &sink{
+		name:  name,
+		write: write,
+		queue: make(chan sinkItem, SINK_QUEUE_SIZE),
+	}
+	s.done.Add(1)
+	go s.run()
+	return s
+}
+
+func (s *sink) run() {
+	defer s.done.Done()
+	for item := range s.queue {
+		if s.failed != nil {
+			s.dropped += 1
+			continue
+		}
+		if err := s.write(item.record, item.state); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %v, not writing any more\n", s.name, err)
+			s.failed = err
+			s.dropped += 1
+		}
+	}
+}
+
+// Queue the Record without waiting
+func (s *sink) send(record viewer.Record, state loader.StateReader) {
+	select {
+	case s.queue <- sinkItem{record, state}:
+	default:
+		s.overflowed += 1
+	}
+}
+
+// Write what is queued and return how many Records were dropped
+func (s *sink) close() int {
+	close(s.queue)
+	s.done.Wait()
+	return s.dropped + s.overflowed
+}
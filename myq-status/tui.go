@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/jayjanssen/myq-tools/lib/loader"
+	"github.com/jayjanssen/myq-tools/lib/viewer"
+)
+
+// How many States the TUI keeps to scroll back through
+const TUI_SCROLLBACK = 1000
+
+// Terminal control sequences
+const (
+	ANSI_ALT_SCREEN    = "\x1b[?1049h"
+	ANSI_MAIN_SCREEN   = "\x1b[?1049l"
+	ANSI_HIDE_CURSOR   = "\x1b[?25l"
+	ANSI_SHOW_CURSOR   = "\x1b[?25h"
+	ANSI_CLEAR         = "\x1b[H\x1b[2J"
+	ANSI_REVERSE       = "\x1b[7m"
+	ANSI_RESET         = "\x1b[0m"
+	ANSI_KEY_UP        = "\x1b[A"
+	ANSI_KEY_DOWN      = "\x1b[B"
+	ANSI_KEY_PAGE_UP   = "\x1b[5~"
+	ANSI_KEY_PAGE_DOWN = "\x1b[6~"
+)
+
+const TUI_HELP = "q quit  space pause  up/down pgup/pgdn scroll  tab/n next view  N previous view"
+
+// A full-screen terminal UI: the header stays on top, past States can be scrolled back to, and the view can be switched to any other whose Sources are collected
+type tui struct {
+	views   []viewer.Viewer
+	current int
+
+	// The States received, oldest first, and how many rows back from the latest we are looking
+	states []loader.StateReader
+	offset int
+
+	// Paused shows the States as they were when paused, new ones are kept for later
+	paused      bool
+	pausedCount int
+
+	// Keys pressed, read in the background
+	keys chan string
+
+	// The terminal settings to restore on exit
+	sttySaved string
+	out       *bufio.Writer
+}
+
+// Start the TUI on the terminal with the given view first.  The views that can be switched to are those using only the given Sources.
+func newTUI(first viewer.Viewer, sources []loader.SourceName) (*tui, error) {
+	t := &tui{
+		keys: make(chan string),
+		out:  bufio.NewWriter(os.Stdout),
+	}
+	t.views = switchableViews(first, sources)
+
+	saved, err := rawTerminal()
+	if err != nil {
+		return nil, fmt.Errorf("-tui needs a terminal: %v", err)
+	}
+	t.sttySaved = saved
+
+	fmt.Fprint(t.out, ANSI_ALT_SCREEN+ANSI_HIDE_CURSOR)
+	t.out.Flush()
+	go readKeys(t.keys)
+	return t, nil
+}
+
+// The given view first, then the others using only the given Sources
+func switchableViews(first viewer.Viewer, sources []loader.SourceName) []viewer.Viewer {
+	views := []viewer.Viewer{first}
+	for _, name := range viewer.ListViews() {
+		view, _ := viewer.GetViewer(name)
+		viewSources, err := view.GetSources()
+		if err != nil || name == first.GetName() {
+			continue
+		}
+		if !slices.ContainsFunc(viewSources, func(s loader.SourceName) bool { return !slices.Contains(sources, s) }) {
+			views = append(views, view)
+		}
+	}
+	return views
+}
+
+// Run stty on our terminal
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// Have keys read one at a time and not echoed, returning the settings to restore
+func rawTerminal() (string, error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return "", err
+	}
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(saved), nil
+}
+
+// Restore the terminal as it was
+func (t *tui) close() {
+	fmt.Fprint(t.out, ANSI_SHOW_CURSOR+ANSI_MAIN_SCREEN)
+	t.out.Flush()
+	stty(t.sttySaved)
+}
+
+// Send the keys pressed, escape sequences like the arrows as one
+func readKeys(keys chan<- string) {
+	buf := make([]byte, 16)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+		keys <- string(buf[:n])
+	}
+}
+
+// Keep the State and show it, unless paused
+func (t *tui) add(state loader.StateReader) {
+	t.states = append(t.states, state)
+	if len(t.states) > TUI_SCROLLBACK {
+		t.states = slices.Delete(t.states, 0, len(t.states)-TUI_SCROLLBACK)
+	}
+	if t.paused {
+		t.pausedCount += 1
+		t.offset = min(t.offset+1, len(t.states)-1)
+		return
+	}
+	t.draw()
+}
+
+// Handle a key, returning false to quit
+func (t *tui) handleKey(key string) bool {
+	_, height := t.dataSize()
+	switch key {
+	case "q", "Q", "\x03":
+		return false
+	case " ", "p":
+		t.paused = !t.paused
+		if !t.paused {
+			t.offset, t.pausedCount = 0, 0
+		}
+	case ANSI_KEY_UP, "k":
+		t.offset += 1
+	case ANSI_KEY_DOWN, "j":
+		t.offset -= 1
+	case ANSI_KEY_PAGE_UP:
+		t.offset += height
+	case ANSI_KEY_PAGE_DOWN:
+		t.offset -= height
+	case "\t", "n":
+		t.current = (t.current + 1) % len(t.views)
+	case "N":
+		t.current = (t.current + len(t.views) - 1) % len(t.views)
+	}
+	t.offset = max(0, min(t.offset, len(t.states)-1))
+	t.draw()
+	return true
+}
+
+// The width of the terminal and how many rows of data fit under the header and status line
+func (t *tui) dataSize() (width, rows int) {
+	height, width := viewer.GetTermSize()
+	rows = height - 1
+	if len(t.states) > 0 {
+		rows -= len(t.views[t.current].GetHeader(t.states[len(t.states)-1]))
+	}
+	return width, max(rows, 1)
+}
+
+// Redraw the screen: the header, as many States as fit ending offset rows back from the latest, and a status line
+func (t *tui) draw() {
+	if len(t.states) == 0 {
+		return
+	}
+	view := t.views[t.current]
+	width, rows := t.dataSize()
+	fit := func(s string) string {
+		if width > 0 {
+			return viewer.TruncateString(s, width)
+		}
+		return s
+	}
+
+	fmt.Fprint(t.out, ANSI_CLEAR)
+	last := len(t.states) - 1 - t.offset
+	for _, line := range view.GetHeader(t.states[last]) {
+		fmt.Fprintln(t.out, fit(line))
+	}
+
+	var lines []string
+	for i := last; i >= 0 && len(lines) < rows; i-- {
+		data := view.GetData(t.states[i])
+		lines = append(data, lines...)
+	}
+	if len(lines) > rows {
+		lines = lines[len(lines)-rows:]
+	}
+	for i := len(lines); i < rows; i++ {
+		fmt.Fprintln(t.out)
+	}
+	for _, line := range lines {
+		fmt.Fprintln(t.out, fit(line))
+	}
+
+	// The status line, with the latest annotation if any
+	status := fmt.Sprintf(" %s (%d/%d)", view.GetName(), t.current+1, len(t.views))
+	if t.paused {
+		status += fmt.Sprintf("  PAUSED, %d new", t.pausedCount)
+	}
+	if t.offset > 0 {
+		status += fmt.Sprintf("  %d back", t.offset)
+	}
+	if annotations := t.states[last].GetAnnotations(); len(annotations) > 0 {
+		status += "  -- " + annotations[len(annotations)-1] + " --"
+	} else {
+		status += "  " + TUI_HELP
+	}
+	fmt.Fprint(t.out, ANSI_REVERSE+fmt.Sprintf("%-*s", width, fit(status))+ANSI_RESET)
+	t.out.Flush()
+}
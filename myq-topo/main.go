@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jayjanssen/myq-tools/lib/clientconf"
+	"github.com/jayjanssen/myq-tools/lib/loader"
+)
+
+// Exit codes
+const (
+	OK int = iota
+	BAD_ARGS
+	LOADER_ERROR
+)
+
+// Current Version (passed in on build)
+var build_version string
+var build_timestamp string
+
+func main() {
+	// Parse arguments
+	help := flag.Bool("help", false, "this help text")
+	version := flag.Bool("version", false, "print the version")
+	queryTimeout := flag.Duration("query-timeout", loader.DEFAULT_QUERY_TIMEOUT, "timeout for each query (0 for none)")
+
+	// Every host gets the same mysql settings, except for the host and port
+	clientconf.SetMySQLFlags()
+
+	flag.Parse()
+
+	if *version {
+		fmt.Printf("myq-tools %s (%s)\n", build_version, build_timestamp)
+		os.Exit(OK)
+	}
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "myq-tools %s (%s)\n\n", build_version, build_timestamp)
+
+		fmt.Fprintln(os.Stderr, "Usage:\n  myq_topo [flags] [host[:port]]")
+		fmt.Fprintln(os.Stderr, "Description:\n  Print the replication topology (sources, replicas and Galera nodes) the host is in, with the role, version and lag of each server.  The host defaults to -host.")
+
+		fmt.Fprintln(os.Stderr, "Options:")
+		flag.PrintDefaults()
+		os.Exit(BAD_ARGS)
+	}
+
+	if *help || flag.NArg() > 1 {
+		flag.Usage()
+	}
+
+	// The servers are reached over tcp, a local socket is never auto-detected
+	config, err := clientconf.GenerateHostsConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v", err)
+	}
+
+	// The other servers are found by their addresses, so we start from one too
+	start := flag.Arg(0)
+	if start == "" {
+		if config.Net != "tcp" {
+			fmt.Fprintln(os.Stderr, "Error: give the host to start from, the servers of a topology are reached over tcp")
+			flag.Usage()
+		}
+		start = config.Addr
+	}
+	start = clientconf.ConfigForHost(config, start).Addr
+
+	root := loader.DiscoverTopology(start, func(addr string) (*loader.TopologyProbe, error) {
+		return probe(clientconf.ConfigForHost(config, addr), *queryTimeout)
+	})
+	for _, line := range root.Lines() {
+		fmt.Println(line)
+	}
+	if root.Err != nil && len(root.Children) == 0 {
+		os.Exit(LOADER_ERROR)
+	}
+	os.Exit(OK)
+}
+
+// Connect to a server and ask about its place in the topology
+func probe(config *mysql.Config, queryTimeout time.Duration) (*loader.TopologyProbe, error) {
+	liveLoader := loader.NewLiveLoader(config)
+	liveLoader.SetQueryTimeout(queryTimeout)
+	defer liveLoader.Close()
+
+	if err := liveLoader.Initialize(0, []loader.SourceName{`replica`}); err != nil {
+		return nil, err
+	}
+	return liveLoader.ProbeTopology()
+}